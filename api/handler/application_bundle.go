@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/labstack/echo/v4"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// handleExportApplication downloads all kalm resources of the application as a yaml bundle.
+// Secrets are omitted unless includeSecrets=true, and they are exported sealed.
+func (h *ApiHandler) handleExportApplication(c echo.Context) error {
+	namespace := c.Param("name")
+	includeSecrets := c.QueryParam("includeSecrets") == "true"
+
+	if !h.clientManager.CanViewNamespace(getCurrentUser(c), namespace) {
+		return resources.NoNamespaceViewerRoleError(namespace)
+	}
+
+	// sealed secrets can be imported into the same cluster, so only editors can export them
+	if includeSecrets && !h.clientManager.CanEditNamespace(getCurrentUser(c), namespace) {
+		return resources.NoNamespaceEditorRoleError(namespace)
+	}
+
+	bundle, err := h.resourceManager.ExportApplication(namespace, includeSecrets)
+
+	if err != nil {
+		return err
+	}
+
+	bts, err := yaml.Marshal(bundle)
+
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%s.kalm.yaml", namespace))
+
+	return c.Blob(http.StatusOK, "application/x-yaml", bts)
+}
+
+func (h *ApiHandler) handleImportApplication(c echo.Context) error {
+	var req resources.ImportApplicationRequest
+
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	bundle, err := resources.ParseApplicationBundle([]byte(req.Bundle))
+
+	if err != nil {
+		return err
+	}
+
+	namespace := req.Name
+
+	if namespace == "" {
+		namespace = bundle.Application
+	}
+
+	currentUser := getCurrentUser(c)
+
+	if _, err := h.resourceManager.GetNamespace(namespace); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+
+		// same as creating an application
+		if !h.clientManager.CanEditCluster(currentUser) {
+			return resources.NoClusterEditorRoleError
+		}
+	} else if !h.clientManager.CanEditNamespace(currentUser, namespace) {
+		return resources.NoNamespaceEditorRoleError(namespace)
+	}
+
+	if err := bundle.Localize(namespace, req.Parameters); err != nil {
+		return err
+	}
+
+	// destinations outside of the bundle are kept as they are, same check as editing the routes one by one
+	for i := range bundle.HttpRoutes {
+		if !h.clientManager.CanOperateHttpRoute(currentUser, "edit", resources.BuildHttpRouteFromResource(&bundle.HttpRoutes[i])) {
+			return resources.InsufficientPermissionsError
+		}
+	}
+
+	res, err := h.resourceManager.ImportApplication(bundle, req.DryRun, currentUser.Email)
+
+	if err != nil {
+		return err
+	}
+
+	if req.DryRun {
+		return c.JSON(http.StatusOK, res)
+	}
+
+	return c.JSON(http.StatusCreated, res)
+}
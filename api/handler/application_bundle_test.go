@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/suite"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+type ApplicationBundleTestSuite struct {
+	WithControllerTestSuite
+	namespace string
+}
+
+func TestApplicationBundleTestSuite(t *testing.T) {
+	suite.Run(t, new(ApplicationBundleTestSuite))
+}
+
+func (suite *ApplicationBundleTestSuite) SetupSuite() {
+	suite.WithControllerTestSuite.SetupSuite()
+	suite.namespace = "kalm-test-bundle"
+	suite.ensureNamespaceExist(suite.namespace)
+}
+
+func (suite *ApplicationBundleTestSuite) TeardownSuite() {
+	suite.ensureNamespaceDeleted(suite.namespace)
+	suite.ensureNamespaceDeleted("kalm-test-bundle-copy")
+}
+
+func (suite *ApplicationBundleTestSuite) TestExportAndImport() {
+	suite.Nil(suite.Create(&v1alpha1.Component{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "web",
+			Namespace: suite.namespace,
+		},
+		Spec: v1alpha1.ComponentSpec{
+			Image: "web:v1",
+		},
+	}))
+
+	var bundle string
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNs(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodGet,
+		Path:      "/v1alpha1/applications/" + suite.namespace + "/export",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, "viewer", suite.namespace)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(200, rec.Code)
+			bundle = rec.BodyAsString()
+
+			var res resources.ApplicationBundle
+			suite.Nil(yaml.Unmarshal([]byte(bundle), &res))
+			suite.Equal(resources.ApplicationBundleVersion, res.Version)
+			suite.Len(res.Components, 1)
+			suite.Equal("", res.Components[0].ResourceVersion)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNs(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodPost,
+		Path:      "/v1alpha1/applications/import",
+		Body: &resources.ImportApplicationRequest{
+			Bundle: bundle,
+			Parameters: resources.ApplicationImportParameters{
+				ImageTags: map[string]string{"web": "v2"},
+			},
+			DryRun: true,
+		},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, "editor", suite.namespace)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.ImportApplicationResponse
+			rec.BodyAsJSON(&res)
+			suite.Equal(200, rec.Code)
+			suite.Len(res.Changes, 1)
+			suite.Equal(resources.ApplicationImportActionUpdate, res.Changes[0].Action)
+			suite.Contains(res.Changes[0].Diff, "+  image: web:v2")
+
+			component, err := suite.getComponent(suite.namespace, "web")
+			suite.Nil(err)
+			suite.Equal("web:v1", component.Spec.Image)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetClusterEditorRole(),
+		},
+		Method: http.MethodPost,
+		Path:   "/v1alpha1/applications/import",
+		Body: &resources.ImportApplicationRequest{
+			Name:   "kalm-test-bundle-copy",
+			Bundle: bundle,
+		},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, "editor", "cluster")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.ImportApplicationResponse
+			rec.BodyAsJSON(&res)
+			suite.Equal(201, rec.Code)
+			suite.Equal("Namespace", res.Changes[0].Kind)
+
+			component, err := suite.getComponent("kalm-test-bundle-copy", "web")
+			suite.Nil(err)
+			suite.Equal("web:v1", component.Spec.Image)
+		},
+	})
+}
+
+func (suite *ApplicationBundleTestSuite) TestImportRouteToOtherApplication() {
+	bundle, err := yaml.Marshal(&resources.ApplicationBundle{
+		Version:     resources.ApplicationBundleVersion,
+		Application: suite.namespace,
+		HttpRoutes: []v1alpha1.HttpRoute{
+			{
+				ObjectMeta: v1.ObjectMeta{Name: "other"},
+				Spec: v1alpha1.HttpRouteSpec{
+					Hosts:   []string{"other.example.com"},
+					Paths:   []string{"/"},
+					Methods: []v1alpha1.HttpRouteMethod{"GET"},
+					Schemes: []v1alpha1.HttpRouteScheme{"http"},
+					Destinations: []v1alpha1.HttpRouteDestination{
+						{Host: "web.kalm-test-bundle-other.svc.cluster.local:80", Weight: 1},
+					},
+				},
+			},
+		},
+	})
+	suite.Nil(err)
+
+	// editors of the target application can't route traffic to applications they can't edit, even in dry run
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNs(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodPost,
+		Path:      "/v1alpha1/applications/import",
+		Body: &resources.ImportApplicationRequest{
+			Bundle: string(bundle),
+			DryRun: true,
+		},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, "editor", suite.namespace)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, resources.InsufficientPermissionsError.Error())
+		},
+	})
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *ApiHandler) handleListApplicationSources(c echo.Context) error {
+	sources, err := h.resourceManager.GetApplicationSources()
+
+	if err != nil {
+		return err
+	}
+
+	res := make([]*resources.ApplicationSource, 0, len(sources))
+
+	for _, source := range sources {
+		if h.clientManager.CanViewNamespace(getCurrentUser(c), source.TargetNamespace) {
+			res = append(res, source)
+		}
+	}
+
+	return c.JSON(200, res)
+}
+
+func (h *ApiHandler) handleGetApplicationSource(c echo.Context) error {
+	source, err := h.resourceManager.GetApplicationSource(c.Param("name"))
+
+	if err != nil {
+		return err
+	}
+
+	if !h.clientManager.CanViewNamespace(getCurrentUser(c), source.TargetNamespace) {
+		return resources.NoNamespaceViewerRoleError(source.TargetNamespace)
+	}
+
+	return c.JSON(200, source)
+}
+
+// handleCreateApplicationSource requires the cluster editor role, sources are pulled and applied by the controller.
+func (h *ApiHandler) handleCreateApplicationSource(c echo.Context) (err error) {
+	if !h.clientManager.CanEditCluster(getCurrentUser(c)) {
+		return resources.NoClusterEditorRoleError
+	}
+
+	var source *resources.ApplicationSource
+
+	if source, err = getApplicationSourceFromContext(c); err != nil {
+		return err
+	}
+
+	if source, err = h.resourceManager.CreateApplicationSource(source); err != nil {
+		return err
+	}
+
+	return c.JSON(201, source)
+}
+
+func (h *ApiHandler) handleUpdateApplicationSource(c echo.Context) (err error) {
+	if !h.clientManager.CanEditCluster(getCurrentUser(c)) {
+		return resources.NoClusterEditorRoleError
+	}
+
+	var source *resources.ApplicationSource
+
+	if source, err = getApplicationSourceFromContext(c); err != nil {
+		return err
+	}
+
+	source.Name = c.Param("name")
+
+	if source, err = h.resourceManager.UpdateApplicationSource(source); err != nil {
+		return err
+	}
+
+	return c.JSON(200, source)
+}
+
+func (h *ApiHandler) handleDeleteApplicationSource(c echo.Context) error {
+	if !h.clientManager.CanEditCluster(getCurrentUser(c)) {
+		return resources.NoClusterEditorRoleError
+	}
+
+	if err := h.resourceManager.DeleteApplicationSource(c.Param("name")); err != nil {
+		return err
+	}
+
+	return c.NoContent(200)
+}
+
+func getApplicationSourceFromContext(c echo.Context) (*resources.ApplicationSource, error) {
+	var source resources.ApplicationSource
+
+	if err := c.Bind(&source); err != nil {
+		return nil, err
+	}
+
+	return &source, nil
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/suite"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ApplicationSourceTestSuite struct {
+	WithControllerTestSuite
+	namespace string
+}
+
+func TestApplicationSourceTestSuite(t *testing.T) {
+	suite.Run(t, new(ApplicationSourceTestSuite))
+}
+
+func (suite *ApplicationSourceTestSuite) SetupSuite() {
+	suite.WithControllerTestSuite.SetupSuite()
+	suite.namespace = "kalm-test-sources"
+	suite.ensureNamespaceExist(suite.namespace)
+}
+
+func (suite *ApplicationSourceTestSuite) TeardownSuite() {
+	suite.ensureNamespaceDeleted(suite.namespace)
+}
+
+func (suite *ApplicationSourceTestSuite) TestCreateAndSuspendApplicationSource() {
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetClusterEditorRole(),
+		},
+		Method: http.MethodPost,
+		Path:   "/v1alpha1/applicationsources",
+		Body: &resources.ApplicationSource{
+			Name: "web",
+			ApplicationSourceSpec: v1alpha1.ApplicationSourceSpec{
+				Repo:            "https://example.com/web.git",
+				TargetNamespace: suite.namespace,
+			},
+		},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, "editor", "cluster")
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.ApplicationSource
+			rec.BodyAsJSON(&res)
+			suite.Equal(201, rec.Code)
+			suite.Equal("https://example.com/web.git", res.Repo)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNs(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodGet,
+		Path:      "/v1alpha1/applicationsources",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			var res []resources.ApplicationSource
+			rec.BodyAsJSON(&res)
+			suite.Len(res, 0)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res []resources.ApplicationSource
+			rec.BodyAsJSON(&res)
+			suite.Equal(200, rec.Code)
+			suite.Len(res, 1)
+		},
+	})
+
+	// applied by the controller from the repository
+	suite.Nil(suite.Create(&v1alpha1.Component{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "web",
+			Namespace: suite.namespace,
+			Labels:    map[string]string{v1alpha1.ApplicationSourceLabelName: "web"},
+		},
+		Spec: v1alpha1.ComponentSpec{
+			Image: "web:v1",
+		},
+	}))
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNs(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodPut,
+		Path:      fmt.Sprintf("/v1alpha1/applications/%s/components/web", suite.namespace),
+		Body: resources.Component{
+			Name: "web",
+			ComponentSpec: &v1alpha1.ComponentSpec{
+				Image: "web:v2",
+			},
+		},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, "editor", suite.namespace)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(200, rec.Code)
+
+			var source v1alpha1.ApplicationSource
+			suite.Nil(suite.Get("", "web", &source))
+			suite.True(source.Spec.Suspend)
+			suite.NotEmpty(source.Annotations[v1alpha1.AnnoApplicationSourceSuspendedBy])
+		},
+	})
+}
@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"net/http"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
 	"github.com/labstack/echo/v4"
 )
 
@@ -13,3 +16,19 @@ func (h *ApiHandler) handleListComponentPlugins(c echo.Context) error {
 
 	return c.JSON(200, plugins)
 }
+
+// handleGetComponentPluginSchema returns the JSON Schema (draft 7) of the plugin config, so clients can render forms.
+// Plugins without configSchema accept any config, an empty schema is returned for them.
+func (h *ApiHandler) handleGetComponentPluginSchema(c echo.Context) error {
+	var plugin v1alpha1.ComponentPlugin
+
+	if err := h.resourceManager.Get("", c.Param("name"), &plugin); err != nil {
+		return err
+	}
+
+	if plugin.Spec.ConfigSchema == nil || len(plugin.Spec.ConfigSchema.Raw) == 0 {
+		return c.JSON(http.StatusOK, map[string]interface{}{})
+	}
+
+	return c.JSONBlob(http.StatusOK, plugin.Spec.ConfigSchema.Raw)
+}
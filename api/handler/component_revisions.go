@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/controllers"
+	"github.com/labstack/echo/v4"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func (h *ApiHandler) handleListComponentRevisions(c echo.Context) error {
+	if !h.clientManager.CanViewNamespace(getCurrentUser(c), c.Param("applicationName")) {
+		return resources.NoNamespaceViewerRoleError(c.Param("applicationName"))
+	}
+
+	revisions, err := h.resourceManager.GetComponentRevisions(c.Param("applicationName"), c.Param("name"))
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, revisions)
+}
+
+func (h *ApiHandler) handleRollbackComponent(c echo.Context) error {
+	component, err := h.rollbackComponent(c)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := h.componentResponse(component)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, res)
+}
+
+// helper
+
+func (h *ApiHandler) rollbackComponent(c echo.Context) (*v1alpha1.Component, error) {
+	namespace := c.Param("applicationName")
+
+	if !h.clientManager.CanEditNamespace(getCurrentUser(c), namespace) {
+		return nil, resources.NoNamespaceEditorRoleError(namespace)
+	}
+
+	revisionNumber, err := strconv.ParseInt(c.Param("revision"), 10, 64)
+
+	if err != nil || revisionNumber < 1 {
+		return nil, errors.NewBadRequest(fmt.Sprintf("invalid revision: %s", c.Param("revision")))
+	}
+
+	component, err := h.resourceManager.GetComponent(namespace, c.Param("name"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var revision v1alpha1.ComponentRevision
+
+	if err := h.resourceManager.Get(namespace, controllers.ComponentRevisionName(component.Name, revisionNumber), &revision); err != nil {
+		return nil, err
+	}
+
+	if revision.Spec.ComponentName != component.Name {
+		return nil, errors.NewBadRequest(fmt.Sprintf("revision %d doesn't belong to component %s", revisionNumber, component.Name))
+	}
+
+	if err := h.resourceManager.SuspendApplicationSourceOf(component, getCurrentUser(c).Email); err != nil {
+		return nil, err
+	}
+
+	copiedComponent := component.DeepCopy()
+	copiedComponent.Spec = *revision.Spec.ComponentSpec.DeepCopy()
+	setComponentChangeAnnotations(copiedComponent, getCurrentUser(c).Email, fmt.Sprintf("rollback to revision %d", revisionNumber))
+
+	// patch the component instead of touching workloads directly, so admission webhooks will validate the old spec again.
+	if err := h.resourceManager.Patch(copiedComponent, client.MergeFrom(component)); err != nil {
+		return nil, err
+	}
+
+	return copiedComponent, nil
+}
+
+// setComponentChangeAnnotations records who and why the component is changed,
+// the controller will copy them into the ComponentRevision created for this change.
+func setComponentChangeAnnotations(component *v1alpha1.Component, changedBy, changeCause string) {
+	if component.Annotations == nil {
+		component.Annotations = make(map[string]string)
+	}
+
+	component.Annotations[v1alpha1.AnnoComponentChangedBy] = changedBy
+
+	if changeCause != "" {
+		component.Annotations[v1alpha1.AnnoComponentChangeCause] = changeCause
+	} else {
+		delete(component.Annotations, v1alpha1.AnnoComponentChangeCause)
+	}
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/suite"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ComponentRevisionTestSuite struct {
+	WithControllerTestSuite
+	namespace string
+}
+
+func TestComponentRevisionTestSuite(t *testing.T) {
+	suite.Run(t, new(ComponentRevisionTestSuite))
+}
+
+func (suite *ComponentRevisionTestSuite) SetupSuite() {
+	suite.WithControllerTestSuite.SetupSuite()
+	suite.namespace = "kalm-test-revisions"
+	suite.ensureNamespaceExist(suite.namespace)
+}
+
+func (suite *ComponentRevisionTestSuite) TeardownSuite() {
+	suite.ensureNamespaceDeleted(suite.namespace)
+}
+
+func (suite *ComponentRevisionTestSuite) TestListAndRollback() {
+	component := v1alpha1.Component{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "foobar",
+			Namespace: suite.namespace,
+		},
+		Spec: v1alpha1.ComponentSpec{
+			Image: "foo:v2",
+		},
+	}
+
+	suite.Nil(suite.Create(&component))
+
+	for i, image := range []string{"foo:v1", "foo:v2"} {
+		suite.Nil(suite.Create(&v1alpha1.ComponentRevision{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      fmt.Sprintf("foobar-%d", i+1),
+				Namespace: suite.namespace,
+				Labels: map[string]string{
+					v1alpha1.ComponentRevisionLabelComponent: "foobar",
+				},
+			},
+			Spec: v1alpha1.ComponentRevisionSpec{
+				ComponentName: "foobar",
+				Revision:      int64(i + 1),
+				ComponentSpec: v1alpha1.ComponentSpec{Image: image},
+			},
+		}))
+	}
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNs(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodGet,
+		Path:      fmt.Sprintf("/v1alpha1/applications/%s/components/foobar/revisions", suite.namespace),
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, "viewer", suite.namespace)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res []resources.ComponentRevision
+			rec.BodyAsJSON(&res)
+			suite.Equal(200, rec.Code)
+			suite.Len(res, 2)
+			suite.Equal(int64(2), res[0].Revision)
+			suite.Equal("foo:v1", res[1].ComponentSpec.Image)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNs(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodPost,
+		Path:      fmt.Sprintf("/v1alpha1/applications/%s/components/foobar/rollback/1", suite.namespace),
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, "editor", suite.namespace)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.Component
+			rec.BodyAsJSON(&res)
+			suite.Equal(200, rec.Code)
+			suite.Equal("foo:v1", res.Image)
+
+			component, err := suite.getComponent(suite.namespace, "foobar")
+			suite.Nil(err)
+			suite.Equal("foo:v1", component.Spec.Image)
+			suite.Equal("rollback to revision 1", component.Annotations[v1alpha1.AnnoComponentChangeCause])
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNs(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodPost,
+		Path:      fmt.Sprintf("/v1alpha1/applications/%s/components/foobar/rollback/3", suite.namespace),
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, "editor", suite.namespace)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(404, rec.Code)
+		},
+	})
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *ApiHandler) handleListComponentRuns(c echo.Context) error {
+	namespace := c.Param("applicationName")
+
+	if !h.clientManager.CanViewNamespace(getCurrentUser(c), namespace) {
+		return resources.NoNamespaceViewerRoleError(namespace)
+	}
+
+	runs, err := h.resourceManager.GetComponentRuns(namespace, c.Param("name"))
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, runs)
+}
+
+// handleCreateComponentRun triggers a manual run of a cronjob component.
+func (h *ApiHandler) handleCreateComponentRun(c echo.Context) error {
+	namespace := c.Param("applicationName")
+
+	if !h.clientManager.CanEditNamespace(getCurrentUser(c), namespace) {
+		return resources.NoNamespaceEditorRoleError(namespace)
+	}
+
+	component, err := h.resourceManager.GetComponent(namespace, c.Param("name"))
+
+	if err != nil {
+		return err
+	}
+
+	run, err := h.resourceManager.CreateComponentRun(component)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, run)
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/controllers"
+	"github.com/stretchr/testify/suite"
+	batchV1 "k8s.io/api/batch/v1"
+	batchV1Beta1 "k8s.io/api/batch/v1beta1"
+	coreV1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ComponentRunTestSuite struct {
+	WithControllerTestSuite
+	namespace string
+}
+
+func TestComponentRunTestSuite(t *testing.T) {
+	suite.Run(t, new(ComponentRunTestSuite))
+}
+
+func (suite *ComponentRunTestSuite) SetupSuite() {
+	suite.WithControllerTestSuite.SetupSuite()
+	suite.namespace = "kalm-test-runs"
+	suite.ensureNamespaceExist(suite.namespace)
+}
+
+func (suite *ComponentRunTestSuite) TeardownSuite() {
+	suite.ensureNamespaceDeleted(suite.namespace)
+}
+
+func (suite *ComponentRunTestSuite) TestCreateAndListRuns() {
+	suite.Nil(suite.Create(&v1alpha1.Component{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "web",
+			Namespace: suite.namespace,
+		},
+		Spec: v1alpha1.ComponentSpec{
+			Image:        "foo:bar",
+			WorkloadType: v1alpha1.WorkloadTypeServer,
+		},
+	}))
+
+	suite.Nil(suite.Create(&v1alpha1.Component{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "backup",
+			Namespace: suite.namespace,
+		},
+		Spec: v1alpha1.ComponentSpec{
+			Image:        "foo:bar",
+			WorkloadType: v1alpha1.WorkloadTypeCronjob,
+			Schedule:     "0 0 * * *",
+		},
+	}))
+
+	// created by the controller in a real cluster
+	suite.Nil(suite.Create(&batchV1Beta1.CronJob{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "backup",
+			Namespace: suite.namespace,
+		},
+		Spec: batchV1Beta1.CronJobSpec{
+			Schedule: "0 0 * * *",
+			JobTemplate: batchV1Beta1.JobTemplateSpec{
+				ObjectMeta: v1.ObjectMeta{
+					Labels: map[string]string{controllers.KalmLabelComponentKey: "backup"},
+				},
+				Spec: batchV1.JobSpec{
+					Template: coreV1.PodTemplateSpec{
+						Spec: coreV1.PodSpec{
+							RestartPolicy: coreV1.RestartPolicyOnFailure,
+							Containers:    []coreV1.Container{{Name: "backup", Image: "foo:bar"}},
+						},
+					},
+				},
+			},
+		},
+	}))
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNs(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodPost,
+		Path:      fmt.Sprintf("/v1alpha1/applications/%s/components/web/runs", suite.namespace),
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, "editor", suite.namespace)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(400, rec.Code)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNs(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodPost,
+		Path:      fmt.Sprintf("/v1alpha1/applications/%s/components/backup/runs", suite.namespace),
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, "editor", suite.namespace)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.ComponentRun
+			rec.BodyAsJSON(&res)
+			suite.Equal(201, rec.Code)
+			suite.True(res.Manual)
+			suite.True(strings.HasPrefix(res.Name, "backup-manual-"))
+			suite.Equal(resources.ComponentRunStatusRunning, res.Status)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNs(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodGet,
+		Path:      fmt.Sprintf("/v1alpha1/applications/%s/components/backup/runs", suite.namespace),
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, "viewer", suite.namespace)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res []resources.ComponentRun
+			rec.BodyAsJSON(&res)
+			suite.Equal(200, rec.Code)
+			suite.Len(res, 1)
+			suite.True(res[0].Manual)
+		},
+	})
+}
@@ -90,12 +90,17 @@ func (h *ApiHandler) deleteComponent(c echo.Context) error {
 		return resources.NoNamespaceEditorRoleError(c.Param("applicationName"))
 	}
 
-	err := h.resourceManager.Delete(&v1alpha1.Component{ObjectMeta: metaV1.ObjectMeta{
-		Name:      c.Param("name"),
-		Namespace: c.Param("applicationName"),
-	}})
+	component, err := h.resourceManager.GetComponent(c.Param("applicationName"), c.Param("name"))
 
-	return err
+	if err != nil {
+		return err
+	}
+
+	if err := h.resourceManager.SuspendApplicationSourceOf(component, getCurrentUser(c).Email); err != nil {
+		return err
+	}
+
+	return h.resourceManager.Delete(component)
 }
 
 func (h *ApiHandler) getComponent(c echo.Context) (*v1alpha1.Component, error) {
@@ -182,6 +187,7 @@ func (h *ApiHandler) createComponent(c echo.Context) (*v1alpha1.Component, error
 	}
 
 	crdComponent.Namespace = c.Param("applicationName")
+	setComponentChangeAnnotations(crdComponent, getCurrentUser(c).Email, component.ChangeCause)
 
 	if err := h.resourceManager.Create(crdComponent); err != nil {
 		return nil, err
@@ -211,10 +217,26 @@ func (h *ApiHandler) updateComponent(c echo.Context) (*v1alpha1.Component, error
 		return nil, resources.NoNamespaceEditorRoleError(crdComponent.Namespace)
 	}
 
-	if err := h.resourceManager.Apply(crdComponent); err != nil {
+	fetched, err := h.resourceManager.GetComponent(crdComponent.Namespace, crdComponent.Name)
+
+	if err != nil {
 		return nil, err
 	}
 
+	if err := h.resourceManager.SuspendApplicationSourceOf(fetched, getCurrentUser(c).Email); err != nil {
+		return nil, err
+	}
+
+	copiedComponent := fetched.DeepCopy()
+	copiedComponent.Spec = crdComponent.Spec
+	setComponentChangeAnnotations(copiedComponent, getCurrentUser(c).Email, component.ChangeCause)
+
+	if err := h.resourceManager.Patch(copiedComponent, client.MergeFrom(fetched)); err != nil {
+		return nil, err
+	}
+
+	crdComponent = copiedComponent
+
 	if err := h.resourceManager.UpdateProtectedEndpointForComponent(crdComponent, component.ProtectedEndpointSpec); err != nil {
 		return nil, err
 	}
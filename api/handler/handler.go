@@ -16,6 +16,7 @@ type ApiHandler struct {
 	resourceManager *resources.ResourceManager
 	clientManager   client.ClientManager
 	logger          logr.Logger
+	webhookNonces   *webhookNonceCache
 }
 
 type H map[string]interface{}
@@ -71,6 +72,12 @@ func (h *ApiHandler) InstallAdminRoutes(e *echo.Echo) {
 func (h *ApiHandler) InstallWebhookRoutes(e *echo.Echo) {
 	e.GET("/ping", handlePing)
 	e.POST("/webhook/components", h.handleDeployWebhookCall)
+	e.POST("/webhook/v2/deploy", h.handleDeployWebhookV2Call)
+	e.GET("/webhook/v2/deployments/:id", h.handleGetWebhookV2Deployment)
+	e.POST("/webhook/dockerhub", h.handleDockerHubWebhookCall)
+	e.POST("/webhook/harbor", h.handleHarborWebhookCall)
+	e.POST("/webhook/github", h.handleGithubWebhookCall)
+	e.POST("/webhook/gitlab", h.handleGitlabWebhookCall)
 }
 
 func (h *ApiHandler) InstallMainRoutes(e *echo.Echo) {
@@ -102,18 +109,32 @@ func (h *ApiHandler) InstallMainRoutes(e *echo.Echo) {
 	gv1Alpha1WithAuth.GET("/cluster", h.handleClusterInfo)
 	gv1Alpha1WithAuth.GET("/applications", h.handleGetApplications)
 	gv1Alpha1WithAuth.POST("/applications", h.handleCreateApplication)
+	gv1Alpha1WithAuth.POST("/applications/import", h.handleImportApplication)
 	gv1Alpha1WithAuth.GET("/applications/:name", h.handleGetApplicationDetails)
 	gv1Alpha1WithAuth.DELETE("/applications/:name", h.handleDeleteApplication)
+	gv1Alpha1WithAuth.GET("/applications/:name/export", h.handleExportApplication)
+	gv1Alpha1WithAuth.GET("/applications/:name/previews", h.handleListPreviewEnvironments)
+	gv1Alpha1WithAuth.POST("/applications/:name/previews", h.handleCreatePreviewEnvironment)
+	gv1Alpha1WithAuth.GET("/applications/:name/previews/:pullRequest", h.handleGetPreviewEnvironment)
+	gv1Alpha1WithAuth.DELETE("/applications/:name/previews/:pullRequest", h.handleDeletePreviewEnvironment)
+	gv1Alpha1WithAuth.GET("/applications/:name/secrets", h.handleListApplicationSecrets)
+	gv1Alpha1WithAuth.PUT("/applications/:name/secrets/:secretName", h.handleSetApplicationSecret)
+	gv1Alpha1WithAuth.DELETE("/applications/:name/secrets/:secretName", h.handleDeleteApplicationSecret)
 
 	gv1Alpha1WithAuth.GET("/services", h.handleListClusterServices)
 
 	gv1Alpha1WithAuth.GET("/componentplugins", h.handleListComponentPlugins)
+	gv1Alpha1WithAuth.GET("/componentplugins/:name/schema", h.handleGetComponentPluginSchema)
 
 	gv1Alpha1WithAuth.GET("/applications/:applicationName/components", h.handleListComponents)
 	gv1Alpha1WithAuth.GET("/applications/:applicationName/components/:name", h.handleGetComponent)
 	gv1Alpha1WithAuth.PUT("/applications/:applicationName/components/:name", h.handleUpdateComponent)
 	gv1Alpha1WithAuth.DELETE("/applications/:applicationName/components/:name", h.handleDeleteComponent)
 	gv1Alpha1WithAuth.POST("/applications/:applicationName/components", h.handleCreateComponent)
+	gv1Alpha1WithAuth.GET("/applications/:applicationName/components/:name/revisions", h.handleListComponentRevisions)
+	gv1Alpha1WithAuth.POST("/applications/:applicationName/components/:name/rollback/:revision", h.handleRollbackComponent)
+	gv1Alpha1WithAuth.GET("/applications/:applicationName/components/:name/runs", h.handleListComponentRuns)
+	gv1Alpha1WithAuth.POST("/applications/:applicationName/components/:name/runs", h.handleCreateComponentRun)
 
 	gv1Alpha1WithAuth.GET("/registries", h.handleListRegistries)
 	gv1Alpha1WithAuth.GET("/registries/:name", h.handleGetRegistry)
@@ -121,6 +142,12 @@ func (h *ApiHandler) InstallMainRoutes(e *echo.Echo) {
 	gv1Alpha1WithAuth.POST("/registries", h.handleCreateRegistry)
 	gv1Alpha1WithAuth.DELETE("/registries/:name", h.handleDeleteRegistry)
 
+	gv1Alpha1WithAuth.GET("/applicationsources", h.handleListApplicationSources)
+	gv1Alpha1WithAuth.GET("/applicationsources/:name", h.handleGetApplicationSource)
+	gv1Alpha1WithAuth.PUT("/applicationsources/:name", h.handleUpdateApplicationSource)
+	gv1Alpha1WithAuth.POST("/applicationsources", h.handleCreateApplicationSource)
+	gv1Alpha1WithAuth.DELETE("/applicationsources/:name", h.handleDeleteApplicationSource)
+
 	gv1Alpha1WithAuth.DELETE("/pods/:namespace/:name", h.handleDeletePod)
 
 	gv1Alpha1WithAuth.GET("/rolebindings", h.handleListRoleBindings)
@@ -195,5 +222,6 @@ func NewApiHandler(clientManager client.ClientManager) *ApiHandler {
 		clientManager:   clientManager,
 		logger:          log.DefaultLogger(),
 		resourceManager: resources.NewResourceManager(clientManager.GetDefaultClusterConfig(), log.DefaultLogger()),
+		webhookNonces:   newWebhookNonceCache(),
 	}
 }
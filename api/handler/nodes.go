@@ -30,6 +30,17 @@ func (h *ApiHandler) handleCordonNode(c echo.Context) error {
 		return err
 	}
 
+	// drain mode evicts pods on the node as well, and reports components blocking the drain
+	if c.QueryParam("drain") == "true" {
+		res, err := h.resourceManager.DrainNode(node)
+
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(200, res)
+	}
+
 	if err := h.resourceManager.CordonNode(node); err != nil {
 		return err
 	}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/labstack/echo/v4"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+func getPullRequestFromContext(c echo.Context) (int32, error) {
+	pullRequest, err := strconv.ParseInt(c.Param("pullRequest"), 10, 32)
+
+	if err != nil || pullRequest < 1 {
+		return 0, errors.NewBadRequest("invalid pull request number " + c.Param("pullRequest"))
+	}
+
+	return int32(pullRequest), nil
+}
+
+func (h *ApiHandler) handleListPreviewEnvironments(c echo.Context) error {
+	application := c.Param("name")
+
+	if !h.clientManager.CanViewNamespace(getCurrentUser(c), application) {
+		return resources.NoNamespaceViewerRoleError(application)
+	}
+
+	previews, err := h.resourceManager.GetPreviewEnvironments(application)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, previews)
+}
+
+// handleGetPreviewEnvironment is polled by CI until the environment is ready, then the url in status is posted to the pull request.
+func (h *ApiHandler) handleGetPreviewEnvironment(c echo.Context) error {
+	application := c.Param("name")
+
+	if !h.clientManager.CanViewNamespace(getCurrentUser(c), application) {
+		return resources.NoNamespaceViewerRoleError(application)
+	}
+
+	pullRequest, err := getPullRequestFromContext(c)
+
+	if err != nil {
+		return err
+	}
+
+	preview, err := h.resourceManager.GetPreviewEnvironment(application, pullRequest)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, preview)
+}
+
+// handleCreatePreviewEnvironment clones the application for a pull request, or updates image tags of the existing clone.
+// Editors of the template application can create previews, the namespace of a preview is derived from the template.
+func (h *ApiHandler) handleCreatePreviewEnvironment(c echo.Context) error {
+	application := c.Param("name")
+
+	if !h.clientManager.CanEditNamespace(getCurrentUser(c), application) {
+		return resources.NoNamespaceEditorRoleError(application)
+	}
+
+	var spec v1alpha1.PreviewEnvironmentSpec
+
+	if err := c.Bind(&spec); err != nil {
+		return err
+	}
+
+	if spec.PullRequest < 1 {
+		return errors.NewBadRequest("pullRequest is required")
+	}
+
+	spec.Application = application
+
+	preview, created, err := h.resourceManager.CreateOrUpdatePreviewEnvironment(spec)
+
+	if err != nil {
+		return err
+	}
+
+	if created {
+		return c.JSON(201, preview)
+	}
+
+	return c.JSON(200, preview)
+}
+
+// handleDeletePreviewEnvironment is called when the pull request is closed
+func (h *ApiHandler) handleDeletePreviewEnvironment(c echo.Context) error {
+	application := c.Param("name")
+
+	if !h.clientManager.CanEditNamespace(getCurrentUser(c), application) {
+		return resources.NoNamespaceEditorRoleError(application)
+	}
+
+	pullRequest, err := getPullRequestFromContext(c)
+
+	if err != nil {
+		return err
+	}
+
+	if err := h.resourceManager.DeletePreviewEnvironment(application, pullRequest); err != nil {
+		return err
+	}
+
+	return c.NoContent(200)
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/suite"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type PreviewEnvironmentsTestSuite struct {
+	WithControllerTestSuite
+	namespace string
+}
+
+func TestPreviewEnvironmentsTestSuite(t *testing.T) {
+	suite.Run(t, new(PreviewEnvironmentsTestSuite))
+}
+
+func (suite *PreviewEnvironmentsTestSuite) SetupSuite() {
+	suite.WithControllerTestSuite.SetupSuite()
+	suite.namespace = "kalm-test-preview"
+	suite.ensureNamespaceExist(suite.namespace)
+}
+
+func (suite *PreviewEnvironmentsTestSuite) TeardownSuite() {
+	suite.ensureNamespaceDeleted(suite.namespace)
+	suite.ensureNamespaceDeleted("kalm-test-preview-pr-7")
+}
+
+func (suite *PreviewEnvironmentsTestSuite) TestCreateAndDeletePreviewEnvironment() {
+	suite.Nil(suite.Create(&v1alpha1.Component{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "web",
+			Namespace: suite.namespace,
+		},
+		Spec: v1alpha1.ComponentSpec{
+			Image: "web:v1",
+		},
+	}))
+
+	suite.Nil(suite.Create(&v1alpha1.HttpRoute{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "web",
+			Namespace: suite.namespace,
+		},
+		Spec: v1alpha1.HttpRouteSpec{
+			Hosts:   []string{"web.example.com"},
+			Paths:   []string{"/"},
+			Methods: []v1alpha1.HttpRouteMethod{"GET"},
+			Schemes: []v1alpha1.HttpRouteScheme{"http"},
+			Destinations: []v1alpha1.HttpRouteDestination{
+				{Host: "web." + suite.namespace + ".svc.cluster.local:80", Weight: 1},
+			},
+		},
+	}))
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNs(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodPost,
+		Path:      "/v1alpha1/applications/" + suite.namespace + "/previews",
+		Body: map[string]interface{}{
+			"pullRequest": 7,
+			"domain":      "preview.example.com",
+			"imageTags":   map[string]string{"web": "pr-7"},
+		},
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, "editor", suite.namespace)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.PreviewEnvironment
+			rec.BodyAsJSON(&res)
+			suite.Equal(201, rec.Code)
+			suite.Equal("kalm-test-preview-pr-7", res.Name)
+
+			var namespace coreV1.Namespace
+			suite.Nil(suite.Get("", res.Name, &namespace))
+			suite.Equal(res.Name, namespace.Labels[v1alpha1.PreviewEnvironmentLabelName])
+
+			component, err := suite.getComponent(res.Name, "web")
+			suite.Nil(err)
+			suite.Equal("web:pr-7", component.Spec.Image)
+
+			var route v1alpha1.HttpRoute
+			suite.Nil(suite.Get(res.Name, "web", &route))
+			suite.Equal([]string{"pr-7.preview.example.com"}, route.Spec.Hosts)
+			suite.Equal("web."+res.Name+".svc.cluster.local:80", route.Spec.Destinations[0].Host)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNs(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodGet,
+		Path:      "/v1alpha1/applications/" + suite.namespace + "/previews/7",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, "viewer", suite.namespace)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			var res resources.PreviewEnvironment
+			rec.BodyAsJSON(&res)
+			suite.Equal(200, rec.Code)
+			suite.Equal(int32(7), res.PullRequest)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNs(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodDelete,
+		Path:      "/v1alpha1/applications/" + suite.namespace + "/previews/7",
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, "editor", suite.namespace)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(200, rec.Code)
+
+			var preview v1alpha1.PreviewEnvironment
+			suite.True(errors.IsNotFound(suite.Get("", "kalm-test-preview-pr-7", &preview)))
+		},
+	})
+}
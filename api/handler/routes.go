@@ -2,7 +2,9 @@ package handler
 
 import (
 	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
 	"github.com/labstack/echo/v4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func (h *ApiHandler) handleListAllRoutes(c echo.Context) error {
@@ -56,6 +58,10 @@ func (h *ApiHandler) handleUpdateRoute(c echo.Context) (err error) {
 		return resources.InsufficientPermissionsError
 	}
 
+	if err := h.suspendApplicationSourceOfRoute(c, route.Namespace, route.Name); err != nil {
+		return err
+	}
+
 	if route, err = h.resourceManager.UpdateHttpRoute(route); err != nil {
 		return err
 	}
@@ -74,6 +80,10 @@ func (h *ApiHandler) handleDeleteRoute(c echo.Context) (err error) {
 		return resources.InsufficientPermissionsError
 	}
 
+	if err := h.suspendApplicationSourceOfRoute(c, route.Namespace, route.Name); err != nil {
+		return err
+	}
+
 	if err = h.resourceManager.DeleteHttpRoute(route.Namespace, route.Name); err != nil {
 		return err
 	}
@@ -81,6 +91,16 @@ func (h *ApiHandler) handleDeleteRoute(c echo.Context) (err error) {
 	return c.NoContent(200)
 }
 
+func (h *ApiHandler) suspendApplicationSourceOfRoute(c echo.Context, namespace, name string) error {
+	var route v1alpha1.HttpRoute
+
+	if err := h.resourceManager.Get(namespace, name, &route); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	return h.resourceManager.SuspendApplicationSourceOf(&route, getCurrentUser(c).Email)
+}
+
 func getHttpRouteFromContext(c echo.Context) (*resources.HttpRoute, error) {
 	var route resources.HttpRoute
 
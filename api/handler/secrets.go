@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/labstack/echo/v4"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func (h *ApiHandler) handleListApplicationSecrets(c echo.Context) error {
+	namespace := c.Param("name")
+
+	if !h.clientManager.CanViewNamespace(getCurrentUser(c), namespace) {
+		return resources.NoNamespaceViewerRoleError(namespace)
+	}
+
+	secrets, err := h.resourceManager.GetApplicationSecrets(namespace)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, secrets)
+}
+
+// handleSetApplicationSecret creates or rotates a secret, the value can't be read back through the api.
+func (h *ApiHandler) handleSetApplicationSecret(c echo.Context) error {
+	namespace := c.Param("name")
+	currentUser := getCurrentUser(c)
+
+	if !h.clientManager.CanEditNamespace(currentUser, namespace) {
+		return resources.NoNamespaceEditorRoleError(namespace)
+	}
+
+	secretName := c.Param("secretName")
+
+	if errs := validation.IsConfigMapKey(secretName); len(errs) > 0 {
+		return errors.NewBadRequest("invalid secret name: " + strings.Join(errs, ", "))
+	}
+
+	var req resources.SetApplicationSecretRequest
+
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if req.Value == "" {
+		return errors.NewBadRequest("value is required")
+	}
+
+	secret, err := h.resourceManager.SetApplicationSecret(namespace, secretName, req.Value, currentUser.Email)
+
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("secret audit", "action", "set", "application", namespace, "secret", secretName, "version", secret.Version, "user", currentUser.Email)
+
+	return c.JSON(200, secret)
+}
+
+func (h *ApiHandler) handleDeleteApplicationSecret(c echo.Context) error {
+	namespace := c.Param("name")
+	currentUser := getCurrentUser(c)
+
+	if !h.clientManager.CanEditNamespace(currentUser, namespace) {
+		return resources.NoNamespaceEditorRoleError(namespace)
+	}
+
+	if err := h.resourceManager.DeleteApplicationSecret(namespace, c.Param("secretName")); err != nil {
+		return err
+	}
+
+	h.logger.Info("secret audit", "action", "delete", "application", namespace, "secret", c.Param("secretName"), "user", currentUser.Email)
+
+	return c.NoContent(200)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/suite"
+)
+
+type SecretsTestSuite struct {
+	WithControllerTestSuite
+	namespace string
+}
+
+func TestSecretsTestSuite(t *testing.T) {
+	suite.Run(t, new(SecretsTestSuite))
+}
+
+func (suite *SecretsTestSuite) SetupSuite() {
+	suite.WithControllerTestSuite.SetupSuite()
+	suite.namespace = "kalm-test-secrets"
+	suite.ensureNamespaceExist(suite.namespace)
+
+	// the cluster key is saved in the system namespace
+	suite.ensureNamespaceExist(v1alpha1.KalmSystemNamespace)
+}
+
+func (suite *SecretsTestSuite) TeardownSuite() {
+	suite.ensureNamespaceDeleted(suite.namespace)
+}
+
+func (suite *SecretsTestSuite) TestSetAndListSecrets() {
+	for _, version := range []int64{1, 2} {
+		suite.DoTestRequest(&TestRequestContext{
+			Roles: []string{
+				GetEditorRoleOfNs(suite.namespace),
+			},
+			Namespace: suite.namespace,
+			Method:    http.MethodPut,
+			Path:      fmt.Sprintf("/v1alpha1/applications/%s/secrets/db-password", suite.namespace),
+			Body:      resources.SetApplicationSecretRequest{Value: fmt.Sprintf("p@ssw0rd-%d", version)},
+			TestWithoutRoles: func(rec *ResponseRecorder) {
+				suite.IsMissingRoleError(rec, "editor", suite.namespace)
+			},
+			TestWithRoles: func(rec *ResponseRecorder) {
+				var res resources.ApplicationSecret
+				rec.BodyAsJSON(&res)
+				suite.Equal(200, rec.Code)
+				suite.Equal("db-password", res.Name)
+				suite.Equal(version, res.Version)
+				suite.Equal("foo@bar", res.UpdatedBy)
+			},
+		})
+	}
+
+	var store v1alpha1.SecretStore
+	suite.Nil(suite.Get(suite.namespace, v1alpha1.SecretStoreName, &store))
+	suite.Len(store.Spec.Secrets, 1)
+	suite.NotContains(store.Spec.Secrets[0].EncryptedValue, "p@ssw0rd")
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetViewerRoleOfNs(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodGet,
+		Path:      fmt.Sprintf("/v1alpha1/applications/%s/secrets", suite.namespace),
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, "viewer", suite.namespace)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(200, rec.Code)
+			suite.NotContains(rec.BodyAsString(), "p@ssw0rd")
+
+			var res []resources.ApplicationSecret
+			rec.BodyAsJSON(&res)
+			suite.Len(res, 1)
+			suite.Equal(int64(2), res[0].Version)
+		},
+	})
+
+	suite.DoTestRequest(&TestRequestContext{
+		Roles: []string{
+			GetEditorRoleOfNs(suite.namespace),
+		},
+		Namespace: suite.namespace,
+		Method:    http.MethodDelete,
+		Path:      fmt.Sprintf("/v1alpha1/applications/%s/secrets/db-password", suite.namespace),
+		TestWithoutRoles: func(rec *ResponseRecorder) {
+			suite.IsMissingRoleError(rec, "editor", suite.namespace)
+		},
+		TestWithRoles: func(rec *ResponseRecorder) {
+			suite.Equal(200, rec.Code)
+			suite.Nil(suite.Get(suite.namespace, v1alpha1.SecretStoreName, &store))
+			suite.Len(store.Spec.Secrets, 0)
+		},
+	})
+}
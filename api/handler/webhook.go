@@ -81,6 +81,12 @@ func (h *ApiHandler) handleDeployWebhookCall(c echo.Context) error {
 
 	updateTs := int(time.Now().Unix())
 	copiedComp.Annotations[controllers.AnnoLastUpdatedByWebhook] = strconv.Itoa(updateTs)
+	setComponentChangeAnnotations(copiedComp, clientInfo.Email, fmt.Sprintf("deploy webhook, image: %s", copiedComp.Spec.Image))
+
+	// the next sync of the application source would revert the image
+	if err := h.resourceManager.SuspendApplicationSourceOf(crdComp, clientInfo.Email); err != nil {
+		return err
+	}
 
 	if err := h.resourceManager.Patch(copiedComp, client.MergeFrom(crdComp)); err != nil {
 		h.logger.Info("fail updating component", "name", copiedComp.Name, "time", updateTs)
@@ -107,16 +113,41 @@ func (h *ApiHandler) handleDeployWebhookCall(c echo.Context) error {
 	})
 }
 
-// controller/foo,    v1 -> controller/foo:v1
-// controller/foo:v2, v3 -> controller/foo:v3
+// controller/foo,             v1 -> controller/foo:v1
+// controller/foo:v2,          v3 -> controller/foo:v3
+// host:5000/foo,              v1 -> host:5000/foo:v1
+// controller/foo@sha256:abc,  v1 -> controller/foo:v1
 func replaceImageTag(image string, tag string) string {
-	sepIdx := strings.LastIndex(image, ":")
-	if sepIdx == -1 {
-		return image + ":" + tag
+	repo, _, _ := splitImageReference(image)
+	return repo + ":" + tag
+}
+
+// controller/foo:v1,          sha256:abc -> controller/foo:v1@sha256:abc
+// host:5000/foo@sha256:abc,   sha256:def -> host:5000/foo@sha256:def
+func replaceImageDigest(image string, digest string) string {
+	repo, tag, _ := splitImageReference(image)
+
+	if tag != "" {
+		return repo + ":" + tag + "@" + digest
 	}
 
-	parts := strings.Split(image, ":")
-	parts[len(parts)-1] = tag
+	return repo + "@" + digest
+}
+
+// splitImageReference splits an image reference into repository, tag and digest.
+// A colon is a tag separator only if it's after the last slash, otherwise it belongs to the registry host port.
+func splitImageReference(image string) (repo, tag, digest string) {
+	repo = image
+
+	if idx := strings.Index(repo, "@"); idx != -1 {
+		digest = repo[idx+1:]
+		repo = repo[:idx]
+	}
+
+	if idx := strings.LastIndex(repo, ":"); idx != -1 && idx > strings.LastIndex(repo, "/") {
+		tag = repo[idx+1:]
+		repo = repo[:idx]
+	}
 
-	return strings.Join(parts, ":")
+	return repo, tag, digest
 }
@@ -0,0 +1,452 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kalmhq/kalm/api/auth"
+	"github.com/kalmhq/kalm/api/client"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/controllers"
+	"github.com/labstack/echo/v4"
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrlClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// Components opt in to push-event redeploys by setting a regexp of tags on this annotation.
+	// e.g. core.kalm.dev/push-tag-pattern: "v[0-9]+\.[0-9]+\.[0-9]+"
+	AnnoPushTagPattern = "core.kalm.dev/push-tag-pattern"
+
+	defaultGitlabRegistry = "registry.gitlab.com"
+	defaultGithubRegistry = "ghcr.io"
+)
+
+// imagePushEvent is the provider independent form of a registry or scm push event
+type imagePushEvent struct {
+	Provider string
+	// full repository without tag, e.g. harbor.example.com/library/nginx
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+type dockerHubPushPayload struct {
+	PushData struct {
+		Tag string `json:"tag"`
+	} `json:"push_data"`
+	Repository struct {
+		RepoName string `json:"repo_name"`
+	} `json:"repository"`
+}
+
+type harborPushPayload struct {
+	Type      string `json:"type"`
+	EventData struct {
+		Resources []struct {
+			Digest      string `json:"digest"`
+			Tag         string `json:"tag"`
+			ResourceURL string `json:"resource_url"`
+		} `json:"resources"`
+	} `json:"event_data"`
+}
+
+type githubPackage struct {
+	Name        string `json:"name"`
+	PackageType string `json:"package_type"`
+	Owner       struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+	PackageVersion struct {
+		Version           string `json:"version"`
+		ContainerMetadata struct {
+			Tag struct {
+				Name   string `json:"name"`
+				Digest string `json:"digest"`
+			} `json:"tag"`
+		} `json:"container_metadata"`
+	} `json:"package_version"`
+}
+
+// The package event carries the package in "package", the registry_package event in "registry_package".
+type githubPackagePayload struct {
+	Action          string         `json:"action"`
+	Package         *githubPackage `json:"package"`
+	RegistryPackage *githubPackage `json:"registry_package"`
+}
+
+// toImagePushEvent returns false if the payload is not a published container image
+func (p *githubPackagePayload) toImagePushEvent(registry string) (imagePushEvent, bool) {
+	pkg := p.Package
+
+	if pkg == nil {
+		pkg = p.RegistryPackage
+	}
+
+	if p.Action != "published" || pkg == nil || !strings.EqualFold(pkg.PackageType, "container") {
+		return imagePushEvent{}, false
+	}
+
+	version := pkg.PackageVersion
+	digest := version.ContainerMetadata.Tag.Digest
+
+	if digest == "" && strings.HasPrefix(version.Version, "sha256:") {
+		digest = version.Version
+	}
+
+	return imagePushEvent{
+		Provider:   "github",
+		Repository: strings.ToLower(fmt.Sprintf("%s/%s/%s", registry, pkg.Owner.Login, pkg.Name)),
+		Tag:        version.ContainerMetadata.Tag.Name,
+		Digest:     digest,
+	}, true
+}
+
+type gitlabTagPushPayload struct {
+	ObjectKind string `json:"object_kind"`
+	Ref        string `json:"ref"`
+	After      string `json:"after"`
+	Project    struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+}
+
+type ImagePushWebhookResponse struct {
+	Components []string `json:"components"`
+}
+
+// Docker Hub webhooks can't carry headers or signatures, the token has to be put in the url query.
+// POST /webhook/dockerhub?token=<token>
+func (h *ApiHandler) handleDockerHubWebhookCall(c echo.Context) error {
+	clientInfo, err := h.clientManager.GetClientInfoFromToken(c.QueryParam("token"))
+
+	if err != nil {
+		return err
+	}
+
+	var payload dockerHubPushPayload
+
+	if err := c.Bind(&payload); err != nil {
+		return err
+	}
+
+	if payload.Repository.RepoName == "" || payload.PushData.Tag == "" {
+		return errors.NewBadRequest("repository.repo_name and push_data.tag are required")
+	}
+
+	return h.handleImagePushEvent(c, clientInfo, []imagePushEvent{{
+		Provider:   "dockerhub",
+		Repository: payload.Repository.RepoName,
+		Tag:        payload.PushData.Tag,
+	}})
+}
+
+// Harbor sends the "Auth Header" configured in the webhook policy as the Authorization header.
+// POST /webhook/harbor
+func (h *ApiHandler) handleHarborWebhookCall(c echo.Context) error {
+	token := auth.ExtractTokenFromHeader(c.Request().Header.Get(echo.HeaderAuthorization))
+
+	if token == "" {
+		token = c.Request().Header.Get(echo.HeaderAuthorization)
+	}
+
+	clientInfo, err := h.clientManager.GetClientInfoFromToken(token)
+
+	if err != nil {
+		return err
+	}
+
+	var payload harborPushPayload
+
+	if err := c.Bind(&payload); err != nil {
+		return err
+	}
+
+	// only image pushes are interesting, ignore other events like scanning or replication
+	if payload.Type != "PUSH_ARTIFACT" && payload.Type != "pushImage" {
+		return c.JSON(http.StatusOK, ImagePushWebhookResponse{Components: []string{}})
+	}
+
+	var events []imagePushEvent
+
+	for _, resource := range payload.EventData.Resources {
+		repo, tag, digest := splitImageReference(resource.ResourceURL)
+
+		if resource.Tag != "" {
+			tag = resource.Tag
+		}
+
+		if resource.Digest != "" {
+			digest = resource.Digest
+		}
+
+		events = append(events, imagePushEvent{
+			Provider:   "harbor",
+			Repository: repo,
+			Tag:        tag,
+			Digest:     digest,
+		})
+	}
+
+	return h.handleImagePushEvent(c, clientInfo, events)
+}
+
+// The webhook secret on GitHub is the access token, the name of the token is passed in the query to find it.
+// Requests are signed with X-Hub-Signature-256.
+// POST /webhook/github?key=<access token name>
+func (h *ApiHandler) handleGithubWebhookCall(c echo.Context) error {
+	body, err := ioutil.ReadAll(c.Request().Body)
+
+	if err != nil {
+		return err
+	}
+
+	var accessToken v1alpha1.AccessToken
+
+	if err := h.resourceManager.Get("", c.QueryParam("key"), &accessToken); err != nil {
+		if errors.IsNotFound(err) {
+			return errors.NewUnauthorized("access token not exist")
+		}
+
+		return err
+	}
+
+	if !verifyGithubSignature(accessToken.Spec.Token, c.Request().Header.Get("X-Hub-Signature-256"), body) {
+		return errors.NewUnauthorized("invalid signature")
+	}
+
+	clientInfo, err := h.clientManager.GetClientInfoFromToken(accessToken.Spec.Token)
+
+	if err != nil {
+		return err
+	}
+
+	event := c.Request().Header.Get("X-GitHub-Event")
+
+	if event == "ping" {
+		return c.JSON(http.StatusOK, map[string]string{"status": "Success"})
+	}
+
+	if event != "package" && event != "registry_package" {
+		return errors.NewBadRequest(fmt.Sprintf("unsupported github event: %s", event))
+	}
+
+	var payload githubPackagePayload
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return errors.NewBadRequest(fmt.Sprintf("invalid payload: %s", err.Error()))
+	}
+
+	registry := c.QueryParam("registry")
+
+	if registry == "" {
+		registry = defaultGithubRegistry
+	}
+
+	pushEvent, ok := payload.toImagePushEvent(registry)
+
+	if !ok {
+		return c.JSON(http.StatusOK, ImagePushWebhookResponse{Components: []string{}})
+	}
+
+	return h.handleImagePushEvent(c, clientInfo, []imagePushEvent{pushEvent})
+}
+
+// GitLab container registry doesn't emit push events, tag pushes of the project are used instead,
+// assuming the ci pipeline builds <registry>/<project path>:<git tag>.
+// The secret token of the webhook is the access token.
+// POST /webhook/gitlab?registry=<registry host>
+func (h *ApiHandler) handleGitlabWebhookCall(c echo.Context) error {
+	clientInfo, err := h.clientManager.GetClientInfoFromToken(c.Request().Header.Get("X-Gitlab-Token"))
+
+	if err != nil {
+		return err
+	}
+
+	var payload gitlabTagPushPayload
+
+	if err := c.Bind(&payload); err != nil {
+		return err
+	}
+
+	// deleting a tag also sends a tag push event, with an all zero after sha
+	if payload.ObjectKind != "tag_push" || strings.Trim(payload.After, "0") == "" {
+		return c.JSON(http.StatusOK, ImagePushWebhookResponse{Components: []string{}})
+	}
+
+	registry := c.QueryParam("registry")
+
+	if registry == "" {
+		registry = defaultGitlabRegistry
+	}
+
+	return h.handleImagePushEvent(c, clientInfo, []imagePushEvent{{
+		Provider:   "gitlab",
+		Repository: strings.ToLower(registry + "/" + payload.Project.PathWithNamespace),
+		Tag:        strings.TrimPrefix(payload.Ref, "refs/tags/"),
+	}})
+}
+
+func verifyGithubSignature(secret, signature string, body []byte) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func (h *ApiHandler) handleImagePushEvent(c echo.Context, clientInfo *client.ClientInfo, events []imagePushEvent) error {
+	updated, err := h.redeployComponentsForImagePush(clientInfo, events)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, ImagePushWebhookResponse{Components: updated})
+}
+
+// redeployComponentsForImagePush updates the image of all components which the client can edit,
+// whose image repository is the pushed one and whose tag pattern matches the pushed tag.
+func (h *ApiHandler) redeployComponentsForImagePush(clientInfo *client.ClientInfo, events []imagePushEvent) ([]string, error) {
+	var componentList v1alpha1.ComponentList
+
+	if err := h.resourceManager.List(&componentList); err != nil {
+		return nil, err
+	}
+
+	updateTs := int(time.Now().Unix())
+	updated := []string{}
+
+	for i := range componentList.Items {
+		component := &componentList.Items[i]
+		event := findMatchedImagePushEvent(component, events)
+
+		if event == nil {
+			continue
+		}
+
+		if !h.clientManager.CanEdit(clientInfo, component.Namespace, "components/"+component.Name) {
+			h.logger.Info("skip redeploying component, no permission", "namespace", component.Namespace, "name", component.Name, "provider", event.Provider)
+			continue
+		}
+
+		newImage := replaceImageTag(component.Spec.Image, event.Tag)
+
+		if event.Digest != "" {
+			newImage = replaceImageDigest(newImage, event.Digest)
+		}
+
+		if newImage == component.Spec.Image {
+			continue
+		}
+
+		// the next sync of the application source would revert the image
+		if err := h.resourceManager.SuspendApplicationSourceOf(component, clientInfo.Email); err != nil {
+			return updated, err
+		}
+
+		copied := component.DeepCopy()
+		copied.Spec.Image = newImage
+
+		if copied.Annotations == nil {
+			copied.Annotations = make(map[string]string)
+		}
+
+		copied.Annotations[controllers.AnnoLastUpdatedByWebhook] = strconv.Itoa(updateTs)
+		setComponentChangeAnnotations(copied, clientInfo.Email, fmt.Sprintf("%s push event, image: %s", event.Provider, newImage))
+
+		if err := h.resourceManager.Patch(copied, ctrlClient.MergeFrom(component)); err != nil {
+			h.logger.Error(err, "fail updating component", "namespace", component.Namespace, "name", component.Name)
+			return updated, err
+		}
+
+		h.logger.Info("redeploy component by push event", "namespace", component.Namespace, "name", component.Name, "image", newImage)
+		updated = append(updated, component.Namespace+"/"+component.Name)
+	}
+
+	if len(updated) > 0 {
+		h.markAccessTokenUsed(clientInfo.Name, updateTs)
+	}
+
+	return updated, nil
+}
+
+func findMatchedImagePushEvent(component *v1alpha1.Component, events []imagePushEvent) *imagePushEvent {
+	pattern, exist := component.Annotations[AnnoPushTagPattern]
+
+	if !exist || pattern == "" {
+		return nil
+	}
+
+	tagRegexp, err := regexp.Compile("^(?:" + pattern + ")$")
+
+	if err != nil {
+		return nil
+	}
+
+	repo, _, _ := splitImageReference(component.Spec.Image)
+	repo = normalizeImageRepository(repo)
+
+	for i := range events {
+		if events[i].Tag == "" || normalizeImageRepository(events[i].Repository) != repo {
+			continue
+		}
+
+		if tagRegexp.MatchString(events[i].Tag) {
+			return &events[i]
+		}
+	}
+
+	return nil
+}
+
+// nginx, library/nginx, docker.io/library/nginx -> docker.io/library/nginx
+func normalizeImageRepository(repo string) string {
+	repo = strings.ToLower(repo)
+	parts := strings.SplitN(repo, "/", 2)
+
+	// the first part is a registry host only if it looks like a domain or is localhost
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		if parts[0] == "index.docker.io" || parts[0] == "registry-1.docker.io" {
+			return "docker.io/" + parts[1]
+		}
+
+		if parts[0] == "docker.io" && !strings.Contains(parts[1], "/") {
+			return "docker.io/library/" + parts[1]
+		}
+
+		return repo
+	}
+
+	if len(parts) == 1 {
+		return "docker.io/library/" + repo
+	}
+
+	return "docker.io/" + repo
+}
+
+func (h *ApiHandler) markAccessTokenUsed(name string, ts int) {
+	var accessToken v1alpha1.AccessToken
+
+	if err := h.resourceManager.Get("", name, &accessToken); err != nil {
+		h.logger.Error(err, "fail to get access token")
+		return
+	}
+
+	copied := accessToken.DeepCopy()
+	copied.Status.UsedCount += 1
+	copied.Status.LastUsedAt = ts
+
+	if err := h.resourceManager.Patch(copied, ctrlClient.MergeFrom(&accessToken)); err != nil {
+		h.logger.Error(err, "fail update status of access token")
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNormalizeImageRepository(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                        "docker.io/library/nginx",
+		"library/nginx":                "docker.io/library/nginx",
+		"docker.io/nginx":              "docker.io/library/nginx",
+		"index.docker.io/kalmhq/kalm":  "docker.io/kalmhq/kalm",
+		"kalmhq/kalm":                  "docker.io/kalmhq/kalm",
+		"host:5000/foo":                "host:5000/foo",
+		"localhost/foo":                "localhost/foo",
+		"Harbor.example.com/lib/Nginx": "harbor.example.com/lib/nginx",
+	}
+
+	for repo, expected := range tests {
+		assert.Equal(t, expected, normalizeImageRepository(repo), repo)
+	}
+}
+
+func TestFindMatchedImagePushEvent(t *testing.T) {
+	component := &v1alpha1.Component{
+		ObjectMeta: metaV1.ObjectMeta{
+			Annotations: map[string]string{
+				AnnoPushTagPattern: `v\d+\.\d+\.\d+`,
+			},
+		},
+		Spec: v1alpha1.ComponentSpec{
+			Image: "host:5000/foo:v1.0.0",
+		},
+	}
+
+	assert.Nil(t, findMatchedImagePushEvent(component, []imagePushEvent{
+		{Repository: "host:5000/foo", Tag: "latest"},
+		{Repository: "host:5000/bar", Tag: "v1.0.1"},
+		{Repository: "host:5000/foo", Tag: "v1.0.1-rc"},
+	}))
+
+	event := findMatchedImagePushEvent(component, []imagePushEvent{
+		{Repository: "host:5000/foo", Tag: "latest"},
+		{Repository: "host:5000/foo", Tag: "v1.0.1"},
+	})
+
+	assert.NotNil(t, event)
+	assert.Equal(t, "v1.0.1", event.Tag)
+
+	// components without the annotation are not redeployed
+	delete(component.Annotations, AnnoPushTagPattern)
+	assert.Nil(t, findMatchedImagePushEvent(component, []imagePushEvent{{Repository: "host:5000/foo", Tag: "v1.0.1"}}))
+}
+
+func TestVerifyGithubSignature(t *testing.T) {
+	body := []byte(`{"action":"published"}`)
+
+	// echo -n '{"action":"published"}' | openssl dgst -sha256 -hmac secret
+	signature := "sha256=73613f9b5dd2a84dbfb8808b1018f51f50de6d5733af651bb5de71db07a6d7f6"
+
+	assert.True(t, verifyGithubSignature("secret", signature, body))
+	assert.False(t, verifyGithubSignature("other", signature, body))
+	assert.False(t, verifyGithubSignature("secret", "", body))
+}
+
+func TestGithubPackagePayload(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	// a registry_package event sent by GitHub, trimmed
+	body := `{
+  "action": "published",
+  "registry_package": {
+    "id": 1,
+    "name": "Hello-World",
+    "namespace": "octo-org",
+    "ecosystem": "CONTAINER",
+    "package_type": "CONTAINER",
+    "html_url": "https://github.com/orgs/octo-org/packages/container/package/Hello-World",
+    "owner": {"login": "octo-org", "type": "Organization"},
+    "package_version": {
+      "id": 2,
+      "version": "` + digest + `",
+      "name": "` + digest + `",
+      "container_metadata": {
+        "tag": {"name": "v1.0.0", "digest": "` + digest + `"},
+        "labels": {},
+        "manifest": {}
+      },
+      "package_url": "ghcr.io/octo-org/hello-world:v1.0.0"
+    },
+    "registry": {"about_url": "https://docs.github.com", "name": "GitHub CONTAINER registry", "type": "CONTAINER", "url": "https://ghcr.io/octo-org", "vendor": "GitHub Inc"}
+  },
+  "repository": {"full_name": "octo-org/Hello-World"},
+  "sender": {"login": "octocat"}
+}`
+
+	var payload githubPackagePayload
+	assert.Nil(t, json.Unmarshal([]byte(body), &payload))
+
+	event, ok := payload.toImagePushEvent(defaultGithubRegistry)
+	assert.True(t, ok)
+	assert.Equal(t, imagePushEvent{
+		Provider:   "github",
+		Repository: "ghcr.io/octo-org/hello-world",
+		Tag:        "v1.0.0",
+		Digest:     digest,
+	}, event)
+
+	// the package event uses the package key
+	payload = githubPackagePayload{}
+	assert.Nil(t, json.Unmarshal([]byte(strings.Replace(body, `"registry_package"`, `"package"`, 1)), &payload))
+	_, ok = payload.toImagePushEvent(defaultGithubRegistry)
+	assert.True(t, ok)
+
+	payload.Action = "updated"
+	_, ok = payload.toImagePushEvent(defaultGithubRegistry)
+	assert.False(t, ok)
+}
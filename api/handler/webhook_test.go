@@ -2,11 +2,14 @@ package handler
 
 import (
 	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/rand"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 )
 
 type WebhookHandlerTestSuite struct {
@@ -86,3 +89,95 @@ func (suite *WebhookHandlerTestSuite) TestWebhookHandler() {
 func TestWebhookHandlerTestSuite(t *testing.T) {
 	suite.Run(t, new(WebhookHandlerTestSuite))
 }
+
+func TestReplaceImageTag(t *testing.T) {
+	tests := []struct {
+		image    string
+		tag      string
+		expected string
+	}{
+		{"controller/foo", "v1", "controller/foo:v1"},
+		{"controller/foo:v2", "v3", "controller/foo:v3"},
+		{"host:5000/foo", "v1", "host:5000/foo:v1"},
+		{"host:5000/foo/bar:v1", "v2", "host:5000/foo/bar:v2"},
+		{"controller/foo@sha256:abc", "v1", "controller/foo:v1"},
+		{"host:5000/foo:v1@sha256:abc", "v2", "host:5000/foo:v2"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, replaceImageTag(test.image, test.tag))
+	}
+}
+
+func TestReplaceImageDigest(t *testing.T) {
+	tests := []struct {
+		image    string
+		digest   string
+		expected string
+	}{
+		{"controller/foo", "sha256:abc", "controller/foo@sha256:abc"},
+		{"controller/foo:v1", "sha256:abc", "controller/foo:v1@sha256:abc"},
+		{"host:5000/foo", "sha256:abc", "host:5000/foo@sha256:abc"},
+		{"host:5000/foo@sha256:abc", "sha256:def", "host:5000/foo@sha256:def"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, replaceImageDigest(test.image, test.digest))
+	}
+}
+
+func TestWebhookNonceCache(t *testing.T) {
+	cache := newWebhookNonceCache()
+
+	assert.True(t, cache.use("nonce", time.Now().Add(time.Minute)))
+	assert.False(t, cache.use("nonce", time.Now().Add(time.Minute)))
+
+	// expired nonces are evicted
+	assert.True(t, cache.use("expired", time.Now().Add(-time.Minute)))
+	assert.True(t, cache.use("expired", time.Now().Add(time.Minute)))
+}
+
+func TestApplyDeployWebhookV2Component(t *testing.T) {
+	component := &v1alpha1.Component{
+		Spec: v1alpha1.ComponentSpec{
+			Image: "host:5000/foo:v1",
+			Env: []v1alpha1.EnvVar{
+				{Name: "A", Value: "a", Type: v1alpha1.EnvVarTypeStatic},
+			},
+		},
+	}
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	err := applyDeployWebhookV2Component(component, &DeployWebhookV2Component{
+		ImageTag:    "v2",
+		ImageDigest: digest,
+		Env:         map[string]string{"A": "b", "E": "e", "D": "d", "C": "c"},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "host:5000/foo:v2@"+digest, component.Spec.Image)
+	assert.Len(t, component.Spec.Env, 4)
+	assert.Equal(t, "b", component.Spec.Env[0].Value)
+
+	// new envs are appended in order of names
+	for i, name := range []string{"A", "C", "D", "E"} {
+		assert.Equal(t, name, component.Spec.Env[i].Name)
+	}
+
+	component.Spec.Env = append(component.Spec.Env, v1alpha1.EnvVar{Name: "TOKEN", Value: "token", Type: v1alpha1.EnvVarTypeSecret})
+	assert.NotNil(t, applyDeployWebhookV2Component(component, &DeployWebhookV2Component{Env: map[string]string{"TOKEN": "plain"}}))
+	assert.Equal(t, v1alpha1.EnvVarTypeSecret, component.Spec.Env[4].Type)
+
+	assert.NotNil(t, applyDeployWebhookV2Component(component, &DeployWebhookV2Component{Image: "foo", ImageTag: "v1"}))
+	assert.NotNil(t, applyDeployWebhookV2Component(component, &DeployWebhookV2Component{ImageDigest: "md5:abc"}))
+}
+
+func TestComputeWebhookSignature(t *testing.T) {
+	sig := computeWebhookSignature("token", "1600000000", "nonce", []byte("{}"))
+
+	assert.True(t, strings.HasPrefix(sig, "sha256="))
+	assert.Equal(t, sig, computeWebhookSignature("token", "1600000000", "nonce", []byte("{}")))
+	assert.NotEqual(t, sig, computeWebhookSignature("token", "1600000001", "nonce", []byte("{}")))
+	assert.NotEqual(t, sig, computeWebhookSignature("other", "1600000000", "nonce", []byte("{}")))
+}
@@ -0,0 +1,468 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kalmhq/kalm/api/client"
+	"github.com/kalmhq/kalm/api/resources"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/controllers"
+	"github.com/labstack/echo/v4"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/rand"
+	ctrlClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// name of the access token, which is the sha256 of the token
+	WebhookHeaderKeyID = "X-Kalm-Key-Id"
+	// unix seconds
+	WebhookHeaderTimestamp = "X-Kalm-Timestamp"
+	WebhookHeaderNonce     = "X-Kalm-Nonce"
+	// "sha256=" + hex of hmac-sha256(token, timestamp + "." + nonce + "." + body)
+	WebhookHeaderSignature = "X-Kalm-Signature"
+
+	// requests with a timestamp out of this window are rejected
+	webhookSignatureTolerance = 5 * time.Minute
+
+	AnnoDeploymentID = "core.kalm.dev/deployment-id"
+)
+
+type DeployWebhookV2Component struct {
+	Name string `json:"name"`
+
+	// full image reference, e.g. registry.example.com:5000/foo/bar:v1
+	Image string `json:"image,omitempty"`
+
+	// replace the tag of current image
+	ImageTag string `json:"imageTag,omitempty"`
+
+	// pin current image to a digest, e.g. sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae
+	ImageDigest string `json:"imageDigest,omitempty"`
+
+	// static env vars to add or override
+	Env map[string]string `json:"env,omitempty"`
+}
+
+type DeployWebhookV2Params struct {
+	Application string                     `json:"application"`
+	Components  []DeployWebhookV2Component `json:"components"`
+}
+
+type DeployWebhookV2Response struct {
+	DeploymentID string   `json:"deploymentId"`
+	Components   []string `json:"components"`
+}
+
+type WebhookDeploymentStatus string
+
+const (
+	WebhookDeploymentProgressing WebhookDeploymentStatus = "Progressing"
+	WebhookDeploymentSucceeded   WebhookDeploymentStatus = "Succeeded"
+	WebhookDeploymentFailed      WebhookDeploymentStatus = "Failed"
+)
+
+type WebhookDeploymentComponentStatus struct {
+	Name          string                  `json:"name"`
+	Status        WebhookDeploymentStatus `json:"status"`
+	Image         string                  `json:"image"`
+	Replicas      int32                   `json:"replicas"`
+	ReadyReplicas int32                   `json:"readyReplicas"`
+	Message       string                  `json:"message,omitempty"`
+}
+
+type WebhookDeployment struct {
+	DeploymentID string                             `json:"deploymentId"`
+	Status       WebhookDeploymentStatus            `json:"status"`
+	Components   []WebhookDeploymentComponentStatus `json:"components"`
+}
+
+// webhookNonceCache remembers used nonces until their timestamps are out of the tolerance window.
+// It's kept in memory, so replay protection is per api server instance.
+type webhookNonceCache struct {
+	mut    sync.Mutex
+	nonces map[string]time.Time
+}
+
+func newWebhookNonceCache() *webhookNonceCache {
+	return &webhookNonceCache{nonces: make(map[string]time.Time)}
+}
+
+// use returns false if the nonce is already used
+func (c *webhookNonceCache) use(nonce string, expireAt time.Time) bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	now := time.Now()
+
+	for k, v := range c.nonces {
+		if v.Before(now) {
+			delete(c.nonces, k)
+		}
+	}
+
+	if _, exist := c.nonces[nonce]; exist {
+		return false
+	}
+
+	c.nonces[nonce] = expireAt
+
+	return true
+}
+
+func computeWebhookSignature(token, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write([]byte(nonce))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyWebhookV2Request checks the signature, timestamp and nonce of the request, returns the client of the signing access token.
+// The token itself is never sent, only its name is carried in the request.
+func (h *ApiHandler) verifyWebhookV2Request(c echo.Context, body []byte) (*client.ClientInfo, *v1alpha1.AccessToken, error) {
+	req := c.Request()
+	keyID := req.Header.Get(WebhookHeaderKeyID)
+	timestamp := req.Header.Get(WebhookHeaderTimestamp)
+	nonce := req.Header.Get(WebhookHeaderNonce)
+	signature := req.Header.Get(WebhookHeaderSignature)
+
+	if keyID == "" || timestamp == "" || nonce == "" || signature == "" {
+		return nil, nil, errors.NewUnauthorized(fmt.Sprintf(
+			"%s, %s, %s and %s headers are required",
+			WebhookHeaderKeyID, WebhookHeaderTimestamp, WebhookHeaderNonce, WebhookHeaderSignature,
+		))
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+
+	if err != nil {
+		return nil, nil, errors.NewUnauthorized("invalid timestamp")
+	}
+
+	signedAt := time.Unix(ts, 0)
+
+	if time.Since(signedAt) > webhookSignatureTolerance || time.Until(signedAt) > webhookSignatureTolerance {
+		return nil, nil, errors.NewUnauthorized("timestamp is out of the tolerance window")
+	}
+
+	var accessToken v1alpha1.AccessToken
+
+	if err := h.resourceManager.Get("", keyID, &accessToken); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil, errors.NewUnauthorized("access token not exist")
+		}
+
+		return nil, nil, err
+	}
+
+	expected := computeWebhookSignature(accessToken.Spec.Token, timestamp, nonce, body)
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, nil, errors.NewUnauthorized("invalid signature")
+	}
+
+	// check the nonce after the signature, so that unsigned requests can't fill the cache
+	if !h.webhookNonces.use(keyID+"/"+nonce, signedAt.Add(webhookSignatureTolerance)) {
+		return nil, nil, errors.NewUnauthorized("nonce is already used")
+	}
+
+	clientInfo, err := h.clientManager.GetClientInfoFromToken(accessToken.Spec.Token)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return clientInfo, &accessToken, nil
+}
+
+func (h *ApiHandler) handleDeployWebhookV2Call(c echo.Context) error {
+	body, err := ioutil.ReadAll(c.Request().Body)
+
+	if err != nil {
+		return err
+	}
+
+	clientInfo, accessToken, err := h.verifyWebhookV2Request(c, body)
+
+	if err != nil {
+		return err
+	}
+
+	var params DeployWebhookV2Params
+
+	if err := json.Unmarshal(body, &params); err != nil {
+		return errors.NewBadRequest(fmt.Sprintf("invalid payload: %s", err.Error()))
+	}
+
+	if params.Application == "" {
+		return errors.NewBadRequest("application can't be blank")
+	}
+
+	if len(params.Components) == 0 {
+		return errors.NewBadRequest("components can't be blank")
+	}
+
+	deploymentID := rand.String(16)
+	updateTs := int(time.Now().Unix())
+
+	originals := make([]*v1alpha1.Component, 0, len(params.Components))
+	updates := make([]*v1alpha1.Component, 0, len(params.Components))
+	names := make([]string, 0, len(params.Components))
+
+	// check permissions and build all changes before touching any component
+	for i, item := range params.Components {
+		if item.Name == "" {
+			return errors.NewBadRequest(fmt.Sprintf("components[%d].name can't be blank", i))
+		}
+
+		if !h.clientManager.CanEdit(clientInfo, params.Application, "components/"+item.Name) {
+			return resources.NoObjectEditorRoleError(params.Application, "components/"+item.Name)
+		}
+
+		component, err := h.resourceManager.GetComponent(params.Application, item.Name)
+
+		if err != nil {
+			return err
+		}
+
+		copied := component.DeepCopy()
+
+		if err := applyDeployWebhookV2Component(copied, &item); err != nil {
+			return errors.NewBadRequest(fmt.Sprintf("components[%d]: %s", i, err.Error()))
+		}
+
+		if copied.Annotations == nil {
+			copied.Annotations = make(map[string]string)
+		}
+
+		copied.Annotations[controllers.AnnoLastUpdatedByWebhook] = strconv.Itoa(updateTs)
+		copied.Annotations[AnnoDeploymentID] = deploymentID
+		setComponentChangeAnnotations(copied, clientInfo.Email, fmt.Sprintf("deploy webhook v2, deployment %s, image: %s", deploymentID, copied.Spec.Image))
+
+		originals = append(originals, component)
+		updates = append(updates, copied)
+		names = append(names, item.Name)
+	}
+
+	// dry run first, so that admission webhooks reject the whole batch if any component is invalid
+	for i := range updates {
+		if err := h.resourceManager.Patch(updates[i].DeepCopy(), ctrlClient.MergeFrom(originals[i]), ctrlClient.DryRunAll); err != nil {
+			return err
+		}
+	}
+
+	// the next sync of application sources would revert the deployment
+	for i := range originals {
+		if err := h.resourceManager.SuspendApplicationSourceOf(originals[i], clientInfo.Email); err != nil {
+			return err
+		}
+	}
+
+	for i := range updates {
+		if err := h.resourceManager.Patch(updates[i], ctrlClient.MergeFrom(originals[i])); err != nil {
+			h.logger.Error(err, "fail updating component, reverting deployed components", "deploymentId", deploymentID, "name", updates[i].Name)
+			h.revertWebhookV2Deployment(originals[:i], updates[:i])
+			return err
+		}
+	}
+
+	h.logger.Info("deployed components by webhook", "deploymentId", deploymentID, "components", names)
+
+	copiedToken := accessToken.DeepCopy()
+	copiedToken.Status.UsedCount += 1
+	copiedToken.Status.LastUsedAt = updateTs
+
+	if err := h.resourceManager.Patch(copiedToken, ctrlClient.MergeFrom(accessToken)); err != nil {
+		h.logger.Error(err, "fail update status of access token")
+	}
+
+	return c.JSON(http.StatusCreated, DeployWebhookV2Response{
+		DeploymentID: deploymentID,
+		Components:   names,
+	})
+}
+
+// revertWebhookV2Deployment restores the spec of components that are already patched in a failed batch.
+func (h *ApiHandler) revertWebhookV2Deployment(originals, updated []*v1alpha1.Component) {
+	for i := range updated {
+		reverted := updated[i].DeepCopy()
+		reverted.Spec = originals[i].Spec
+		delete(reverted.Annotations, AnnoDeploymentID)
+		setComponentChangeAnnotations(reverted, "kalm", "revert failed deploy webhook batch")
+
+		if err := h.resourceManager.Patch(reverted, ctrlClient.MergeFrom(updated[i])); err != nil {
+			h.logger.Error(err, "fail reverting component", "name", reverted.Name)
+		}
+	}
+}
+
+func (h *ApiHandler) handleGetWebhookV2Deployment(c echo.Context) error {
+	clientInfo, _, err := h.verifyWebhookV2Request(c, []byte{})
+
+	if err != nil {
+		return err
+	}
+
+	namespace := c.QueryParam("application")
+
+	if namespace == "" {
+		return errors.NewBadRequest("application can't be blank")
+	}
+
+	var componentList v1alpha1.ComponentList
+
+	if err := h.resourceManager.List(&componentList, ctrlClient.InNamespace(namespace)); err != nil {
+		return err
+	}
+
+	deployment := WebhookDeployment{
+		DeploymentID: c.Param("id"),
+		Status:       WebhookDeploymentSucceeded,
+		Components:   []WebhookDeploymentComponentStatus{},
+	}
+
+	for i := range componentList.Items {
+		component := &componentList.Items[i]
+
+		if component.Annotations[AnnoDeploymentID] != deployment.DeploymentID {
+			continue
+		}
+
+		if !h.clientManager.CanView(clientInfo, namespace, "components/"+component.Name) {
+			return resources.NoObjectViewerRoleError(namespace, "components/"+component.Name)
+		}
+
+		componentStatus := getWebhookDeploymentComponentStatus(component)
+
+		if componentStatus.Status == WebhookDeploymentFailed {
+			deployment.Status = WebhookDeploymentFailed
+		} else if componentStatus.Status == WebhookDeploymentProgressing && deployment.Status != WebhookDeploymentFailed {
+			deployment.Status = WebhookDeploymentProgressing
+		}
+
+		deployment.Components = append(deployment.Components, componentStatus)
+	}
+
+	// components are re-annotated by a newer deployment, or deleted
+	if len(deployment.Components) == 0 {
+		return errors.NewNotFound(v1alpha1.GroupVersion.WithResource("deployments").GroupResource(), deployment.DeploymentID)
+	}
+
+	return c.JSON(http.StatusOK, deployment)
+}
+
+func getWebhookDeploymentComponentStatus(component *v1alpha1.Component) WebhookDeploymentComponentStatus {
+	status := WebhookDeploymentComponentStatus{
+		Name:          component.Name,
+		Image:         component.Spec.Image,
+		Replicas:      component.Status.Replicas,
+		ReadyReplicas: component.Status.ReadyReplicas,
+		Status:        WebhookDeploymentProgressing,
+	}
+
+	if component.Status.ObservedGeneration < component.Generation {
+		return status
+	}
+
+	if rollout := component.Status.Rollout; rollout != nil {
+		if rollout.Phase == v1alpha1.RolloutPhaseAborted {
+			status.Status = WebhookDeploymentFailed
+			status.Message = rollout.Message
+			return status
+		}
+
+		if rollout.Phase == v1alpha1.RolloutPhaseProgressing {
+			status.Message = rollout.Message
+			return status
+		}
+	}
+
+	if degraded := component.Status.GetCondition(v1alpha1.ComponentConditionDegraded); degraded != nil && degraded.Status == coreV1.ConditionTrue {
+		status.Status = WebhookDeploymentFailed
+		status.Message = degraded.Message
+		return status
+	}
+
+	if component.Status.IsReady() && component.Status.Image == component.Spec.Image {
+		status.Status = WebhookDeploymentSucceeded
+	}
+
+	return status
+}
+
+// applyDeployWebhookV2Component applies the image and env changes to the component
+func applyDeployWebhookV2Component(component *v1alpha1.Component, item *DeployWebhookV2Component) error {
+	if item.Image != "" && (item.ImageTag != "" || item.ImageDigest != "") {
+		return fmt.Errorf("image can't be used together with imageTag or imageDigest")
+	}
+
+	if item.Image != "" {
+		component.Spec.Image = item.Image
+	}
+
+	if item.ImageTag != "" {
+		component.Spec.Image = replaceImageTag(component.Spec.Image, item.ImageTag)
+	}
+
+	if item.ImageDigest != "" {
+		if !strings.HasPrefix(item.ImageDigest, "sha256:") || len(item.ImageDigest) != len("sha256:")+64 {
+			return fmt.Errorf("invalid image digest: %s", item.ImageDigest)
+		}
+
+		component.Spec.Image = replaceImageDigest(component.Spec.Image, item.ImageDigest)
+	}
+
+	// sorted, so the same payload always results in the same spec
+	names := make([]string, 0, len(item.Env))
+
+	for name := range item.Env {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := item.Env[name]
+		found := false
+
+		for i := range component.Spec.Env {
+			if component.Spec.Env[i].Name != name {
+				continue
+			}
+
+			if component.Spec.Env[i].Type != v1alpha1.EnvVarTypeStatic && component.Spec.Env[i].Type != "" {
+				return fmt.Errorf("env %s is of type %s, only static envs can be set", name, component.Spec.Env[i].Type)
+			}
+
+			component.Spec.Env[i].Value = value
+			found = true
+			break
+		}
+
+		if !found {
+			component.Spec.Env = append(component.Spec.Env, v1alpha1.EnvVar{
+				Name:  name,
+				Value: value,
+				Type:  v1alpha1.EnvVarTypeStatic,
+			})
+		}
+	}
+
+	return nil
+}
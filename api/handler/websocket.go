@@ -15,8 +15,10 @@ import (
 	"github.com/kalmhq/kalm/api/client"
 	"github.com/kalmhq/kalm/api/log"
 	"github.com/kalmhq/kalm/api/utils"
+	"github.com/kalmhq/kalm/controller/controllers"
 	"github.com/labstack/echo/v4"
 	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/remotecommand"
@@ -114,6 +116,7 @@ type WSPodDataResponse struct {
 	Type      WSResponseType `json:"type"`
 	Namespace string         `json:"namespace"`
 	PodName   string         `json:"podName"`
+	Container string         `json:"container,omitempty"`
 	Data      string         `json:"data"`
 }
 
@@ -134,9 +137,11 @@ type TerminalSession struct {
 	namespace string
 
 	podName string
+
+	container string
 }
 
-func NewTerminalSession(conn *WSConn, ctx context.Context, ns, podName string) *TerminalSession {
+func NewTerminalSession(conn *WSConn, ctx context.Context, ns, podName, container string) *TerminalSession {
 	return &TerminalSession{
 		conn,
 		make(chan []byte),
@@ -144,6 +149,7 @@ func NewTerminalSession(conn *WSConn, ctx context.Context, ns, podName string) *
 		ctx,
 		ns,
 		podName,
+		container,
 	}
 }
 
@@ -170,6 +176,7 @@ func (t *TerminalSession) Write(p []byte) (int, error) {
 		Type:      WSResponseTypeExecStdout,
 		Namespace: t.namespace,
 		PodName:   t.podName,
+		Container: t.container,
 		Data:      string(p),
 	})
 
@@ -307,9 +314,11 @@ func handleLogRequests(conn *WSConn) {
 		case <-conn.ctx.Done():
 			return
 		case m := <-conn.podResourceRequest:
-			key := fmt.Sprintf("%s___%s", m.Namespace, m.PodName)
+			key := podContainerKey(m.Namespace, m.PodName, m.Container)
 
 			if m.Type == WSRequestTypeSubscribePodLog {
+				k8sClient, _ := kubernetes.NewForConfig(conn.clientInfo.Cfg)
+
 				podLogOpts := coreV1.PodLogOptions{
 					Container:  m.Container,
 					TailLines:  &m.TailLines,
@@ -318,9 +327,12 @@ func handleLogRequests(conn *WSConn) {
 					Previous:   m.Previous,
 				}
 
-				k8sClient, _ := kubernetes.NewForConfig(conn.clientInfo.Cfg)
-				req := k8sClient.CoreV1().Pods(m.Namespace).GetLogs(m.PodName, &podLogOpts)
-				podLogs, err := req.Stream(conn.ctx)
+				var podLogs io.ReadCloser
+				var err error
+
+				if podLogOpts.Container, err = resolvePodContainer(conn.ctx, k8sClient, m.Namespace, m.PodName, m.Container); err == nil {
+					podLogs, err = k8sClient.CoreV1().Pods(m.Namespace).GetLogs(m.PodName, &podLogOpts).Stream(conn.ctx)
+				}
 
 				if err != nil {
 					log.Error(err, "stream error")
@@ -328,6 +340,7 @@ func handleLogRequests(conn *WSConn) {
 						Type:      WSResponseTypeLogStreamDisconnected,
 						Namespace: m.Namespace,
 						PodName:   m.PodName,
+						Container: m.Container,
 						Data:      err.Error(),
 					})
 					continue
@@ -340,7 +353,7 @@ func handleLogRequests(conn *WSConn) {
 				podRegistrations[key] = stop
 
 				go func() {
-					copyPodLogStreamToWS(ctx, m.Namespace, m.PodName, conn, podLogs)
+					copyPodLogStreamToWS(ctx, m.Namespace, m.PodName, m.Container, conn, podLogs)
 					delete(podRegistrations, key)
 				}()
 			} else {
@@ -353,7 +366,7 @@ func handleLogRequests(conn *WSConn) {
 	}
 }
 
-func copyPodLogStreamToWS(ctx context.Context, namespace, podName string, conn *WSConn, logStream io.ReadCloser) {
+func copyPodLogStreamToWS(ctx context.Context, namespace, podName, container string, conn *WSConn, logStream io.ReadCloser) {
 	defer logStream.Close()
 
 	defer func() {
@@ -363,6 +376,7 @@ func copyPodLogStreamToWS(ctx context.Context, namespace, podName string, conn *
 			Type:      WSResponseTypeLogStreamDisconnected,
 			Namespace: namespace,
 			PodName:   podName,
+			Container: container,
 		})
 	}()
 
@@ -401,6 +415,7 @@ func copyPodLogStreamToWS(ctx context.Context, namespace, podName string, conn *
 				Type:      WSResponseTypeLogStreamUpdate,
 				Namespace: namespace,
 				PodName:   podName,
+				Container: container,
 				Data:      string(data),
 			})
 
@@ -470,7 +485,7 @@ func handleExecRequests(conn *WSConn) {
 		case <-conn.ctx.Done():
 			return
 		case m := <-conn.podResourceRequest:
-			key := fmt.Sprintf("%s___%s", m.Namespace, m.PodName)
+			key := podContainerKey(m.Namespace, m.PodName, m.Container)
 
 			if m.Type == WSRequestTypeExecStartSession {
 				ctx, stop := context.WithCancel(conn.ctx)
@@ -479,7 +494,7 @@ func handleExecRequests(conn *WSConn) {
 					oldStop()
 				}
 
-				session := NewTerminalSession(conn, ctx, m.Namespace, m.PodName)
+				session := NewTerminalSession(conn, ctx, m.Namespace, m.PodName, m.Container)
 
 				mut.Lock()
 				podRegistrations[key] = stop
@@ -495,13 +510,21 @@ func handleExecRequests(conn *WSConn) {
 						mut.Unlock()
 					}()
 
-					var err error
-					validShells := []string{"bash", "ash", "sh"}
-					for _, shell := range validShells {
-						err = startExecTerminalSession(conn, shell, session, m.Namespace, m.PodName, m.Container)
+					var container string
+					k8sClient, err := kubernetes.NewForConfig(conn.clientInfo.Cfg)
+
+					if err == nil {
+						container, err = resolvePodContainer(ctx, k8sClient, m.Namespace, m.PodName, m.Container)
+					}
+
+					if err == nil {
+						validShells := []string{"bash", "ash", "sh"}
+						for _, shell := range validShells {
+							err = startExecTerminalSession(conn, shell, session, m.Namespace, m.PodName, container)
 
-						if err == nil {
-							break
+							if err == nil {
+								break
+							}
 						}
 					}
 
@@ -516,6 +539,7 @@ func handleExecRequests(conn *WSConn) {
 						Type:      WSResponseTypeExecDisconnected,
 						Namespace: m.Namespace,
 						PodName:   m.PodName,
+						Container: m.Container,
 						Data:      data,
 					})
 				}()
@@ -555,6 +579,43 @@ func handleExecRequests(conn *WSConn) {
 	}
 }
 
+// Sessions and log streams are identified by the container as well, a pod of a component may have sidecars.
+func podContainerKey(namespace, podName, container string) string {
+	return fmt.Sprintf("%s___%s___%s", namespace, podName, container)
+}
+
+// resolvePodContainer defaults the container to the main container of the component if it's not specified.
+// Pods with sidecars, including the istio proxy, have multiple containers, k8s requires the container to be specified.
+func resolvePodContainer(ctx context.Context, k8sClient *kubernetes.Clientset, namespace, podName, container string) (string, error) {
+	if container != "" {
+		return container, nil
+	}
+
+	pod, err := k8sClient.CoreV1().Pods(namespace).Get(ctx, podName, metaV1.GetOptions{})
+
+	if err != nil {
+		return "", err
+	}
+
+	return defaultPodContainer(pod), nil
+}
+
+func defaultPodContainer(pod *coreV1.Pod) string {
+	componentName := pod.Labels[controllers.KalmLabelComponentKey]
+
+	for _, container := range pod.Spec.Containers {
+		if container.Name == componentName {
+			return container.Name
+		}
+	}
+
+	if len(pod.Spec.Containers) > 0 {
+		return pod.Spec.Containers[0].Name
+	}
+
+	return ""
+}
+
 func (h *ApiHandler) prepareWSConnection(c echo.Context) (*WSConn, error) {
 	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
 
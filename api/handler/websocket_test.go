@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/controllers"
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDefaultPodContainer(t *testing.T) {
+	pod := &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{
+			Labels: map[string]string{controllers.KalmLabelComponentKey: "web"},
+		},
+		Spec: coreV1.PodSpec{
+			Containers: []coreV1.Container{{Name: "istio-proxy"}, {Name: "web"}, {Name: "log-shipper"}},
+		},
+	}
+
+	assert.Equal(t, "web", defaultPodContainer(pod))
+
+	pod.Labels = nil
+	assert.Equal(t, "istio-proxy", defaultPodContainer(pod))
+
+	pod.Spec.Containers = nil
+	assert.Equal(t, "", defaultPodContainer(pod))
+}
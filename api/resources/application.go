@@ -39,6 +39,7 @@ type PodStatus struct {
 	CreationTimestamp int64             `json:"createTimestamp"`
 	StartTimestamp    int64             `json:"startTimestamp"`
 	Containers        []ContainerStatus `json:"containers"`
+	InitContainers    []ContainerStatus `json:"initContainers"`
 	Metrics           MetricHistories   `json:"metrics"`
 	Warnings          []coreV1.Event    `json:"warnings"`
 }
@@ -240,6 +241,17 @@ func GetPodStatus(pod coreV1.Pod, events []coreV1.Event, workloadType v1alpha1.W
 		break
 	}
 
+	initContainers := []ContainerStatus{}
+
+	for _, container := range pod.Status.InitContainerStatuses {
+		initContainers = append(initContainers, ContainerStatus{
+			Name:         container.Name,
+			RestartCount: container.RestartCount,
+			Ready:        container.Ready,
+			Started:      container.Started != nil && *container.Started == true,
+		})
+	}
+
 	containers := []ContainerStatus{}
 
 	if !initializing {
@@ -292,6 +304,7 @@ func GetPodStatus(pod coreV1.Pod, events []coreV1.Event, workloadType v1alpha1.W
 		CreationTimestamp: pod.CreationTimestamp.UnixNano() / int64(time.Millisecond),
 		StartTimestamp:    startTimestamp,
 		Containers:        containers,
+		InitContainers:    initContainers,
 		Warnings:          warnings,
 	}
 }
@@ -0,0 +1,517 @@
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/controllers"
+	"github.com/kalmhq/kalm/controller/lib/files"
+	"github.com/pmezard/go-difflib/difflib"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// ApplicationBundleVersion is increased when the bundle format changes in a way old versions can't import.
+const ApplicationBundleVersion = "v1"
+
+const (
+	ApplicationImportActionCreate    = "create"
+	ApplicationImportActionUpdate    = "update"
+	ApplicationImportActionUnchanged = "unchanged"
+)
+
+// ApplicationBundle is a portable copy of all kalm resources of an application.
+// Only names, labels, annotations and specs are kept, the status and cluster generated fields are dropped.
+type ApplicationBundle struct {
+	Version                 string                            `json:"version"`
+	Application             string                            `json:"application"`
+	ExportedAt              metaV1.Time                       `json:"exportedAt"`
+	Components              []v1alpha1.Component              `json:"components,omitempty"`
+	ComponentPluginBindings []v1alpha1.ComponentPluginBinding `json:"componentPluginBindings,omitempty"`
+	HttpRoutes              []v1alpha1.HttpRoute              `json:"httpRoutes,omitempty"`
+	ProtectedEndpoints      []v1alpha1.ProtectedEndpoint      `json:"protectedEndpoints,omitempty"`
+	ConfigMaps              []coreV1.ConfigMap                `json:"configMaps,omitempty"`
+
+	// Secrets are sealed, values are encrypted with the cluster key, so they can only be imported into the same cluster.
+	// It's omitted unless secrets are requested explicitly.
+	SecretStore *v1alpha1.SecretStore `json:"secretStore,omitempty"`
+}
+
+// ApplicationImportParameters are substituted into the bundle before it's imported.
+type ApplicationImportParameters struct {
+	// old host -> new host, for hosts of http routes
+	Hosts map[string]string `json:"hosts,omitempty"`
+
+	// component name -> image tag
+	ImageTags map[string]string `json:"imageTags,omitempty"`
+
+	// component name -> replicas
+	Replicas map[string]int32 `json:"replicas,omitempty"`
+}
+
+type ImportApplicationRequest struct {
+	// Name of the target application, the application in the bundle is used if it's empty.
+	Name string `json:"name,omitempty"`
+
+	// the exported bundle in yaml
+	Bundle string `json:"bundle"`
+
+	Parameters ApplicationImportParameters `json:"parameters"`
+
+	// only returns the changes, nothing is written
+	DryRun bool `json:"dryRun"`
+}
+
+type ApplicationImportChange struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	Diff   string `json:"diff,omitempty"`
+}
+
+type ImportApplicationResponse struct {
+	Application string                     `json:"application"`
+	DryRun      bool                       `json:"dryRun"`
+	Changes     []*ApplicationImportChange `json:"changes"`
+}
+
+// exportObjectMeta keeps the fields of metadata which make sense in another namespace or cluster.
+func exportObjectMeta(objectMeta metaV1.ObjectMeta) metaV1.ObjectMeta {
+	res := metaV1.ObjectMeta{
+		Name:   objectMeta.Name,
+		Labels: objectMeta.Labels,
+	}
+
+	for k, v := range objectMeta.Annotations {
+		if k == coreV1.LastAppliedConfigAnnotation {
+			continue
+		}
+
+		if res.Annotations == nil {
+			res.Annotations = make(map[string]string)
+		}
+
+		res.Annotations[k] = v
+	}
+
+	return res
+}
+
+func exportTypeMeta(kind string) metaV1.TypeMeta {
+	return metaV1.TypeMeta{
+		APIVersion: v1alpha1.GroupVersion.String(),
+		Kind:       kind,
+	}
+}
+
+func (resourceManager *ResourceManager) ExportApplication(namespace string, includeSecrets bool) (*ApplicationBundle, error) {
+	if _, err := resourceManager.GetNamespace(namespace); err != nil {
+		return nil, err
+	}
+
+	bundle := &ApplicationBundle{
+		Version:     ApplicationBundleVersion,
+		Application: namespace,
+		ExportedAt:  metaV1.Now(),
+	}
+
+	var componentList v1alpha1.ComponentList
+
+	if err := resourceManager.List(&componentList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	for _, item := range componentList.Items {
+		bundle.Components = append(bundle.Components, v1alpha1.Component{
+			TypeMeta:   exportTypeMeta("Component"),
+			ObjectMeta: exportObjectMeta(item.ObjectMeta),
+			Spec:       item.Spec,
+		})
+	}
+
+	var bindingList v1alpha1.ComponentPluginBindingList
+
+	if err := resourceManager.List(&bindingList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	for _, item := range bindingList.Items {
+		bundle.ComponentPluginBindings = append(bundle.ComponentPluginBindings, v1alpha1.ComponentPluginBinding{
+			TypeMeta:   exportTypeMeta("ComponentPluginBinding"),
+			ObjectMeta: exportObjectMeta(item.ObjectMeta),
+			Spec:       item.Spec,
+		})
+	}
+
+	var routeList v1alpha1.HttpRouteList
+
+	if err := resourceManager.List(&routeList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	for _, item := range routeList.Items {
+		bundle.HttpRoutes = append(bundle.HttpRoutes, v1alpha1.HttpRoute{
+			TypeMeta:   exportTypeMeta("HttpRoute"),
+			ObjectMeta: exportObjectMeta(item.ObjectMeta),
+			Spec:       item.Spec,
+		})
+	}
+
+	var endpointList v1alpha1.ProtectedEndpointList
+
+	if err := resourceManager.List(&endpointList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	for _, item := range endpointList.Items {
+		bundle.ProtectedEndpoints = append(bundle.ProtectedEndpoints, v1alpha1.ProtectedEndpoint{
+			TypeMeta:   exportTypeMeta("ProtectedEndpoint"),
+			ObjectMeta: exportObjectMeta(item.ObjectMeta),
+			Spec:       item.Spec,
+		})
+	}
+
+	var configMap coreV1.ConfigMap
+
+	if err := resourceManager.Get(namespace, files.KALM_CONFIG_MAP_NAME, &configMap); err == nil {
+		bundle.ConfigMaps = append(bundle.ConfigMaps, coreV1.ConfigMap{
+			TypeMeta:   metaV1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: exportObjectMeta(configMap.ObjectMeta),
+			Data:       configMap.Data,
+			BinaryData: configMap.BinaryData,
+		})
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if includeSecrets {
+		store, err := resourceManager.getSecretStore(namespace)
+
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+
+		if store != nil {
+			bundle.SecretStore = &v1alpha1.SecretStore{
+				TypeMeta:   exportTypeMeta("SecretStore"),
+				ObjectMeta: exportObjectMeta(store.ObjectMeta),
+				Spec:       store.Spec,
+			}
+		}
+	}
+
+	return bundle, nil
+}
+
+func ParseApplicationBundle(data []byte) (*ApplicationBundle, error) {
+	var bundle ApplicationBundle
+
+	if err := yaml.Unmarshal(data, &bundle); err != nil {
+		return nil, errors.NewBadRequest("invalid bundle: " + err.Error())
+	}
+
+	if bundle.Version != ApplicationBundleVersion {
+		return nil, errors.NewBadRequest(fmt.Sprintf("unsupported bundle version \"%s\", expected \"%s\"", bundle.Version, ApplicationBundleVersion))
+	}
+
+	return &bundle, nil
+}
+
+// rewriteDestinationHost moves a service host of the source application to the target application.
+// web.foo.svc.cluster.local:80 -> web.bar.svc.cluster.local:80
+func rewriteDestinationHost(host, from, to string) string {
+	hostname, port := host, ""
+
+	if idx := strings.LastIndex(host, ":"); idx != -1 {
+		hostname, port = host[:idx], host[idx:]
+	}
+
+	parts := strings.Split(hostname, ".")
+
+	if len(parts) < 2 || parts[1] != from {
+		return host
+	}
+
+	parts[1] = to
+
+	return strings.Join(parts, ".") + port
+}
+
+// Localize moves the bundle into the target namespace and substitutes the parameters.
+func (bundle *ApplicationBundle) Localize(namespace string, params ApplicationImportParameters) error {
+	componentNames := make(map[string]bool, len(bundle.Components))
+
+	for i := range bundle.Components {
+		component := &bundle.Components[i]
+		component.Namespace = namespace
+		componentNames[component.Name] = true
+
+		if tag, exist := params.ImageTags[component.Name]; exist {
+			component.Spec.Image = controllers.ReplaceImageTagInPlace(component.Spec.Image, tag)
+		}
+
+		if replicas, exist := params.Replicas[component.Name]; exist {
+			r := replicas
+			component.Spec.Replicas = &r
+		}
+	}
+
+	for name := range params.ImageTags {
+		if !componentNames[name] {
+			return errors.NewBadRequest(fmt.Sprintf("image tag of unknown component %s", name))
+		}
+	}
+
+	for name := range params.Replicas {
+		if !componentNames[name] {
+			return errors.NewBadRequest(fmt.Sprintf("replicas of unknown component %s", name))
+		}
+	}
+
+	usedHosts := make(map[string]bool)
+
+	for i := range bundle.HttpRoutes {
+		route := &bundle.HttpRoutes[i]
+		route.Namespace = namespace
+
+		for j, host := range route.Spec.Hosts {
+			if newHost, exist := params.Hosts[host]; exist {
+				route.Spec.Hosts[j] = newHost
+				usedHosts[host] = true
+			}
+		}
+
+		for j := range route.Spec.Destinations {
+			route.Spec.Destinations[j].Host = rewriteDestinationHost(route.Spec.Destinations[j].Host, bundle.Application, namespace)
+		}
+	}
+
+	for host := range params.Hosts {
+		if !usedHosts[host] {
+			return errors.NewBadRequest(fmt.Sprintf("host %s is not used by any route", host))
+		}
+	}
+
+	for i := range bundle.ComponentPluginBindings {
+		bundle.ComponentPluginBindings[i].Namespace = namespace
+	}
+
+	for i := range bundle.ProtectedEndpoints {
+		bundle.ProtectedEndpoints[i].Namespace = namespace
+	}
+
+	for i := range bundle.ConfigMaps {
+		bundle.ConfigMaps[i].Namespace = namespace
+	}
+
+	if bundle.SecretStore != nil {
+		bundle.SecretStore.Namespace = namespace
+	}
+
+	bundle.Application = namespace
+
+	return nil
+}
+
+// Objects returns resources of the bundle in the order they should be created.
+// Files and secrets come first, so pods can start with them.
+func (bundle *ApplicationBundle) Objects() []runtime.Object {
+	var res []runtime.Object
+
+	for i := range bundle.ConfigMaps {
+		res = append(res, &bundle.ConfigMaps[i])
+	}
+
+	if bundle.SecretStore != nil {
+		res = append(res, bundle.SecretStore)
+	}
+
+	for i := range bundle.Components {
+		res = append(res, &bundle.Components[i])
+	}
+
+	for i := range bundle.ComponentPluginBindings {
+		res = append(res, &bundle.ComponentPluginBindings[i])
+	}
+
+	for i := range bundle.ProtectedEndpoints {
+		res = append(res, &bundle.ProtectedEndpoints[i])
+	}
+
+	for i := range bundle.HttpRoutes {
+		res = append(res, &bundle.HttpRoutes[i])
+	}
+
+	return res
+}
+
+// exportObject returns a copy of a fetched object with the same fields as objects in bundles, so they can be compared.
+func exportObject(obj runtime.Object) runtime.Object {
+	switch v := obj.(type) {
+	case *v1alpha1.Component:
+		return &v1alpha1.Component{TypeMeta: exportTypeMeta("Component"), ObjectMeta: exportObjectMeta(v.ObjectMeta), Spec: v.Spec}
+	case *v1alpha1.ComponentPluginBinding:
+		return &v1alpha1.ComponentPluginBinding{TypeMeta: exportTypeMeta("ComponentPluginBinding"), ObjectMeta: exportObjectMeta(v.ObjectMeta), Spec: v.Spec}
+	case *v1alpha1.HttpRoute:
+		return &v1alpha1.HttpRoute{TypeMeta: exportTypeMeta("HttpRoute"), ObjectMeta: exportObjectMeta(v.ObjectMeta), Spec: v.Spec}
+	case *v1alpha1.ProtectedEndpoint:
+		return &v1alpha1.ProtectedEndpoint{TypeMeta: exportTypeMeta("ProtectedEndpoint"), ObjectMeta: exportObjectMeta(v.ObjectMeta), Spec: v.Spec}
+	case *v1alpha1.SecretStore:
+		return &v1alpha1.SecretStore{TypeMeta: exportTypeMeta("SecretStore"), ObjectMeta: exportObjectMeta(v.ObjectMeta), Spec: v.Spec}
+	case *coreV1.ConfigMap:
+		return &coreV1.ConfigMap{
+			TypeMeta:   metaV1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: exportObjectMeta(v.ObjectMeta),
+			Data:       v.Data,
+			BinaryData: v.BinaryData,
+		}
+	}
+
+	return obj
+}
+
+func diffObjects(current, desired runtime.Object) (string, error) {
+	var a, b []byte
+	var err error
+
+	if current != nil {
+		if a, err = yaml.Marshal(current); err != nil {
+			return "", err
+		}
+	}
+
+	if b, err = yaml.Marshal(desired); err != nil {
+		return "", err
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(a)),
+		B:        difflib.SplitLines(string(b)),
+		FromFile: "current",
+		ToFile:   "bundle",
+		Context:  3,
+	})
+}
+
+// ImportApplication creates or updates resources of the bundle in the target namespace.
+// The bundle should be localized. In dry run mode, changes are computed without writing anything.
+// Application sources which updated objects are synced from are suspended in the name of importedBy.
+func (resourceManager *ResourceManager) ImportApplication(bundle *ApplicationBundle, dryRun bool, importedBy string) (*ImportApplicationResponse, error) {
+	res := &ImportApplicationResponse{
+		Application: bundle.Application,
+		DryRun:      dryRun,
+		Changes:     []*ApplicationImportChange{},
+	}
+
+	if _, err := resourceManager.GetNamespace(bundle.Application); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+
+		res.Changes = append(res.Changes, &ApplicationImportChange{
+			Kind:   "Namespace",
+			Name:   bundle.Application,
+			Action: ApplicationImportActionCreate,
+		})
+
+		if !dryRun {
+			if err := resourceManager.CreateNamespace(&coreV1.Namespace{
+				ObjectMeta: metaV1.ObjectMeta{
+					Name: bundle.Application,
+					Labels: map[string]string{
+						controllers.KalmEnableLabelName: controllers.KalmEnableLabelValue,
+					},
+				},
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, obj := range bundle.Objects() {
+		change, err := resourceManager.importObject(obj, dryRun, importedBy)
+
+		if err != nil {
+			return nil, err
+		}
+
+		res.Changes = append(res.Changes, change)
+	}
+
+	return res, nil
+}
+
+func (resourceManager *ResourceManager) importObject(obj runtime.Object, dryRun bool, importedBy string) (*ApplicationImportChange, error) {
+	objMeta, err := meta.Accessor(obj)
+
+	if err != nil {
+		return nil, err
+	}
+
+	change := &ApplicationImportChange{
+		Kind: obj.GetObjectKind().GroupVersionKind().Kind,
+		Name: objMeta.GetName(),
+	}
+
+	existing, err := scheme.Scheme.New(obj.GetObjectKind().GroupVersionKind())
+
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("unsupported kind %s in bundle", change.Kind))
+	}
+
+	if err := resourceManager.Get(objMeta.GetNamespace(), objMeta.GetName(), existing); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+
+		change.Action = ApplicationImportActionCreate
+
+		if change.Diff, err = diffObjects(nil, obj); err != nil {
+			return nil, err
+		}
+
+		if dryRun {
+			return change, nil
+		}
+
+		return change, resourceManager.Create(obj)
+	}
+
+	current := exportObject(existing)
+
+	if currentMeta, err := meta.Accessor(current); err == nil {
+		currentMeta.SetNamespace(objMeta.GetNamespace())
+	}
+
+	if change.Diff, err = diffObjects(current, obj); err != nil {
+		return nil, err
+	}
+
+	if change.Diff == "" {
+		change.Action = ApplicationImportActionUnchanged
+		return change, nil
+	}
+
+	change.Action = ApplicationImportActionUpdate
+
+	if dryRun {
+		return change, nil
+	}
+
+	// the next sync of the application source would revert the import
+	if existingMeta, err := meta.Accessor(existing); err == nil {
+		if err := resourceManager.SuspendApplicationSourceOf(existingMeta, importedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	// the patch only contains fields of the bundle, finalizers and owners of the existing object are kept
+	return change, resourceManager.Patch(obj, client.MergeFrom(current))
+}
@@ -0,0 +1,92 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func newTestApplicationBundle() *ApplicationBundle {
+	return &ApplicationBundle{
+		Version:     ApplicationBundleVersion,
+		Application: "foo",
+		Components: []v1alpha1.Component{
+			{
+				TypeMeta:   exportTypeMeta("Component"),
+				ObjectMeta: metaV1.ObjectMeta{Name: "web"},
+				Spec:       v1alpha1.ComponentSpec{Image: "registry.local:5000/web:v1"},
+			},
+		},
+		HttpRoutes: []v1alpha1.HttpRoute{
+			{
+				TypeMeta:   exportTypeMeta("HttpRoute"),
+				ObjectMeta: metaV1.ObjectMeta{Name: "web"},
+				Spec: v1alpha1.HttpRouteSpec{
+					Hosts: []string{"foo.example.com", "www.example.com"},
+					Destinations: []v1alpha1.HttpRouteDestination{
+						{Host: "web.foo.svc.cluster.local:80", Weight: 1},
+						{Host: "external.com", Weight: 1},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestLocalizeApplicationBundle(t *testing.T) {
+	bundle := newTestApplicationBundle()
+
+	err := bundle.Localize("bar", ApplicationImportParameters{
+		Hosts:     map[string]string{"foo.example.com": "bar.example.com"},
+		ImageTags: map[string]string{"web": "v2"},
+		Replicas:  map[string]int32{"web": 3},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "bar", bundle.Application)
+	assert.Equal(t, "bar", bundle.Components[0].Namespace)
+	assert.Equal(t, "registry.local:5000/web:v2", bundle.Components[0].Spec.Image)
+	assert.Equal(t, int32(3), *bundle.Components[0].Spec.Replicas)
+	assert.Equal(t, []string{"bar.example.com", "www.example.com"}, bundle.HttpRoutes[0].Spec.Hosts)
+	assert.Equal(t, "web.bar.svc.cluster.local:80", bundle.HttpRoutes[0].Spec.Destinations[0].Host)
+	assert.Equal(t, "external.com", bundle.HttpRoutes[0].Spec.Destinations[1].Host)
+
+	assert.NotNil(t, newTestApplicationBundle().Localize("bar", ApplicationImportParameters{
+		ImageTags: map[string]string{"api": "v2"},
+	}))
+
+	assert.NotNil(t, newTestApplicationBundle().Localize("bar", ApplicationImportParameters{
+		Hosts: map[string]string{"unknown.example.com": "bar.example.com"},
+	}))
+}
+
+func TestParseApplicationBundle(t *testing.T) {
+	bts, err := yaml.Marshal(newTestApplicationBundle())
+	assert.Nil(t, err)
+
+	bundle, err := ParseApplicationBundle(bts)
+	assert.Nil(t, err)
+	assert.Equal(t, "web", bundle.Components[0].Name)
+	assert.Len(t, bundle.Objects(), 2)
+
+	_, err = ParseApplicationBundle([]byte("version: v0\n"))
+	assert.NotNil(t, err)
+}
+
+func TestDiffObjects(t *testing.T) {
+	bundle := newTestApplicationBundle()
+	current := exportObject(bundle.Components[0].DeepCopy())
+
+	diff, err := diffObjects(current, &bundle.Components[0])
+	assert.Nil(t, err)
+	assert.Equal(t, "", diff)
+
+	bundle.Components[0].Spec.Image = "registry.local:5000/web:v2"
+	diff, err = diffObjects(current, &bundle.Components[0])
+	assert.Nil(t, err)
+	assert.Contains(t, diff, "-  image: registry.local:5000/web:v1")
+	assert.Contains(t, diff, "+  image: registry.local:5000/web:v2")
+}
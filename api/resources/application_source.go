@@ -0,0 +1,97 @@
+package resources
+
+import (
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/controllers"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type ApplicationSource struct {
+	Name                           string `json:"name"`
+	v1alpha1.ApplicationSourceSpec `json:",inline"`
+	SuspendedBy                    string                           `json:"suspendedBy,omitempty"`
+	Status                         v1alpha1.ApplicationSourceStatus `json:"status"`
+}
+
+func BuildApplicationSourceFromResource(source *v1alpha1.ApplicationSource) *ApplicationSource {
+	return &ApplicationSource{
+		Name:                  source.Name,
+		ApplicationSourceSpec: source.Spec,
+		SuspendedBy:           source.Annotations[v1alpha1.AnnoApplicationSourceSuspendedBy],
+		Status:                source.Status,
+	}
+}
+
+func (resourceManager *ResourceManager) GetApplicationSources() ([]*ApplicationSource, error) {
+	var fetched v1alpha1.ApplicationSourceList
+
+	if err := resourceManager.List(&fetched); err != nil {
+		return nil, err
+	}
+
+	res := make([]*ApplicationSource, len(fetched.Items))
+
+	for i := range fetched.Items {
+		res[i] = BuildApplicationSourceFromResource(&fetched.Items[i])
+	}
+
+	return res, nil
+}
+
+func (resourceManager *ResourceManager) GetApplicationSource(name string) (*ApplicationSource, error) {
+	var source v1alpha1.ApplicationSource
+
+	if err := resourceManager.Get("", name, &source); err != nil {
+		return nil, err
+	}
+
+	return BuildApplicationSourceFromResource(&source), nil
+}
+
+func (resourceManager *ResourceManager) CreateApplicationSource(source *ApplicationSource) (*ApplicationSource, error) {
+	resource := &v1alpha1.ApplicationSource{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: source.Name,
+		},
+		Spec: source.ApplicationSourceSpec,
+	}
+
+	if err := resourceManager.Create(resource); err != nil {
+		return nil, err
+	}
+
+	return BuildApplicationSourceFromResource(resource), nil
+}
+
+// UpdateApplicationSource updates the spec, the suspended-by annotation is cleared when the sync is resumed.
+func (resourceManager *ResourceManager) UpdateApplicationSource(source *ApplicationSource) (*ApplicationSource, error) {
+	var fetched v1alpha1.ApplicationSource
+
+	if err := resourceManager.Get("", source.Name, &fetched); err != nil {
+		return nil, err
+	}
+
+	copied := fetched.DeepCopy()
+	copied.Spec = source.ApplicationSourceSpec
+
+	if !copied.Spec.Suspend {
+		delete(copied.Annotations, v1alpha1.AnnoApplicationSourceSuspendedBy)
+	}
+
+	if err := resourceManager.Patch(copied, client.MergeFrom(&fetched)); err != nil {
+		return nil, err
+	}
+
+	return BuildApplicationSourceFromResource(copied), nil
+}
+
+func (resourceManager *ResourceManager) DeleteApplicationSource(name string) error {
+	return resourceManager.Delete(&v1alpha1.ApplicationSource{ObjectMeta: metaV1.ObjectMeta{Name: name}})
+}
+
+// SuspendApplicationSourceOf pauses the sync of the ApplicationSource the object is applied from,
+// so edits through the dashboard are not reverted by the next sync.
+func (resourceManager *ResourceManager) SuspendApplicationSourceOf(obj metaV1.Object, suspendedBy string) error {
+	return controllers.SuspendApplicationSourceOf(resourceManager.ctx, resourceManager.Client, obj, suspendedBy)
+}
@@ -55,6 +55,7 @@ func (resourceManager *ResourceManager) GetComponentListChannel(namespaces strin
 type Component struct {
 	Name                            string                 `json:"name"`
 	Plugins                         []runtime.RawExtension `json:"plugins,omitempty"`
+	ChangeCause                     string                 `json:"changeCause,omitempty"`
 	*v1alpha1.ComponentSpec         `json:",inline"`
 	*v1alpha1.ProtectedEndpointSpec `json:"protectedEndpoint,omitempty"`
 }
@@ -93,6 +94,8 @@ type ComponentDetails struct {
 
 	Plugins []runtime.RawExtension `json:"plugins,omitempty"`
 
+	Status v1alpha1.ComponentStatus `json:"status"`
+
 	Metrics              MetricHistories       `json:"metrics"`
 	IstioMetricHistories *IstioMetricHistories `json:"istioMetricHistories"`
 	Services             []ServiceStatus       `json:"services"`
@@ -189,6 +192,7 @@ func (resourceManager *ResourceManager) BuildComponentDetails(
 
 		ComponentSpec: component.Spec,
 		Plugins:       plugins,
+		Status:        component.Status,
 
 		Services: servicesStatus,
 		Metrics: MetricHistories{
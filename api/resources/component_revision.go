@@ -0,0 +1,56 @@
+package resources
+
+import (
+	"sort"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type ComponentRevision struct {
+	Name          string                 `json:"name"`
+	ComponentName string                 `json:"componentName"`
+	Revision      int64                  `json:"revision"`
+	ChangedBy     string                 `json:"changedBy,omitempty"`
+	ChangeCause   string                 `json:"changeCause,omitempty"`
+	CreatedAt     metaV1.Time            `json:"createdAt"`
+	ComponentSpec v1alpha1.ComponentSpec `json:"componentSpec"`
+}
+
+func BuildComponentRevisionFromResource(revision *v1alpha1.ComponentRevision) *ComponentRevision {
+	return &ComponentRevision{
+		Name:          revision.Name,
+		ComponentName: revision.Spec.ComponentName,
+		Revision:      revision.Spec.Revision,
+		ChangedBy:     revision.Spec.ChangedBy,
+		ChangeCause:   revision.Spec.ChangeCause,
+		CreatedAt:     revision.CreationTimestamp,
+		ComponentSpec: revision.Spec.ComponentSpec,
+	}
+}
+
+// GetComponentRevisions returns revisions of the component, the latest revision comes first.
+func (resourceManager *ResourceManager) GetComponentRevisions(namespace, componentName string) ([]*ComponentRevision, error) {
+	var fetched v1alpha1.ComponentRevisionList
+
+	if err := resourceManager.List(
+		&fetched,
+		client.InNamespace(namespace),
+		client.MatchingLabels{v1alpha1.ComponentRevisionLabelComponent: componentName},
+	); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(fetched.Items, func(i, j int) bool {
+		return fetched.Items[i].Spec.Revision > fetched.Items[j].Spec.Revision
+	})
+
+	res := make([]*ComponentRevision, len(fetched.Items))
+
+	for i := range fetched.Items {
+		res[i] = BuildComponentRevisionFromResource(&fetched.Items[i])
+	}
+
+	return res, nil
+}
@@ -0,0 +1,189 @@
+package resources
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/controllers"
+	batchV1 "k8s.io/api/batch/v1"
+	batchV1Beta1 "k8s.io/api/batch/v1beta1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	ComponentRunStatusRunning   = "Running"
+	ComponentRunStatusSucceeded = "Succeeded"
+	ComponentRunStatusFailed    = "Failed"
+
+	// same annotation as kubectl create job --from=cronjob
+	annoCronJobInstantiate = "cronjob.kubernetes.io/instantiate"
+)
+
+type ComponentRunPod struct {
+	Name    string `json:"name"`
+	Phase   string `json:"phase"`
+	LogLink string `json:"logLink"`
+}
+
+// ComponentRun is a job created by the cronjob of a component, either on schedule or manually.
+type ComponentRun struct {
+	Name           string             `json:"name"`
+	Manual         bool               `json:"manual"`
+	Status         string             `json:"status"`
+	Active         int32              `json:"active"`
+	Succeeded      int32              `json:"succeeded"`
+	Failed         int32              `json:"failed"`
+	StartTime      *metaV1.Time       `json:"startTime,omitempty"`
+	CompletionTime *metaV1.Time       `json:"completionTime,omitempty"`
+	CreatedAt      metaV1.Time        `json:"createdAt"`
+	Pods           []*ComponentRunPod `json:"pods"`
+}
+
+func getComponentRunStatus(job *batchV1.Job) string {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != coreV1.ConditionTrue {
+			continue
+		}
+
+		switch condition.Type {
+		case batchV1.JobComplete:
+			return ComponentRunStatusSucceeded
+		case batchV1.JobFailed:
+			return ComponentRunStatusFailed
+		}
+	}
+
+	return ComponentRunStatusRunning
+}
+
+// getPodLogLink returns the link of the log page in the dashboard, the same query is generated by the frontend.
+func getPodLogLink(namespace, podName, containerName string) string {
+	query := url.Values{
+		"active":    {podName, containerName},
+		"namespace": {namespace},
+		"pods":      {podName + "," + containerName},
+	}
+
+	return fmt.Sprintf("/applications/%s/logs?%s", namespace, query.Encode())
+}
+
+func BuildComponentRunFromResource(job *batchV1.Job, pods []coreV1.Pod, containerName string) *ComponentRun {
+	run := &ComponentRun{
+		Name:           job.Name,
+		Manual:         job.Annotations[annoCronJobInstantiate] == "manual",
+		Status:         getComponentRunStatus(job),
+		Active:         job.Status.Active,
+		Succeeded:      job.Status.Succeeded,
+		Failed:         job.Status.Failed,
+		StartTime:      job.Status.StartTime,
+		CompletionTime: job.Status.CompletionTime,
+		CreatedAt:      job.CreationTimestamp,
+		Pods:           []*ComponentRunPod{},
+	}
+
+	for _, pod := range pods {
+		if pod.Labels["job-name"] != job.Name {
+			continue
+		}
+
+		run.Pods = append(run.Pods, &ComponentRunPod{
+			Name:    pod.Name,
+			Phase:   string(pod.Status.Phase),
+			LogLink: getPodLogLink(pod.Namespace, pod.Name, containerName),
+		})
+	}
+
+	return run
+}
+
+// GetComponentRuns returns runs of a cronjob component, the latest run comes first.
+// Finished runs are kept by the history limits of the cronjob.
+func (resourceManager *ResourceManager) GetComponentRuns(namespace, componentName string) ([]*ComponentRun, error) {
+	var jobList batchV1.JobList
+
+	if err := resourceManager.List(
+		&jobList,
+		client.InNamespace(namespace),
+		client.MatchingLabels{controllers.KalmLabelComponentKey: componentName},
+	); err != nil {
+		return nil, err
+	}
+
+	var podList coreV1.PodList
+
+	if err := resourceManager.List(
+		&podList,
+		client.InNamespace(namespace),
+		client.MatchingLabels{controllers.KalmLabelComponentKey: componentName},
+	); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(jobList.Items, func(i, j int) bool {
+		return jobList.Items[j].CreationTimestamp.Before(&jobList.Items[i].CreationTimestamp)
+	})
+
+	res := make([]*ComponentRun, len(jobList.Items))
+
+	for i := range jobList.Items {
+		res[i] = BuildComponentRunFromResource(&jobList.Items[i], podList.Items, componentName)
+	}
+
+	return res, nil
+}
+
+// CreateComponentRun runs the cronjob of the component now, like kubectl create job --from=cronjob.
+func (resourceManager *ResourceManager) CreateComponentRun(component *v1alpha1.Component) (*ComponentRun, error) {
+	if component.Spec.WorkloadType != v1alpha1.WorkloadTypeCronjob {
+		return nil, errors.NewBadRequest(fmt.Sprintf("component %s is not a cronjob", component.Name))
+	}
+
+	var cronJob batchV1Beta1.CronJob
+
+	if err := resourceManager.Get(component.Namespace, component.Name, &cronJob); err != nil {
+		return nil, err
+	}
+
+	annotations := make(map[string]string, len(cronJob.Spec.JobTemplate.Annotations)+1)
+
+	for k, v := range cronJob.Spec.JobTemplate.Annotations {
+		annotations[k] = v
+	}
+
+	annotations[annoCronJobInstantiate] = "manual"
+
+	labels := make(map[string]string, len(cronJob.Spec.JobTemplate.Labels)+1)
+
+	for k, v := range cronJob.Spec.JobTemplate.Labels {
+		labels[k] = v
+	}
+
+	// templates of cronjobs created by old versions have no labels
+	labels[controllers.KalmLabelComponentKey] = component.Name
+
+	job := &batchV1.Job{
+		ObjectMeta: metaV1.ObjectMeta{
+			// runs triggered in the same second must not collide, let the api server pick the suffix
+			GenerateName: component.Name + "-manual-",
+			Namespace:    component.Namespace,
+			Labels:       labels,
+			Annotations:  annotations,
+			// owned by the cronjob, so it's deleted with the component
+			OwnerReferences: []metaV1.OwnerReference{
+				*metaV1.NewControllerRef(&cronJob, batchV1Beta1.SchemeGroupVersion.WithKind("CronJob")),
+			},
+		},
+		Spec: *cronJob.Spec.JobTemplate.Spec.DeepCopy(),
+	}
+
+	if err := resourceManager.Create(job); err != nil {
+		return nil, err
+	}
+
+	return BuildComponentRunFromResource(job, nil, component.Name), nil
+}
@@ -0,0 +1,41 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	batchV1 "k8s.io/api/batch/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildComponentRunFromResource(t *testing.T) {
+	job := &batchV1.Job{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:        "backup-manual-1",
+			Namespace:   "app",
+			Annotations: map[string]string{annoCronJobInstantiate: "manual"},
+		},
+		Status: batchV1.JobStatus{
+			Failed: 1,
+			Conditions: []batchV1.JobCondition{
+				{Type: batchV1.JobFailed, Status: coreV1.ConditionTrue},
+			},
+		},
+	}
+
+	pods := []coreV1.Pod{
+		{ObjectMeta: metaV1.ObjectMeta{Name: "backup-manual-1-abc", Namespace: "app", Labels: map[string]string{"job-name": "backup-manual-1"}}},
+		{ObjectMeta: metaV1.ObjectMeta{Name: "backup-2-def", Namespace: "app", Labels: map[string]string{"job-name": "backup-2"}}},
+	}
+
+	run := BuildComponentRunFromResource(job, pods, "backup")
+
+	assert.True(t, run.Manual)
+	assert.Equal(t, ComponentRunStatusFailed, run.Status)
+	assert.Len(t, run.Pods, 1)
+	assert.Equal(t,
+		"/applications/app/logs?active=backup-manual-1-abc&active=backup&namespace=app&pods=backup-manual-1-abc%2Cbackup",
+		run.Pods[0].LogLink,
+	)
+}
@@ -4,11 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/kalmhq/kalm/api/log"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/controllers"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -36,6 +40,8 @@ type IstioMetricHistories struct {
 	HTTPRespCode5XXCount MetricHistory `json:"httpRespCode5XXCount,omitempty"`
 	HTTPRequestBytes     MetricHistory `json:"httpRequestBytes,omitempty"`
 	HTTPResponseBytes    MetricHistory `json:"httpResponseBytes,omitempty"`
+	// requests rejected by rate limits of routes
+	HTTPRateLimitedCount MetricHistory `json:"httpRateLimitedCount,omitempty"`
 
 	//TCP
 	TCPSentBytesTotal     MetricHistory `json:"tcpSentBytesTotal,omitempty"`
@@ -57,6 +63,7 @@ func mergeIstioMetricHistories(a, b *IstioMetricHistories) *IstioMetricHistories
 	rst.HTTPRespCode5XXCount = mergeMetricHistories(a.HTTPRespCode5XXCount, b.HTTPRespCode5XXCount)
 	rst.HTTPRequestBytes = mergeMetricHistories(a.HTTPRequestBytes, b.HTTPRequestBytes)
 	rst.HTTPResponseBytes = mergeMetricHistories(a.HTTPResponseBytes, b.HTTPResponseBytes)
+	rst.HTTPRateLimitedCount = mergeMetricHistories(a.HTTPRateLimitedCount, b.HTTPRateLimitedCount)
 	rst.TCPReceivedBytesTotal = mergeMetricHistories(a.TCPReceivedBytesTotal, b.TCPReceivedBytesTotal)
 	rst.TCPSentBytesTotal = mergeMetricHistories(a.TCPSentBytesTotal, b.TCPSentBytesTotal)
 
@@ -77,7 +84,13 @@ func (resourceManager *ResourceManager) GetIstioMetricsListChannel(ns string) *I
 			return
 		}
 
-		httpSvc2MetricMap, err := getIstioMetricHistoriesMap(ns)
+		var routeList v1alpha1.HttpRouteList
+
+		if err := resourceManager.List(&routeList, client.InNamespace(ns)); err != nil {
+			log.Debug("err when list http routes, rate limited requests are ignored", "ns", ns, "err", err)
+		}
+
+		httpSvc2MetricMap, err := getIstioMetricHistoriesMap(ns, routeList.Items)
 		channel.List <- httpSvc2MetricMap
 		channel.Error <- err
 	}()
@@ -85,8 +98,45 @@ func (resourceManager *ResourceManager) GetIstioMetricsListChannel(ns string) *I
 	return &channel
 }
 
+const rateLimitedQueryKeyPrefix = "httpRateLimited/"
+
+// getRateLimitedQueries returns queries of requests limited by each route, and services the requests are sent to.
+// Limited requests are replied by the ingress gateway before they are routed, they have no destination service in istio metrics,
+// so the counter of the local rate limit filter of the route is used.
+func getRateLimitedQueries(ns string, routes []v1alpha1.HttpRoute) (queries map[string]string, services map[string][]string) {
+	queries = make(map[string]string)
+	services = make(map[string][]string)
+
+	for _, route := range routes {
+		if route.Spec.RateLimit == nil {
+			continue
+		}
+
+		key := rateLimitedQueryKeyPrefix + route.Name
+		queries[key] = fmt.Sprintf(`sum(rate(envoy_%s_http_local_rate_limit_rate_limited[5m]))`, controllers.GetRateLimitStatPrefix(route.Namespace, route.Name))
+
+		for _, destination := range route.Spec.Destinations {
+			host := strings.Split(destination.Host, ":")[0]
+			parts := strings.Split(host, ".")
+
+			if len(parts) == 1 {
+				parts = append(parts, route.Namespace)
+			}
+
+			// only services of the application are in the map
+			if parts[1] != ns {
+				continue
+			}
+
+			services[key] = append(services[key], fmt.Sprintf("%s.%s.svc.cluster.local", parts[0], parts[1]))
+		}
+	}
+
+	return queries, services
+}
+
 // map of {svc -> istioMetricHistories}
-func getIstioMetricHistoriesMap(ns string) (map[string]*IstioMetricHistories, error) {
+func getIstioMetricHistoriesMap(ns string, routes []v1alpha1.HttpRoute) (map[string]*IstioMetricHistories, error) {
 	svcName := fmt.Sprintf(`.*.%s.svc.cluster.local`, ns)
 
 	httpRequestsTotal := fmt.Sprintf(`istio:istio_requests_total:by_destination_service:rate5m{destination_service=~"%s"}`, svcName)
@@ -109,6 +159,12 @@ func getIstioMetricHistoriesMap(ns string) (map[string]*IstioMetricHistories, er
 		"tcpReceiveBytes":   receiveBytes,
 	}
 
+	rateLimitedQueries, rateLimitedServices := getRateLimitedQueries(ns, routes)
+
+	for k, query := range rateLimitedQueries {
+		queryMap[k] = query
+	}
+
 	now := time.Now().Unix()
 	startAs30MinAgo := now - 30*60
 	stepAs1Min := 60
@@ -162,6 +218,27 @@ func getIstioMetricHistoriesMap(ns string) (map[string]*IstioMetricHistories, er
 		k := resp.Key
 		promResp := resp.Resp
 
+		if strings.HasPrefix(k, rateLimitedQueryKeyPrefix) {
+			for _, rst := range promResp.Data.Result {
+				metricPoints := trans2MetricPoints(rst.Values)
+
+				for _, svc := range rateLimitedServices[k] {
+					if _, exist := svc2MetricHistoriesMap[svc]; !exist {
+						svc2MetricHistoriesMap[svc] = &IstioMetricHistories{}
+					}
+
+					// a service can be the destination of many routes
+					svc2MetricHistoriesMap[svc].HTTPRateLimitedCount = mergeMetricHistories(svc2MetricHistoriesMap[svc].HTTPRateLimitedCount, metricPoints)
+				}
+			}
+
+			if cnt == len(queryMap) {
+				break
+			}
+
+			continue
+		}
+
 		for _, rst := range promResp.Data.Result {
 
 			svc, exist := rst.Metric["destination_service"]
@@ -0,0 +1,41 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetRateLimitedQueries(t *testing.T) {
+	routes := []v1alpha1.HttpRoute{
+		{
+			ObjectMeta: metaV1.ObjectMeta{Namespace: "my-app", Name: "api"},
+			Spec: v1alpha1.HttpRouteSpec{
+				Destinations: []v1alpha1.HttpRouteDestination{
+					{Host: "web.my-app.svc.cluster.local:80"},
+					{Host: "worker:8080"},
+					{Host: "web.other.svc.cluster.local:80"},
+				},
+				RateLimit: &v1alpha1.HttpRouteRateLimit{RequestsPerUnit: 10, Unit: v1alpha1.HttpRouteRateLimitUnitSecond},
+			},
+		},
+		{
+			ObjectMeta: metaV1.ObjectMeta{Namespace: "my-app", Name: "static"},
+			Spec: v1alpha1.HttpRouteSpec{
+				Destinations: []v1alpha1.HttpRouteDestination{{Host: "web.my-app.svc.cluster.local:80"}},
+			},
+		},
+	}
+
+	queries, services := getRateLimitedQueries("my-app", routes)
+
+	assert.Equal(t, map[string]string{
+		"httpRateLimited/api": "sum(rate(envoy_kalm_route_my_app_api_http_local_rate_limit_rate_limited[5m]))",
+	}, queries)
+
+	assert.Equal(t, map[string][]string{
+		"httpRateLimited/api": {"web.my-app.svc.cluster.local", "worker.my-app.svc.cluster.local"},
+	}, services)
+}
@@ -4,9 +4,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/kalmhq/kalm/controller/controllers"
 	coreV1 "k8s.io/api/core/v1"
+	policyV1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -37,6 +42,23 @@ type Node struct {
 	AllocatedResources AllocatedResources `json:"allocatedResources"`
 }
 
+// DrainBlocker is a pod that can't be evicted, usually because of the disruption budget of its component.
+type DrainBlocker struct {
+	Namespace string `json:"namespace"`
+	Component string `json:"component,omitempty"`
+	PodName   string `json:"podName"`
+	Message   string `json:"message"`
+}
+
+type DrainNodeResponse struct {
+	Node *Node `json:"node"`
+
+	// true if all pods are evicted
+	Drained     bool           `json:"drained"`
+	EvictedPods []string       `json:"evictedPods"`
+	BlockedBy   []DrainBlocker `json:"blockedBy"`
+}
+
 type NodesResponse struct {
 	Nodes   []Node          `json:"nodes"`
 	Metrics MetricHistories `json:"metrics"`
@@ -130,6 +152,106 @@ func (resourceManager *ResourceManager) CordonNode(node *coreV1.Node) error {
 	return nil
 }
 
+// DrainNode cordons the node and evicts its pods through the eviction api, so disruption budgets are respected.
+// Pods blocked by budgets are reported instead of failing the request, draining again evicts them once the budgets allow.
+func (resourceManager *ResourceManager) DrainNode(node *coreV1.Node) (*DrainNodeResponse, error) {
+	if !node.Spec.Unschedulable {
+		if err := resourceManager.CordonNode(node); err != nil {
+			return nil, err
+		}
+	}
+
+	fieldSelector, err := fields.ParseSelector("spec.nodeName=" + node.Name + ",status.phase!=" + string(coreV1.PodSucceeded) + ",status.phase!=" + string(coreV1.PodFailed))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var podList coreV1.PodList
+
+	if err := resourceManager.List(&podList, client.MatchingFieldsSelector{Selector: fieldSelector}); err != nil {
+		return nil, err
+	}
+
+	k8sClient, err := kubernetes.NewForConfig(resourceManager.Cfg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := &DrainNodeResponse{
+		EvictedPods: []string{},
+		BlockedBy:   []DrainBlocker{},
+	}
+
+	for _, pod := range podsToDrain(podList.Items) {
+		err := k8sClient.PolicyV1beta1().Evictions(pod.Namespace).Evict(resourceManager.ctx, &policyV1beta1.Eviction{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      pod.Name,
+				Namespace: pod.Namespace,
+			},
+		})
+
+		if err == nil || errors.IsNotFound(err) {
+			res.EvictedPods = append(res.EvictedPods, pod.Namespace+"/"+pod.Name)
+			continue
+		}
+
+		res.BlockedBy = append(res.BlockedBy, getDrainBlocker(pod, err))
+	}
+
+	res.Drained = len(res.BlockedBy) == 0
+
+	var updated coreV1.Node
+
+	if err := resourceManager.Get("", node.Name, &updated); err != nil {
+		return nil, err
+	}
+
+	res.Node = resourceManager.BuildNodeResponse(&updated)
+
+	return res, nil
+}
+
+// podsToDrain skips pods that would be recreated on the node anyway, i.e. daemonset pods and static pods, and terminating pods.
+func podsToDrain(pods []coreV1.Pod) []coreV1.Pod {
+	var res []coreV1.Pod
+
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		if _, isMirror := pod.Annotations[coreV1.MirrorPodAnnotationKey]; isMirror {
+			continue
+		}
+
+		if owner := metaV1.GetControllerOf(&pod); owner != nil && owner.Kind == "DaemonSet" {
+			continue
+		}
+
+		res = append(res, pod)
+	}
+
+	return res
+}
+
+func getDrainBlocker(pod coreV1.Pod, err error) DrainBlocker {
+	blocker := DrainBlocker{
+		Namespace: pod.Namespace,
+		Component: pod.Labels[controllers.KalmLabelComponentKey],
+		PodName:   pod.Name,
+		Message:   err.Error(),
+	}
+
+	// the eviction api responds 429 if the eviction would violate a disruption budget
+	if errors.IsTooManyRequests(err) {
+		blocker.Message = "eviction is blocked by the disruption budget: " + err.Error()
+	}
+
+	return blocker
+}
+
 func (resourceManager *ResourceManager) UncordonNode(node *coreV1.Node) error {
 	nodeCopy := node.DeepCopy()
 	nodeCopy.Spec.Unschedulable = false
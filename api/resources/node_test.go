@@ -0,0 +1,69 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/controllers"
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	policyV1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPodsToDrain(t *testing.T) {
+	isController := true
+	now := metaV1.Now()
+
+	pods := []coreV1.Pod{
+		{ObjectMeta: metaV1.ObjectMeta{Name: "web"}},
+		{ObjectMeta: metaV1.ObjectMeta{
+			Name: "daemon",
+			OwnerReferences: []metaV1.OwnerReference{
+				{Kind: "DaemonSet", Name: "fluentd", Controller: &isController},
+			},
+		}},
+		{ObjectMeta: metaV1.ObjectMeta{
+			Name:        "static",
+			Annotations: map[string]string{coreV1.MirrorPodAnnotationKey: "hash"},
+		}},
+		{ObjectMeta: metaV1.ObjectMeta{Name: "terminating", DeletionTimestamp: &now}},
+		{ObjectMeta: metaV1.ObjectMeta{
+			Name: "replica",
+			OwnerReferences: []metaV1.OwnerReference{
+				{Kind: "ReplicaSet", Name: "api", Controller: &isController},
+			},
+		}},
+	}
+
+	var names []string
+
+	for _, pod := range podsToDrain(pods) {
+		names = append(names, pod.Name)
+	}
+
+	assert.Equal(t, []string{"web", "replica"}, names)
+}
+
+func TestGetDrainBlocker(t *testing.T) {
+	pod := coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      "api-0",
+			Namespace: "prod",
+			Labels:    map[string]string{controllers.KalmLabelComponentKey: "api"},
+		},
+	}
+
+	err := errors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 10)
+	blocker := getDrainBlocker(pod, err)
+
+	assert.Equal(t, "prod", blocker.Namespace)
+	assert.Equal(t, "api", blocker.Component)
+	assert.Equal(t, "api-0", blocker.PodName)
+	assert.Contains(t, blocker.Message, "disruption budget")
+
+	err = errors.NewForbidden(schema.GroupResource{Group: policyV1beta1.GroupName, Resource: "evictions"}, "api-0", nil)
+	blocker = getDrainBlocker(pod, err)
+	assert.Equal(t, err.Error(), blocker.Message)
+}
@@ -0,0 +1,314 @@
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/controllers"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type PreviewEnvironment struct {
+	Name                            string `json:"name"`
+	v1alpha1.PreviewEnvironmentSpec `json:",inline"`
+	Status                          v1alpha1.PreviewEnvironmentStatus `json:"status"`
+}
+
+func BuildPreviewEnvironmentFromResource(preview *v1alpha1.PreviewEnvironment) *PreviewEnvironment {
+	return &PreviewEnvironment{
+		Name:                   preview.Name,
+		PreviewEnvironmentSpec: preview.Spec,
+		Status:                 preview.Status,
+	}
+}
+
+// PreviewEnvironmentName is the name of the environment and its namespace, e.g. app-pr-123
+func PreviewEnvironmentName(application string, pullRequest int32) string {
+	return fmt.Sprintf("%s-pr-%d", application, pullRequest)
+}
+
+// previewEnvironmentHosts maps hosts of the template application to hosts under the preview domain.
+// An application with a single host gets pr-123.<domain>, otherwise the first label of each host is kept, e.g. pr-123-api.<domain>
+func previewEnvironmentHosts(hosts []string, pullRequest int32, domain string) map[string]string {
+	distinct := make([]string, 0, len(hosts))
+	seen := make(map[string]bool, len(hosts))
+
+	for _, host := range hosts {
+		if !seen[host] {
+			seen[host] = true
+			distinct = append(distinct, host)
+		}
+	}
+
+	sort.Strings(distinct)
+
+	prefix := fmt.Sprintf("pr-%d", pullRequest)
+	res := make(map[string]string, len(distinct))
+
+	if len(distinct) == 1 {
+		res[distinct[0]] = prefix + "." + domain
+		return res
+	}
+
+	used := make(map[string]bool, len(distinct))
+
+	for i, host := range distinct {
+		label := strings.Split(host, ".")[0]
+
+		if label == "*" || label == "" {
+			label = strconv.Itoa(i + 1)
+		}
+
+		newHost := fmt.Sprintf("%s-%s.%s", prefix, label, domain)
+
+		// api.foo.com and api.bar.com
+		for n := 2; used[newHost]; n++ {
+			newHost = fmt.Sprintf("%s-%s-%d.%s", prefix, label, n, domain)
+		}
+
+		used[newHost] = true
+		res[host] = newHost
+	}
+
+	return res
+}
+
+func httpsCertCoversDomain(cert *v1alpha1.HttpsCert, domain string) bool {
+	for _, d := range cert.Spec.Domains {
+		if d == "*."+domain {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getPreviewEnvironmentHttpsCert returns the wildcard cert of the domain, or an empty string if there isn't one.
+// If a cert is specified, it must cover the domain.
+func (resourceManager *ResourceManager) getPreviewEnvironmentHttpsCert(name, domain string) (string, error) {
+	if name != "" {
+		var cert v1alpha1.HttpsCert
+
+		if err := resourceManager.Get("", name, &cert); err != nil {
+			return "", err
+		}
+
+		if !httpsCertCoversDomain(&cert, domain) {
+			return "", errors.NewBadRequest(fmt.Sprintf("https cert %s doesn't cover *.%s", name, domain))
+		}
+
+		return name, nil
+	}
+
+	var certList v1alpha1.HttpsCertList
+
+	if err := resourceManager.List(&certList); err != nil {
+		return "", err
+	}
+
+	for i := range certList.Items {
+		if httpsCertCoversDomain(&certList.Items[i], domain) {
+			return certList.Items[i].Name, nil
+		}
+	}
+
+	return "", nil
+}
+
+func (resourceManager *ResourceManager) GetPreviewEnvironments(application string) ([]*PreviewEnvironment, error) {
+	var fetched v1alpha1.PreviewEnvironmentList
+
+	if err := resourceManager.List(&fetched); err != nil {
+		return nil, err
+	}
+
+	res := make([]*PreviewEnvironment, 0, len(fetched.Items))
+
+	for i := range fetched.Items {
+		if fetched.Items[i].Spec.Application == application {
+			res = append(res, BuildPreviewEnvironmentFromResource(&fetched.Items[i]))
+		}
+	}
+
+	return res, nil
+}
+
+func (resourceManager *ResourceManager) GetPreviewEnvironment(application string, pullRequest int32) (*PreviewEnvironment, error) {
+	var preview v1alpha1.PreviewEnvironment
+
+	if err := resourceManager.Get("", PreviewEnvironmentName(application, pullRequest), &preview); err != nil {
+		return nil, err
+	}
+
+	return BuildPreviewEnvironmentFromResource(&preview), nil
+}
+
+// CreateOrUpdatePreviewEnvironment clones the application into the namespace of the pull request.
+// Calling it again for the same pull request updates image tags of the environment, the ttl still counts from creation.
+// The returned bool is true if the environment is created.
+func (resourceManager *ResourceManager) CreateOrUpdatePreviewEnvironment(spec v1alpha1.PreviewEnvironmentSpec) (*PreviewEnvironment, bool, error) {
+	name := PreviewEnvironmentName(spec.Application, spec.PullRequest)
+
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return nil, false, errors.NewBadRequest(fmt.Sprintf("invalid preview environment name %s: %s", name, strings.Join(errs, ", ")))
+	}
+
+	if spec.Domain == "" {
+		return nil, false, errors.NewBadRequest("domain can't be blank")
+	}
+
+	bundle, err := resourceManager.ExportApplication(spec.Application, true)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	var hosts []string
+	useHttps := false
+
+	for _, route := range bundle.HttpRoutes {
+		hosts = append(hosts, route.Spec.Hosts...)
+
+		for _, scheme := range route.Spec.Schemes {
+			if scheme == "https" {
+				useHttps = true
+			}
+		}
+	}
+
+	if spec.HttpsCert, err = resourceManager.getPreviewEnvironmentHttpsCert(spec.HttpsCert, spec.Domain); err != nil {
+		return nil, false, err
+	}
+
+	if useHttps && spec.HttpsCert == "" {
+		return nil, false, errors.NewBadRequest(fmt.Sprintf("routes use https, but there is no https cert of *.%s", spec.Domain))
+	}
+
+	err = bundle.Localize(name, ApplicationImportParameters{
+		Hosts:     previewEnvironmentHosts(hosts, spec.PullRequest, spec.Domain),
+		ImageTags: spec.ImageTags,
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	// the copy is not synced from the source of the template, and edits of it shouldn't suspend the source
+	for _, obj := range bundle.Objects() {
+		if objMeta, err := meta.Accessor(obj); err == nil {
+			labels := objMeta.GetLabels()
+			delete(labels, v1alpha1.ApplicationSourceLabelName)
+			objMeta.SetLabels(labels)
+		}
+	}
+
+	preview, created, err := resourceManager.savePreviewEnvironment(name, spec)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := resourceManager.ensurePreviewEnvironmentNamespace(preview); err != nil {
+		return nil, false, err
+	}
+
+	if _, err := resourceManager.ImportApplication(bundle, false, "kalm"); err != nil {
+		return nil, false, err
+	}
+
+	return BuildPreviewEnvironmentFromResource(preview), created, nil
+}
+
+func (resourceManager *ResourceManager) savePreviewEnvironment(name string, spec v1alpha1.PreviewEnvironmentSpec) (*v1alpha1.PreviewEnvironment, bool, error) {
+	var fetched v1alpha1.PreviewEnvironment
+
+	if err := resourceManager.Get("", name, &fetched); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, false, err
+		}
+
+		preview := &v1alpha1.PreviewEnvironment{
+			ObjectMeta: metaV1.ObjectMeta{Name: name},
+			Spec:       spec,
+		}
+
+		if err := resourceManager.Create(preview); err != nil {
+			return nil, false, err
+		}
+
+		return preview, true, nil
+	}
+
+	copied := fetched.DeepCopy()
+	copied.Spec = spec
+
+	if err := resourceManager.Patch(copied, client.MergeFrom(&fetched)); err != nil {
+		return nil, false, err
+	}
+
+	return copied, false, nil
+}
+
+// ensurePreviewEnvironmentNamespace creates the namespace owned by the environment, so it's garbage collected with the environment.
+func (resourceManager *ResourceManager) ensurePreviewEnvironmentNamespace(preview *v1alpha1.PreviewEnvironment) error {
+	namespace, err := resourceManager.GetNamespace(preview.Name)
+
+	if err == nil {
+		if namespace.Labels[v1alpha1.PreviewEnvironmentLabelName] != preview.Name {
+			return errors.NewConflict(v1alpha1.GroupVersion.WithResource("previewenvironments").GroupResource(), preview.Name,
+				fmt.Errorf("namespace %s already exists and doesn't belong to the preview environment", preview.Name))
+		}
+
+		return nil
+	}
+
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	return resourceManager.CreateNamespace(&coreV1.Namespace{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: preview.Name,
+			Labels: map[string]string{
+				controllers.KalmEnableLabelName:      controllers.KalmEnableLabelValue,
+				v1alpha1.PreviewEnvironmentLabelName: preview.Name,
+			},
+			OwnerReferences: []metaV1.OwnerReference{
+				*metaV1.NewControllerRef(preview, v1alpha1.GroupVersion.WithKind("PreviewEnvironment")),
+			},
+		},
+	})
+}
+
+// DeletePreviewEnvironment closes the environment of the pull request, its namespace is deleted as well.
+func (resourceManager *ResourceManager) DeletePreviewEnvironment(application string, pullRequest int32) error {
+	name := PreviewEnvironmentName(application, pullRequest)
+
+	var preview v1alpha1.PreviewEnvironment
+
+	if err := resourceManager.Get("", name, &preview); err != nil {
+		return err
+	}
+
+	namespace, err := resourceManager.GetNamespace(name)
+
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if err == nil && namespace.Labels[v1alpha1.PreviewEnvironmentLabelName] == name {
+		if err := resourceManager.DeleteNamespace(namespace); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return resourceManager.Delete(&preview)
+}
@@ -0,0 +1,30 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewEnvironmentHosts(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		"app.example.com": "pr-12.preview.example.com",
+	}, previewEnvironmentHosts([]string{"app.example.com", "app.example.com"}, 12, "preview.example.com"))
+
+	assert.Equal(t, map[string]string{
+		"api.example.com": "pr-12-api.preview.example.com",
+		"api.example.org": "pr-12-api-2.preview.example.com",
+		"*.example.com":   "pr-12-1.preview.example.com",
+		"www.example.com": "pr-12-www.preview.example.com",
+	}, previewEnvironmentHosts([]string{"www.example.com", "api.example.com", "*.example.com", "api.example.org"}, 12, "preview.example.com"))
+
+	assert.Empty(t, previewEnvironmentHosts(nil, 12, "preview.example.com"))
+}
+
+func TestHttpsCertCoversDomain(t *testing.T) {
+	cert := &v1alpha1.HttpsCert{Spec: v1alpha1.HttpsCertSpec{Domains: []string{"example.com", "*.preview.example.com"}}}
+
+	assert.True(t, httpsCertCoversDomain(cert, "preview.example.com"))
+	assert.False(t, httpsCertCoversDomain(cert, "example.com"))
+}
@@ -43,8 +43,9 @@ func (resourceManager *ResourceManager) GetHttpRouteListChannel(listOptions ...c
 
 type HttpRoute struct {
 	*v1alpha1.HttpRouteSpec `json:",inline"`
-	Name                    string `json:"name"`
-	Namespace               string `json:"namespace"`
+	Name                    string                    `json:"name"`
+	Namespace               string                    `json:"namespace"`
+	Status                  *v1alpha1.HttpRouteStatus `json:"status,omitempty"`
 }
 
 func (resourceManager *ResourceManager) GetHttpRoute(namespace, name string) (*HttpRoute, error) {
@@ -78,6 +79,7 @@ func BuildHttpRouteFromResource(route *v1alpha1.HttpRoute) *HttpRoute {
 		HttpRouteSpec: &route.Spec,
 		Name:          route.Name,
 		Namespace:     route.Namespace,
+		Status:        &route.Status,
 	}
 }
 
@@ -0,0 +1,148 @@
+package resources
+
+import (
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/controllers"
+	"github.com/kalmhq/kalm/controller/utils/secretbox"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ApplicationSecret is a secret in the SecretStore of an application. Values are write only, they are never returned.
+type ApplicationSecret struct {
+	Name      string      `json:"name"`
+	Version   int64       `json:"version"`
+	UpdatedBy string      `json:"updatedBy,omitempty"`
+	UpdatedAt metaV1.Time `json:"updatedAt"`
+}
+
+type SetApplicationSecretRequest struct {
+	Value string `json:"value"`
+}
+
+func BuildApplicationSecretFromResource(item *v1alpha1.SecretStoreItem) *ApplicationSecret {
+	return &ApplicationSecret{
+		Name:      item.Name,
+		Version:   item.Version,
+		UpdatedBy: item.UpdatedBy,
+		UpdatedAt: item.UpdatedAt,
+	}
+}
+
+func (resourceManager *ResourceManager) getSecretStore(namespace string) (*v1alpha1.SecretStore, error) {
+	var store v1alpha1.SecretStore
+
+	if err := resourceManager.Get(namespace, v1alpha1.SecretStoreName, &store); err != nil {
+		return nil, err
+	}
+
+	return &store, nil
+}
+
+func (resourceManager *ResourceManager) GetApplicationSecrets(namespace string) ([]*ApplicationSecret, error) {
+	store, err := resourceManager.getSecretStore(namespace)
+
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return []*ApplicationSecret{}, nil
+		}
+
+		return nil, err
+	}
+
+	res := make([]*ApplicationSecret, len(store.Spec.Secrets))
+
+	for i := range store.Spec.Secrets {
+		res[i] = BuildApplicationSecretFromResource(&store.Spec.Secrets[i])
+	}
+
+	return res, nil
+}
+
+// SetApplicationSecret creates or rotates a secret, the value is encrypted with the cluster key before it's saved.
+// Components using the secret are restarted by the controller once the version is increased.
+func (resourceManager *ResourceManager) SetApplicationSecret(namespace, name, value, updatedBy string) (*ApplicationSecret, error) {
+	key, err := controllers.GetOrCreateSecretStoreKey(resourceManager.ctx, resourceManager.Client, resourceManager.Client)
+
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := secretbox.Encrypt(key, value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	item := v1alpha1.SecretStoreItem{
+		Name:           name,
+		EncryptedValue: encrypted,
+		Version:        1,
+		UpdatedBy:      updatedBy,
+		UpdatedAt:      metaV1.Now(),
+	}
+
+	store, err := resourceManager.getSecretStore(namespace)
+
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+
+		store = &v1alpha1.SecretStore{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      v1alpha1.SecretStoreName,
+				Namespace: namespace,
+			},
+			Spec: v1alpha1.SecretStoreSpec{
+				Secrets: []v1alpha1.SecretStoreItem{item},
+			},
+		}
+
+		if err := resourceManager.Create(store); err != nil {
+			return nil, err
+		}
+
+		return BuildApplicationSecretFromResource(&item), nil
+	}
+
+	copied := store.DeepCopy()
+
+	if existing := copied.GetSecret(name); existing != nil {
+		item.Version = existing.Version + 1
+		*existing = item
+	} else {
+		copied.Spec.Secrets = append(copied.Spec.Secrets, item)
+	}
+
+	// secrets are saved as a list, the lock prevents concurrent writes from overwriting each other
+	if err := resourceManager.Patch(copied, client.MergeFromWithOptions(store, client.MergeFromWithOptimisticLock{})); err != nil {
+		return nil, err
+	}
+
+	return BuildApplicationSecretFromResource(&item), nil
+}
+
+func (resourceManager *ResourceManager) DeleteApplicationSecret(namespace, name string) error {
+	store, err := resourceManager.getSecretStore(namespace)
+
+	if err != nil {
+		return err
+	}
+
+	if store.GetSecret(name) == nil {
+		return errors.NewNotFound(v1alpha1.GroupVersion.WithResource("secretstores").GroupResource(), name)
+	}
+
+	copied := store.DeepCopy()
+	copied.Spec.Secrets = copied.Spec.Secrets[:0]
+
+	for _, item := range store.Spec.Secrets {
+		if item.Name != name {
+			copied.Spec.Secrets = append(copied.Spec.Secrets, item)
+		}
+	}
+
+	return resourceManager.Patch(copied, client.MergeFromWithOptions(store, client.MergeFromWithOptimisticLock{}))
+}
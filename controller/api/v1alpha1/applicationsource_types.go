@@ -0,0 +1,123 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Label on objects applied from an ApplicationSource, the value is the name of the source.
+	// It's used to prune objects removed from the repository.
+	ApplicationSourceLabelName = "core.kalm.dev/application-source"
+
+	// Annotation on the ApplicationSource, the user whose edit through the dashboard paused the sync.
+	AnnoApplicationSourceSuspendedBy = "core.kalm.dev/suspended-by"
+)
+
+type ApplicationSourceSyncState string
+
+const (
+	ApplicationSourceSynced    ApplicationSourceSyncState = "Synced"
+	ApplicationSourceOutOfSync ApplicationSourceSyncState = "OutOfSync"
+	ApplicationSourceFailed    ApplicationSourceSyncState = "Failed"
+)
+
+// ApplicationSourceSpec defines the desired state of ApplicationSource
+type ApplicationSourceSpec struct {
+	// The git repository, a https, http, ssh or git url, or a scp-like address such as git@github.com:org/repo.git.
+	// Absolute local paths and file:// urls are only allowed under the directory set by the --local-git-repo-root flag
+	// of the controller, they are disabled by default as they would expose the controller filesystem.
+	// +kubebuilder:validation:MinLength=1
+	Repo string `json:"repo"`
+
+	// Branch, tag or commit to sync, the default branch of the repository is used if it's empty.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Directory of manifests in the repository, the root of the repository is used if it's empty.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// The namespace, which should be a kalm application, manifests are applied into.
+	// +kubebuilder:validation:MinLength=1
+	TargetNamespace string `json:"targetNamespace"`
+
+	// Seconds between two pulls of the repository, 180 by default.
+	// +kubebuilder:validation:Minimum=10
+	// +optional
+	IntervalSeconds *int32 `json:"intervalSeconds,omitempty"`
+
+	// Delete objects which are applied from this source but removed from the repository.
+	// Objects are kept when the source itself is deleted.
+	// +optional
+	Prune bool `json:"prune,omitempty"`
+
+	// Pause applying changes, drift is still detected.
+	// It's set when objects of the source are edited through the dashboard.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+type ApplicationSourceObject struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// ApplicationSourceStatus defines the observed state of ApplicationSource
+type ApplicationSourceStatus struct {
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// +optional
+	SyncState ApplicationSourceSyncState `json:"syncState,omitempty"`
+
+	// The commit sha of the last sync
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// +optional
+	LastSyncedAt *metav1.Time `json:"lastSyncedAt,omitempty"`
+
+	// Objects in the repository
+	// +optional
+	Objects []ApplicationSourceObject `json:"objects,omitempty"`
+
+	// Objects whose state in the cluster is different from the repository
+	// +optional
+	Drift []ApplicationSourceObject `json:"drift,omitempty"`
+
+	// The error of the last sync
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Namespace",type="string",JSONPath=".spec.targetNamespace"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.syncState"
+// +kubebuilder:printcolumn:name="Revision",type="string",JSONPath=".status.revision"
+// +kubebuilder:printcolumn:name="Suspend",type="boolean",JSONPath=".spec.suspend"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ApplicationSource syncs kalm manifests in a git repository into an application
+type ApplicationSource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ApplicationSourceSpec   `json:"spec"`
+	Status ApplicationSourceStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// ApplicationSourceList contains a list of ApplicationSource
+type ApplicationSourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ApplicationSource `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ApplicationSource{}, &ApplicationSourceList{})
+}
@@ -0,0 +1,137 @@
+package v1alpha1
+
+import (
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var applicationsourcelog = logf.Log.WithName("applicationsource-resource")
+
+// remote protocols of git, file:// is checked separately, ext:: is excluded
+var allowedGitRepoSchemes = map[string]bool{
+	"https": true,
+	"http":  true,
+	"ssh":   true,
+	"git":   true,
+}
+
+// scp-like syntax of ssh, [user@]host:path
+var scpLikeGitRepoRegex = regexp.MustCompile(`^([A-Za-z0-9._~-]+@)?[A-Za-z0-9][A-Za-z0-9.-]*:[^:]+$`)
+
+// local repos, absolute paths or file:// urls, are only allowed under this directory, empty means they are disabled
+var localGitRepoRoot string
+
+// SetLocalGitRepoRoot allows repos on the controller filesystem under root, an empty root disables local repos.
+func SetLocalGitRepoRoot(root string) {
+	if root != "" {
+		root = filepath.Clean(root)
+	}
+
+	localGitRepoRoot = root
+}
+
+func (r *ApplicationSource) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-core-kalm-dev-v1alpha1-applicationsource,mutating=false,failurePolicy=fail,groups=core.kalm.dev,resources=applicationsources,versions=v1alpha1,name=vapplicationsource.kb.io
+
+var _ webhook.Validator = &ApplicationSource{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *ApplicationSource) ValidateCreate() error {
+	applicationsourcelog.Info("validate create", "name", r.Name)
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *ApplicationSource) ValidateUpdate(old runtime.Object) error {
+	applicationsourcelog.Info("validate update", "name", r.Name)
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *ApplicationSource) ValidateDelete() error {
+	applicationsourcelog.Info("validate delete", "name", r.Name)
+	return nil
+}
+
+func (r *ApplicationSource) validate() error {
+	var rst KalmValidateErrorList
+
+	if !IsValidGitRepo(r.Spec.Repo) {
+		err := "repo should be a https, http, ssh or git url, or a scp-like address as user@host:path"
+
+		if localGitRepoRoot != "" {
+			err += ", or a local path under " + localGitRepoRoot
+		}
+
+		rst = append(rst, KalmValidateError{
+			Err:  err,
+			Path: "spec.repo",
+		})
+	}
+
+	// revisions are passed to git as arguments
+	if strings.HasPrefix(r.Spec.Revision, "-") {
+		rst = append(rst, KalmValidateError{
+			Err:  "invalid revision:" + r.Spec.Revision,
+			Path: "spec.revision",
+		})
+	}
+
+	if len(rst) == 0 {
+		return nil
+	}
+
+	return rst
+}
+
+// IsValidGitRepo is also checked by the controller before cloning, in case webhooks are not enabled.
+func IsValidGitRepo(repo string) bool {
+	// options of git can't be injected
+	if repo == "" || strings.HasPrefix(repo, "-") {
+		return false
+	}
+
+	if filepath.IsAbs(repo) {
+		return isAllowedLocalGitRepo(repo)
+	}
+
+	if !strings.Contains(repo, "://") {
+		return scpLikeGitRepoRegex.MatchString(repo)
+	}
+
+	u, err := url.Parse(repo)
+
+	if err != nil {
+		return false
+	}
+
+	if u.Scheme == "file" {
+		return (u.Host == "" || u.Host == "localhost") && isAllowedLocalGitRepo(u.Path)
+	}
+
+	return allowedGitRepoSchemes[u.Scheme] && u.Hostname() != "" && !strings.HasPrefix(u.Hostname(), "-")
+}
+
+// relative paths are not allowed, they depend on the working directory of the controller
+func isAllowedLocalGitRepo(path string) bool {
+	if localGitRepoRoot == "" || !filepath.IsAbs(path) {
+		return false
+	}
+
+	rel, err := filepath.Rel(localGitRepoRoot, filepath.Clean(path))
+
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
@@ -0,0 +1,83 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidGitRepo(t *testing.T) {
+	for _, repo := range []string{
+		"https://github.com/kalmhq/kalm.git",
+		"http://git.example.com:8080/org/repo",
+		"ssh://git@github.com/kalmhq/kalm.git",
+		"git://git.example.com/repo.git",
+		"git@github.com:kalmhq/kalm.git",
+		"git.example.com:repo.git",
+	} {
+		assert.True(t, IsValidGitRepo(repo), repo)
+	}
+
+	for _, repo := range []string{
+		"",
+		"-uhelp",
+		"--upload-pack=touch /tmp/pwned",
+		"/var/lib/kalm/repo",
+		"./repo",
+		"../repo",
+		"file:///var/lib/kalm/repo",
+		"ext::sh -c touch% /tmp/pwned",
+		"ftp://example.com/repo.git",
+		"ssh://-oProxyCommand=touch/repo",
+		"https:///repo",
+	} {
+		assert.False(t, IsValidGitRepo(repo), repo)
+	}
+}
+
+func TestIsValidLocalGitRepo(t *testing.T) {
+	// disabled by default
+	assert.False(t, IsValidGitRepo("/var/lib/kalm/repos/app"))
+	assert.False(t, IsValidGitRepo("file:///var/lib/kalm/repos/app"))
+
+	SetLocalGitRepoRoot("/var/lib/kalm/repos/")
+	defer SetLocalGitRepoRoot("")
+
+	for _, repo := range []string{
+		"/var/lib/kalm/repos/app",
+		"/var/lib/kalm/repos/org/app.git",
+		"file:///var/lib/kalm/repos/app",
+		"file://localhost/var/lib/kalm/repos/app",
+	} {
+		assert.True(t, IsValidGitRepo(repo), repo)
+	}
+
+	for _, repo := range []string{
+		"/var/lib/kalm",
+		"/var/lib/kalm/repos-other/app",
+		"/var/lib/kalm/repos/../../../../etc",
+		"./app",
+		"repos/app",
+		"file:///etc",
+		"file://example.com/var/lib/kalm/repos/app",
+		"file://var/lib/kalm/repos/app",
+	} {
+		assert.False(t, IsValidGitRepo(repo), repo)
+	}
+}
+
+func TestApplicationSource_Validate(t *testing.T) {
+	source := ApplicationSource{
+		Spec: ApplicationSourceSpec{
+			Repo:            "https://github.com/kalmhq/kalm.git",
+			Revision:        "master",
+			TargetNamespace: "app",
+		},
+	}
+
+	assert.Nil(t, source.validate())
+
+	source.Spec.Repo = "file:///etc"
+	source.Spec.Revision = "--output=/tmp/x"
+	assert.Equal(t, []string{"spec.repo", "spec.revision"}, errorPaths(source.validate().(KalmValidateErrorList)))
+}
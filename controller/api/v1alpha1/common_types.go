@@ -16,6 +16,9 @@ const (
 	EnvVarTypeFieldRef EnvVarType = "fieldref"
 	EnvVarTypeBuiltin  EnvVarType = "builtin"
 
+	// value is the name of a secret in the SecretStore of the application
+	EnvVarTypeSecret EnvVarType = "secret"
+
 	EnvVarBuiltinHost      string = "host"
 	EnvVarBuiltinPodName   string = "podName"
 	EnvVarBuiltinNamespace string = "namespace"
@@ -43,7 +46,7 @@ type EnvVar struct {
 
 	Value string `json:"value,omitempty"`
 
-	// +kubebuilder:validation:Enum=static;external;linked;fieldref;builtin
+	// +kubebuilder:validation:Enum=static;external;linked;fieldref;builtin;secret
 	Type EnvVarType `json:"type,omitempty"`
 
 	Prefix string `json:"prefix,omitempty"`
@@ -16,8 +16,13 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
@@ -25,7 +30,12 @@ import (
 // log is for logging in this package.
 var componentpluginbindinglog = logf.Log.WithName("componentpluginbinding-resource")
 
+// used to find the plugin of a binding, nil if webhook is not set up with a manager
+var componentPluginBindingReader client.Reader
+
 func (r *ComponentPluginBinding) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	componentPluginBindingReader = mgr.GetAPIReader()
+
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
 		Complete()
@@ -66,9 +76,40 @@ func (r *ComponentPluginBinding) validate() error {
 		}
 	}
 
+	rst = append(rst, r.validateConfig()...)
+
 	if len(rst) == 0 {
 		return nil
 	}
 
 	return rst
 }
+
+// validateConfig validates config against the configSchema of the plugin.
+// Bindings of plugins that don't exist yet are allowed, the controller reports the error once the plugin is created.
+func (r *ComponentPluginBinding) validateConfig() KalmValidateErrorList {
+	if componentPluginBindingReader == nil {
+		return nil
+	}
+
+	var plugin ComponentPlugin
+
+	if err := componentPluginBindingReader.Get(context.Background(), types.NamespacedName{Name: r.Spec.PluginName}, &plugin); err != nil {
+		if !errors.IsNotFound(err) {
+			componentpluginbindinglog.Error(err, "get plugin error", "plugin", r.Spec.PluginName)
+		}
+
+		return nil
+	}
+
+	schema, err := CompilePluginConfigSchema(plugin.Spec.ConfigSchema)
+
+	if err != nil {
+		return KalmValidateErrorList{{
+			Err:  "invalid configSchema of plugin: " + err.Error(),
+			Path: ".spec.pluginName",
+		}}
+	}
+
+	return ValidatePluginConfig(schema, r.Spec.Config)
+}
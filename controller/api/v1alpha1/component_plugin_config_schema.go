@@ -0,0 +1,97 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// CompilePluginConfigSchema compiles the configSchema of a ComponentPlugin as JSON Schema draft 7.
+// The schema itself is validated against the draft 7 meta schema.
+func CompilePluginConfigSchema(schema *runtime.RawExtension) (*gojsonschema.Schema, error) {
+	if schema == nil || len(schema.Raw) == 0 {
+		return nil, nil
+	}
+
+	loader := gojsonschema.NewSchemaLoader()
+	loader.AutoDetect = false
+	loader.Draft = gojsonschema.Draft7
+	loader.Validate = true
+
+	return loader.Compile(gojsonschema.NewBytesLoader(schema.Raw))
+}
+
+// ValidatePluginConfig validates the config of a ComponentPluginBinding against the compiled schema,
+// each error carries the path of the invalid field, e.g. .spec.config.ports[0].name
+func ValidatePluginConfig(schema *gojsonschema.Schema, config *runtime.RawExtension) KalmValidateErrorList {
+	if schema == nil {
+		return nil
+	}
+
+	if config == nil || len(config.Raw) == 0 {
+		return KalmValidateErrorList{{
+			Err:  "config is required by the plugin",
+			Path: ".spec.config",
+		}}
+	}
+
+	res, err := schema.Validate(gojsonschema.NewBytesLoader(config.Raw))
+
+	if err != nil {
+		return KalmValidateErrorList{{
+			Err:  err.Error(),
+			Path: ".spec.config",
+		}}
+	}
+
+	if res.Valid() {
+		return nil
+	}
+
+	var rst KalmValidateErrorList
+
+	for _, resErr := range res.Errors() {
+		field := resErr.Field()
+
+		// the context of a required error is the parent object
+		if resErr.Type() == "required" {
+			if property, ok := resErr.Details()["property"].(string); ok {
+				if field == gojsonschema.STRING_CONTEXT_ROOT {
+					field = property
+				} else {
+					field = field + "." + property
+				}
+			}
+		}
+
+		rst = append(rst, KalmValidateError{
+			Err:  resErr.Description(),
+			Path: pluginConfigFieldPath(field),
+		})
+	}
+
+	return rst
+}
+
+// (root) -> .spec.config
+// a.0.b  -> .spec.config.a[0].b
+func pluginConfigFieldPath(field string) string {
+	path := ".spec.config"
+
+	if field == gojsonschema.STRING_CONTEXT_ROOT || field == "" {
+		return path
+	}
+
+	for _, part := range strings.Split(field, ".") {
+		if _, err := strconv.Atoi(part); err == nil {
+			path = path + fmt.Sprintf("[%s]", part)
+		} else {
+			path = path + "." + part
+		}
+	}
+
+	return path
+}
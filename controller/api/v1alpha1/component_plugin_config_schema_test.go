@@ -0,0 +1,68 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestValidatePluginConfig(t *testing.T) {
+	schema, err := CompilePluginConfigSchema(&runtime.RawExtension{Raw: []byte(`{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["name"],
+  "properties": {
+    "name": {"type": "string"},
+    "ports": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["port"],
+        "properties": {
+          "port": {"type": "integer", "exclusiveMinimum": 0}
+        }
+      }
+    },
+    "mode": {"const": "strict"},
+    "timeout": {"if": {"type": "string"}, "then": {"pattern": "^[0-9]+s$"}}
+  }
+}`)})
+
+	assert.Nil(t, err)
+
+	assert.Nil(t, ValidatePluginConfig(schema, &runtime.RawExtension{Raw: []byte(`{"name": "foo", "ports": [{"port": 80}], "mode": "strict", "timeout": "10s"}`)}))
+
+	errList := ValidatePluginConfig(schema, &runtime.RawExtension{Raw: []byte(`{"ports": [{"port": 0}, {}], "mode": "loose", "timeout": "10m"}`)})
+
+	paths := make([]string, 0, len(errList))
+
+	for _, e := range errList {
+		paths = append(paths, e.Path)
+	}
+
+	// a failed if-then reports both the failed keyword and the then branch
+	assert.Subset(t, paths, []string{
+		".spec.config.name",
+		".spec.config.ports[0].port",
+		".spec.config.ports[1].port",
+		".spec.config.mode",
+		".spec.config.timeout",
+	}, paths)
+
+	errList = ValidatePluginConfig(schema, nil)
+	assert.Equal(t, 1, len(errList))
+	assert.Equal(t, ".spec.config", errList[0].Path)
+
+	// no schema means any config is valid
+	assert.Nil(t, ValidatePluginConfig(nil, nil))
+}
+
+func TestCompileInvalidPluginConfigSchema(t *testing.T) {
+	_, err := CompilePluginConfigSchema(&runtime.RawExtension{Raw: []byte(`{"type": "not-a-type"}`)})
+	assert.NotNil(t, err)
+
+	schema, err := CompilePluginConfigSchema(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, schema)
+}
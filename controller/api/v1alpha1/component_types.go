@@ -18,7 +18,9 @@ package v1alpha1
 import (
 	apps1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 type PreInjectFile struct {
@@ -57,6 +59,18 @@ type ComponentSpec struct {
 	NodeSelectorLabels map[string]string `json:"nodeSelectorLabels,omitempty"`
 	PreferNotCoLocated bool              `json:"preferNotCoLocated,omitempty"`
 
+	// Spread pods across zones or hosts
+	// +optional
+	TopologySpread []TopologySpread `json:"topologySpread,omitempty"`
+
+	// Limit the number of pods that are down simultaneously during voluntary disruptions, e.g. node drains.
+	// Only available for server and statefulset workloads.
+	// +optional
+	DisruptionBudget *DisruptionBudget `json:"disruptionBudget,omitempty"`
+
+	// Names of components in the same application which should be ready before the workload of this component is created.
+	// The workload is not touched by this once it's created.
+	// +optional
 	StartAfterComponents []string `json:"startAfterComponents,omitempty"`
 
 	Command string `json:"command,omitempty"`
@@ -66,11 +80,15 @@ type ComponentSpec struct {
 
 	Ports []Port `json:"ports,omitempty"`
 
-	// +kubebuilder:validation:Enum=server;cronjob;statefulset;daemonset
+	// +kubebuilder:validation:Enum=server;cronjob;statefulset;daemonset;job
 	WorkloadType WorkloadType `json:"workloadType,omitempty"`
 
 	Schedule string `json:"schedule,omitempty"`
 
+	// Options of jobs, used by job workload and jobs created by cronjob workload.
+	// +optional
+	JobOptions *JobOptions `json:"jobOptions,omitempty"`
+
 	// +k8s:openapi-gen=true
 	// +optional
 	LivenessProbe *v1.Probe `json:"livenessProbe,omitempty"`
@@ -102,11 +120,39 @@ type ComponentSpec struct {
 	// +kubebuilder:validation:Enum=Recreate;RollingUpdate
 	RestartStrategy apps1.DeploymentStrategyType `json:"restartStrategy,omitempty"`
 
+	// Progressive rollout of spec changes, only available for server workload.
+	// When set, a new spec runs side by side with the stable one and traffic of HttpRoutes
+	// is shifted to it step by step.
+	// +optional
+	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+	// Update the image automatically when a matching tag is found in a DockerRegistry
+	// +optional
+	ImageUpdatePolicy *ImageUpdatePolicy `json:"imageUpdatePolicy,omitempty"`
+
+	// Scale the workload with a HorizontalPodAutoscaler, only available for server and statefulset workloads.
+	// Replicas is ignored once autoscaling is set.
+	// +optional
+	Autoscaling *Autoscaling `json:"autoscaling,omitempty"`
+
 	// +optional
 	Volumes []Volume `json:"volumes,omitempty"`
 
+	// Containers running along with the main container in the pod, e.g. log shippers.
+	// +optional
+	Sidecars []Container `json:"sidecars,omitempty"`
+
+	// Containers running to completion in order before the main container starts, e.g. db migrations.
+	// +optional
+	InitContainers []Container `json:"initContainers,omitempty"`
+
 	RunnerPermission *RunnerPermission `json:"runnerPermission,omitempty"`
 
+	// Security settings of the pod, container level settings are applied to all containers of the pod.
+	// Overrides the deprecated podExt-securityContext annotations.
+	// +optional
+	SecurityContext *SecurityContext `json:"securityContext,omitempty"`
+
 	PreInjectedFiles []PreInjectFile `json:"preInjectedFiles,omitempty"`
 
 	// +optional
@@ -117,15 +163,529 @@ type ComponentSpec struct {
 	DirectConfigs []DirectConfig `json:"directConfigs,omitempty"`
 }
 
+type SeccompProfileType string
+
+const (
+	SeccompProfileRuntimeDefault SeccompProfileType = "RuntimeDefault"
+	SeccompProfileUnconfined     SeccompProfileType = "Unconfined"
+	SeccompProfileLocalhost      SeccompProfileType = "Localhost"
+)
+
+type SeccompProfile struct {
+	// +kubebuilder:validation:Enum=RuntimeDefault;Unconfined;Localhost
+	Type SeccompProfileType `json:"type"`
+
+	// path of the profile on the node, relative to the kubelet seccomp profile root. Required for Localhost profiles.
+	// +optional
+	LocalhostProfile string `json:"localhostProfile,omitempty"`
+}
+
+type SecurityContext struct {
+	// +optional
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+
+	// +optional
+	RunAsGroup *int64 `json:"runAsGroup,omitempty"`
+
+	// +optional
+	RunAsNonRoot *bool `json:"runAsNonRoot,omitempty"`
+
+	// +optional
+	FSGroup *int64 `json:"fsGroup,omitempty"`
+
+	// +optional
+	ReadOnlyRootFilesystem *bool `json:"readOnlyRootFilesystem,omitempty"`
+
+	// +optional
+	AllowPrivilegeEscalation *bool `json:"allowPrivilegeEscalation,omitempty"`
+
+	// +optional
+	Privileged *bool `json:"privileged,omitempty"`
+
+	// linux capabilities added to containers, e.g. NET_BIND_SERVICE
+	// +optional
+	AddCapabilities []v1.Capability `json:"addCapabilities,omitempty"`
+
+	// linux capabilities dropped from containers, ALL drops all capabilities
+	// +optional
+	DropCapabilities []v1.Capability `json:"dropCapabilities,omitempty"`
+
+	// +optional
+	SeccompProfile *SeccompProfile `json:"seccompProfile,omitempty"`
+}
+
+// Container is an extra container in the pod of a component.
+// The main container is named after the component, names of extra containers must be different from it.
+type Container struct {
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?$
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// +optional
+	Command string `json:"command,omitempty"`
+
+	// +optional
+	Env []EnvVar `json:"env,omitempty"`
+
+	// Ports of sidecars are also exposed by the service of the component.
+	// +optional
+	Ports []Port `json:"ports,omitempty"`
+
+	// +optional
+	LivenessProbe *v1.Probe `json:"livenessProbe,omitempty"`
+
+	// +optional
+	ReadinessProbe *v1.Probe `json:"readinessProbe,omitempty"`
+
+	// +optional
+	ResourceRequirements *v1.ResourceRequirements `json:"resourceRequirements,omitempty"`
+
+	// +optional
+	VolumeMounts []ContainerVolumeMount `json:"volumeMounts,omitempty"`
+}
+
+// ContainerVolumeMount mounts a volume of the component into an extra container.
+type ContainerVolumeMount struct {
+	// path of the volume in spec.volumes, volumes are identified by their path.
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+
+	// where to mount the volume in the container, default to path.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+
+	// +optional
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// ServicePorts returns ports of the main container and sidecars, which are exposed by the service of the component.
+func (spec *ComponentSpec) ServicePorts() []Port {
+	if len(spec.Sidecars) == 0 {
+		return spec.Ports
+	}
+
+	ports := make([]Port, 0, len(spec.Ports))
+	ports = append(ports, spec.Ports...)
+
+	for _, sidecar := range spec.Sidecars {
+		ports = append(ports, sidecar.Ports...)
+	}
+
+	return ports
+}
+
+// +kubebuilder:validation:Enum=canary;blueGreen
+type RolloutStrategyType string
+
+const (
+	RolloutStrategyCanary    RolloutStrategyType = "canary"
+	RolloutStrategyBlueGreen RolloutStrategyType = "blueGreen"
+)
+
+type RolloutStep struct {
+	// percentage of traffic sent to the new version in this step
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Weight int `json:"weight"`
+
+	// how long to stay in this step before moving to the next one
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	PauseSeconds int `json:"pauseSeconds,omitempty"`
+}
+
+type RolloutAnalysis struct {
+	// the rollout is aborted if the ratio of 5xx responses of the new version exceeds this value
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	MaxErrorRatePercent int `json:"maxErrorRatePercent"`
+
+	// how often the error rate is checked during a step, default to 30 seconds
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	IntervalSeconds int `json:"intervalSeconds,omitempty"`
+}
+
+type RolloutStrategy struct {
+	// +kubebuilder:validation:Enum=canary;blueGreen
+	Type RolloutStrategyType `json:"type"`
+
+	// For canary, the weights are applied in order, the new version is promoted after the last step.
+	// For blueGreen, only the pause of the first step is used, all traffic is switched at once.
+	// +optional
+	Steps []RolloutStep `json:"steps,omitempty"`
+
+	// +optional
+	Analysis *RolloutAnalysis `json:"analysis,omitempty"`
+}
+
+// EffectiveSteps returns the steps that will be actually executed for the strategy.
+func (s *RolloutStrategy) EffectiveSteps() []RolloutStep {
+	if s.Type == RolloutStrategyBlueGreen {
+		step := RolloutStep{Weight: 100}
+
+		if len(s.Steps) > 0 {
+			step.PauseSeconds = s.Steps[0].PauseSeconds
+		}
+
+		return []RolloutStep{step}
+	}
+
+	return s.Steps
+}
+
+type ImageUpdatePolicyType string
+
+const (
+	// the highest version in the semver range
+	ImageUpdatePolicySemver ImageUpdatePolicyType = "semver"
+	// the latest pushed tag matching the regex
+	ImageUpdatePolicyRegex ImageUpdatePolicyType = "regex"
+	// the latest pushed tag
+	ImageUpdatePolicyLatest ImageUpdatePolicyType = "latest"
+)
+
+// ImageUpdatePolicy selects a tag from the tags of the image repository polled by the DockerRegistry of the image host.
+// The image is never downgraded, a tag is used only if it's newer than the current one.
+// Registries don't expose push time, it's the time the DockerRegistry poll first sees the tag or its new manifest.
+// Tags pushed before the repository is polled have no known push time, regex and latest policies only pick tags pushed after that.
+type ImageUpdatePolicy struct {
+	// +kubebuilder:validation:Enum=semver;regex;latest
+	Type ImageUpdatePolicyType `json:"type"`
+
+	// semver range, e.g. "^1.2.0", "~1.2.3", ">=1.2.0 <2.0.0", "1.x"
+	// +optional
+	Semver string `json:"semver,omitempty"`
+
+	// tags not matching the regex are ignored, required by regex type, optional for latest type.
+	// The regex must match the whole tag.
+	// +optional
+	Regex string `json:"regex,omitempty"`
+}
+
+type JobOptions struct {
+	// retries before the job is marked as failed, default to 6
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// the job is terminated and marked as failed once it runs longer than the deadline
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	// max pods running in parallel
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Parallelism *int32 `json:"parallelism,omitempty"`
+
+	// pods that must succeed before the job is completed
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Completions *int32 `json:"completions,omitempty"`
+
+	// finished jobs are deleted after the ttl, their status is kept in the component status.
+	// Requires the TTLAfterFinished feature gate of kubernetes.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+}
+
+// ComponentJobStatus is the status of the job of a job workload.
+type ComponentJobStatus struct {
+	// hash of the job spec, the job runs again once the spec changes
+	SpecHash string `json:"specHash"`
+
+	// +optional
+	Completions int32 `json:"completions"`
+
+	// +optional
+	Active int32 `json:"active"`
+
+	// +optional
+	Succeeded int32 `json:"succeeded"`
+
+	// +optional
+	Failed int32 `json:"failed"`
+
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// reason and message of the failure, e.g. BackoffLimitExceeded
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type TopologySpreadTopology string
+
+const (
+	TopologySpreadZone TopologySpreadTopology = "zone"
+	TopologySpreadHost TopologySpreadTopology = "host"
+)
+
+type TopologySpread struct {
+	// +kubebuilder:validation:Enum=zone;host
+	Topology TopologySpreadTopology `json:"topology"`
+
+	// the max difference of pod numbers between any two zones or hosts, default to 1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxSkew int32 `json:"maxSkew,omitempty"`
+
+	// Pods are not scheduled if the constraint can't be satisfied when hard is true,
+	// otherwise the scheduler only prefers nodes that reduce the skew.
+	// +optional
+	Hard bool `json:"hard,omitempty"`
+}
+
+// DisruptionBudget sets either minAvailable or maxUnavailable, as a number or a percentage.
+type DisruptionBudget struct {
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+type Autoscaling struct {
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// target average cpu utilization of pods, in percentage of the requested cpu
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+
+	// target average memory utilization of pods, in percentage of the requested memory
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+
+	// +optional
+	CustomMetrics []AutoscalingCustomMetric `json:"customMetrics,omitempty"`
+}
+
+const (
+	AutoscalingCustomMetricIstioRequestsPerSecond         = "istio_requests_per_second"
+	AutoscalingCustomMetricIstioTcpReceivedBytesPerSecond = "istio_tcp_received_bytes_per_second"
+)
+
+// Custom metrics components can be scaled on, rates of the istio sidecar counters of the pods.
+var SupportedAutoscalingCustomMetrics = []string{
+	AutoscalingCustomMetricIstioRequestsPerSecond,
+	AutoscalingCustomMetricIstioTcpReceivedBytesPerSecond,
+}
+
+// AutoscalingCustomMetric is a per pod metric served by the custom metrics api (custom.metrics.k8s.io).
+// Kalm doesn't install a custom metrics server, prometheus-adapter has to be installed with rules
+// exposing the rates of the istio counters per pod, e.g. istio_requests_total as istio_requests_per_second.
+// Without it the autoscaler can't get the metric and won't scale on it.
+type AutoscalingCustomMetric struct {
+	// one of istio_requests_per_second and istio_tcp_received_bytes_per_second
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// target average value of the metric across pods
+	TargetAverageValue resource.Quantity `json:"targetAverageValue"`
+}
+
+type ComponentAutoscalingStatus struct {
+	// +optional
+	CurrentReplicas int32 `json:"currentReplicas"`
+
+	// +optional
+	DesiredReplicas int32 `json:"desiredReplicas"`
+
+	// +optional
+	LastScaleTime *metav1.Time `json:"lastScaleTime,omitempty"`
+}
+
+type RolloutPhase string
+
+const (
+	RolloutPhaseProgressing RolloutPhase = "Progressing"
+	RolloutPhaseSucceeded   RolloutPhase = "Succeeded"
+	RolloutPhaseAborted     RolloutPhase = "Aborted"
+)
+
+type ComponentRolloutStatus struct {
+	// +optional
+	Phase RolloutPhase `json:"phase,omitempty"`
+
+	// hash of the spec that is fully rolled out, scaling settings are excluded
+	// +optional
+	StableSpecHash string `json:"stableSpecHash,omitempty"`
+
+	// hash of the spec that is being rolled out, scaling settings are excluded
+	// +optional
+	CanarySpecHash string `json:"canarySpecHash,omitempty"`
+
+	// index of the current step
+	// +optional
+	Step int `json:"step"`
+
+	// percentage of traffic sent to the new version
+	// +optional
+	CanaryWeight int `json:"canaryWeight"`
+
+	// +optional
+	CanaryReadyReplicas int32 `json:"canaryReadyReplicas"`
+
+	// +optional
+	StepStartedAt *metav1.Time `json:"stepStartedAt,omitempty"`
+
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 // ComponentStatus defines the observed state of Component
 type ComponentStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// The generation of the component that the status was computed from.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// +optional
+	Conditions []ComponentCondition `json:"conditions,omitempty"`
+
+	// desired replicas of the workload.
+	// For daemonset, it's the number of nodes that should be running the pod.
+	// +optional
+	Replicas int32 `json:"replicas"`
+
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas"`
+
+	// +optional
+	AvailableReplicas int32 `json:"availableReplicas"`
+
+	// The image of the latest rollout that is fully available.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// The error message of the last failed reconcile, cleared once reconcile succeeds.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// Progress of the rollout, only set when rolloutStrategy is used.
+	// +optional
+	Rollout *ComponentRolloutStatus `json:"rollout,omitempty"`
+
+	// Replicas decided by the HorizontalPodAutoscaler, only set when autoscaling is used.
+	// +optional
+	Autoscaling *ComponentAutoscalingStatus `json:"autoscaling,omitempty"`
+
+	// Status of the job, only set for job workload. It's kept after the job is deleted by ttl.
+	// +optional
+	Job *ComponentJobStatus `json:"job,omitempty"`
+}
+
+type ComponentConditionType string
+
+const (
+	ComponentConditionReady       ComponentConditionType = "Ready"
+	ComponentConditionProgressing ComponentConditionType = "Progressing"
+	ComponentConditionDegraded    ComponentConditionType = "Degraded"
+	ComponentConditionPluginError ComponentConditionType = "PluginError"
+
+	// True when components in startAfterComponents are not ready, only set when the component has dependencies.
+	ComponentConditionBlocked ComponentConditionType = "Blocked"
+)
+
+type ComponentCondition struct {
+	// Type of the condition, one of ('Ready', 'Progressing', 'Degraded', 'PluginError', 'Blocked').
+	Type ComponentConditionType `json:"type"`
+
+	// Status of the condition, one of ('True', 'False', 'Unknown').
+	Status v1.ConditionStatus `json:"status"`
+
+	// Last time the condition transitioned from one status to another.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// Reason is a brief machine readable explanation for the condition's last
+	// transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message is a human readable description of the details of the last
+	// transition, complementing reason.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// GetCondition returns the condition with the given type, or nil if it's not set yet.
+func (s *ComponentStatus) GetCondition(conditionType ComponentConditionType) *ComponentCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == conditionType {
+			return &s.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
+// SetCondition adds or updates a condition. LastTransitionTime is only bumped when the status changes.
+func (s *ComponentStatus) SetCondition(condition ComponentCondition) {
+	existing := s.GetCondition(condition.Type)
+
+	if existing == nil {
+		if condition.LastTransitionTime.IsZero() {
+			condition.LastTransitionTime = metav1.Now()
+		}
+
+		s.Conditions = append(s.Conditions, condition)
+		return
+	}
+
+	if existing.Status != condition.Status {
+		existing.Status = condition.Status
+
+		if condition.LastTransitionTime.IsZero() {
+			existing.LastTransitionTime = metav1.Now()
+		} else {
+			existing.LastTransitionTime = condition.LastTransitionTime
+		}
+	}
+
+	existing.Reason = condition.Reason
+	existing.Message = condition.Message
+}
+
+// RemoveCondition removes the condition with the given type if it exists.
+func (s *ComponentStatus) RemoveCondition(conditionType ComponentConditionType) {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == conditionType {
+			s.Conditions = append(s.Conditions[:i], s.Conditions[i+1:]...)
+			return
+		}
+	}
+}
+
+// IsReady returns true when the Ready condition of the component is True
+func (s *ComponentStatus) IsReady() bool {
+	condition := s.GetCondition(ComponentConditionReady)
+	return condition != nil && condition.Status == v1.ConditionTrue
 }
 
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Workload",type="string",JSONPath=".spec.workloadType"
 // +kubebuilder:printcolumn:name="Image",type="string",JSONPath=".spec.image"
+// +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyReplicas"
+// +kubebuilder:printcolumn:name="Desired",type="integer",JSONPath=".status.replicas"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // Component is the Schema for the components API
@@ -17,14 +17,21 @@ package v1alpha1
 
 import (
 	//rbacvalidation "k8s.io/kubernetes/pkg/apis/rbac/validation"
+	"context"
 	"fmt"
+	"github.com/Masterminds/semver/v3"
 	"github.com/robfig/cron"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	apimachineryval "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"math/rand"
+	"regexp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"strings"
@@ -34,7 +41,12 @@ import (
 // log is for logging in this package.
 var componentlog = logf.Log.WithName("component-webhook")
 
+// used to find the security policy of the namespace, nil if webhook is not set up with a manager
+var componentReader client.Reader
+
 func (r *Component) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	componentReader = mgr.GetAPIReader()
+
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
 		Complete()
@@ -210,6 +222,15 @@ func (r *Component) validate() KalmValidateErrorList {
 	rst = append(rst, r.validateVolumesOfComponent()...)
 	rst = append(rst, r.validateRunnerPermission()...)
 	rst = append(rst, r.validatePreInjectedFiles()...)
+	rst = append(rst, r.validateRolloutStrategy()...)
+	rst = append(rst, r.validateImageUpdatePolicy()...)
+	rst = append(rst, r.validateExtraContainers()...)
+	rst = append(rst, r.validateAutoscaling()...)
+	rst = append(rst, r.validateDisruptionBudget()...)
+	rst = append(rst, r.validateSecurityContext()...)
+	rst = append(rst, r.validateJobOptions()...)
+	rst = append(rst, r.validateSecurityPolicy()...)
+	rst = append(rst, r.validateStartAfterComponents()...)
 
 	if len(rst) == 0 {
 		return nil
@@ -323,7 +344,7 @@ func (r *Component) validateVolumesOfComponent() (rst KalmValidateErrorList) {
 
 func (r *Component) isStatelessWorkload() bool {
 	switch r.Spec.WorkloadType {
-	case WorkloadTypeServer, WorkloadTypeDaemonSet, WorkloadTypeCronjob:
+	case WorkloadTypeServer, WorkloadTypeDaemonSet, WorkloadTypeCronjob, WorkloadTypeJob:
 		return true
 	default:
 		return false
@@ -373,50 +394,445 @@ func (r *Component) validateProbes() (rst KalmValidateErrorList) {
 }
 
 func (r *Component) validateEnvVarList() (rst KalmValidateErrorList) {
-	if len(r.Spec.Env) == 0 {
+	return validateEnvVars(r.Spec.Env, ".spec.env")
+}
+
+func (r *Component) validateResRequirement() (rst KalmValidateErrorList) {
+	return validateResourceRequirements(r.Spec.ResourceRequirements, "spec.resourceRequirements")
+}
+
+func validateResourceRequirements(resRequirement *v1.ResourceRequirements, path string) (rst KalmValidateErrorList) {
+	if resRequirement == nil {
 		return nil
 	}
 
-	for i, env := range r.Spec.Env {
+	resList := []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory}
+	for _, resName := range resList {
+		isIntegerRes := resName == v1.ResourceMemory
+
+		if limit, exist := resRequirement.Limits[resName]; exist {
+
+			fldPath := field.NewPath(path + ".limits." + string(resName))
+			errList := ValidateResourceQuantityValue(limit, fldPath, isIntegerRes)
+			rst = append(rst, toKalmValidateErrors(errList)...)
+		}
+
+		if request, exist := resRequirement.Requests[resName]; exist {
+			fldPath := field.NewPath(path + ".requests." + string(resName))
+			errList := ValidateResourceQuantityValue(request, fldPath, isIntegerRes)
+			rst = append(rst, toKalmValidateErrors(errList)...)
+		}
+	}
+
+	return rst
+}
+
+func validateEnvVars(envs []EnvVar, path string) (rst KalmValidateErrorList) {
+	for i, env := range envs {
 		errs := apimachineryval.IsCIdentifier(env.Name)
 		for _, err := range errs {
 			rst = append(rst, KalmValidateError{
 				Err:  err,
-				Path: fmt.Sprintf(".spec.env[%d]", i),
+				Path: fmt.Sprintf("%s[%d]", path, i),
 			})
 		}
+
+		if env.Type == EnvVarTypeSecret {
+			for _, err := range apimachineryval.IsConfigMapKey(env.Value) {
+				rst = append(rst, KalmValidateError{
+					Err:  "invalid secret name: " + err,
+					Path: fmt.Sprintf("%s[%d].value", path, i),
+				})
+			}
+		}
 	}
 
 	return rst
 }
 
-func (r *Component) validateResRequirement() (rst KalmValidateErrorList) {
-	resRequirement := r.Spec.ResourceRequirements
-	if resRequirement == nil {
+func (r *Component) validateDisruptionBudget() (rst KalmValidateErrorList) {
+	budget := r.Spec.DisruptionBudget
+	if budget == nil {
 		return nil
 	}
 
-	resList := []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory}
-	for _, resName := range resList {
-		isIntegerRes := resName == v1.ResourceMemory
+	switch r.Spec.WorkloadType {
+	case WorkloadTypeServer, WorkloadTypeStatefulSet, "":
+	default:
+		rst = append(rst, KalmValidateError{
+			Err:  "disruptionBudget is only available for server and statefulset workloads",
+			Path: ".spec.disruptionBudget",
+		})
+	}
 
-		if limit, exist := resRequirement.Limits[resName]; exist {
+	if (budget.MinAvailable == nil) == (budget.MaxUnavailable == nil) {
+		rst = append(rst, KalmValidateError{
+			Err:  "exactly one of minAvailable and maxUnavailable is required",
+			Path: ".spec.disruptionBudget",
+		})
+	}
 
-			fldPath := field.NewPath("spec.resourceRequirements.limits." + string(resName))
-			errList := ValidateResourceQuantityValue(limit, fldPath, isIntegerRes)
-			rst = append(rst, toKalmValidateErrors(errList)...)
+	values := []struct {
+		value *intstr.IntOrString
+		path  string
+	}{
+		{budget.MinAvailable, ".spec.disruptionBudget.minAvailable"},
+		{budget.MaxUnavailable, ".spec.disruptionBudget.maxUnavailable"},
+	}
+
+	for _, v := range values {
+		if v.value == nil {
+			continue
 		}
 
-		if request, exist := resRequirement.Requests[resName]; exist {
-			fldPath := field.NewPath("spec.resourceRequirements.requests." + string(resName))
-			errList := ValidateResourceQuantityValue(request, fldPath, isIntegerRes)
-			rst = append(rst, toKalmValidateErrors(errList)...)
+		value, err := intstr.GetValueFromIntOrPercent(v.value, 100, true)
+
+		// strings must be percentages
+		if v.value.Type == intstr.String && !strings.HasSuffix(v.value.StrVal, "%") {
+			err = fmt.Errorf("not a percentage")
+		}
+
+		if err != nil || value < 0 {
+			rst = append(rst, KalmValidateError{Err: "should be a non-negative number or a percentage", Path: v.path})
 		}
 	}
 
 	return rst
 }
 
+func (r *Component) validateJobOptions() (rst KalmValidateErrorList) {
+	options := r.Spec.JobOptions
+	if options == nil {
+		return nil
+	}
+
+	if r.Spec.WorkloadType != WorkloadTypeJob && r.Spec.WorkloadType != WorkloadTypeCronjob {
+		rst = append(rst, KalmValidateError{
+			Err:  "jobOptions is only available for job and cronjob workloads",
+			Path: ".spec.jobOptions",
+		})
+	}
+
+	if options.Parallelism != nil && options.Completions != nil && *options.Parallelism > *options.Completions {
+		rst = append(rst, KalmValidateError{
+			Err:  "parallelism should not be greater than completions",
+			Path: ".spec.jobOptions.parallelism",
+		})
+	}
+
+	return rst
+}
+
+func (r *Component) validateSecurityContext() (rst KalmValidateErrorList) {
+	sc := r.Spec.SecurityContext
+	if sc == nil {
+		return nil
+	}
+
+	if sc.RunAsNonRoot != nil && *sc.RunAsNonRoot && sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+		rst = append(rst, KalmValidateError{
+			Err:  "runAsUser 0 is root, conflicts with runAsNonRoot",
+			Path: ".spec.securityContext.runAsUser",
+		})
+	}
+
+	if sc.Privileged != nil && *sc.Privileged && sc.AllowPrivilegeEscalation != nil && !*sc.AllowPrivilegeEscalation {
+		rst = append(rst, KalmValidateError{
+			Err:  "privileged containers always allow privilege escalation",
+			Path: ".spec.securityContext.allowPrivilegeEscalation",
+		})
+	}
+
+	if sc.SeccompProfile != nil {
+		switch sc.SeccompProfile.Type {
+		case SeccompProfileLocalhost:
+			if sc.SeccompProfile.LocalhostProfile == "" {
+				rst = append(rst, KalmValidateError{
+					Err:  "localhostProfile is required for Localhost seccomp profiles",
+					Path: ".spec.securityContext.seccompProfile.localhostProfile",
+				})
+			}
+		case SeccompProfileRuntimeDefault, SeccompProfileUnconfined:
+			if sc.SeccompProfile.LocalhostProfile != "" {
+				rst = append(rst, KalmValidateError{
+					Err:  "localhostProfile is only available for Localhost seccomp profiles",
+					Path: ".spec.securityContext.seccompProfile.localhostProfile",
+				})
+			}
+		default:
+			rst = append(rst, KalmValidateError{
+				Err:  "should be one of RuntimeDefault, Unconfined and Localhost",
+				Path: ".spec.securityContext.seccompProfile.type",
+			})
+		}
+	}
+
+	return rst
+}
+
+// validateSecurityPolicy checks the component against the security policy of its namespace.
+// Unlike other lookups in webhooks, errors reject the component, so the policy can't be bypassed.
+func (r *Component) validateSecurityPolicy() KalmValidateErrorList {
+	if componentReader == nil {
+		return nil
+	}
+
+	var namespace v1.Namespace
+
+	if err := componentReader.Get(context.Background(), types.NamespacedName{Name: r.Namespace}, &namespace); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		componentlog.Error(err, "get namespace error", "ns", r.Namespace)
+
+		return KalmValidateErrorList{{
+			Err:  "unable to load security policy of the namespace: " + err.Error(),
+			Path: ".metadata.namespace",
+		}}
+	}
+
+	policy, err := GetNamespaceSecurityPolicy(&namespace)
+
+	if err != nil {
+		return KalmValidateErrorList{{
+			Err:  err.Error(),
+			Path: ".metadata.namespace",
+		}}
+	}
+
+	if policy == nil {
+		return nil
+	}
+
+	return policy.Check(r)
+}
+
+// validateStartAfterComponents rejects dependency loops formed with other components in the namespace.
+func (r *Component) validateStartAfterComponents() KalmValidateErrorList {
+	if len(r.Spec.StartAfterComponents) == 0 {
+		return nil
+	}
+
+	if componentReader == nil {
+		return isValidateDependency(*r, nil)
+	}
+
+	var componentList ComponentList
+
+	if err := componentReader.List(context.Background(), &componentList, client.InNamespace(r.Namespace)); err != nil {
+		componentlog.Error(err, "list components error", "ns", r.Namespace)
+
+		return KalmValidateErrorList{{
+			Err:  "unable to check dependencies of the component: " + err.Error(),
+			Path: ".spec.startAfterComponents",
+		}}
+	}
+
+	return isValidateDependency(*r, componentList.Items)
+}
+
+// validateAutoscaling checks the autoscaling block.
+// Utilization targets are computed against requests, so every container must request the resource.
+func (r *Component) validateAutoscaling() (rst KalmValidateErrorList) {
+	autoscaling := r.Spec.Autoscaling
+	if autoscaling == nil {
+		return nil
+	}
+
+	switch r.Spec.WorkloadType {
+	case WorkloadTypeServer, WorkloadTypeStatefulSet, "":
+	default:
+		rst = append(rst, KalmValidateError{
+			Err:  "autoscaling is only available for server and statefulset workloads",
+			Path: ".spec.autoscaling",
+		})
+	}
+
+	if autoscaling.MinReplicas != nil && *autoscaling.MinReplicas > autoscaling.MaxReplicas {
+		rst = append(rst, KalmValidateError{
+			Err:  "minReplicas should not be greater than maxReplicas",
+			Path: ".spec.autoscaling.minReplicas",
+		})
+	}
+
+	if autoscaling.TargetCPUUtilizationPercentage == nil &&
+		autoscaling.TargetMemoryUtilizationPercentage == nil &&
+		len(autoscaling.CustomMetrics) == 0 {
+
+		rst = append(rst, KalmValidateError{
+			Err:  "at least one of targetCPUUtilizationPercentage, targetMemoryUtilizationPercentage and customMetrics is required",
+			Path: ".spec.autoscaling",
+		})
+	}
+
+	resourceTargets := []struct {
+		name   v1.ResourceName
+		target *int32
+		path   string
+	}{
+		{v1.ResourceCPU, autoscaling.TargetCPUUtilizationPercentage, ".spec.autoscaling.targetCPUUtilizationPercentage"},
+		{v1.ResourceMemory, autoscaling.TargetMemoryUtilizationPercentage, ".spec.autoscaling.targetMemoryUtilizationPercentage"},
+	}
+
+	for _, resourceTarget := range resourceTargets {
+		if resourceTarget.target == nil {
+			continue
+		}
+
+		requirements := []*v1.ResourceRequirements{r.Spec.ResourceRequirements}
+
+		for _, sidecar := range r.Spec.Sidecars {
+			requirements = append(requirements, sidecar.ResourceRequirements)
+		}
+
+		for _, requirement := range requirements {
+			if !hasResourceRequest(requirement, resourceTarget.name) {
+				rst = append(rst, KalmValidateError{
+					Err:  fmt.Sprintf("%s requests of all containers are required to scale on %s utilization", resourceTarget.name, resourceTarget.name),
+					Path: resourceTarget.path,
+				})
+				break
+			}
+		}
+	}
+
+	for i, metric := range autoscaling.CustomMetrics {
+		if !isSupportedAutoscalingCustomMetric(metric.Name) {
+			rst = append(rst, KalmValidateError{
+				Err:  fmt.Sprintf("unsupported metric, should be one of %s", strings.Join(SupportedAutoscalingCustomMetrics, ", ")),
+				Path: fmt.Sprintf(".spec.autoscaling.customMetrics[%d].name", i),
+			})
+		}
+
+		if metric.TargetAverageValue.Sign() <= 0 {
+			rst = append(rst, KalmValidateError{
+				Err:  "should be greater than 0",
+				Path: fmt.Sprintf(".spec.autoscaling.customMetrics[%d].targetAverageValue", i),
+			})
+		}
+	}
+
+	return rst
+}
+
+func isSupportedAutoscalingCustomMetric(name string) bool {
+	for _, metric := range SupportedAutoscalingCustomMetrics {
+		if metric == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requests default to limits if only limits are set
+func hasResourceRequest(requirement *v1.ResourceRequirements, name v1.ResourceName) bool {
+	if requirement == nil {
+		return false
+	}
+
+	if _, exist := requirement.Requests[name]; exist {
+		return true
+	}
+
+	_, exist := requirement.Limits[name]
+
+	return exist
+}
+
+// validateExtraContainers checks sidecars and init containers.
+// Container names must be unique in the pod, and container ports must not conflict with other containers.
+func (r *Component) validateExtraContainers() (rst KalmValidateErrorList) {
+	if len(r.Spec.Sidecars) > 0 && (r.Spec.WorkloadType == WorkloadTypeCronjob || r.Spec.WorkloadType == WorkloadTypeJob) {
+		rst = append(rst, KalmValidateError{
+			Err:  fmt.Sprintf("sidecars are not supported by %s, the job never completes while sidecars are running", r.Spec.WorkloadType),
+			Path: ".spec.sidecars",
+		})
+	}
+
+	names := map[string]bool{r.Name: true}
+	ports := make(map[uint32]bool)
+
+	for _, port := range r.Spec.Ports {
+		ports[port.ContainerPort] = true
+	}
+
+	volumes := make(map[string]bool)
+
+	for _, vol := range r.Spec.Volumes {
+		volumes[vol.Path] = true
+	}
+
+	validate := func(container Container, path string, isInit bool) {
+		if names[container.Name] {
+			rst = append(rst, KalmValidateError{
+				Err:  fmt.Sprintf("container name %s is used by the component or another container", container.Name),
+				Path: path + ".name",
+			})
+		}
+
+		names[container.Name] = true
+
+		if container.Image == "" {
+			rst = append(rst, KalmValidateError{Err: "image is required", Path: path + ".image"})
+		}
+
+		rst = append(rst, validateEnvVars(container.Env, path+".env")...)
+		rst = append(rst, validateResourceRequirements(container.ResourceRequirements, strings.TrimPrefix(path, ".")+".resourceRequirements")...)
+
+		if isInit && (container.LivenessProbe != nil || container.ReadinessProbe != nil) {
+			rst = append(rst, KalmValidateError{Err: "probes are not supported by init containers", Path: path})
+		}
+
+		if container.LivenessProbe != nil {
+			rst = append(rst, toKalmValidateErrors(validateProbe(container.LivenessProbe, field.NewPath(path+".livenessProbe")))...)
+		}
+
+		if container.ReadinessProbe != nil {
+			rst = append(rst, toKalmValidateErrors(validateProbe(container.ReadinessProbe, field.NewPath(path+".readinessProbe")))...)
+		}
+
+		if !isInit {
+			for i, port := range container.Ports {
+				if ports[port.ContainerPort] {
+					rst = append(rst, KalmValidateError{
+						Err:  fmt.Sprintf("container port %d is used by another container", port.ContainerPort),
+						Path: fmt.Sprintf("%s.ports[%d]", path, i),
+					})
+				}
+
+				ports[port.ContainerPort] = true
+			}
+		}
+
+		for i, mount := range container.VolumeMounts {
+			mountPath := fmt.Sprintf("%s.volumeMounts[%d]", path, i)
+
+			if !volumes[mount.Path] {
+				rst = append(rst, KalmValidateError{
+					Err:  fmt.Sprintf("volume %s doesn't exist in spec.volumes", mount.Path),
+					Path: mountPath + ".path",
+				})
+			}
+
+			if mount.MountPath != "" && !strings.HasPrefix(mount.MountPath, "/") {
+				rst = append(rst, KalmValidateError{Err: "should start with: /", Path: mountPath + ".mountPath"})
+			}
+		}
+	}
+
+	for i, sidecar := range r.Spec.Sidecars {
+		validate(sidecar, fmt.Sprintf(".spec.sidecars[%d]", i), false)
+	}
+
+	for i, initContainer := range r.Spec.InitContainers {
+		validate(initContainer, fmt.Sprintf(".spec.initContainers[%d]", i), true)
+	}
+
+	return rst
+}
+
 func (r *Component) validatePreInjectedFiles() (rst KalmValidateErrorList) {
 	for i, preInjectFile := range r.Spec.PreInjectedFiles {
 		isPrefixOK := strings.HasPrefix(preInjectFile.MountPath, "/")
@@ -431,6 +847,105 @@ func (r *Component) validatePreInjectedFiles() (rst KalmValidateErrorList) {
 	return rst
 }
 
+func (r *Component) validateRolloutStrategy() (rst KalmValidateErrorList) {
+	strategy := r.Spec.RolloutStrategy
+	if strategy == nil {
+		return nil
+	}
+
+	if r.Spec.WorkloadType != WorkloadTypeServer && r.Spec.WorkloadType != "" {
+		rst = append(rst, KalmValidateError{
+			Err:  "rolloutStrategy is only available for server workload",
+			Path: ".spec.rolloutStrategy",
+		})
+	}
+
+	if len(r.Spec.ServicePorts()) == 0 {
+		rst = append(rst, KalmValidateError{
+			Err:  "rolloutStrategy requires at least one port to shift traffic",
+			Path: ".spec.rolloutStrategy",
+		})
+	}
+
+	if strategy.Type == RolloutStrategyCanary && len(strategy.Steps) == 0 {
+		rst = append(rst, KalmValidateError{
+			Err:  "canary rollout requires at least one step",
+			Path: ".spec.rolloutStrategy.steps",
+		})
+	}
+
+	for i, step := range strategy.Steps {
+		if step.Weight < 0 || step.Weight > 100 {
+			rst = append(rst, KalmValidateError{
+				Err:  "weight should be between 0 and 100",
+				Path: fmt.Sprintf(".spec.rolloutStrategy.steps[%d].weight", i),
+			})
+		}
+
+		if step.PauseSeconds < 0 {
+			rst = append(rst, KalmValidateError{
+				Err:  "pauseSeconds should not be negative",
+				Path: fmt.Sprintf(".spec.rolloutStrategy.steps[%d].pauseSeconds", i),
+			})
+		}
+	}
+
+	if strategy.Analysis != nil && (strategy.Analysis.MaxErrorRatePercent < 0 || strategy.Analysis.MaxErrorRatePercent > 100) {
+		rst = append(rst, KalmValidateError{
+			Err:  "maxErrorRatePercent should be between 0 and 100",
+			Path: ".spec.rolloutStrategy.analysis.maxErrorRatePercent",
+		})
+	}
+
+	return rst
+}
+
+func (r *Component) validateImageUpdatePolicy() (rst KalmValidateErrorList) {
+	policy := r.Spec.ImageUpdatePolicy
+	if policy == nil {
+		return nil
+	}
+
+	if policy.Type == ImageUpdatePolicySemver {
+		if policy.Semver == "" {
+			rst = append(rst, KalmValidateError{
+				Err:  "semver range is required for semver policy",
+				Path: ".spec.imageUpdatePolicy.semver",
+			})
+		} else if _, err := semver.NewConstraint(policy.Semver); err != nil {
+			rst = append(rst, KalmValidateError{
+				Err:  err.Error(),
+				Path: ".spec.imageUpdatePolicy.semver",
+			})
+		}
+	}
+
+	if policy.Type == ImageUpdatePolicyRegex && policy.Regex == "" {
+		rst = append(rst, KalmValidateError{
+			Err:  "regex is required for regex policy",
+			Path: ".spec.imageUpdatePolicy.regex",
+		})
+	}
+
+	if policy.Regex != "" {
+		if _, err := regexp.Compile(policy.Regex); err != nil {
+			rst = append(rst, KalmValidateError{
+				Err:  err.Error(),
+				Path: ".spec.imageUpdatePolicy.regex",
+			})
+		}
+	}
+
+	if strings.Contains(r.Spec.Image, "@") {
+		rst = append(rst, KalmValidateError{
+			Err:  "image pinned by digest can't be updated by imageUpdatePolicy",
+			Path: ".spec.imageUpdatePolicy",
+		})
+	}
+
+	return rst
+}
+
 func (r *Component) validateRunnerPermission() (rst KalmValidateErrorList) {
 	runnerPermission := r.Spec.RunnerPermission
 	if runnerPermission == nil {
@@ -3,8 +3,12 @@ package v1alpha1
 import (
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"testing"
 )
 
@@ -216,3 +220,334 @@ func TestSTSForbiddenUpdatePersistVol(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), "should not update volume of type: pvcTemplate")
 }
+
+func TestComponentRolloutStrategyValidate(t *testing.T) {
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm",
+		},
+		Spec: ComponentSpec{
+			Image:        "foo:bar",
+			WorkloadType: WorkloadTypeDaemonSet,
+			RolloutStrategy: &RolloutStrategy{
+				Type: RolloutStrategyCanary,
+			},
+		},
+	}
+
+	component.Default()
+
+	errList := component.validateRolloutStrategy()
+	assert.Equal(t, 3, len(errList))
+
+	component.Spec.WorkloadType = WorkloadTypeServer
+	component.Spec.RolloutStrategy.Steps = []RolloutStep{{Weight: 20, PauseSeconds: 60}, {Weight: 50, PauseSeconds: 60}}
+
+	// ports of sidecars are exposed by the service too
+	component.Spec.Sidecars = []Container{{Name: "proxy", Image: "proxy", Ports: []Port{{Protocol: PortProtocolHTTP, ContainerPort: 8080}}}}
+	assert.Nil(t, component.validateRolloutStrategy())
+
+	component.Spec.Sidecars = nil
+	component.Spec.Ports = []Port{{Protocol: PortProtocolHTTP, ContainerPort: 80}}
+
+	assert.Nil(t, component.validateRolloutStrategy())
+
+	component.Spec.RolloutStrategy.Type = RolloutStrategyBlueGreen
+	assert.Equal(t, []RolloutStep{{Weight: 100, PauseSeconds: 60}}, component.Spec.RolloutStrategy.EffectiveSteps())
+}
+
+func TestComponentImageUpdatePolicyValidate(t *testing.T) {
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm",
+		},
+		Spec: ComponentSpec{
+			Image: "foo@sha256:abc",
+			ImageUpdatePolicy: &ImageUpdatePolicy{
+				Type:   ImageUpdatePolicySemver,
+				Semver: "not a range",
+				Regex:  "(",
+			},
+		},
+	}
+
+	assert.Equal(t, 3, len(component.validateImageUpdatePolicy()))
+
+	component.Spec.Image = "foo:1.0.0"
+	component.Spec.ImageUpdatePolicy = &ImageUpdatePolicy{Type: ImageUpdatePolicyRegex}
+	assert.Equal(t, 1, len(component.validateImageUpdatePolicy()))
+
+	component.Spec.ImageUpdatePolicy = &ImageUpdatePolicy{Type: ImageUpdatePolicySemver, Semver: ">=1.0.0 <2.0.0"}
+	assert.Nil(t, component.validateImageUpdatePolicy())
+}
+
+func TestComponentExtraContainersValidate(t *testing.T) {
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm",
+		},
+		Spec: ComponentSpec{
+			Image: "foo:bar",
+			Ports: []Port{{Protocol: PortProtocolHTTP, ContainerPort: 3001}},
+			Volumes: []Volume{
+				{Path: "/var/log", Type: VolumeTypeTemporaryDisk},
+			},
+			Sidecars: []Container{
+				{
+					Name:         "log-shipper",
+					Image:        "fluent-bit:1.5",
+					Ports:        []Port{{Protocol: PortProtocolHTTP, ContainerPort: 2020}},
+					VolumeMounts: []ContainerVolumeMount{{Path: "/var/log", ReadOnly: true}},
+				},
+			},
+			InitContainers: []Container{
+				{Name: "migrate", Image: "foo:bar", Command: "./migrate up"},
+			},
+		},
+	}
+
+	assert.Nil(t, component.validateExtraContainers())
+
+	component.Spec.Sidecars = append(component.Spec.Sidecars, Container{
+		Name:         "migrate",
+		Ports:        []Port{{Protocol: PortProtocolHTTP, ContainerPort: 3001}},
+		VolumeMounts: []ContainerVolumeMount{{Path: "/data"}},
+	})
+	component.Spec.InitContainers[0].ReadinessProbe = &v1.Probe{}
+
+	errs := component.validateExtraContainers()
+	assert.Equal(t, []string{
+		".spec.sidecars[1].image",
+		".spec.sidecars[1].ports[0]",
+		".spec.sidecars[1].volumeMounts[0].path",
+		".spec.initContainers[0].name",
+		".spec.initContainers[0]",
+		".spec.initContainers[0].readinessProbe",
+	}, errorPaths(errs))
+}
+
+func errorPaths(errs KalmValidateErrorList) []string {
+	var paths []string
+
+	for _, err := range errs {
+		paths = append(paths, err.Path)
+	}
+
+	return paths
+}
+
+func TestComponentAutoscalingValidate(t *testing.T) {
+	minReplicas := int32(3)
+	targetCPU := int32(80)
+
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm",
+		},
+		Spec: ComponentSpec{
+			Image:        "foo:bar",
+			WorkloadType: WorkloadTypeDaemonSet,
+			Autoscaling: &Autoscaling{
+				MinReplicas: &minReplicas,
+				MaxReplicas: 2,
+			},
+		},
+	}
+
+	assert.Equal(t, []string{
+		".spec.autoscaling",
+		".spec.autoscaling.minReplicas",
+		".spec.autoscaling",
+	}, errorPaths(component.validateAutoscaling()))
+
+	component.Spec.WorkloadType = WorkloadTypeServer
+	component.Spec.Autoscaling.MaxReplicas = 10
+	component.Spec.Autoscaling.TargetCPUUtilizationPercentage = &targetCPU
+	assert.Equal(t, []string{".spec.autoscaling.targetCPUUtilizationPercentage"}, errorPaths(component.validateAutoscaling()))
+
+	component.Spec.ResourceRequirements = &v1.ResourceRequirements{
+		Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")},
+	}
+	assert.Nil(t, component.validateAutoscaling())
+
+	component.Spec.Autoscaling.CustomMetrics = []AutoscalingCustomMetric{{Name: "istio_requests_per_second"}}
+	assert.Equal(t, []string{".spec.autoscaling.customMetrics[0].targetAverageValue"}, errorPaths(component.validateAutoscaling()))
+
+	component.Spec.Autoscaling.CustomMetrics = []AutoscalingCustomMetric{
+		{Name: "istio_requests_per_second", TargetAverageValue: resource.MustParse("100")},
+		{Name: "http_requests", TargetAverageValue: resource.MustParse("100")},
+	}
+	assert.Equal(t, []string{".spec.autoscaling.customMetrics[1].name"}, errorPaths(component.validateAutoscaling()))
+}
+
+func TestComponentDisruptionBudgetValidate(t *testing.T) {
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm",
+		},
+		Spec: ComponentSpec{
+			Image:            "foo:bar",
+			WorkloadType:     WorkloadTypeCronjob,
+			DisruptionBudget: &DisruptionBudget{},
+		},
+	}
+
+	assert.Equal(t, []string{".spec.disruptionBudget", ".spec.disruptionBudget"}, errorPaths(component.validateDisruptionBudget()))
+
+	minAvailable := intstr.FromString("50")
+	maxUnavailable := intstr.FromInt(1)
+	component.Spec.WorkloadType = WorkloadTypeServer
+	component.Spec.DisruptionBudget = &DisruptionBudget{MinAvailable: &minAvailable}
+	assert.Equal(t, []string{".spec.disruptionBudget.minAvailable"}, errorPaths(component.validateDisruptionBudget()))
+
+	minAvailable = intstr.FromString("50%")
+	assert.Nil(t, component.validateDisruptionBudget())
+
+	component.Spec.DisruptionBudget = &DisruptionBudget{MaxUnavailable: &maxUnavailable}
+	assert.Nil(t, component.validateDisruptionBudget())
+}
+
+func TestComponentSecurityContextValidate(t *testing.T) {
+	root := int64(0)
+	yes := true
+	no := false
+
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm",
+		},
+		Spec: ComponentSpec{
+			Image: "foo:bar",
+			SecurityContext: &SecurityContext{
+				RunAsUser:                &root,
+				RunAsNonRoot:             &yes,
+				Privileged:               &yes,
+				AllowPrivilegeEscalation: &no,
+				SeccompProfile:           &SeccompProfile{Type: SeccompProfileLocalhost},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{
+		".spec.securityContext.runAsUser",
+		".spec.securityContext.allowPrivilegeEscalation",
+		".spec.securityContext.seccompProfile.localhostProfile",
+	}, errorPaths(component.validateSecurityContext()))
+
+	component.Spec.SecurityContext = &SecurityContext{
+		RunAsNonRoot:   &yes,
+		SeccompProfile: &SeccompProfile{Type: SeccompProfileRuntimeDefault},
+	}
+	assert.Nil(t, component.validateSecurityContext())
+}
+
+func TestComponentSecurityPolicyValidate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1.AddToScheme(scheme)
+
+	componentReader = fake.NewFakeClientWithScheme(scheme, &v1.Namespace{
+		ObjectMeta: ctrl.ObjectMeta{
+			Name: "production",
+			Annotations: map[string]string{
+				AnnoSecurityPolicy: `{"requireRunAsNonRoot": true, "forbidPrivilegeEscalation": true, "requiredDropCapabilities": ["ALL"], "allowedAddCapabilities": ["NET_BIND_SERVICE"]}`,
+			},
+		},
+	}, &v1.Namespace{
+		ObjectMeta: ctrl.ObjectMeta{
+			Name:        "invalid",
+			Annotations: map[string]string{AnnoSecurityPolicy: "{"},
+		},
+	})
+	defer func() { componentReader = nil }()
+
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "production",
+			Name:      "api",
+		},
+		Spec: ComponentSpec{
+			Image: "foo:bar",
+			SecurityContext: &SecurityContext{
+				AddCapabilities: []v1.Capability{"NET_ADMIN", "net_bind_service"},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{
+		".spec.securityContext.runAsNonRoot",
+		".spec.securityContext.allowPrivilegeEscalation",
+		".spec.securityContext.dropCapabilities",
+		".spec.securityContext.addCapabilities[0]",
+	}, errorPaths(component.validateSecurityPolicy()))
+
+	uid := int64(1000)
+	no := false
+	component.Spec.SecurityContext = &SecurityContext{
+		RunAsUser:                &uid,
+		AllowPrivilegeEscalation: &no,
+		DropCapabilities:         []v1.Capability{"CAP_ALL"},
+		AddCapabilities:          []v1.Capability{"NET_BIND_SERVICE"},
+	}
+	assert.Nil(t, component.validateSecurityPolicy())
+
+	// namespaces without policies
+	component.Namespace = "default"
+	component.Spec.SecurityContext = nil
+	assert.Nil(t, component.validateSecurityPolicy())
+
+	component.Namespace = "invalid"
+	assert.Equal(t, []string{".metadata.namespace"}, errorPaths(component.validateSecurityPolicy()))
+}
+
+func TestComponentSecretEnvValidate(t *testing.T) {
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm",
+		},
+		Spec: ComponentSpec{
+			Image: "foo:bar",
+			Env: []EnvVar{
+				{Name: "DB_PASSWORD", Type: EnvVarTypeSecret, Value: "db-password"},
+				{Name: "TOKEN", Type: EnvVarTypeSecret, Value: "api/token"},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{".spec.env[1].value"}, errorPaths(component.validateEnvVarList()))
+}
+
+func TestComponentJobOptionsValidate(t *testing.T) {
+	parallelism := int32(3)
+	completions := int32(2)
+
+	component := Component{
+		ObjectMeta: ctrl.ObjectMeta{
+			Namespace: "kalm-system",
+			Name:      "kalm",
+		},
+		Spec: ComponentSpec{
+			Image:        "foo:bar",
+			WorkloadType: WorkloadTypeServer,
+			JobOptions: &JobOptions{
+				Parallelism: &parallelism,
+				Completions: &completions,
+			},
+		},
+	}
+
+	assert.Equal(t, []string{".spec.jobOptions", ".spec.jobOptions.parallelism"}, errorPaths(component.validateJobOptions()))
+
+	component.Spec.WorkloadType = WorkloadTypeJob
+	component.Spec.JobOptions.Completions = &parallelism
+	assert.Nil(t, component.validateJobOptions())
+
+	component.Spec.Sidecars = []Container{{Name: "proxy", Image: "envoy"}}
+	assert.Equal(t, []string{".spec.sidecars"}, errorPaths(component.validateExtraContainers()))
+}
@@ -0,0 +1,81 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Annotations on Component, set by whoever changes the spec,
+	// will be recorded into the ComponentRevision created for the change.
+	AnnoComponentChangedBy   = "core.kalm.dev/changed-by"
+	AnnoComponentChangeCause = "core.kalm.dev/change-cause"
+
+	ComponentRevisionLabelComponent = "kalm-component"
+	ComponentRevisionLabelSpecHash  = "kalm-component-spec-hash"
+)
+
+// ComponentRevisionSpec defines the desired state of ComponentRevision
+type ComponentRevisionSpec struct {
+	// +kubebuilder:validation:MinLength=1
+	ComponentName string `json:"componentName"`
+
+	// +kubebuilder:validation:Minimum=1
+	Revision int64 `json:"revision"`
+
+	// snapshot of the component spec at this revision
+	ComponentSpec ComponentSpec `json:"componentSpec"`
+
+	// +optional
+	ChangedBy string `json:"changedBy,omitempty"`
+
+	// +optional
+	ChangeCause string `json:"changeCause,omitempty"`
+}
+
+// ComponentRevisionStatus defines the observed state of ComponentRevision
+type ComponentRevisionStatus struct {
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Component",type="string",JSONPath=".spec.componentName"
+// +kubebuilder:printcolumn:name="Revision",type="integer",JSONPath=".spec.revision"
+// +kubebuilder:printcolumn:name="Image",type="string",JSONPath=".spec.componentSpec.image"
+// +kubebuilder:printcolumn:name="ChangedBy",type="string",JSONPath=".spec.changedBy"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ComponentRevision is the Schema for the componentrevisions API
+type ComponentRevision struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ComponentRevisionSpec   `json:"spec,omitempty"`
+	Status ComponentRevisionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ComponentRevisionList contains a list of ComponentRevision
+type ComponentRevisionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ComponentRevision `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ComponentRevision{}, &ComponentRevisionList{})
+}
@@ -21,7 +21,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// +kubebuilder:validation:Enum=server;cronjob;daemonset;statefulset
+// +kubebuilder:validation:Enum=server;cronjob;daemonset;statefulset;job
 type WorkloadType string
 
 const (
@@ -29,6 +29,7 @@ const (
 	WorkloadTypeCronjob     WorkloadType = "cronjob"
 	WorkloadTypeDaemonSet   WorkloadType = "daemonset"
 	WorkloadTypeStatefulSet WorkloadType = "statefulset"
+	WorkloadTypeJob         WorkloadType = "job"
 )
 
 // ComponentTemplateSpec defines the desired state of ComponentTemplate
@@ -51,7 +52,7 @@ type ComponentTemplateSpec struct {
 	// +optional
 	// ReadinessProbe *v1.Probe `json:"readinessProbe,omitempty"`
 
-	// +kubebuilder:validation:Enum=server;cronjob;daemonset;statefulset
+	// +kubebuilder:validation:Enum=server;cronjob;daemonset;statefulset;job
 	WorkLoadType WorkloadType `json:"workloadType,omitempty"`
 
 	Schedule string `json:"schedule,omitempty"`
@@ -26,9 +26,11 @@ type DockerRegistrySpec struct {
 }
 
 type RepositoryTag struct {
-	Name           string `json:"name"`
-	Manifest       string `json:"manifest"`
-	TimeCreatedMs  string `json:"timeCreatedMs"`
+	Name     string `json:"name"`
+	Manifest string `json:"manifest"`
+	// the created time of the image config, it's the build time, or a fixed time for reproducible builds
+	TimeCreatedMs string `json:"timeCreatedMs"`
+	// the time the tag or its new manifest is first seen by polling, empty if it was there before polling started
 	TimeUploadedMs string `json:"timeUploadedMs"`
 }
 
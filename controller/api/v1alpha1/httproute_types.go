@@ -16,6 +16,10 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -97,6 +101,157 @@ type HttpRouteCORS struct {
 	MaxAgeSeconds    int                  `json:"maxAgeSeconds"`
 }
 
+type HttpRouteRewrite struct {
+	// Replaces the matched prefix of the path, e.g. /api/v2 -> /.
+	// The whole path is replaced if paths are matched exactly or by regexp.
+	// +optional
+	Uri string `json:"uri,omitempty"`
+
+	// Replaces the Host header
+	// +optional
+	Authority string `json:"authority,omitempty"`
+}
+
+type HttpRouteHeaderOperations struct {
+	// Overwrite headers
+	// +optional
+	Set map[string]string `json:"set,omitempty"`
+
+	// Append values to headers, values are comma-separated if the header exists
+	// +optional
+	Add map[string]string `json:"add,omitempty"`
+
+	// +optional
+	Remove []string `json:"remove,omitempty"`
+}
+
+type HttpRouteHeaders struct {
+	// Operations on headers of requests sent to destinations
+	// +optional
+	Request *HttpRouteHeaderOperations `json:"request,omitempty"`
+
+	// Operations on headers of responses sent to clients
+	// +optional
+	Response *HttpRouteHeaderOperations `json:"response,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=prefix;exact;regex
+type HttpRoutePathType string
+
+const (
+	HttpRoutePathTypePrefix HttpRoutePathType = "prefix"
+	HttpRoutePathTypeExact  HttpRoutePathType = "exact"
+	HttpRoutePathTypeRegex  HttpRoutePathType = "regex"
+)
+
+// +kubebuilder:validation:Enum=second;minute
+type HttpRouteRateLimitUnit string
+
+const (
+	HttpRouteRateLimitUnitSecond HttpRouteRateLimitUnit = "second"
+	HttpRouteRateLimitUnitMinute HttpRouteRateLimitUnit = "minute"
+)
+
+// +kubebuilder:validation:Enum=ip;header;jwtClaim
+type HttpRouteRateLimitKeyType string
+
+const (
+	HttpRouteRateLimitKeyTypeIP       HttpRouteRateLimitKeyType = "ip"
+	HttpRouteRateLimitKeyTypeHeader   HttpRouteRateLimitKeyType = "header"
+	HttpRouteRateLimitKeyTypeJwtClaim HttpRouteRateLimitKeyType = "jwtClaim"
+
+	DefaultHttpRouteRateLimitResponseStatus = 429
+)
+
+type HttpRouteRateLimitKey struct {
+	// +kubebuilder:validation:Enum=ip;header;jwtClaim
+	Type HttpRouteRateLimitKeyType `json:"type"`
+
+	// Name of the header or the claim
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Issuer of the jwt, claims are read from tokens verified by the RequestAuthentication of this issuer
+	// +optional
+	Issuer string `json:"issuer,omitempty"`
+}
+
+// HttpRouteRateLimit limits requests with the local rate limit filter of the ingress gateway, no rate limit service is needed.
+// Each gateway replica counts requests on its own, so the effective limit is multiplied by the number of replicas.
+// The ingress gateway needs istio 1.9 or later, and 1.26 or later for limits with a key. Otherwise the limit is not applied,
+// and the error is reported in the status of the route.
+type HttpRouteRateLimit struct {
+	// +kubebuilder:validation:Minimum=1
+	RequestsPerUnit int `json:"requestsPerUnit"`
+
+	// +kubebuilder:validation:Enum=second;minute
+	Unit HttpRouteRateLimitUnit `json:"unit"`
+
+	// Requests allowed at once before limiting, defaults to requestsPerUnit
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Burst *int `json:"burst,omitempty"`
+
+	// Requests are counted separately for each value of the key, all requests of the route share the limit if it's not set.
+	// Requests without the key share one limit.
+	// +optional
+	Key *HttpRouteRateLimitKey `json:"key,omitempty"`
+
+	// Status code of limited requests, 429 by default
+	// +optional
+	ResponseStatus *int `json:"responseStatus,omitempty"`
+}
+
+func (l *HttpRouteRateLimit) GetBurst() int {
+	if l.Burst != nil {
+		return *l.Burst
+	}
+
+	return l.RequestsPerUnit
+}
+
+func (l *HttpRouteRateLimit) GetResponseStatus() int {
+	if l.ResponseStatus != nil {
+		return *l.ResponseStatus
+	}
+
+	return DefaultHttpRouteRateLimitResponseStatus
+}
+
+// HttpRouteSourceIP matches the client address of requests on the ingress gateway.
+// It's the peer address of the connection, or the address in X-Forwarded-For before the trusted proxies,
+// if the controller is configured with the number of proxies in front of the gateway (KALM_GATEWAY_XFF_NUM_TRUSTED_HOPS).
+// Denied requests get 403 responses.
+type HttpRouteSourceIP struct {
+	// Only requests from these CIDRs or IPs are allowed, requests from all sources are allowed if it's empty
+	// +optional
+	Allow []string `json:"allow,omitempty"`
+
+	// Requests from these CIDRs or IPs are denied, even if they are allowed
+	// +optional
+	Deny []string `json:"deny,omitempty"`
+}
+
+const DefaultClientCertificateSubjectHeader = "X-Client-Cert-Subject"
+
+type HttpRouteClientCertificate struct {
+	// Name of the Kalm secret of the application holding PEM encoded CA certificates, client certificates must be signed by them
+	// +kubebuilder:validation:MinLength=1
+	CASecret string `json:"caSecret"`
+
+	// The subject of the client certificate is forwarded to destinations in this header, X-Client-Cert-Subject by default
+	// +optional
+	SubjectHeader string `json:"subjectHeader,omitempty"`
+}
+
+func (c *HttpRouteClientCertificate) GetSubjectHeader() string {
+	if c.SubjectHeader != "" {
+		return c.SubjectHeader
+	}
+
+	return DefaultClientCertificateSubjectHeader
+}
+
 // +kubebuilder:validation:Enum=GET;HEAD;POST;PUT;PATCH;DELETE;OPTIONS;TRACE;CONNECT
 type AllowMethod string
 
@@ -116,6 +271,10 @@ type HttpRouteSpec struct {
 	// +kubebuilder:validation:MinItems=1
 	Paths []string `json:"paths"`
 
+	// How paths are matched, paths are prefixes by default
+	// +optional
+	PathType HttpRoutePathType `json:"pathType,omitempty"`
+
 	// +kubebuilder:validation:MinItems=1
 	Methods []HttpRouteMethod `json:"methods"`
 
@@ -124,6 +283,13 @@ type HttpRouteSpec struct {
 
 	StripPath bool `json:"stripPath,omitempty"`
 
+	// Rewrite the path and host of requests, it can't be used with stripPath
+	// +optional
+	Rewrite *HttpRouteRewrite `json:"rewrite,omitempty"`
+
+	// +optional
+	Headers *HttpRouteHeaders `json:"headers,omitempty"`
+
 	Conditions []HttpRouteCondition `json:"conditions,omitempty"`
 
 	// +kubebuilder:validation:MinItems=1
@@ -138,11 +304,234 @@ type HttpRouteSpec struct {
 	Fault  *HttpRouteFault  `json:"fault,omitempty"`
 	Delay  *HttpRouteDelay  `json:"delay,omitempty"`
 	CORS   *HttpRouteCORS   `json:"cors,omitempty"`
+
+	// +optional
+	RateLimit *HttpRouteRateLimit `json:"rateLimit,omitempty"`
+
+	// Allow or deny requests by the client ip, X-Forwarded-For is used if the gateway trusts proxies in front of it
+	// +optional
+	SourceIP *HttpRouteSourceIP `json:"sourceIP,omitempty"`
+
+	// Clients must present certificates signed by the CA, it only works for https.
+	// The tls handshake is done before routing, so hosts of the route require certificates for all routes on them.
+	// +optional
+	ClientCertificate *HttpRouteClientCertificate `json:"clientCertificate,omitempty"`
+}
+
+type HttpRouteStatusConditionType string
+
+const (
+	// True when the route is written into virtual services of all its hosts.
+	HttpRouteConditionAccepted HttpRouteStatusConditionType = "Accepted"
+
+	// True when another route claims the same host and path with the same methods and conditions.
+	HttpRouteConditionConflicted HttpRouteStatusConditionType = "Conflicted"
+
+	// True when some rules of the route are never matched, because rules of other routes before them match the same requests.
+	HttpRouteConditionShadowed HttpRouteStatusConditionType = "Shadowed"
+)
+
+type HttpRouteStatusCondition struct {
+	// Type of the condition, one of ('Accepted', 'Conflicted', 'Shadowed').
+	Type HttpRouteStatusConditionType `json:"type"`
+
+	// Status of the condition, one of ('True', 'False', 'Unknown').
+	Status v1.ConditionStatus `json:"status"`
+
+	// Last time the condition transitioned from one status to another.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type HttpRouteHostStatus struct {
+	Host string `json:"host"`
+
+	// Index of the first rule of the route in the virtual service of the host.
+	// Rules are matched in order, the first matched one serves the request.
+	Priority int `json:"priority"`
+
+	// "namespace/name" of routes claiming the same matches on this host
+	// +optional
+	ConflictingRoutes []string `json:"conflictingRoutes,omitempty"`
+
+	// "namespace/name" of routes whose rules are matched before rules of this route on this host
+	// +optional
+	ShadowedBy []string `json:"shadowedBy,omitempty"`
+}
+
+type HttpRouteDestinationStatus struct {
+	Host string `json:"host"`
+
+	// The service of the destination exists, always true for hosts outside of the cluster
+	ServiceExists bool `json:"serviceExists"`
+
+	// The port of the destination is a port of the service
+	PortMatched bool `json:"portMatched"`
+
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // HttpRouteStatus defines the observed state of HttpRoute
 type HttpRouteStatus struct {
 	HostCertifications map[string]string `json:"hostCertifications,omitempty"`
+
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// +optional
+	Conditions []HttpRouteStatusCondition `json:"conditions,omitempty"`
+
+	// +optional
+	Hosts []HttpRouteHostStatus `json:"hosts,omitempty"`
+
+	// +optional
+	Destinations []HttpRouteDestinationStatus `json:"destinations,omitempty"`
+
+	// The error of the last reconcile, empty if it succeeded
+	// +optional
+	LastReconcileError string `json:"lastReconcileError,omitempty"`
+}
+
+// GetCondition returns the condition with the given type, or nil if it's not set yet.
+func (s *HttpRouteStatus) GetCondition(conditionType HttpRouteStatusConditionType) *HttpRouteStatusCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == conditionType {
+			return &s.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
+// SetCondition adds or updates a condition. LastTransitionTime is only bumped when the status changes.
+func (s *HttpRouteStatus) SetCondition(condition HttpRouteStatusCondition) {
+	existing := s.GetCondition(condition.Type)
+
+	if existing == nil {
+		if condition.LastTransitionTime.IsZero() {
+			condition.LastTransitionTime = metav1.Now()
+		}
+
+		s.Conditions = append(s.Conditions, condition)
+		return
+	}
+
+	if existing.Status != condition.Status {
+		existing.Status = condition.Status
+
+		if condition.LastTransitionTime.IsZero() {
+			existing.LastTransitionTime = metav1.Now()
+		} else {
+			existing.LastTransitionTime = condition.LastTransitionTime
+		}
+	}
+
+	existing.Reason = condition.Reason
+	existing.Message = condition.Message
+}
+
+func httpRouteMethodSet(methods []HttpRouteMethod) map[HttpRouteMethod]bool {
+	res := make(map[HttpRouteMethod]bool, len(methods))
+
+	for _, m := range methods {
+		res[m] = true
+	}
+
+	return res
+}
+
+func httpRouteConditionKeys(conditions []HttpRouteCondition) []string {
+	res := make([]string, 0, len(conditions))
+
+	for _, c := range conditions {
+		res = append(res, fmt.Sprintf("%s/%s/%s/%s", c.Type, c.Name, c.Operator, c.Value))
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+// GetPathType returns the path type, prefix if it's not set
+func (r *HttpRoute) GetPathType() HttpRoutePathType {
+	if r.Spec.PathType == "" {
+		return HttpRoutePathTypePrefix
+	}
+
+	return r.Spec.PathType
+}
+
+// DuplicateMatches returns hosts and paths, in "host path" format, which both routes claim with
+// the same methods and conditions on a shared scheme. Only one of the routes serves these requests.
+func (r *HttpRoute) DuplicateMatches(other *HttpRoute) []string {
+	sharedScheme := false
+
+	for _, a := range r.Spec.Schemes {
+		for _, b := range other.Spec.Schemes {
+			if a == b {
+				sharedScheme = true
+			}
+		}
+	}
+
+	if !sharedScheme {
+		return nil
+	}
+
+	if r.GetPathType() != other.GetPathType() {
+		return nil
+	}
+
+	methods, otherMethods := httpRouteMethodSet(r.Spec.Methods), httpRouteMethodSet(other.Spec.Methods)
+
+	if len(methods) != len(otherMethods) {
+		return nil
+	}
+
+	for m := range methods {
+		if !otherMethods[m] {
+			return nil
+		}
+	}
+
+	conditions, otherConditions := httpRouteConditionKeys(r.Spec.Conditions), httpRouteConditionKeys(other.Spec.Conditions)
+
+	if len(conditions) != len(otherConditions) {
+		return nil
+	}
+
+	for i := range conditions {
+		if conditions[i] != otherConditions[i] {
+			return nil
+		}
+	}
+
+	var res []string
+
+	for _, host := range r.Spec.Hosts {
+		for _, otherHost := range other.Spec.Hosts {
+			if host != otherHost {
+				continue
+			}
+
+			for _, path := range r.Spec.Paths {
+				for _, otherPath := range other.Spec.Paths {
+					if path == otherPath {
+						res = append(res, host+" "+path)
+					}
+				}
+			}
+		}
+	}
+
+	return res
 }
 
 // +kubebuilder:object:root=true
@@ -16,11 +16,16 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	"fmt"
 	"k8s.io/apimachinery/pkg/runtime"
+	"net"
+	"regexp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -28,7 +33,12 @@ import (
 // log is for logging in this package.
 var httproutelog = logf.Log.WithName("httproute-resource")
 
+// used to find routes of other namespaces, nil if webhook is not set up with a manager
+var httpRouteReader client.Reader
+
 func (r *HttpRoute) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	httpRouteReader = mgr.GetAPIReader()
+
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
 		Complete()
@@ -80,7 +90,14 @@ func (r *HttpRoute) validate() error {
 	}
 
 	for i, path := range r.Spec.Paths {
-		if !isValidPath(path) {
+		if r.GetPathType() == HttpRoutePathTypeRegex {
+			if _, err := regexp.Compile(path); err != nil {
+				rst = append(rst, KalmValidateError{
+					Err:  "invalid path regexp: " + err.Error(),
+					Path: fmt.Sprintf("spec.paths[%d]", i),
+				})
+			}
+		} else if !isValidPath(path) {
 			rst = append(rst, KalmValidateError{
 				Err:  "invalid path, should start with: /",
 				Path: fmt.Sprintf("spec.paths[%d]", i),
@@ -88,6 +105,11 @@ func (r *HttpRoute) validate() error {
 		}
 	}
 
+	rst = append(rst, r.validateRewrite()...)
+	rst = append(rst, r.validateHeaders()...)
+	rst = append(rst, r.validateRateLimit()...)
+	rst = append(rst, r.validateAccessControl()...)
+
 	for i, dest := range r.Spec.Destinations {
 		if !isValidDestinationHost(dest.Host) {
 			rst = append(rst, KalmValidateError{
@@ -118,6 +140,8 @@ func (r *HttpRoute) validate() error {
 		}
 	}
 
+	rst = append(rst, r.validateDuplicates()...)
+
 	if len(rst) == 0 {
 		return nil
 	}
@@ -125,6 +149,294 @@ func (r *HttpRoute) validate() error {
 	return rst
 }
 
+// validateDuplicates rejects routes claiming exactly the same requests as routes in other namespaces,
+// so an application can't take over traffic of another one. Duplicates in the same namespace are
+// reported in the status of routes instead.
+func (r *HttpRoute) validateDuplicates() KalmValidateErrorList {
+	if httpRouteReader == nil {
+		return nil
+	}
+
+	var routeList HttpRouteList
+
+	if err := httpRouteReader.List(context.Background(), &routeList); err != nil {
+		httproutelog.Error(err, "list http routes error")
+
+		return KalmValidateErrorList{{
+			Err:  "unable to check duplicate routes: " + err.Error(),
+			Path: "spec.hosts",
+		}}
+	}
+
+	return r.findDuplicatesInOtherNamespaces(routeList.Items)
+}
+
+func (r *HttpRoute) findDuplicatesInOtherNamespaces(routes []HttpRoute) (rst KalmValidateErrorList) {
+	for i := range routes {
+		other := &routes[i]
+
+		if other.Namespace == r.Namespace {
+			continue
+		}
+
+		for _, match := range r.DuplicateMatches(other) {
+			rst = append(rst, KalmValidateError{
+				Err:  fmt.Sprintf("%s is already routed by %s/%s", match, other.Namespace, other.Name),
+				Path: "spec.hosts",
+			})
+		}
+	}
+
+	return rst
+}
+
+func (r *HttpRoute) validateRewrite() (rst KalmValidateErrorList) {
+	rewrite := r.Spec.Rewrite
+
+	if rewrite == nil {
+		return nil
+	}
+
+	if r.Spec.StripPath && rewrite.Uri != "" {
+		rst = append(rst, KalmValidateError{
+			Err:  "rewrite uri can't be used with stripPath",
+			Path: "spec.rewrite.uri",
+		})
+	}
+
+	if rewrite.Uri != "" && !isValidPath(rewrite.Uri) {
+		rst = append(rst, KalmValidateError{
+			Err:  "invalid rewrite uri, should start with: /",
+			Path: "spec.rewrite.uri",
+		})
+	}
+
+	if rewrite.Authority != "" {
+		host := stripIfHasPort(rewrite.Authority)
+
+		if !isValidK8sHost(host) && !isValidDomain(host) && !isValidIP(host) {
+			rst = append(rst, KalmValidateError{
+				Err:  "invalid rewrite authority:" + rewrite.Authority,
+				Path: "spec.rewrite.authority",
+			})
+		}
+	}
+
+	return rst
+}
+
+// headers kalm sets or removes on requests for sso and routing, routes can't change them.
+// Keep it in sync with DANGEROUS_HEADERS of the http route controller.
+var reservedHttpRouteHeaders = []string{
+	"kalm-sso-userinfo",
+	"allow-to-pass-if-has-bearer-token",
+	"kalm-route",
+	"kalm-set-cookie",
+}
+
+// https://tools.ietf.org/html/rfc7230#section-3.2.6
+var headerNameReg = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+func validateHeaderName(name, path string, request bool) *KalmValidateError {
+	if !headerNameReg.MatchString(name) {
+		return &KalmValidateError{Err: "invalid header name: " + name, Path: path}
+	}
+
+	if !request {
+		return nil
+	}
+
+	for _, reserved := range reservedHttpRouteHeaders {
+		if strings.EqualFold(name, reserved) {
+			return &KalmValidateError{Err: "header " + name + " is reserved by kalm", Path: path}
+		}
+	}
+
+	return nil
+}
+
+func validateHeaderOperations(operations *HttpRouteHeaderOperations, path string, request bool) (rst KalmValidateErrorList) {
+	if operations == nil {
+		return nil
+	}
+
+	for field, values := range map[string]map[string]string{"set": operations.Set, "add": operations.Add} {
+		for name, value := range values {
+			p := fmt.Sprintf("%s.%s.%s", path, field, name)
+
+			if err := validateHeaderName(name, p, request); err != nil {
+				rst = append(rst, *err)
+			}
+
+			if strings.ContainsAny(value, "\r\n") {
+				rst = append(rst, KalmValidateError{Err: "header value can't contain line breaks", Path: p})
+			}
+		}
+	}
+
+	for i, name := range operations.Remove {
+		if err := validateHeaderName(name, fmt.Sprintf("%s.remove[%d]", path, i), request); err != nil {
+			rst = append(rst, *err)
+		}
+	}
+
+	// map iteration order is random
+	sort.Slice(rst, func(i, j int) bool { return rst[i].Path < rst[j].Path })
+
+	return rst
+}
+
+func (r *HttpRoute) validateHeaders() (rst KalmValidateErrorList) {
+	if r.Spec.Headers == nil {
+		return nil
+	}
+
+	rst = append(rst, validateHeaderOperations(r.Spec.Headers.Request, "spec.headers.request", true)...)
+	rst = append(rst, validateHeaderOperations(r.Spec.Headers.Response, "spec.headers.response", false)...)
+
+	return rst
+}
+
+func (r *HttpRoute) validateRateLimit() (rst KalmValidateErrorList) {
+	limit := r.Spec.RateLimit
+
+	if limit == nil {
+		return nil
+	}
+
+	if limit.RequestsPerUnit < 1 {
+		rst = append(rst, KalmValidateError{
+			Err:  "should be positive",
+			Path: "spec.rateLimit.requestsPerUnit",
+		})
+	}
+
+	if limit.Unit != HttpRouteRateLimitUnitSecond && limit.Unit != HttpRouteRateLimitUnitMinute {
+		rst = append(rst, KalmValidateError{
+			Err:  "unit should be second or minute",
+			Path: "spec.rateLimit.unit",
+		})
+	}
+
+	if limit.Burst != nil && *limit.Burst < limit.RequestsPerUnit {
+		rst = append(rst, KalmValidateError{
+			Err:  "burst can't be less than requestsPerUnit",
+			Path: "spec.rateLimit.burst",
+		})
+	}
+
+	if limit.ResponseStatus != nil && (*limit.ResponseStatus < 400 || *limit.ResponseStatus > 599) {
+		rst = append(rst, KalmValidateError{
+			Err:  "response status should be in range [400, 599]",
+			Path: "spec.rateLimit.responseStatus",
+		})
+	}
+
+	key := limit.Key
+
+	if key == nil {
+		return rst
+	}
+
+	switch key.Type {
+	case HttpRouteRateLimitKeyTypeIP:
+	case HttpRouteRateLimitKeyTypeHeader:
+		if !headerNameReg.MatchString(key.Name) {
+			rst = append(rst, KalmValidateError{
+				Err:  "invalid header name: " + key.Name,
+				Path: "spec.rateLimit.key.name",
+			})
+		}
+	case HttpRouteRateLimitKeyTypeJwtClaim:
+		if key.Name == "" {
+			rst = append(rst, KalmValidateError{
+				Err:  "claim name can't be blank",
+				Path: "spec.rateLimit.key.name",
+			})
+		}
+
+		if key.Issuer == "" {
+			rst = append(rst, KalmValidateError{
+				Err:  "issuer can't be blank",
+				Path: "spec.rateLimit.key.issuer",
+			})
+		}
+	default:
+		rst = append(rst, KalmValidateError{
+			Err:  "unknown key type: " + string(key.Type),
+			Path: "spec.rateLimit.key.type",
+		})
+	}
+
+	return rst
+}
+
+func isValidCIDROrIP(s string) bool {
+	if _, _, err := net.ParseCIDR(s); err == nil {
+		return true
+	}
+
+	return isValidIP(s)
+}
+
+func (r *HttpRoute) validateAccessControl() (rst KalmValidateErrorList) {
+	if r.Spec.SourceIP == nil && r.Spec.ClientCertificate == nil {
+		return nil
+	}
+
+	if sourceIP := r.Spec.SourceIP; sourceIP != nil {
+		for i, ip := range sourceIP.Allow {
+			if !isValidCIDROrIP(ip) {
+				rst = append(rst, KalmValidateError{
+					Err:  "invalid CIDR or IP:" + ip,
+					Path: fmt.Sprintf("spec.sourceIP.allow[%d]", i),
+				})
+			}
+		}
+
+		for i, ip := range sourceIP.Deny {
+			if !isValidCIDROrIP(ip) {
+				rst = append(rst, KalmValidateError{
+					Err:  "invalid CIDR or IP:" + ip,
+					Path: fmt.Sprintf("spec.sourceIP.deny[%d]", i),
+				})
+			}
+		}
+	}
+
+	if cert := r.Spec.ClientCertificate; cert != nil {
+		// authorization policies match paths by exact values and prefixes only
+		if r.GetPathType() == HttpRoutePathTypeRegex {
+			rst = append(rst, KalmValidateError{
+				Err:  "clientCertificate can't be used with regex paths",
+				Path: "spec.pathType",
+			})
+		}
+
+		if cert.CASecret == "" {
+			rst = append(rst, KalmValidateError{
+				Err:  "caSecret can't be blank",
+				Path: "spec.clientCertificate.caSecret",
+			})
+		}
+
+		if err := validateHeaderName(cert.GetSubjectHeader(), "spec.clientCertificate.subjectHeader", true); err != nil {
+			rst = append(rst, *err)
+		}
+
+		for _, scheme := range r.Spec.Schemes {
+			if scheme != "https" {
+				rst = append(rst, KalmValidateError{
+					Err:  "client certificates are only verified on https, http can't be used",
+					Path: "spec.schemes",
+				})
+			}
+		}
+	}
+
+	return rst
+}
+
 func isValidDestinationHost(host string) bool {
 	host = stripIfHasPort(host)
 	return isValidK8sHost(host)
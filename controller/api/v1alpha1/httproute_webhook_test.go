@@ -63,3 +63,172 @@ func TestHttpRoute_isValidDestinationHost(t *testing.T) {
 		assert.True(t, isValidDestinationHost(h))
 	}
 }
+
+func TestHttpRoute_DuplicateMatches(t *testing.T) {
+	route := HttpRoute{
+		ObjectMeta: ctrl.ObjectMeta{Namespace: "foo", Name: "web"},
+		Spec: HttpRouteSpec{
+			Hosts:   []string{"example.com", "www.example.com"},
+			Paths:   []string{"/", "/api"},
+			Methods: []HttpRouteMethod{"GET", "POST"},
+			Schemes: []HttpRouteScheme{"http", "https"},
+		},
+	}
+
+	other := HttpRoute{
+		ObjectMeta: ctrl.ObjectMeta{Namespace: "bar", Name: "web"},
+		Spec: HttpRouteSpec{
+			Hosts:   []string{"www.example.com"},
+			Paths:   []string{"/api"},
+			Methods: []HttpRouteMethod{"POST", "GET"},
+			Schemes: []HttpRouteScheme{"https"},
+		},
+	}
+
+	assert.Equal(t, []string{"www.example.com /api"}, route.DuplicateMatches(&other))
+
+	same := HttpRoute{ObjectMeta: ctrl.ObjectMeta{Namespace: "foo", Name: "web-v2"}, Spec: *other.Spec.DeepCopy()}
+	rst := route.findDuplicatesInOtherNamespaces([]HttpRoute{other, same})
+	assert.Len(t, rst, 1)
+	assert.Equal(t, "www.example.com /api is already routed by bar/web", rst[0].Err)
+
+	other.Spec.Methods = []HttpRouteMethod{"GET"}
+	assert.Empty(t, route.DuplicateMatches(&other))
+
+	other.Spec.Methods = []HttpRouteMethod{"GET", "POST"}
+	other.Spec.Conditions = []HttpRouteCondition{{Type: HttpRouteConditionTypeHeader, Name: "x-canary", Operator: HRCOEqual, Value: "true"}}
+	assert.Empty(t, route.DuplicateMatches(&other))
+
+	other.Spec.Conditions = nil
+	other.Spec.Schemes = []HttpRouteScheme{"http"}
+	route.Spec.Schemes = []HttpRouteScheme{"https"}
+	assert.Empty(t, route.DuplicateMatches(&other))
+}
+
+func TestHttpRoute_ValidateRewriteAndHeaders(t *testing.T) {
+	route := HttpRoute{
+		ObjectMeta: ctrl.ObjectMeta{Namespace: "test-ns", Name: "legacy"},
+		Spec: HttpRouteSpec{
+			Hosts:        []string{"example.com"},
+			Methods:      []HttpRouteMethod{"GET"},
+			Schemes:      []HttpRouteScheme{"http"},
+			Paths:        []string{"/api/v2"},
+			Destinations: []HttpRouteDestination{{Host: "legacy:80", Weight: 1}},
+			Rewrite:      &HttpRouteRewrite{Uri: "/", Authority: "legacy.internal:8080"},
+			Headers: &HttpRouteHeaders{
+				Request:  &HttpRouteHeaderOperations{Set: map[string]string{"X-Forwarded-Prefix": "/api/v2"}},
+				Response: &HttpRouteHeaderOperations{Remove: []string{"Server"}, Add: map[string]string{"kalm-route": "true"}},
+			},
+		},
+	}
+
+	assert.Nil(t, route.validate())
+
+	route.Spec.StripPath = true
+	route.Spec.Rewrite.Authority = "not a host"
+	route.Spec.Headers.Request.Set["Kalm-Route"] = "false"
+	route.Spec.Headers.Request.Add = map[string]string{"X-Bad": "a\r\nb"}
+	route.Spec.Headers.Request.Remove = []string{"bad header"}
+
+	assert.Equal(t, []string{
+		"spec.rewrite.uri",
+		"spec.rewrite.authority",
+		"spec.headers.request.add.X-Bad",
+		"spec.headers.request.remove[0]",
+		"spec.headers.request.set.Kalm-Route",
+	}, errorPaths(route.validate().(KalmValidateErrorList)))
+
+	route.Spec.StripPath = false
+	route.Spec.Rewrite = nil
+	route.Spec.Headers = nil
+	route.Spec.PathType = HttpRoutePathTypeRegex
+	route.Spec.Paths = []string{"^/users/[0-9]+$", "/users/(["}
+
+	assert.Equal(t, []string{"spec.paths[1]"}, errorPaths(route.validate().(KalmValidateErrorList)))
+}
+
+func TestHttpRoute_ValidateRateLimit(t *testing.T) {
+	burst := 5
+	status := 200
+
+	route := HttpRoute{
+		ObjectMeta: ctrl.ObjectMeta{Namespace: "test-ns", Name: "api"},
+		Spec: HttpRouteSpec{
+			Hosts:        []string{"example.com"},
+			Methods:      []HttpRouteMethod{"GET"},
+			Schemes:      []HttpRouteScheme{"http"},
+			Paths:        []string{"/"},
+			Destinations: []HttpRouteDestination{{Host: "api:80", Weight: 1}},
+			RateLimit: &HttpRouteRateLimit{
+				RequestsPerUnit: 10,
+				Unit:            HttpRouteRateLimitUnitSecond,
+				Key:             &HttpRouteRateLimitKey{Type: HttpRouteRateLimitKeyTypeIP},
+			},
+		},
+	}
+
+	assert.Nil(t, route.validate())
+	assert.Equal(t, 10, route.Spec.RateLimit.GetBurst())
+	assert.Equal(t, 429, route.Spec.RateLimit.GetResponseStatus())
+
+	route.Spec.RateLimit.Unit = "hour"
+	route.Spec.RateLimit.Burst = &burst
+	route.Spec.RateLimit.ResponseStatus = &status
+	route.Spec.RateLimit.Key = &HttpRouteRateLimitKey{Type: HttpRouteRateLimitKeyTypeJwtClaim}
+
+	assert.Equal(t, []string{
+		"spec.rateLimit.unit",
+		"spec.rateLimit.burst",
+		"spec.rateLimit.responseStatus",
+		"spec.rateLimit.key.name",
+		"spec.rateLimit.key.issuer",
+	}, errorPaths(route.validate().(KalmValidateErrorList)))
+
+	route.Spec.RateLimit.Unit = HttpRouteRateLimitUnitMinute
+	route.Spec.RateLimit.Burst = nil
+	route.Spec.RateLimit.ResponseStatus = nil
+	route.Spec.RateLimit.Key = &HttpRouteRateLimitKey{Type: HttpRouteRateLimitKeyTypeHeader, Name: "bad header"}
+
+	assert.Equal(t, []string{"spec.rateLimit.key.name"}, errorPaths(route.validate().(KalmValidateErrorList)))
+}
+
+func TestHttpRoute_ValidateAccessControl(t *testing.T) {
+	route := HttpRoute{
+		ObjectMeta: ctrl.ObjectMeta{Namespace: "test-ns", Name: "admin"},
+		Spec: HttpRouteSpec{
+			Hosts:        []string{"admin.example.com"},
+			Methods:      []HttpRouteMethod{"GET"},
+			Schemes:      []HttpRouteScheme{"https"},
+			Paths:        []string{"/"},
+			Destinations: []HttpRouteDestination{{Host: "admin:80", Weight: 1}},
+			SourceIP: &HttpRouteSourceIP{
+				Allow: []string{"10.0.0.0/8", "192.168.1.10"},
+				Deny:  []string{"10.1.0.0/16"},
+			},
+			ClientCertificate: &HttpRouteClientCertificate{CASecret: "client-ca"},
+		},
+	}
+
+	assert.Nil(t, route.validate())
+	assert.Equal(t, "X-Client-Cert-Subject", route.Spec.ClientCertificate.GetSubjectHeader())
+
+	// source ips are matched by virtual service rules, which support regex paths
+	route.Spec.PathType = HttpRoutePathTypeRegex
+	route.Spec.ClientCertificate = nil
+
+	assert.Nil(t, route.validate())
+
+	route.Spec.Schemes = []HttpRouteScheme{"http", "https"}
+	route.Spec.SourceIP.Allow = []string{"10.0.0.0/33"}
+	route.Spec.SourceIP.Deny = []string{"example.com"}
+	route.Spec.ClientCertificate = &HttpRouteClientCertificate{SubjectHeader: "Kalm-Route"}
+
+	assert.Equal(t, []string{
+		"spec.sourceIP.allow[0]",
+		"spec.sourceIP.deny[0]",
+		"spec.pathType",
+		"spec.clientCertificate.caSecret",
+		"spec.clientCertificate.subjectHeader",
+		"spec.schemes",
+	}, errorPaths(route.validate().(KalmValidateErrorList)))
+}
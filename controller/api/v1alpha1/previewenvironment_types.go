@@ -0,0 +1,108 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Label on the namespace of a preview environment, the value is the name of the PreviewEnvironment.
+	PreviewEnvironmentLabelName = "core.kalm.dev/preview-environment"
+
+	// 3 days
+	DefaultPreviewEnvironmentTTLSeconds = 259200
+)
+
+// PreviewEnvironmentSpec defines the desired state of PreviewEnvironment
+type PreviewEnvironmentSpec struct {
+	// The application the preview environment is cloned from
+	// +kubebuilder:validation:MinLength=1
+	Application string `json:"application"`
+
+	// Number of the pull request
+	// +kubebuilder:validation:Minimum=1
+	PullRequest int32 `json:"pullRequest"`
+
+	// Hosts of routes are moved under this domain, e.g. pr-123.preview.example.com
+	// +kubebuilder:validation:MinLength=1
+	Domain string `json:"domain"`
+
+	// The wildcard certificate of the domain, which is shared by all preview environments
+	// +optional
+	HttpsCert string `json:"httpsCert,omitempty"`
+
+	// component name -> image tag
+	// +optional
+	ImageTags map[string]string `json:"imageTags,omitempty"`
+
+	// The environment is deleted after this many seconds since it's created, 3 days by default.
+	// +kubebuilder:validation:Minimum=60
+	// +optional
+	TTLSeconds *int32 `json:"ttlSeconds,omitempty"`
+}
+
+// PreviewEnvironmentStatus defines the observed state of PreviewEnvironment
+type PreviewEnvironmentStatus struct {
+	// The namespace of the cloned application
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// The first entry of urls, it's the one CI should post
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// +optional
+	URLs []string `json:"urls,omitempty"`
+
+	// All components of the environment are ready
+	// +optional
+	Ready bool `json:"ready,omitempty"`
+
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Application",type="string",JSONPath=".spec.application"
+// +kubebuilder:printcolumn:name="PR",type="integer",JSONPath=".spec.pullRequest"
+// +kubebuilder:printcolumn:name="URL",type="string",JSONPath=".status.url"
+// +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready"
+// +kubebuilder:printcolumn:name="Expires",type="date",JSONPath=".status.expiresAt"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// PreviewEnvironment is an ephemeral copy of an application for a pull request.
+// The namespace of the copy is owned by it, and both are deleted when it expires.
+type PreviewEnvironment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PreviewEnvironmentSpec   `json:"spec"`
+	Status PreviewEnvironmentStatus `json:"status,omitempty"`
+}
+
+// GetTTL returns the ttl in seconds
+func (p *PreviewEnvironment) GetTTL() int32 {
+	if p.Spec.TTLSeconds != nil {
+		return *p.Spec.TTLSeconds
+	}
+
+	return DefaultPreviewEnvironmentTTLSeconds
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// PreviewEnvironmentList contains a list of PreviewEnvironment
+type PreviewEnvironmentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PreviewEnvironment `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PreviewEnvironment{}, &PreviewEnvironmentList{})
+}
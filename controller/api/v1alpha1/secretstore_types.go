@@ -0,0 +1,86 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Each application has one SecretStore with this name, managed by the api.
+	// The controller decrypts it into a Secret with the same name, which is referenced by env vars of type secret.
+	SecretStoreName = "kalm-secrets"
+
+	// Annotation on pod templates, changes when secrets used by the component are rotated, so the pods are restarted.
+	AnnoSecretsVersion = "core.kalm.dev/secrets-version"
+)
+
+type SecretStoreItem struct {
+	// Name of the secret, it's also the key in the decrypted Secret.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// value encrypted with the cluster key, see utils/secretbox
+	EncryptedValue string `json:"encryptedValue"`
+
+	// increases every time the value is rotated
+	// +kubebuilder:validation:Minimum=1
+	Version int64 `json:"version"`
+
+	// +optional
+	UpdatedBy string `json:"updatedBy,omitempty"`
+
+	// +optional
+	UpdatedAt metav1.Time `json:"updatedAt,omitempty"`
+}
+
+// SecretStoreSpec defines the desired state of SecretStore
+type SecretStoreSpec struct {
+	// +optional
+	Secrets []SecretStoreItem `json:"secrets,omitempty"`
+}
+
+// SecretStoreStatus defines the observed state of SecretStore
+type SecretStoreStatus struct {
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// the error of the last decryption, empty if all secrets are decrypted.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// SecretStore is the Schema for the secretstores API
+type SecretStore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SecretStoreSpec   `json:"spec,omitempty"`
+	Status SecretStoreStatus `json:"status,omitempty"`
+}
+
+// GetSecret returns the item with the given name, nil if it doesn't exist.
+func (s *SecretStore) GetSecret(name string) *SecretStoreItem {
+	for i := range s.Spec.Secrets {
+		if s.Spec.Secrets[i].Name == name {
+			return &s.Spec.Secrets[i]
+		}
+	}
+
+	return nil
+}
+
+// +kubebuilder:object:root=true
+
+// SecretStoreList contains a list of SecretStore
+type SecretStoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SecretStore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SecretStore{}, &SecretStoreList{})
+}
@@ -0,0 +1,136 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// AnnoSecurityPolicy is the annotation of a namespace holding its SecurityPolicy in json.
+// Components of the namespace are rejected by the webhook if they don't satisfy the policy.
+const AnnoSecurityPolicy = "core.kalm.dev/security-policy"
+
+// SecurityPolicy requires or forbids security settings of components in a namespace.
+// e.g. {"requireRunAsNonRoot": true} rejects root containers in a production app.
+type SecurityPolicy struct {
+	// runAsNonRoot must be true, or runAsUser must be a non-root uid
+	RequireRunAsNonRoot bool `json:"requireRunAsNonRoot,omitempty"`
+
+	RequireReadOnlyRootFilesystem bool `json:"requireReadOnlyRootFilesystem,omitempty"`
+
+	// allowPrivilegeEscalation must be explicitly set to false
+	ForbidPrivilegeEscalation bool `json:"forbidPrivilegeEscalation,omitempty"`
+
+	ForbidPrivileged bool `json:"forbidPrivileged,omitempty"`
+
+	// capabilities that must be dropped, e.g. ALL
+	RequiredDropCapabilities []v1.Capability `json:"requiredDropCapabilities,omitempty"`
+
+	// capabilities that can be added, any capability can be added if empty
+	AllowedAddCapabilities []v1.Capability `json:"allowedAddCapabilities,omitempty"`
+
+	// seccompProfile must be set and must not be Unconfined
+	RequireSeccompProfile bool `json:"requireSeccompProfile,omitempty"`
+}
+
+// GetNamespaceSecurityPolicy returns the policy in the annotations of the namespace, nil if there is no policy.
+func GetNamespaceSecurityPolicy(namespace *v1.Namespace) (*SecurityPolicy, error) {
+	raw, exist := namespace.Annotations[AnnoSecurityPolicy]
+
+	if !exist || strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var policy SecurityPolicy
+
+	if err := json.Unmarshal([]byte(raw), &policy); err != nil {
+		return nil, fmt.Errorf("invalid security policy of namespace %s: %s", namespace.Name, err.Error())
+	}
+
+	return &policy, nil
+}
+
+// Check returns the settings of the component that violate the policy.
+func (p *SecurityPolicy) Check(component *Component) (rst KalmValidateErrorList) {
+	sc := component.Spec.SecurityContext
+
+	if sc == nil {
+		sc = &SecurityContext{}
+	}
+
+	isTrue := func(b *bool) bool { return b != nil && *b }
+
+	if p.RequireRunAsNonRoot && !isTrue(sc.RunAsNonRoot) && (sc.RunAsUser == nil || *sc.RunAsUser == 0) {
+		rst = append(rst, KalmValidateError{
+			Err:  "security policy of the namespace forbids root containers, set runAsNonRoot or a non-root runAsUser",
+			Path: ".spec.securityContext.runAsNonRoot",
+		})
+	}
+
+	if p.RequireReadOnlyRootFilesystem && !isTrue(sc.ReadOnlyRootFilesystem) {
+		rst = append(rst, KalmValidateError{
+			Err:  "security policy of the namespace requires readOnlyRootFilesystem",
+			Path: ".spec.securityContext.readOnlyRootFilesystem",
+		})
+	}
+
+	if p.ForbidPrivilegeEscalation && (sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation) {
+		rst = append(rst, KalmValidateError{
+			Err:  "security policy of the namespace requires allowPrivilegeEscalation to be false",
+			Path: ".spec.securityContext.allowPrivilegeEscalation",
+		})
+	}
+
+	if p.ForbidPrivileged && isTrue(sc.Privileged) {
+		rst = append(rst, KalmValidateError{
+			Err:  "security policy of the namespace forbids privileged containers",
+			Path: ".spec.securityContext.privileged",
+		})
+	}
+
+	for _, capability := range p.RequiredDropCapabilities {
+		if !containsCapability(sc.DropCapabilities, capability) {
+			rst = append(rst, KalmValidateError{
+				Err:  fmt.Sprintf("security policy of the namespace requires dropping capability %s", capability),
+				Path: ".spec.securityContext.dropCapabilities",
+			})
+		}
+	}
+
+	if len(p.AllowedAddCapabilities) > 0 {
+		for i, capability := range sc.AddCapabilities {
+			if !containsCapability(p.AllowedAddCapabilities, capability) {
+				rst = append(rst, KalmValidateError{
+					Err:  fmt.Sprintf("security policy of the namespace doesn't allow adding capability %s", capability),
+					Path: fmt.Sprintf(".spec.securityContext.addCapabilities[%d]", i),
+				})
+			}
+		}
+	}
+
+	if p.RequireSeccompProfile && (sc.SeccompProfile == nil || sc.SeccompProfile.Type == SeccompProfileUnconfined) {
+		rst = append(rst, KalmValidateError{
+			Err:  "security policy of the namespace requires a confined seccompProfile",
+			Path: ".spec.securityContext.seccompProfile",
+		})
+	}
+
+	return rst
+}
+
+// capabilities are case insensitive, the CAP_ prefix is optional
+func containsCapability(capabilities []v1.Capability, capability v1.Capability) bool {
+	normalize := func(c v1.Capability) string {
+		return strings.TrimPrefix(strings.ToUpper(string(c)), "CAP_")
+	}
+
+	for _, c := range capabilities {
+		if normalize(c) == normalize(capability) {
+			return true
+		}
+	}
+
+	return false
+}
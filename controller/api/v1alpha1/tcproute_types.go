@@ -0,0 +1,110 @@
+package v1alpha1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:validation:Enum=tcp;tls
+type TcpRouteProtocol string
+
+const (
+	// All connections to the port are forwarded to the destinations
+	TcpRouteProtocolTCP TcpRouteProtocol = "tcp"
+
+	// Connections are routed by the server name (SNI) of the tls handshake, tls is terminated by the destinations, not the gateway
+	TcpRouteProtocolTLS TcpRouteProtocol = "tls"
+)
+
+type TcpRouteDestination struct {
+	// Service of the destination in format of "name[:port]", "name.namespace[:port]" or "name.namespace.svc.cluster.local[:port]"
+	// +kubebuilder:validation:MinLength=1
+	Host string `json:"host"`
+
+	// +kubebuilder:validation:Minimum=0
+	Weight int `json:"weight"`
+}
+
+// TcpRouteSpec defines the desired state of TcpRoute
+type TcpRouteSpec struct {
+	// The port opened on the ingress gateway
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int `json:"port"`
+
+	// +kubebuilder:validation:Enum=tcp;tls
+	Protocol TcpRouteProtocol `json:"protocol"`
+
+	// Server names routed to the destinations, required by tls routes. Tcp routes accept all connections of the port.
+	// +optional
+	Hosts []string `json:"hosts,omitempty"`
+
+	// +kubebuilder:validation:MinItems=1
+	Destinations []TcpRouteDestination `json:"destinations"`
+}
+
+// TcpRouteStatus defines the observed state of TcpRoute
+type TcpRouteStatus struct {
+	// The route is served by the gateway
+	Accepted bool `json:"accepted"`
+
+	// Why the route is not accepted
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Protocol",type="string",JSONPath=".spec.protocol"
+// +kubebuilder:printcolumn:name="Port",type="integer",JSONPath=".spec.port"
+// +kubebuilder:printcolumn:name="Accepted",type="boolean",JSONPath=".status.accepted"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// TcpRoute exposes tcp services, e.g. databases and message brokers, on an extra port of the ingress gateway.
+type TcpRoute struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TcpRouteSpec   `json:"spec,omitempty"`
+	Status TcpRouteStatus `json:"status,omitempty"`
+}
+
+// ConflictsWith explains why the two routes can't be served at the same time, or returns an empty string.
+// A port is used by either a tcp route or tls routes, and a server name of a port is claimed by one tls route.
+func (r *TcpRoute) ConflictsWith(other *TcpRoute) string {
+	if r.Spec.Port != other.Spec.Port {
+		return ""
+	}
+
+	if r.Spec.Protocol != other.Spec.Protocol {
+		return fmt.Sprintf("port %d is used by %s route %s/%s", r.Spec.Port, other.Spec.Protocol, other.Namespace, other.Name)
+	}
+
+	if r.Spec.Protocol == TcpRouteProtocolTCP {
+		return fmt.Sprintf("port %d is already routed by %s/%s", r.Spec.Port, other.Namespace, other.Name)
+	}
+
+	for _, host := range r.Spec.Hosts {
+		for _, otherHost := range other.Spec.Hosts {
+			if host == otherHost {
+				return fmt.Sprintf("%s on port %d is already routed by %s/%s", host, r.Spec.Port, other.Namespace, other.Name)
+			}
+		}
+	}
+
+	return ""
+}
+
+// +kubebuilder:object:root=true
+
+// TcpRouteList contains a list of TcpRoute
+type TcpRouteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TcpRoute `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&TcpRoute{}, &TcpRouteList{})
+}
@@ -0,0 +1,168 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var tcproutelog = logf.Log.WithName("tcproute-resource")
+
+// used to find routes on the same port, nil if webhook is not set up with a manager
+var tcpRouteReader client.Reader
+
+// ports of the ingress gateway used by http routes and istio
+var reservedGatewayPorts = map[int]bool{
+	80:    true,
+	443:   true,
+	15000: true,
+	15020: true,
+	15021: true,
+	15090: true,
+	15443: true,
+}
+
+func (r *TcpRoute) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	tcpRouteReader = mgr.GetAPIReader()
+
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-core-kalm-dev-v1alpha1-tcproute,mutating=false,failurePolicy=fail,groups=core.kalm.dev,resources=tcproutes,versions=v1alpha1,name=vtcproute.kb.io
+
+var _ webhook.Validator = &TcpRoute{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *TcpRoute) ValidateCreate() error {
+	tcproutelog.Info("validate create", "name", r.Name)
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *TcpRoute) ValidateUpdate(old runtime.Object) error {
+	tcproutelog.Info("validate update", "name", r.Name)
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *TcpRoute) ValidateDelete() error {
+	tcproutelog.Info("validate delete", "name", r.Name)
+	return nil
+}
+
+func (r *TcpRoute) validate() error {
+	var rst KalmValidateErrorList
+
+	if r.Spec.Port < 1 || r.Spec.Port > 65535 {
+		rst = append(rst, KalmValidateError{
+			Err:  "port should be in range [1, 65535]",
+			Path: "spec.port",
+		})
+	} else if reservedGatewayPorts[r.Spec.Port] {
+		rst = append(rst, KalmValidateError{
+			Err:  fmt.Sprintf("port %d is reserved by the gateway", r.Spec.Port),
+			Path: "spec.port",
+		})
+	}
+
+	switch r.Spec.Protocol {
+	case TcpRouteProtocolTCP:
+		if len(r.Spec.Hosts) > 0 {
+			rst = append(rst, KalmValidateError{
+				Err:  "hosts can't be used by tcp routes, connections are not routed by server names",
+				Path: "spec.hosts",
+			})
+		}
+	case TcpRouteProtocolTLS:
+		if len(r.Spec.Hosts) == 0 {
+			rst = append(rst, KalmValidateError{
+				Err:  "hosts are required by tls routes",
+				Path: "spec.hosts",
+			})
+		}
+
+		for i, host := range r.Spec.Hosts {
+			if !isValidDomain(host) && !isValidWildcardDomain(host) {
+				rst = append(rst, KalmValidateError{
+					Err:  "invalid server name:" + host,
+					Path: fmt.Sprintf("spec.hosts[%d]", i),
+				})
+			}
+		}
+	default:
+		rst = append(rst, KalmValidateError{
+			Err:  "protocol should be tcp or tls",
+			Path: "spec.protocol",
+		})
+	}
+
+	if len(r.Spec.Destinations) == 0 {
+		rst = append(rst, KalmValidateError{
+			Err:  "at least one destination is required",
+			Path: "spec.destinations",
+		})
+	}
+
+	for i, dest := range r.Spec.Destinations {
+		if !isValidDestinationHost(dest.Host) {
+			rst = append(rst, KalmValidateError{
+				Err:  "invalid destination host:" + dest.Host,
+				Path: fmt.Sprintf("spec.destinations[%d].host", i),
+			})
+		}
+	}
+
+	rst = append(rst, r.validateConflicts()...)
+
+	if len(rst) == 0 {
+		return nil
+	}
+
+	return rst
+}
+
+func (r *TcpRoute) validateConflicts() KalmValidateErrorList {
+	if tcpRouteReader == nil {
+		return nil
+	}
+
+	var routeList TcpRouteList
+
+	if err := tcpRouteReader.List(context.Background(), &routeList); err != nil {
+		tcproutelog.Error(err, "list tcp routes error")
+
+		return KalmValidateErrorList{{
+			Err:  "unable to check routes on the same port: " + err.Error(),
+			Path: "spec.port",
+		}}
+	}
+
+	return r.findConflicts(routeList.Items)
+}
+
+func (r *TcpRoute) findConflicts(routes []TcpRoute) (rst KalmValidateErrorList) {
+	for i := range routes {
+		other := &routes[i]
+
+		if other.Namespace == r.Namespace && other.Name == r.Name {
+			continue
+		}
+
+		if msg := r.ConflictsWith(other); msg != "" {
+			rst = append(rst, KalmValidateError{
+				Err:  msg,
+				Path: "spec.port",
+			})
+		}
+	}
+
+	return rst
+}
@@ -0,0 +1,66 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func newTestTcpRoute(namespace, name string, port int, protocol TcpRouteProtocol, hosts ...string) TcpRoute {
+	return TcpRoute{
+		ObjectMeta: ctrl.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: TcpRouteSpec{
+			Port:         port,
+			Protocol:     protocol,
+			Hosts:        hosts,
+			Destinations: []TcpRouteDestination{{Host: "postgres:5432", Weight: 1}},
+		},
+	}
+}
+
+func TestTcpRoute_Validate(t *testing.T) {
+	route := newTestTcpRoute("db", "postgres", 5432, TcpRouteProtocolTCP)
+	assert.Nil(t, route.validate())
+
+	route.Spec.Port = 443
+	route.Spec.Hosts = []string{"db.example.com"}
+	route.Spec.Destinations = append(route.Spec.Destinations, TcpRouteDestination{Host: "bad_host"})
+
+	assert.Equal(t, []string{
+		"spec.port",
+		"spec.hosts",
+		"spec.destinations[1].host",
+	}, errorPaths(route.validate().(KalmValidateErrorList)))
+
+	route = newTestTcpRoute("mqtt", "broker", 8883, TcpRouteProtocolTLS, "mqtt.example.com", "*.mqtt.example.com")
+	assert.Nil(t, route.validate())
+
+	route.Spec.Hosts = []string{"not a host"}
+	assert.Equal(t, []string{"spec.hosts[0]"}, errorPaths(route.validate().(KalmValidateErrorList)))
+
+	route.Spec.Hosts = nil
+	assert.Equal(t, []string{"spec.hosts"}, errorPaths(route.validate().(KalmValidateErrorList)))
+}
+
+func TestTcpRoute_FindConflicts(t *testing.T) {
+	routes := []TcpRoute{
+		newTestTcpRoute("db", "postgres", 5432, TcpRouteProtocolTCP),
+		newTestTcpRoute("mqtt", "broker", 8883, TcpRouteProtocolTLS, "mqtt.example.com"),
+	}
+
+	route := newTestTcpRoute("db", "postgres", 5432, TcpRouteProtocolTCP)
+	assert.Empty(t, route.findConflicts(routes))
+
+	route = newTestTcpRoute("db2", "postgres", 5432, TcpRouteProtocolTCP)
+	assert.Equal(t, KalmValidateErrorList{{Err: "port 5432 is already routed by db/postgres", Path: "spec.port"}}, route.findConflicts(routes))
+
+	route = newTestTcpRoute("db2", "postgres", 5432, TcpRouteProtocolTLS, "db.example.com")
+	assert.Equal(t, KalmValidateErrorList{{Err: "port 5432 is used by tcp route db/postgres", Path: "spec.port"}}, route.findConflicts(routes))
+
+	route = newTestTcpRoute("mqtt2", "broker", 8883, TcpRouteProtocolTLS, "mqtt2.example.com")
+	assert.Empty(t, route.findConflicts(routes))
+
+	route.Spec.Hosts = append(route.Spec.Hosts, "mqtt.example.com")
+	assert.Equal(t, KalmValidateErrorList{{Err: "mqtt.example.com on port 8883 is already routed by mqtt/broker", Path: "spec.port"}}, route.findConflicts(routes))
+}
@@ -1,154 +1,83 @@
 package v1alpha1
 
 import (
-	"container/list"
 	"fmt"
+	"strings"
 )
 
-// 1. check if there is any loop in dependency graph
-//func isValidateDependency(spec ComponentSpec) KalmValidateErrorList {
-//	//spec := app.Spec
-//
-//	// build graph
-//	nodeMap := buildDependencyGraph(spec)
-//	loopExist, nodesInLoop := bfsCheckIfLoopExist(nodeMap)
-//
-//	if !loopExist {
-//		return nil
-//	}
-//
-//	var errs []KalmValidateError
-//	for _, node := range nodesInLoop {
-//		errs = append(errs, KalmValidateError{
-//			Err:  "dependency loop exist",
-//			Path: getJsonPathOfWrongField(node.Name, spec),
-//		})
-//	}
-//
-//	return errs
-//}
-
-//func getJsonPathOfWrongField(name string, spec ApplicationSpec) string {
-//	idx := idxOfComponent(name, spec)
-//
-//	return fmt.Sprintf(".components[%d].dependencies", idx)
-//}
-//
-//func idxOfComponent(componentName string, spec ApplicationSpec) int {
-//	for i := range spec.Components {
-//		if componentName == spec.Components[i].Name {
-//			return i
-//		}
-//	}
-//
-//	return -1
-//}
-
-// componentName -> node
-func buildDependencyGraph(component Component) map[string]*node {
-	var nodeMap = make(map[string]*node)
-	//for _, component := range spec.Components {
-
-	if _, exist := nodeMap[component.Name]; !exist {
-		nodeMap[component.Name] = &node{
-			Name: component.Name,
+// isValidateDependency checks if the component is in a loop of the dependency graph of components in the namespace.
+// components are the existing components, the one being validated replaces the stored version of itself.
+func isValidateDependency(component Component, components []Component) KalmValidateErrorList {
+	var rst KalmValidateErrorList
+
+	for i, dep := range component.Spec.StartAfterComponents {
+		if dep == component.Name {
+			rst = append(rst, KalmValidateError{
+				Err:  "component can't start after itself",
+				Path: fmt.Sprintf(".spec.startAfterComponents[%d]", i),
+			})
 		}
 	}
-	curNode := nodeMap[component.Name]
 
-	for _, dep := range component.Spec.StartAfterComponents {
-		if _, exist := nodeMap[dep]; !exist {
-			nodeMap[dep] = &node{
-				Name: dep,
-			}
-		}
-
-		nodeMap[dep].Refed = append(nodeMap[dep].Refed, curNode)
-		//fmt.Println("> node:", dep, "refedBy:", nodeMap[dep].Refed[0])
+	if len(rst) > 0 {
+		return rst
 	}
 
-	//fmt.Println("node in depGraph:", component.Name, "dependOn:", component.StartAfterComponents)
-	//}
+	graph := buildDependencyGraph(append([]Component{component}, components...))
+	loop := findDependencyLoop(graph, component.Name)
 
-	//fmt.Println("graph size:", len(nodeMap))
-	return nodeMap
-}
-
-func bfsCheckIfLoopExist(nodeMap map[string]*node) (bool, []*node) {
-	// bfs
-	var queue = list.New()
-	for _, v := range nodeMap {
-		if len(v.Refed) <= 0 {
-			queue.PushBack(v)
-		}
+	if loop == nil {
+		return nil
 	}
 
-	for queue.Len() > 0 {
-		size := queue.Len()
-		fmt.Println("queue size of 0-degree nodes:", size)
-
-		for i := 0; i < size; i++ {
-			curEle := queue.Front()
-			queue.Remove(curEle)
+	return KalmValidateErrorList{{
+		Err:  "dependency loop exist: " + strings.Join(loop, " -> "),
+		Path: ".spec.startAfterComponents",
+	}}
+}
 
-			curNode := curEle.Value.(*node)
-			for _, n := range nodeMap {
-				if existNode(n.Refed, curNode) {
-					n.Refed = removeFromSlice(n.Refed, curNode)
-				}
-			}
+// componentName -> names of components it starts after.
+// If a component appears more than once, the first one wins.
+func buildDependencyGraph(components []Component) map[string][]string {
+	var graph = make(map[string][]string)
 
-			//delete this node from map
-			delete(nodeMap, curNode.Name)
+	for _, component := range components {
+		if _, exist := graph[component.Name]; exist {
+			continue
 		}
 
-		fmt.Println("map size after rm 0-inDegree nodes:", len(nodeMap))
-
-		// recheck in-degree zero nodes
-		for _, v := range nodeMap {
-			fmt.Println("leftNode:", v)
-
-			if len(v.Refed) <= 0 {
-				queue.PushBack(v)
-			}
-		}
+		graph[component.Name] = component.Spec.StartAfterComponents
 	}
 
-	loopExist := len(nodeMap) > 0
+	return graph
+}
 
-	var nodesInLoop []*node
-	for _, node := range nodeMap {
-		nodesInLoop = append(nodesInLoop, node)
-	}
+// findDependencyLoop returns the path from the component back to itself, or nil if the component is not in a loop.
+// Loops which the component only depends on are not reported, they are rejected when they are created.
+func findDependencyLoop(graph map[string][]string, name string) []string {
+	visited := make(map[string]bool)
 
-	return loopExist, nodesInLoop
-}
+	var dfs func(cur string, path []string) []string
 
-func existNode(slice []*node, target *node) bool {
-	for _, node := range slice {
-		if node == target {
-			return true
-		}
-	}
+	dfs = func(cur string, path []string) []string {
+		for _, dep := range graph[cur] {
+			if dep == name {
+				return append(path, dep)
+			}
 
-	return false
-}
+			if visited[dep] {
+				continue
+			}
 
-func removeFromSlice(slice []*node, target *node) []*node {
-	for i := 0; i < len(slice); i++ {
-		if slice[i] == target {
-			// overwrite by head, and remove without head
-			slice[i] = slice[0]
-			slice = slice[1:]
+			visited[dep] = true
 
-			return slice
+			if loop := dfs(dep, append(path, dep)); loop != nil {
+				return loop
+			}
 		}
-	}
 
-	return slice
-}
+		return nil
+	}
 
-type node struct {
-	Name  string
-	Refed []*node
+	return dfs(name, []string{name})
 }
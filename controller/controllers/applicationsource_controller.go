@@ -0,0 +1,541 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/utils/gitrepo"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const defaultApplicationSourceIntervalSeconds = 180
+
+// kinds which can be synced from a repository, all of them are namespaced
+var applicationSourceKinds = []schema.GroupVersionKind{
+	coreV1.SchemeGroupVersion.WithKind("ConfigMap"),
+	corev1alpha1.GroupVersion.WithKind("SecretStore"),
+	corev1alpha1.GroupVersion.WithKind("Component"),
+	corev1alpha1.GroupVersion.WithKind("ComponentPluginBinding"),
+	corev1alpha1.GroupVersion.WithKind("ProtectedEndpoint"),
+	corev1alpha1.GroupVersion.WithKind("HttpRoute"),
+}
+
+// ApplicationSourceReconciler syncs manifests in git repositories into applications
+type ApplicationSourceReconciler struct {
+	*BaseReconciler
+}
+
+type ApplicationSourceReconcilerTask struct {
+	*ApplicationSourceReconciler
+	ctx    context.Context
+	source *corev1alpha1.ApplicationSource
+
+	drift   []corev1alpha1.ApplicationSourceObject
+	changed bool
+}
+
+// +kubebuilder:rbac:groups=core.kalm.dev,resources=applicationsources,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.kalm.dev,resources=applicationsources/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+
+func (r *ApplicationSourceReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	var source corev1alpha1.ApplicationSource
+
+	if err := r.Get(context.Background(), req.NamespacedName, &source); err != nil {
+		if errors.IsNotFound(err) {
+			os.RemoveAll(getApplicationSourceDir(req.Name))
+		}
+
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	interval := time.Duration(defaultApplicationSourceIntervalSeconds) * time.Second
+
+	if source.Spec.IntervalSeconds != nil {
+		interval = time.Duration(*source.Spec.IntervalSeconds) * time.Second
+	}
+
+	task := &ApplicationSourceReconcilerTask{
+		ApplicationSourceReconciler: r,
+		ctx:                         context.Background(),
+		source:                      &source,
+	}
+
+	// errors are recorded in status, the repo is pulled again in next interval instead of backoff
+	return ctrl.Result{RequeueAfter: interval}, task.Run()
+}
+
+func getApplicationSourceDir(name string) string {
+	return filepath.Join(os.TempDir(), "kalm-application-sources", name)
+}
+
+func (r *ApplicationSourceReconcilerTask) Run() error {
+	status := r.source.Status.DeepCopy()
+	status.ObservedGeneration = r.source.Generation
+
+	objects, sha, err := r.sync()
+
+	if sha != "" {
+		if sha != status.Revision {
+			r.changed = true
+		}
+
+		status.Revision = sha
+	}
+
+	if err != nil {
+		r.EmitWarningEvent(r.source, err, "sync application source failed")
+		status.SyncState = corev1alpha1.ApplicationSourceFailed
+		status.Message = err.Error()
+	} else {
+		status.Message = ""
+		status.Objects = objects
+
+		if len(r.drift) > 0 {
+			status.SyncState = corev1alpha1.ApplicationSourceOutOfSync
+		} else {
+			status.SyncState = corev1alpha1.ApplicationSourceSynced
+		}
+	}
+
+	status.Drift = r.drift
+
+	if r.changed {
+		now := metaV1.Now()
+		status.LastSyncedAt = &now
+	}
+
+	if reflect.DeepEqual(*status, r.source.Status) {
+		return nil
+	}
+
+	copied := r.source.DeepCopy()
+	copied.Status = *status
+
+	return r.Status().Patch(r.ctx, copied, client.MergeFrom(r.source))
+}
+
+// sync pulls the repository and applies the manifests, objects in the repository are returned.
+func (r *ApplicationSourceReconcilerTask) sync() ([]corev1alpha1.ApplicationSourceObject, string, error) {
+	spec := r.source.Spec
+	dir := getApplicationSourceDir(r.source.Name)
+
+	// the webhook may be disabled
+	if !corev1alpha1.IsValidGitRepo(spec.Repo) {
+		return nil, "", fmt.Errorf("repo %s is not allowed", spec.Repo)
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, 2*time.Minute)
+	defer cancel()
+
+	sha, err := gitrepo.Checkout(ctx, spec.Repo, spec.Revision, dir)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	manifestsDir := filepath.Join(dir, filepath.Clean("/"+spec.Path))
+	manifests, err := loadApplicationSourceManifests(manifestsDir, spec.TargetNamespace)
+
+	if err != nil {
+		return nil, sha, err
+	}
+
+	var namespace coreV1.Namespace
+
+	if err := r.Get(r.ctx, types.NamespacedName{Name: spec.TargetNamespace}, &namespace); err != nil {
+		return nil, sha, err
+	}
+
+	if !IsNamespaceKalmEnabled(namespace) {
+		return nil, sha, fmt.Errorf("namespace %s is not a kalm application", spec.TargetNamespace)
+	}
+
+	objects := make([]corev1alpha1.ApplicationSourceObject, 0, len(manifests))
+	desired := make(map[corev1alpha1.ApplicationSourceObject]bool, len(manifests))
+
+	for _, manifest := range manifests {
+		labels := manifest.GetLabels()
+
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+
+		labels[corev1alpha1.ApplicationSourceLabelName] = r.source.Name
+		manifest.SetLabels(labels)
+
+		if err := r.applyObject(manifest); err != nil {
+			return nil, sha, err
+		}
+
+		object := corev1alpha1.ApplicationSourceObject{Kind: manifest.GetKind(), Name: manifest.GetName()}
+		objects = append(objects, object)
+		desired[object] = true
+	}
+
+	if spec.Prune {
+		if err := r.pruneObjects(desired); err != nil {
+			return nil, sha, err
+		}
+	}
+
+	return objects, sha, nil
+}
+
+// applyObject creates or updates the object, or records the drift if the source is suspended.
+func (r *ApplicationSourceReconcilerTask) applyObject(desired *unstructured.Unstructured) error {
+	object := corev1alpha1.ApplicationSourceObject{Kind: desired.GetKind(), Name: desired.GetName()}
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(desired.GroupVersionKind())
+
+	err := r.Reader.Get(r.ctx, types.NamespacedName{Namespace: desired.GetNamespace(), Name: desired.GetName()}, existing)
+
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+
+		if r.source.Spec.Suspend {
+			r.drift = append(r.drift, object)
+			return nil
+		}
+
+		if err := r.Create(r.ctx, desired); err != nil {
+			return err
+		}
+
+		r.changed = true
+		r.EmitNormalEvent(r.source, "ObjectCreated", "%s %s is created.", object.Kind, object.Name)
+
+		return nil
+	}
+
+	if existing.GetLabels()[corev1alpha1.ApplicationSourceLabelName] != r.source.Name {
+		return fmt.Errorf("%s %s already exists and is not managed by this source", object.Kind, object.Name)
+	}
+
+	if !isApplicationSourceObjectDrifted(desired, existing) {
+		return nil
+	}
+
+	if r.source.Spec.Suspend {
+		r.drift = append(r.drift, object)
+		return nil
+	}
+
+	copied := existing.DeepCopy()
+
+	for k, v := range desired.Object {
+		if k == "apiVersion" || k == "kind" || k == "metadata" || k == "status" {
+			continue
+		}
+
+		copied.Object[k] = v
+	}
+
+	copied.SetLabels(mergeStringMap(existing.GetLabels(), desired.GetLabels()))
+	copied.SetAnnotations(mergeStringMap(existing.GetAnnotations(), desired.GetAnnotations()))
+
+	if err := r.Patch(r.ctx, copied, client.MergeFrom(existing)); err != nil {
+		return err
+	}
+
+	r.changed = true
+	r.EmitNormalEvent(r.source, "ObjectUpdated", "%s %s is updated.", object.Kind, object.Name)
+
+	return nil
+}
+
+// pruneObjects deletes objects applied from this source which are removed from the repository.
+func (r *ApplicationSourceReconcilerTask) pruneObjects(desired map[corev1alpha1.ApplicationSourceObject]bool) error {
+	for _, gvk := range applicationSourceKinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+
+		if err := r.Reader.List(
+			r.ctx,
+			list,
+			client.InNamespace(r.source.Spec.TargetNamespace),
+			client.MatchingLabels{corev1alpha1.ApplicationSourceLabelName: r.source.Name},
+		); err != nil {
+			return err
+		}
+
+		for i := range list.Items {
+			object := corev1alpha1.ApplicationSourceObject{Kind: gvk.Kind, Name: list.Items[i].GetName()}
+
+			if desired[object] {
+				continue
+			}
+
+			if r.source.Spec.Suspend {
+				r.drift = append(r.drift, object)
+				continue
+			}
+
+			if err := r.Delete(r.ctx, &list.Items[i]); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+
+			r.changed = true
+			r.EmitNormalEvent(r.source, "ObjectPruned", "%s %s is removed from the repository, it's deleted.", object.Kind, object.Name)
+		}
+	}
+
+	return nil
+}
+
+// isApplicationSourceObjectDrifted returns true if fields in the manifest are different in the cluster.
+// Fields only in the cluster, such as defaults set by webhooks, are not drift.
+func isApplicationSourceObjectDrifted(desired, existing *unstructured.Unstructured) bool {
+	for k, v := range desired.Object {
+		if k == "apiVersion" || k == "kind" || k == "metadata" || k == "status" {
+			continue
+		}
+
+		if !isSubsetOf(v, existing.Object[k]) {
+			return true
+		}
+	}
+
+	return !isSubsetOf(toInterfaceMap(desired.GetLabels()), toInterfaceMap(existing.GetLabels())) ||
+		!isSubsetOf(toInterfaceMap(desired.GetAnnotations()), toInterfaceMap(existing.GetAnnotations()))
+}
+
+// isSubsetOf compares decoded json values, maps in a can have less keys than maps in b.
+func isSubsetOf(a, b interface{}) bool {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+
+		if !ok {
+			return len(av) == 0 && b == nil
+		}
+
+		for k, v := range av {
+			if !isSubsetOf(v, bv[k]) {
+				return false
+			}
+		}
+
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+
+		if !ok || len(av) != len(bv) {
+			return len(av) == 0 && b == nil
+		}
+
+		for i := range av {
+			if !isSubsetOf(av[i], bv[i]) {
+				return false
+			}
+		}
+
+		return true
+	case nil:
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func toInterfaceMap(m map[string]string) map[string]interface{} {
+	res := make(map[string]interface{}, len(m))
+
+	for k, v := range m {
+		res[k] = v
+	}
+
+	return res
+}
+
+func mergeStringMap(base, override map[string]string) map[string]string {
+	res := make(map[string]string, len(base)+len(override))
+
+	for k, v := range base {
+		res[k] = v
+	}
+
+	for k, v := range override {
+		res[k] = v
+	}
+
+	return res
+}
+
+func isApplicationSourceKind(gvk schema.GroupVersionKind) bool {
+	for _, kind := range applicationSourceKinds {
+		if kind == gvk {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadApplicationSourceManifests reads yaml and json files in the dir and its sub dirs, files can have multiple documents.
+// Manifests are moved into the namespace, a manifest in another namespace is an error.
+func loadApplicationSourceManifests(dir, namespace string) ([]*unstructured.Unstructured, error) {
+	var res []*unstructured.Unstructured
+	seen := make(map[string]string)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if path != dir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+
+		file, err := os.Open(path)
+
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		relPath, _ := filepath.Rel(dir, path)
+		decoder := utilyaml.NewYAMLOrJSONDecoder(file, 4096)
+
+		for {
+			var raw json.RawMessage
+
+			if err := decoder.Decode(&raw); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+
+				return fmt.Errorf("%s: %s", relPath, err.Error())
+			}
+
+			if trimmed := bytes.TrimSpace(raw); len(trimmed) == 0 || string(trimmed) == "null" {
+				continue
+			}
+
+			obj := &unstructured.Unstructured{}
+
+			if err := obj.UnmarshalJSON(raw); err != nil {
+				return fmt.Errorf("%s: %s", relPath, err.Error())
+			}
+
+			if !isApplicationSourceKind(obj.GroupVersionKind()) {
+				return fmt.Errorf("%s: %s %s is not allowed in application sources", relPath, obj.GetAPIVersion(), obj.GetKind())
+			}
+
+			if obj.GetName() == "" {
+				return fmt.Errorf("%s: name of %s is required", relPath, obj.GetKind())
+			}
+
+			if obj.GetNamespace() != "" && obj.GetNamespace() != namespace {
+				return fmt.Errorf("%s: %s %s is in namespace %s, not the target namespace", relPath, obj.GetKind(), obj.GetName(), obj.GetNamespace())
+			}
+
+			key := obj.GetKind() + "/" + obj.GetName()
+
+			if previous, exist := seen[key]; exist {
+				return fmt.Errorf("%s: %s is already defined in %s", relPath, key, previous)
+			}
+
+			seen[key] = relPath
+			obj.SetNamespace(namespace)
+			res = append(res, obj)
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// keep the order of applicationSourceKinds, files and secrets are created before components using them
+	sort.SliceStable(res, func(i, j int) bool {
+		return applicationSourceKindIndex(res[i].GroupVersionKind()) < applicationSourceKindIndex(res[j].GroupVersionKind())
+	})
+
+	return res, nil
+}
+
+func applicationSourceKindIndex(gvk schema.GroupVersionKind) int {
+	for i, kind := range applicationSourceKinds {
+		if kind == gvk {
+			return i
+		}
+	}
+
+	return len(applicationSourceKinds)
+}
+
+// SuspendApplicationSourceOf pauses the sync of the ApplicationSource the object is applied from,
+// so changes made outside of the repository are not reverted by the next sync. Git stays the source of truth,
+// the drift is reported in the status of the source until it's resumed.
+func SuspendApplicationSourceOf(ctx context.Context, c client.Client, obj metaV1.Object, suspendedBy string) error {
+	name := obj.GetLabels()[corev1alpha1.ApplicationSourceLabelName]
+
+	if name == "" {
+		return nil
+	}
+
+	var source corev1alpha1.ApplicationSource
+
+	if err := c.Get(ctx, types.NamespacedName{Name: name}, &source); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if source.Spec.Suspend {
+		return nil
+	}
+
+	copied := source.DeepCopy()
+	copied.Spec.Suspend = true
+
+	if copied.Annotations == nil {
+		copied.Annotations = make(map[string]string)
+	}
+
+	copied.Annotations[corev1alpha1.AnnoApplicationSourceSuspendedBy] = suspendedBy
+
+	return c.Patch(ctx, copied, client.MergeFrom(&source))
+}
+
+func NewApplicationSourceReconciler(mgr ctrl.Manager) *ApplicationSourceReconciler {
+	return &ApplicationSourceReconciler{
+		NewBaseReconciler(mgr, "ApplicationSource"),
+	}
+}
+
+func (r *ApplicationSourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1alpha1.ApplicationSource{}).
+		Complete(r)
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func writeManifest(t *testing.T, dir, name, content string) {
+	assert.Nil(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func TestLoadApplicationSourceManifests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifests")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	writeManifest(t, dir, "web.yaml", `
+apiVersion: core.kalm.dev/v1alpha1
+kind: HttpRoute
+metadata:
+  name: web
+spec:
+  hosts: ["web.example.com"]
+---
+apiVersion: core.kalm.dev/v1alpha1
+kind: Component
+metadata:
+  name: web
+spec:
+  image: web:v1
+  replicas: 2
+`)
+	writeManifest(t, dir, "files/config.json", `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "config"}}`)
+	writeManifest(t, dir, ".github/workflow.yaml", `on: push`)
+	writeManifest(t, dir, "README.md", `# manifests`)
+
+	manifests, err := loadApplicationSourceManifests(dir, "app")
+	assert.Nil(t, err)
+	assert.Len(t, manifests, 3)
+	assert.Equal(t, "ConfigMap", manifests[0].GetKind())
+	assert.Equal(t, "Component", manifests[1].GetKind())
+	assert.Equal(t, "HttpRoute", manifests[2].GetKind())
+	assert.Equal(t, "app", manifests[1].GetNamespace())
+
+	replicas, _, _ := unstructured.NestedInt64(manifests[1].Object, "spec", "replicas")
+	assert.Equal(t, int64(2), replicas)
+
+	writeManifest(t, dir, "deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`)
+
+	_, err = loadApplicationSourceManifests(dir, "app")
+	assert.EqualError(t, err, "deployment.yaml: apps/v1 Deployment is not allowed in application sources")
+
+	os.Remove(filepath.Join(dir, "deployment.yaml"))
+	writeManifest(t, dir, "other.yaml", `
+apiVersion: core.kalm.dev/v1alpha1
+kind: Component
+metadata:
+  name: web
+  namespace: other
+`)
+
+	_, err = loadApplicationSourceManifests(dir, "app")
+	assert.EqualError(t, err, "other.yaml: Component web is in namespace other, not the target namespace")
+}
+
+func TestIsApplicationSourceObjectDrifted(t *testing.T) {
+	desired := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "core.kalm.dev/v1alpha1",
+		"kind":       "Component",
+		"metadata":   map[string]interface{}{"name": "web", "labels": map[string]interface{}{"app": "web"}},
+		"spec": map[string]interface{}{
+			"image": "web:v1",
+			"ports": []interface{}{map[string]interface{}{"containerPort": int64(80)}},
+		},
+	}}
+
+	existing := desired.DeepCopy()
+
+	// defaults set by webhooks
+	existing.Object["spec"].(map[string]interface{})["replicas"] = int64(1)
+	existing.Object["spec"].(map[string]interface{})["ports"] = []interface{}{
+		map[string]interface{}{"containerPort": int64(80), "servicePort": int64(80)},
+	}
+	existing.SetLabels(map[string]string{"app": "web", "kalm-managed": "true"})
+	existing.Object["status"] = map[string]interface{}{"readyReplicas": int64(1)}
+
+	assert.False(t, isApplicationSourceObjectDrifted(desired, existing))
+
+	existing.Object["spec"].(map[string]interface{})["image"] = "web:v2"
+	assert.True(t, isApplicationSourceObjectDrifted(desired, existing))
+
+	existing = desired.DeepCopy()
+	existing.SetLabels(nil)
+	assert.True(t, isApplicationSourceObjectDrifted(desired, existing))
+}
@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	autoscalingV2beta2 "k8s.io/api/autoscaling/v2beta2"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func isAutoscalingEnabled(component *corev1alpha1.Component) bool {
+	if component.Spec.Autoscaling == nil {
+		return false
+	}
+
+	switch component.Spec.WorkloadType {
+	case corev1alpha1.WorkloadTypeServer, corev1alpha1.WorkloadTypeStatefulSet, "":
+		return true
+	}
+
+	return false
+}
+
+// getDesiredReplicas returns the replicas of the workload.
+// When autoscaling is enabled, the current replicas of the workload are kept, they are managed by the autoscaler.
+func (r *ComponentReconcilerTask) getDesiredReplicas(current *int32) *int32 {
+	if !isAutoscalingEnabled(r.component) {
+		return r.component.Spec.Replicas
+	}
+
+	if current != nil {
+		return current
+	}
+
+	minReplicas := int32(1)
+
+	if r.component.Spec.Autoscaling.MinReplicas != nil {
+		minReplicas = *r.component.Spec.Autoscaling.MinReplicas
+	}
+
+	return &minReplicas
+}
+
+func buildHorizontalPodAutoscalerSpec(component *corev1alpha1.Component) autoscalingV2beta2.HorizontalPodAutoscalerSpec {
+	autoscaling := component.Spec.Autoscaling
+
+	kind := "Deployment"
+
+	if component.Spec.WorkloadType == corev1alpha1.WorkloadTypeStatefulSet {
+		kind = "StatefulSet"
+	}
+
+	spec := autoscalingV2beta2.HorizontalPodAutoscalerSpec{
+		ScaleTargetRef: autoscalingV2beta2.CrossVersionObjectReference{
+			APIVersion: "apps/v1",
+			Kind:       kind,
+			Name:       component.Name,
+		},
+		MinReplicas: autoscaling.MinReplicas,
+		MaxReplicas: autoscaling.MaxReplicas,
+	}
+
+	resourceTargets := []struct {
+		name   coreV1.ResourceName
+		target *int32
+	}{
+		{coreV1.ResourceCPU, autoscaling.TargetCPUUtilizationPercentage},
+		{coreV1.ResourceMemory, autoscaling.TargetMemoryUtilizationPercentage},
+	}
+
+	for _, resourceTarget := range resourceTargets {
+		if resourceTarget.target == nil {
+			continue
+		}
+
+		spec.Metrics = append(spec.Metrics, autoscalingV2beta2.MetricSpec{
+			Type: autoscalingV2beta2.ResourceMetricSourceType,
+			Resource: &autoscalingV2beta2.ResourceMetricSource{
+				Name: resourceTarget.name,
+				Target: autoscalingV2beta2.MetricTarget{
+					Type:               autoscalingV2beta2.UtilizationMetricType,
+					AverageUtilization: resourceTarget.target,
+				},
+			},
+		})
+	}
+
+	for i := range autoscaling.CustomMetrics {
+		metric := autoscaling.CustomMetrics[i]
+
+		spec.Metrics = append(spec.Metrics, autoscalingV2beta2.MetricSpec{
+			Type: autoscalingV2beta2.PodsMetricSourceType,
+			Pods: &autoscalingV2beta2.PodsMetricSource{
+				Metric: autoscalingV2beta2.MetricIdentifier{
+					Name: metric.Name,
+				},
+				Target: autoscalingV2beta2.MetricTarget{
+					Type:         autoscalingV2beta2.AverageValueMetricType,
+					AverageValue: &metric.TargetAverageValue,
+				},
+			},
+		})
+	}
+
+	return spec
+}
+
+// ReconcileHorizontalPodAutoscaler creates or updates the autoscaler of the component, deletes it once autoscaling is disabled.
+func (r *ComponentReconcilerTask) ReconcileHorizontalPodAutoscaler() error {
+	if !isAutoscalingEnabled(r.component) || !IsNamespaceKalmEnabled(r.namespace) {
+		if r.horizontalPodAutoscaler == nil {
+			return nil
+		}
+
+		if err := r.Delete(r.ctx, r.horizontalPodAutoscaler); client.IgnoreNotFound(err) != nil {
+			r.WarningEvent(err, "unable to delete HorizontalPodAutoscaler for Component")
+			return err
+		}
+
+		r.horizontalPodAutoscaler = nil
+
+		return nil
+	}
+
+	spec := buildHorizontalPodAutoscalerSpec(r.component)
+
+	if r.horizontalPodAutoscaler == nil {
+		hpa := &autoscalingV2beta2.HorizontalPodAutoscaler{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      r.component.Name,
+				Namespace: r.component.Namespace,
+				Labels:    r.GetLabels(),
+			},
+			Spec: spec,
+		}
+
+		if err := ctrl.SetControllerReference(r.component, hpa, r.Scheme); err != nil {
+			r.WarningEvent(err, "unable to set owner for HorizontalPodAutoscaler")
+			return err
+		}
+
+		if err := r.Create(r.ctx, hpa); err != nil {
+			r.WarningEvent(err, "unable to create HorizontalPodAutoscaler for Component")
+			return err
+		}
+
+		r.horizontalPodAutoscaler = hpa
+
+		return nil
+	}
+
+	copied := r.horizontalPodAutoscaler.DeepCopy()
+	copied.Spec = spec
+
+	if err := r.Patch(r.ctx, copied, client.MergeFrom(r.horizontalPodAutoscaler)); err != nil {
+		r.WarningEvent(err, "unable to patch HorizontalPodAutoscaler for Component")
+		return err
+	}
+
+	r.horizontalPodAutoscaler = copied
+
+	return nil
+}
+
+func (r *ComponentReconcilerTask) LoadHorizontalPodAutoscaler() error {
+	var hpa autoscalingV2beta2.HorizontalPodAutoscaler
+
+	if err := r.LoadItem(&hpa); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if metaV1.IsControlledBy(&hpa, r.component) {
+		r.horizontalPodAutoscaler = &hpa
+	}
+
+	return nil
+}
+
+func getAutoscalingStatus(hpa *autoscalingV2beta2.HorizontalPodAutoscaler) *corev1alpha1.ComponentAutoscalingStatus {
+	if hpa == nil {
+		return nil
+	}
+
+	return &corev1alpha1.ComponentAutoscalingStatus{
+		CurrentReplicas: hpa.Status.CurrentReplicas,
+		DesiredReplicas: hpa.Status.DesiredReplicas,
+		LastScaleTime:   hpa.Status.LastScaleTime,
+	}
+}
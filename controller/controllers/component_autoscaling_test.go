@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	autoscalingV2beta2 "k8s.io/api/autoscaling/v2beta2"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildHorizontalPodAutoscalerSpec(t *testing.T) {
+	minReplicas := int32(2)
+	targetCPU := int32(70)
+
+	component := &corev1alpha1.Component{
+		ObjectMeta: metaV1.ObjectMeta{Name: "web", Namespace: "app"},
+		Spec: corev1alpha1.ComponentSpec{
+			WorkloadType: corev1alpha1.WorkloadTypeStatefulSet,
+			Autoscaling: &corev1alpha1.Autoscaling{
+				MinReplicas:                    &minReplicas,
+				MaxReplicas:                    5,
+				TargetCPUUtilizationPercentage: &targetCPU,
+				CustomMetrics: []corev1alpha1.AutoscalingCustomMetric{
+					{Name: "istio_requests_per_second", TargetAverageValue: resource.MustParse("100")},
+				},
+			},
+		},
+	}
+
+	spec := buildHorizontalPodAutoscalerSpec(component)
+
+	assert.Equal(t, "StatefulSet", spec.ScaleTargetRef.Kind)
+	assert.Equal(t, "web", spec.ScaleTargetRef.Name)
+	assert.Equal(t, int32(2), *spec.MinReplicas)
+	assert.Equal(t, int32(5), spec.MaxReplicas)
+	assert.Equal(t, 2, len(spec.Metrics))
+	assert.Equal(t, coreV1.ResourceCPU, spec.Metrics[0].Resource.Name)
+	assert.Equal(t, int32(70), *spec.Metrics[0].Resource.Target.AverageUtilization)
+	assert.Equal(t, autoscalingV2beta2.PodsMetricSourceType, spec.Metrics[1].Type)
+	assert.Equal(t, "istio_requests_per_second", spec.Metrics[1].Pods.Metric.Name)
+	assert.Equal(t, "100", spec.Metrics[1].Pods.Target.AverageValue.String())
+}
+
+func TestGetDesiredReplicas(t *testing.T) {
+	replicas := int32(4)
+	current := int32(7)
+
+	task := &ComponentReconcilerTask{
+		component: &corev1alpha1.Component{
+			Spec: corev1alpha1.ComponentSpec{Replicas: &replicas},
+		},
+	}
+
+	assert.Equal(t, int32(4), *task.getDesiredReplicas(&current))
+
+	task.component.Spec.Autoscaling = &corev1alpha1.Autoscaling{MaxReplicas: 10}
+
+	// the autoscaler owns the replicas
+	assert.Equal(t, int32(7), *task.getDesiredReplicas(&current))
+	assert.Equal(t, int32(1), *task.getDesiredReplicas(nil))
+
+	task.component.Spec.WorkloadType = corev1alpha1.WorkloadTypeDaemonSet
+	assert.Equal(t, int32(4), *task.getDesiredReplicas(&current))
+}
@@ -24,13 +24,14 @@ import (
 	js "github.com/dop251/goja"
 	"github.com/kalmhq/kalm/controller/lib/files"
 	"github.com/kalmhq/kalm/controller/vm"
-	"github.com/xeipuuv/gojsonschema"
 	v1alpha32 "istio.io/api/networking/v1alpha3"
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
 	appsV1 "k8s.io/api/apps/v1"
+	autoscalingV2beta2 "k8s.io/api/autoscaling/v2beta2"
 	batchV1 "k8s.io/api/batch/v1"
 	batchV1Beta1 "k8s.io/api/batch/v1beta1"
 	coreV1 "k8s.io/api/core/v1"
+	policyV1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -46,6 +47,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
 )
@@ -73,10 +75,24 @@ type ComponentReconcilerTask struct {
 	destinationRule *v1alpha3.DestinationRule
 	headlessService *coreV1.Service
 	cronJob         *batchV1Beta1.CronJob
+	job             *batchV1.Job
 	deployment      *appsV1.Deployment
 	daemonSet       *appsV1.DaemonSet
 	statefulSet     *appsV1.StatefulSet
 	pluginBindings  *corev1alpha1.ComponentPluginBindingList
+
+	horizontalPodAutoscaler *autoscalingV2beta2.HorizontalPodAutoscaler
+	podDisruptionBudget     *policyV1beta1.PodDisruptionBudget
+
+	// new version of the component during rollout
+	canaryDeployment *appsV1.Deployment
+	canaryService    *coreV1.Service
+	rollout          *corev1alpha1.ComponentRolloutStatus
+
+	// the Blocked condition, nil if the component has no dependencies
+	dependencyCondition *corev1alpha1.ComponentCondition
+
+	requeueAfter time.Duration
 }
 
 // +kubebuilder:rbac:groups=core.kalm.dev,resources=components,verbs=get;list;watch;create;update;patch;delete
@@ -89,8 +105,14 @@ type ComponentReconcilerTask struct {
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+// +kubebuilder:rbac:groups=core.kalm.dev,resources=secretstores,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=persistentvolume,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.istio.io,resources=destinationrules,verbs=*
 
@@ -102,7 +124,9 @@ func (r *ComponentReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		ctx:                 context.Background(),
 	}
 
-	return ctrl.Result{}, task.Run(req)
+	err := task.Run(req)
+
+	return ctrl.Result{RequeueAfter: task.requeueAfter}, err
 }
 
 func (r *ComponentReconcilerTask) WarningEvent(err error, msg string, args ...interface{}) {
@@ -213,11 +237,20 @@ func (r *ComponentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Watches(&source.Kind{Type: &corev1alpha1.ComponentPluginBinding{}}, &handler.EnqueueRequestsFromMapFunc{
 			ToRequests: &ComponentPluginBindingsMapper{r.BaseReconciler},
 		}).
+		Watches(&source.Kind{Type: &corev1alpha1.SecretStore{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: &SecretStoreMapper{r.BaseReconciler},
+		}).
+		Watches(&source.Kind{Type: &corev1alpha1.Component{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: &ComponentDependencyMapper{r.BaseReconciler},
+		}).
 		Owns(&appsV1.Deployment{}).
 		Owns(&batchV1Beta1.CronJob{}).
+		Owns(&batchV1.Job{}).
 		Owns(&appsV1.DaemonSet{}).
 		Owns(&appsV1.StatefulSet{}).
 		Owns(&coreV1.Service{}).
+		Owns(&autoscalingV2beta2.HorizontalPodAutoscaler{}).
+		Owns(&policyV1beta1.PodDisruptionBudget{}).
 		Complete(r)
 }
 func (r *ComponentReconcilerTask) Run(req ctrl.Request) error {
@@ -230,7 +263,25 @@ func (r *ComponentReconcilerTask) Run(req ctrl.Request) error {
 	}
 
 	if !r.component.ObjectMeta.DeletionTimestamp.IsZero() {
-		return nil
+		return r.HandleDelete()
+	}
+
+	if err := r.LoadDependencies(); err != nil {
+		return err
+	}
+
+	err := r.ReconcileResources()
+
+	if statusErr := r.UpdateStatus(err); statusErr != nil && err == nil {
+		return statusErr
+	}
+
+	return err
+}
+
+func (r *ComponentReconcilerTask) ReconcileResources() error {
+	if err := r.ReconcileRevision(); err != nil {
+		return err
 	}
 
 	if err := r.ReconcileService(); err != nil {
@@ -241,6 +292,34 @@ func (r *ComponentReconcilerTask) Run(req ctrl.Request) error {
 		return err
 	}
 
+	if err := r.ReconcilePluginFinalizer(); err != nil {
+		return err
+	}
+
+	if err := r.ReconcileHorizontalPodAutoscaler(); err != nil {
+		return err
+	}
+
+	if err := r.ReconcilePodDisruptionBudget(); err != nil {
+		return err
+	}
+
+	// the workload is held back until all dependencies are ready
+	if r.isBlockedByDependencies() && !r.isWorkloadCreated() {
+		return nil
+	}
+
+	if isRolloutEnabled(r.component) {
+		return r.ReconcileRollout()
+	}
+
+	// rollout strategy is removed, clean the new version if exists
+	r.rollout = nil
+
+	if err := r.releaseCanary(); err != nil {
+		return err
+	}
+
 	if err := r.ReconcileWorkload(); err != nil {
 		return err
 	}
@@ -374,7 +453,9 @@ func (r *ComponentReconcilerTask) ReconcileService() (err error) {
 		return nil
 	}
 
-	if len(r.component.Spec.Ports) > 0 {
+	servicePorts := r.component.Spec.ServicePorts()
+
+	if len(servicePorts) > 0 {
 		newService := false
 		newHeadlessService := false
 
@@ -412,7 +493,7 @@ func (r *ComponentReconcilerTask) ReconcileService() (err error) {
 		}
 
 		var ps []coreV1.ServicePort
-		for _, port := range r.component.Spec.Ports {
+		for _, port := range servicePorts {
 			// if service port is missing, set it same as containerPort
 			if port.ServicePort == 0 && port.ContainerPort != 0 {
 				port.ServicePort = port.ContainerPort
@@ -436,14 +517,26 @@ func (r *ComponentReconcilerTask) ReconcileService() (err error) {
 			ps = append(ps, sp)
 		}
 
-		// TODO service ComponentPlugin call
 		r.service.Spec.Ports = ps
+
+		if err := r.runPlugins(ComponentPluginMethodAfterServiceGeneration, r.component, r.service, r.service); err != nil {
+			r.WarningEvent(err, "run "+ComponentPluginMethodAfterServiceGeneration+" plugin error")
+			return err
+		}
+
 		if newService {
 			if err := ctrl.SetControllerReference(r.component, r.service, r.Scheme); err != nil {
 				r.WarningEvent(err, "unable to set owner for Service")
 				return err
 			}
+		}
 
+		if err := r.runPlugins(ComponentPluginMethodBeforeServiceSave, r.component, r.service, r.service); err != nil {
+			r.WarningEvent(err, "run before service save error.")
+			return err
+		}
+
+		if newService {
 			if err := r.Create(r.ctx, r.service); err != nil {
 				r.WarningEvent(err, "unable to create Service for Component")
 				return err
@@ -458,12 +551,25 @@ func (r *ComponentReconcilerTask) ReconcileService() (err error) {
 
 		if r.component.Spec.EnableHeadlessService || r.component.Spec.WorkloadType == corev1alpha1.WorkloadTypeStatefulSet {
 			r.headlessService.Spec.Ports = ps
+
+			if err := r.runPlugins(ComponentPluginMethodAfterServiceGeneration, r.component, r.headlessService, r.headlessService); err != nil {
+				r.WarningEvent(err, "run "+ComponentPluginMethodAfterServiceGeneration+" plugin error")
+				return err
+			}
+
 			if newHeadlessService {
 				if err := ctrl.SetControllerReference(r.component, r.headlessService, r.Scheme); err != nil {
 					r.WarningEvent(err, "unable to set owner for headlessService")
 					return err
 				}
+			}
 
+			if err := r.runPlugins(ComponentPluginMethodBeforeServiceSave, r.component, r.headlessService, r.headlessService); err != nil {
+				r.WarningEvent(err, "run before service save error.")
+				return err
+			}
+
+			if newHeadlessService {
 				if err := r.Create(r.ctx, r.headlessService); err != nil {
 					r.WarningEvent(err, "unable to create headlessService for Component")
 					return err
@@ -485,13 +591,13 @@ func (r *ComponentReconcilerTask) ReconcileService() (err error) {
 				Host: fmt.Sprintf("%s.%s.svc.cluster.local", r.component.Name, r.component.Namespace),
 				TrafficPolicy: &v1alpha32.TrafficPolicy{
 
-					PortLevelSettings: make([]*v1alpha32.TrafficPolicy_PortTrafficPolicy, len(r.component.Spec.Ports)),
+					PortLevelSettings: make([]*v1alpha32.TrafficPolicy_PortTrafficPolicy, len(servicePorts)),
 				},
 				ExportTo: []string{"*"},
 			},
 		}
 
-		for i, port := range r.component.Spec.Ports {
+		for i, port := range servicePorts {
 			servicePort := port.ServicePort
 
 			if servicePort == 0 {
@@ -556,7 +662,7 @@ func (r *ComponentReconcilerTask) ReconcileService() (err error) {
 
 	}
 
-	if len(r.component.Spec.Ports) == 0 {
+	if len(servicePorts) == 0 {
 		if r.service != nil {
 			err := r.Delete(r.ctx, r.service)
 			if err != nil {
@@ -617,6 +723,11 @@ func (r *ComponentReconcilerTask) ReconcileWorkload() (err error) {
 				return err
 			}
 		}
+		if r.job != nil {
+			if err := r.Delete(r.ctx, r.job, client.PropagationPolicy(metaV1.DeletePropagationBackground)); err != nil {
+				return err
+			}
+		}
 		if r.daemonSet != nil {
 			if err := r.Delete(r.ctx, r.daemonSet); err != nil {
 				return err
@@ -657,6 +768,12 @@ func (r *ComponentReconcilerTask) ReconcileWorkload() (err error) {
 		}
 
 		return r.ReconcileCronJob(template)
+	case corev1alpha1.WorkloadTypeJob:
+		if err := r.prepareVolsForSimpleWorkload(template); err != nil {
+			return err
+		}
+
+		return r.ReconcileJob(template)
 	case corev1alpha1.WorkloadTypeDaemonSet:
 		if err := r.prepareVolsForSimpleWorkload(template); err != nil {
 			return err
@@ -720,11 +837,7 @@ func (r *ComponentReconcilerTask) ReconcileDeployment(podTemplateSpec *coreV1.Po
 	}
 
 	// TODO consider to move to plugin
-	if component.Spec.Replicas != nil {
-		deployment.Spec.Replicas = component.Spec.Replicas
-	} else {
-		deployment.Spec.Replicas = nil
-	}
+	deployment.Spec.Replicas = r.getDesiredReplicas(deployment.Spec.Replicas)
 
 	if err := ctrl.SetControllerReference(component, deployment, r.Scheme); err != nil {
 		r.WarningEvent(err, "unable to set owner for deployment")
@@ -815,13 +928,7 @@ func (r *ComponentReconcilerTask) ReconcileCronJob(podTemplateSpec *coreV1.PodTe
 	labelMap := r.GetLabels()
 	annotations := r.GetAnnotations()
 
-	// restartPolicy
-	if podTemplateSpec.Spec.RestartPolicy == coreV1.RestartPolicyAlways ||
-		podTemplateSpec.Spec.RestartPolicy == "" {
-
-		podTemplateSpec.Spec.RestartPolicy = coreV1.RestartPolicyOnFailure
-
-	}
+	prepareJobPodTemplate(podTemplateSpec)
 
 	successJobHistoryLimit := int32(3)
 	failJobHistoryLimit := int32(5)
@@ -829,9 +936,11 @@ func (r *ComponentReconcilerTask) ReconcileCronJob(podTemplateSpec *coreV1.PodTe
 	desiredCJSpec := batchV1Beta1.CronJobSpec{
 		Schedule: component.Spec.Schedule,
 		JobTemplate: batchV1Beta1.JobTemplateSpec{
-			Spec: batchV1.JobSpec{
-				Template: *podTemplateSpec,
+			// labels of jobs, used to find runs of the component
+			ObjectMeta: metaV1.ObjectMeta{
+				Labels: labelMap,
 			},
+			Spec: buildJobSpec(component, podTemplateSpec),
 		},
 		SuccessfulJobsHistoryLimit: &successJobHistoryLimit,
 		FailedJobsHistoryLimit:     &failJobHistoryLimit,
@@ -918,8 +1027,8 @@ func (r *ComponentReconcilerTask) ReconcileStatefulSet(
 		sts.Spec.Template = *spec
 	}
 
-	if r.component.Spec.Replicas != nil {
-		sts.Spec.Replicas = r.component.Spec.Replicas
+	if replicas := r.getDesiredReplicas(sts.Spec.Replicas); replicas != nil {
+		sts.Spec.Replicas = replicas
 	}
 
 	if isNewSts {
@@ -988,7 +1097,6 @@ func (r *ComponentReconcilerTask) GetPodTemplateWithoutVols() (template *coreV1.
 					LivenessProbe:  r.FixProbe(component.Spec.LivenessProbe),
 				},
 			},
-			SecurityContext: GetPodSecurityContextFromAnnotation(annotations),
 		},
 	}
 
@@ -1002,14 +1110,7 @@ func (r *ComponentReconcilerTask) GetPodTemplateWithoutVols() (template *coreV1.
 		template.Spec.TerminationGracePeriodSeconds = component.Spec.TerminationGracePeriodSeconds
 	}
 
-	if component.Spec.Command != "" {
-		if strings.Contains(component.Spec.Command, " ") {
-			mainContainer.Command = []string{"sh"}
-			mainContainer.Args = []string{"-c", component.Spec.Command}
-		} else {
-			mainContainer.Command = []string{component.Spec.Command}
-		}
-	}
+	setContainerCommand(mainContainer, component.Spec.Command)
 
 	var pullImageSecrets coreV1.SecretList
 	if err := r.Client.List(
@@ -1044,6 +1145,8 @@ func (r *ComponentReconcilerTask) GetPodTemplateWithoutVols() (template *coreV1.
 		template.Spec.Affinity = affinity
 	}
 
+	template.Spec.TopologySpreadConstraints = r.buildTopologySpreadConstraints()
+
 	if component.Spec.RunnerPermission != nil {
 		template.Spec.ServiceAccountName = r.getNameForPermission()
 	}
@@ -1055,8 +1158,50 @@ func (r *ComponentReconcilerTask) GetPodTemplateWithoutVols() (template *coreV1.
 	}
 
 	// apply envs
-	var envs []coreV1.EnvVar
-	for _, env := range component.Spec.Env {
+	if mainContainer.Env, err = r.buildEnvs(component.Spec.Env); err != nil {
+		return nil, err
+	}
+
+	for _, sidecar := range component.Spec.Sidecars {
+		container, err := r.buildExtraContainer(sidecar)
+
+		if err != nil {
+			return nil, err
+		}
+
+		template.Spec.Containers = append(template.Spec.Containers, container)
+	}
+
+	for _, initContainer := range component.Spec.InitContainers {
+		container, err := r.buildExtraContainer(initContainer)
+
+		if err != nil {
+			return nil, err
+		}
+
+		template.Spec.InitContainers = append(template.Spec.InitContainers, container)
+	}
+
+	r.applySecurityContext(template)
+
+	if err := r.setSecretsVersion(template); err != nil {
+		return nil, err
+	}
+
+	err = r.runPlugins(ComponentPluginMethodAfterPodTemplateGeneration, component, template, template)
+	if err != nil {
+		r.WarningEvent(err, "run "+ComponentPluginMethodAfterPodTemplateGeneration+" save plugin error")
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// buildEnvs converts envs of the component or extra containers to container envs.
+func (r *ComponentReconcilerTask) buildEnvs(envs []corev1alpha1.EnvVar) ([]coreV1.EnvVar, error) {
+	var res []coreV1.EnvVar
+
+	for _, env := range envs {
 		var value string
 		var valueFrom *coreV1.EnvVarSource
 
@@ -1071,6 +1216,7 @@ func (r *ComponentReconcilerTask) GetPodTemplateWithoutVols() (template *coreV1.
 			//	continue
 			//}
 		case corev1alpha1.EnvVarTypeLinked:
+			var err error
 			value, err = r.getValueOfLinkedEnv(env)
 			if err != nil {
 				return nil, err
@@ -1105,23 +1251,127 @@ func (r *ComponentReconcilerTask) GetPodTemplateWithoutVols() (template *coreV1.
 					},
 				}
 			}
+		case corev1alpha1.EnvVarTypeSecret:
+			valueFrom = buildSecretEnvSource(env.Value)
 		}
 
-		envs = append(envs, coreV1.EnvVar{
+		res = append(res, coreV1.EnvVar{
 			Name:      env.Name,
 			Value:     value,
 			ValueFrom: valueFrom,
 		})
 	}
-	mainContainer.Env = envs
 
-	err = r.runPlugins(ComponentPluginMethodAfterPodTemplateGeneration, component, template, template)
+	return res, nil
+}
+
+// setContainerCommand runs commands with spaces in a shell.
+func setContainerCommand(container *coreV1.Container, command string) {
+	if command == "" {
+		return
+	}
+
+	if strings.Contains(command, " ") {
+		container.Command = []string{"sh"}
+		container.Args = []string{"-c", command}
+	} else {
+		container.Command = []string{command}
+	}
+}
+
+// buildExtraContainer builds a sidecar or init container. Volume mounts are set when volumes are prepared.
+func (r *ComponentReconcilerTask) buildExtraContainer(c corev1alpha1.Container) (coreV1.Container, error) {
+	container := coreV1.Container{
+		Name:           c.Name,
+		Image:          c.Image,
+		ReadinessProbe: r.FixProbe(c.ReadinessProbe),
+		LivenessProbe:  r.FixProbe(c.LivenessProbe),
+	}
+
+	setContainerCommand(&container, c.Command)
+
+	if c.ResourceRequirements != nil {
+		container.Resources = *c.ResourceRequirements
+	}
+
+	for _, port := range c.Ports {
+		protocol := coreV1.ProtocolTCP
+
+		if port.Protocol == corev1alpha1.PortProtocolUDP {
+			protocol = coreV1.ProtocolUDP
+		}
+
+		container.Ports = append(container.Ports, coreV1.ContainerPort{
+			ContainerPort: int32(port.ContainerPort),
+			Protocol:      protocol,
+		})
+	}
+
+	envs, err := r.buildEnvs(c.Env)
+
 	if err != nil {
-		r.WarningEvent(err, "run "+ComponentPluginMethodAfterPodTemplateGeneration+" save plugin error")
-		return nil, err
+		return container, err
 	}
 
-	return template, nil
+	container.Env = envs
+
+	return container, nil
+}
+
+// mountVolumesToExtraContainers mounts volumes of the component into sidecars and init containers.
+// volNames maps paths of volumes in the component spec to names of the volumes in the pod.
+func (r *ComponentReconcilerTask) mountVolumesToExtraContainers(template *coreV1.PodTemplateSpec, volNames map[string]string) error {
+	extraContainers := []struct {
+		specs      []corev1alpha1.Container
+		containers []coreV1.Container
+	}{
+		{r.component.Spec.Sidecars, template.Spec.Containers},
+		{r.component.Spec.InitContainers, template.Spec.InitContainers},
+	}
+
+	for _, extra := range extraContainers {
+		for _, spec := range extra.specs {
+			container := findContainer(extra.containers, spec.Name)
+
+			if container == nil {
+				continue
+			}
+
+			container.VolumeMounts = nil
+
+			for _, mount := range spec.VolumeMounts {
+				volName, exist := volNames[mount.Path]
+
+				if !exist {
+					return fmt.Errorf("volume %s mounted by container %s doesn't exist", mount.Path, spec.Name)
+				}
+
+				mountPath := mount.MountPath
+
+				if mountPath == "" {
+					mountPath = mount.Path
+				}
+
+				container.VolumeMounts = append(container.VolumeMounts, coreV1.VolumeMount{
+					Name:      volName,
+					MountPath: mountPath,
+					ReadOnly:  mount.ReadOnly,
+				})
+			}
+		}
+	}
+
+	return nil
+}
+
+func findContainer(containers []coreV1.Container, name string) *coreV1.Container {
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i]
+		}
+	}
+
+	return nil
 }
 
 func getVolName(componentName, diskPath string) string {
@@ -1166,8 +1416,8 @@ func (r *ComponentReconcilerTask) getValueOfLinkedEnv(env corev1alpha1.EnvVar) (
 	return fmt.Sprintf("%s%s%s", env.Prefix, value, env.Suffix), nil
 }
 
-func (r *ComponentReconcilerTask) initPluginRuntime(component *corev1alpha1.Component) *js.Runtime {
-	rt := vm.InitRuntime()
+func (r *ComponentReconcilerTask) initPluginRuntime(component *corev1alpha1.Component, pluginName string) *js.Runtime {
+	rt := vm.InitRuntimeWithHost(&componentPluginHost{task: r, component: component, pluginName: pluginName})
 
 	rt.Set("getApplicationName", func(call js.FunctionCall) js.Value {
 		return rt.ToValue(r.namespace.Name)
@@ -1189,7 +1439,9 @@ func (r *ComponentReconcilerTask) runPlugins(methodName string, component *corev
 		return nil
 	}
 
-	for _, binding := range r.pluginBindings.Items {
+	for i := range r.pluginBindings.Items {
+		binding := &r.pluginBindings.Items[i]
+
 		if binding.DeletionTimestamp != nil || binding.Spec.IsDisabled {
 			continue
 		}
@@ -1198,17 +1450,17 @@ func (r *ComponentReconcilerTask) runPlugins(methodName string, component *corev
 			continue
 		}
 
-		pluginProgram, config, err := findPluginAndValidateConfigNew(&binding, methodName, component)
+		pluginProgram, config, err := findPluginAndValidateConfigNew(binding, methodName, component)
 
 		if err != nil {
-			return err
+			return &ComponentPluginError{PluginName: binding.Spec.PluginName, MethodName: methodName, Err: err}
 		}
 
 		if pluginProgram == nil {
 			continue
 		}
 
-		rt := r.initPluginRuntime(component)
+		rt := r.initPluginRuntime(component, binding.Spec.PluginName)
 
 		r.insertBuildInPluginImpls(rt, binding.Spec.PluginName, methodName, component, desc, args)
 
@@ -1226,7 +1478,8 @@ func (r *ComponentReconcilerTask) runPlugins(methodName string, component *corev
 			)
 
 			if err != nil {
-				return err
+				r.reportPluginBindingError(binding, ComponentPluginMethodComponentFilter, err)
+				return &ComponentPluginError{PluginName: binding.Spec.PluginName, MethodName: ComponentPluginMethodComponentFilter, Err: err}
 			}
 
 			if !*shouldExecute {
@@ -1245,13 +1498,60 @@ func (r *ComponentReconcilerTask) runPlugins(methodName string, component *corev
 
 		if err != nil {
 			r.WarningEvent(err, fmt.Sprintf("Run plugin error. methodName: %s, componentName: %s, pluginName: %s", methodName, component.Name, binding.Spec.PluginName))
-			return err
+			r.reportPluginBindingError(binding, methodName, err)
+			return &ComponentPluginError{PluginName: binding.Spec.PluginName, MethodName: methodName, Err: err}
 		}
+
+		r.clearPluginBindingRuntimeError(binding)
 	}
 
 	return nil
 }
 
+// reportPluginBindingError records plugin runtime errors, such as timeouts and panics, on the binding,
+// so that they are visible on the binding instead of only in controller logs.
+func (r *ComponentReconcilerTask) reportPluginBindingError(binding *corev1alpha1.ComponentPluginBinding, methodName string, err error) {
+	message := fmt.Sprintf("%s failed: %s", methodName, err.Error())
+
+	if vm.IsLimitError(err) {
+		r.EmitWarningEvent(binding, err, "Plugin %s is stopped in %s. %s", binding.Spec.PluginName, methodName, err.Error())
+	} else {
+		r.EmitWarningEvent(binding, err, "Plugin %s failed in %s. %s", binding.Spec.PluginName, methodName, err.Error())
+	}
+
+	if binding.Status.ConfigError == message {
+		return
+	}
+
+	copied := binding.DeepCopy()
+	copied.Status.ConfigError = message
+
+	if err := r.Status().Patch(r.ctx, copied, client.MergeFrom(binding)); err != nil {
+		r.EmitWarningEvent(binding, err, "Patch plugin binding status error.")
+		return
+	}
+
+	binding.Status = copied.Status
+}
+
+// clearPluginBindingRuntimeError clears the error reported by reportPluginBindingError once the plugin runs successfully.
+// Errors of invalid configs are left to the binding controller.
+func (r *ComponentReconcilerTask) clearPluginBindingRuntimeError(binding *corev1alpha1.ComponentPluginBinding) {
+	if !binding.Status.ConfigValid || binding.Status.ConfigError == "" {
+		return
+	}
+
+	copied := binding.DeepCopy()
+	copied.Status.ConfigError = ""
+
+	if err := r.Status().Patch(r.ctx, copied, client.MergeFrom(binding)); err != nil {
+		r.EmitWarningEvent(binding, err, "Patch plugin binding status error.")
+		return
+	}
+
+	binding.Status = copied.Status
+}
+
 func findPluginAndValidateConfigNew(pluginBinding *corev1alpha1.ComponentPluginBinding, methodName string, component *corev1alpha1.Component) (*ComponentPluginProgram, []byte, error) {
 	pluginProgram := componentPluginsCache.Get(pluginBinding.Spec.PluginName)
 
@@ -1275,19 +1575,8 @@ func findPluginAndValidateConfigNew(pluginBinding *corev1alpha1.ComponentPluginB
 	}
 
 	if pluginProgram.ConfigSchema != nil {
-		if pluginBinding.Spec.Config == nil {
-			return nil, nil, fmt.Errorf("ComponentPlugin %s require configuration.", pluginBinding.Spec.PluginName)
-		}
-
-		pluginConfig := gojsonschema.NewStringLoader(string(pluginBinding.Spec.Config.Raw))
-		res, err := pluginProgram.ConfigSchema.Validate(pluginConfig)
-
-		if err != nil {
-			return nil, nil, err
-		}
-
-		if !res.Valid() {
-			return nil, nil, fmt.Errorf(res.Errors()[0].String())
+		if errList := corev1alpha1.ValidatePluginConfig(pluginProgram.ConfigSchema, pluginBinding.Spec.Config); len(errList) > 0 {
+			return nil, nil, errList
 		}
 
 		return pluginProgram, pluginBinding.Spec.Config.Raw, nil
@@ -1477,6 +1766,7 @@ func (r *ComponentReconcilerTask) SetupAttributes(req ctrl.Request) (err error)
 		return err
 	}
 	r.component = &component
+	r.rollout = component.Status.Rollout.DeepCopy()
 
 	var ns coreV1.Namespace
 	err = r.Reader.Get(r.ctx, types.NamespacedName{
@@ -1520,6 +1810,13 @@ func (r *ComponentReconcilerTask) DeleteResources() (err error) {
 		}
 	}
 
+	// pods of jobs are orphaned by default
+	if r.job != nil {
+		if err := r.Delete(r.ctx, r.job, client.PropagationPolicy(metaV1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
 	if r.statefulSet != nil {
 		if err := r.DeleteItem(r.statefulSet); client.IgnoreNotFound(err) != nil {
 			return err
@@ -1567,11 +1864,25 @@ func (r *ComponentReconcilerTask) LoadResources() (err error) {
 		return err
 	}
 
+	if err := r.LoadCanary(); err != nil {
+		return err
+	}
+
+	if err := r.LoadHorizontalPodAutoscaler(); err != nil {
+		return err
+	}
+
+	if err := r.LoadPodDisruptionBudget(); err != nil {
+		return err
+	}
+
 	switch r.component.Spec.WorkloadType {
 	case corev1alpha1.WorkloadTypeServer, "":
 		return r.LoadDeployment()
 	case corev1alpha1.WorkloadTypeCronjob:
 		return r.LoadCronJob()
+	case corev1alpha1.WorkloadTypeJob:
+		return r.LoadJob()
 	case corev1alpha1.WorkloadTypeDaemonSet:
 		return r.LoadDaemonSet()
 	case corev1alpha1.WorkloadTypeStatefulSet:
@@ -1786,6 +2097,8 @@ func (r *ComponentReconcilerTask) prepareVolsForSTS(podTemplate *coreV1.PodTempl
 		return nil, err
 	}
 
+	volNames := make(map[string]string)
+
 	for _, disk := range component.Spec.Volumes {
 		// used in volumeMount, correspond to volume's name or volClaimTemplate's name
 		volName := getVolName(component.Name, disk.Path)
@@ -1847,6 +2160,8 @@ func (r *ComponentReconcilerTask) prepareVolsForSTS(podTemplate *coreV1.PodTempl
 			Name:      volName,
 			MountPath: disk.Path,
 		})
+
+		volNames[disk.Path] = volName
 	}
 
 	// set volumes & volMounts for podTemplate of STS
@@ -1856,6 +2171,10 @@ func (r *ComponentReconcilerTask) prepareVolsForSTS(podTemplate *coreV1.PodTempl
 	mainContainer := &podTemplate.Spec.Containers[0]
 	mainContainer.VolumeMounts = volumeMounts
 
+	if err := r.mountVolumesToExtraContainers(podTemplate, volNames); err != nil {
+		return nil, err
+	}
+
 	// for STS, pvc is not in podTemplate but in volumeClaimTemplate
 	return volClaimTemplates, nil
 }
@@ -1872,6 +2191,8 @@ func (r *ComponentReconcilerTask) prepareVolsForSimpleWorkload(template *coreV1.
 		return err
 	}
 
+	volNames := make(map[string]string)
+
 	for _, disk := range component.Spec.Volumes {
 
 		// used in volumeMount, correspond to volume's name or volClaimTemplate's name
@@ -1984,6 +2305,8 @@ func (r *ComponentReconcilerTask) prepareVolsForSimpleWorkload(template *coreV1.
 			Name:      volName,
 			MountPath: disk.Path,
 		})
+
+		volNames[disk.Path] = volName
 	}
 
 	template.Spec.Volumes = volumes
@@ -1991,7 +2314,7 @@ func (r *ComponentReconcilerTask) prepareVolsForSimpleWorkload(template *coreV1.
 	mainContainer := &template.Spec.Containers[0]
 	mainContainer.VolumeMounts = volumeMounts
 
-	return nil
+	return r.mountVolumesToExtraContainers(template, volNames)
 }
 
 // 2. diff ns pv reuse, remove old pvc, clean ref in pv
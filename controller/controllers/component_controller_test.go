@@ -120,6 +120,112 @@ func (suite *ComponentControllerSuite) TestComponentBasicCRUD() {
 	}, "component delete is not working")
 }
 
+func (suite *ComponentControllerSuite) TestComponentSidecarsAndInitContainers() {
+	component := generateEmptyComponent(suite.ns.Name)
+	component.Spec.Volumes = []v1alpha1.Volume{
+		{Path: "/var/log", Type: v1alpha1.VolumeTypeTemporaryDisk},
+	}
+	component.Spec.Sidecars = []v1alpha1.Container{
+		{
+			Name:         "log-shipper",
+			Image:        "fluent/fluent-bit:1.5",
+			Env:          []v1alpha1.EnvVar{{Name: "LEVEL", Value: "info"}},
+			Ports:        []v1alpha1.Port{{ContainerPort: 2020, Protocol: v1alpha1.PortProtocolHTTP}},
+			VolumeMounts: []v1alpha1.ContainerVolumeMount{{Path: "/var/log", MountPath: "/logs", ReadOnly: true}},
+		},
+	}
+	component.Spec.InitContainers = []v1alpha1.Container{
+		{Name: "migrate", Image: "busybox", Command: "echo migrate"},
+	}
+	suite.createComponent(component)
+
+	key := types.NamespacedName{
+		Namespace: component.Namespace,
+		Name:      component.Name,
+	}
+
+	suite.Eventually(func() bool {
+		var deployment appsV1.Deployment
+		var service coreV1.Service
+
+		if err := suite.K8sClient.Get(context.Background(), key, &deployment); err != nil {
+			return false
+		}
+
+		if err := suite.K8sClient.Get(context.Background(), key, &service); err != nil {
+			return false
+		}
+
+		podSpec := deployment.Spec.Template.Spec
+
+		return len(podSpec.Containers) == 2 &&
+			podSpec.Containers[0].Name == component.Name &&
+			podSpec.Containers[1].Name == "log-shipper" &&
+			len(podSpec.Containers[1].Env) == 1 &&
+			len(podSpec.Containers[1].VolumeMounts) == 1 &&
+			podSpec.Containers[1].VolumeMounts[0].MountPath == "/logs" &&
+			len(podSpec.InitContainers) == 1 &&
+			podSpec.InitContainers[0].Command[0] == "sh" &&
+			len(service.Spec.Ports) == 2 // port of the main container and the sidecar
+	}, "can't get deployment with sidecars")
+}
+
+func (suite *ComponentControllerSuite) TestComponentStatus() {
+	component := generateEmptyComponent(suite.ns.Name)
+	suite.createComponent(component)
+
+	// there is no deployment controller in test env, so the deployment will never be ready
+	suite.Eventually(func() bool {
+		suite.reloadComponent(component)
+
+		progressing := component.Status.GetCondition(v1alpha1.ComponentConditionProgressing)
+
+		return component.Status.ObservedGeneration == component.Generation &&
+			component.Status.Replicas == 1 &&
+			component.Status.ReadyReplicas == 0 &&
+			!component.Status.IsReady() &&
+			progressing != nil && progressing.Status == coreV1.ConditionTrue
+	}, "component status is not updated")
+}
+
+func (suite *ComponentControllerSuite) TestComponentRevision() {
+	component := generateEmptyComponent(suite.ns.Name)
+	component.Annotations = map[string]string{
+		v1alpha1.AnnoComponentChangedBy: "foo@bar.com",
+	}
+	suite.createComponent(component)
+
+	var revisionList v1alpha1.ComponentRevisionList
+
+	suite.Eventually(func() bool {
+		suite.Nil(suite.K8sClient.List(suite.ctx, &revisionList, client.InNamespace(component.Namespace), client.MatchingLabels{
+			v1alpha1.ComponentRevisionLabelComponent: component.Name,
+		}))
+
+		return len(revisionList.Items) == 1 &&
+			revisionList.Items[0].Spec.Revision == 1 &&
+			revisionList.Items[0].Spec.ChangedBy == "foo@bar.com"
+	}, "first revision is not created")
+
+	suite.reloadComponent(component)
+	component.Spec.Image = "nginx:stable"
+	suite.updateComponent(component)
+
+	suite.Eventually(func() bool {
+		suite.Nil(suite.K8sClient.List(suite.ctx, &revisionList, client.InNamespace(component.Namespace), client.MatchingLabels{
+			v1alpha1.ComponentRevisionLabelComponent: component.Name,
+		}))
+
+		for _, revision := range revisionList.Items {
+			if revision.Spec.Revision == 2 {
+				return revision.Spec.ComponentSpec.Image == "nginx:stable"
+			}
+		}
+
+		return false
+	}, "second revision is not created")
+}
+
 func (suite *ComponentControllerSuite) TestDaemonSetCRUD() {}
 
 func (suite *ComponentControllerSuite) TestCronJobCRUD() {}
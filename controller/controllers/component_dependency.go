@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// getDependencyCondition returns the Blocked condition of a component starting after the given dependencies.
+// A failed dependency takes precedence over a missing one, which takes precedence over one that is not ready yet.
+func getDependencyCondition(dependencies []string, components []corev1alpha1.Component) corev1alpha1.ComponentCondition {
+	componentMap := make(map[string]*corev1alpha1.Component, len(components))
+
+	for i := range components {
+		componentMap[components[i].Name] = &components[i]
+	}
+
+	var failed, notFound, notReady []string
+
+	for _, name := range dependencies {
+		dep, exist := componentMap[name]
+
+		switch {
+		case !exist:
+			notFound = append(notFound, name)
+		case dep.Status.IsReady():
+			continue
+		case isComponentDegraded(dep):
+			failed = append(failed, name)
+		default:
+			notReady = append(notReady, name)
+		}
+	}
+
+	var messages []string
+
+	if len(failed) > 0 {
+		messages = append(messages, "failed: "+strings.Join(failed, ", "))
+	}
+
+	if len(notFound) > 0 {
+		messages = append(messages, "not found: "+strings.Join(notFound, ", "))
+	}
+
+	if len(notReady) > 0 {
+		messages = append(messages, "not ready: "+strings.Join(notReady, ", "))
+	}
+
+	condition := corev1alpha1.ComponentCondition{
+		Type:   corev1alpha1.ComponentConditionBlocked,
+		Status: coreV1.ConditionTrue,
+	}
+
+	switch {
+	case len(failed) > 0:
+		condition.Reason = "DependencyFailed"
+	case len(notFound) > 0:
+		condition.Reason = "DependencyNotFound"
+	case len(notReady) > 0:
+		condition.Reason = "DependencyNotReady"
+	default:
+		condition.Status = coreV1.ConditionFalse
+		condition.Reason = "DependenciesReady"
+		return condition
+	}
+
+	condition.Message = fmt.Sprintf("waiting for dependencies, %s", strings.Join(messages, "; "))
+
+	return condition
+}
+
+func isComponentDegraded(component *corev1alpha1.Component) bool {
+	condition := component.Status.GetCondition(corev1alpha1.ComponentConditionDegraded)
+	return condition != nil && condition.Status == coreV1.ConditionTrue
+}
+
+// LoadDependencies computes the Blocked condition from the status of components in startAfterComponents.
+func (r *ComponentReconcilerTask) LoadDependencies() error {
+	r.dependencyCondition = nil
+
+	if len(r.component.Spec.StartAfterComponents) == 0 {
+		return nil
+	}
+
+	var componentList corev1alpha1.ComponentList
+
+	if err := r.List(r.ctx, &componentList, client.InNamespace(r.component.Namespace)); err != nil {
+		r.WarningEvent(err, "list dependencies of component failed")
+		return err
+	}
+
+	condition := getDependencyCondition(r.component.Spec.StartAfterComponents, componentList.Items)
+	r.dependencyCondition = &condition
+
+	return nil
+}
+
+func (r *ComponentReconcilerTask) isBlockedByDependencies() bool {
+	return r.dependencyCondition != nil && r.dependencyCondition.Status == coreV1.ConditionTrue
+}
+
+// isWorkloadCreated returns true if the workload of the component exists.
+// Dependencies only hold back the first start, a running workload is not stopped when a dependency fails.
+func (r *ComponentReconcilerTask) isWorkloadCreated() bool {
+	switch r.component.Spec.WorkloadType {
+	case corev1alpha1.WorkloadTypeServer, "":
+		return r.deployment != nil
+	case corev1alpha1.WorkloadTypeStatefulSet:
+		return r.statefulSet != nil
+	case corev1alpha1.WorkloadTypeDaemonSet:
+		return r.daemonSet != nil
+	case corev1alpha1.WorkloadTypeCronjob:
+		return r.cronJob != nil
+	case corev1alpha1.WorkloadTypeJob:
+		return r.job != nil || r.component.Status.Job != nil
+	}
+
+	return false
+}
+
+// ComponentDependencyMapper reconciles components starting after the changed component
+type ComponentDependencyMapper struct {
+	*BaseReconciler
+}
+
+func (r *ComponentDependencyMapper) Map(object handler.MapObject) []reconcile.Request {
+	component, ok := object.Object.(*corev1alpha1.Component)
+
+	if !ok {
+		return nil
+	}
+
+	var componentList corev1alpha1.ComponentList
+
+	if err := r.Client.List(context.Background(), &componentList, client.InNamespace(component.Namespace)); err != nil {
+		r.Log.Error(err, "Can't list components in mapper.")
+		return nil
+	}
+
+	var res []reconcile.Request
+
+	for i := range componentList.Items {
+		for _, dep := range componentList.Items[i].Spec.StartAfterComponents {
+			if dep != component.Name {
+				continue
+			}
+
+			res = append(res, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      componentList.Items[i].Name,
+					Namespace: component.Namespace,
+				},
+			})
+
+			break
+		}
+	}
+
+	return res
+}
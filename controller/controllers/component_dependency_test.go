@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newDependencyComponent(name string, conditions ...corev1alpha1.ComponentCondition) corev1alpha1.Component {
+	return corev1alpha1.Component{
+		ObjectMeta: metaV1.ObjectMeta{Name: name, Namespace: "app"},
+		Status:     corev1alpha1.ComponentStatus{Conditions: conditions},
+	}
+}
+
+func TestGetDependencyCondition(t *testing.T) {
+	ready := corev1alpha1.ComponentCondition{Type: corev1alpha1.ComponentConditionReady, Status: coreV1.ConditionTrue}
+	notReady := corev1alpha1.ComponentCondition{Type: corev1alpha1.ComponentConditionReady, Status: coreV1.ConditionFalse}
+	degraded := corev1alpha1.ComponentCondition{Type: corev1alpha1.ComponentConditionDegraded, Status: coreV1.ConditionTrue}
+
+	components := []corev1alpha1.Component{
+		newDependencyComponent("db", ready),
+		newDependencyComponent("cache", notReady),
+		newDependencyComponent("queue", notReady, degraded),
+	}
+
+	condition := getDependencyCondition([]string{"db"}, components)
+	assert.Equal(t, coreV1.ConditionFalse, condition.Status)
+	assert.Equal(t, "DependenciesReady", condition.Reason)
+
+	condition = getDependencyCondition([]string{"db", "cache"}, components)
+	assert.Equal(t, coreV1.ConditionTrue, condition.Status)
+	assert.Equal(t, "DependencyNotReady", condition.Reason)
+	assert.Equal(t, "waiting for dependencies, not ready: cache", condition.Message)
+
+	condition = getDependencyCondition([]string{"cache", "search", "queue"}, components)
+	assert.Equal(t, "DependencyFailed", condition.Reason)
+	assert.Equal(t, "waiting for dependencies, failed: queue; not found: search; not ready: cache", condition.Message)
+}
+
+func TestIsWorkloadCreated(t *testing.T) {
+	task := &ComponentReconcilerTask{
+		component: &corev1alpha1.Component{
+			Spec: corev1alpha1.ComponentSpec{WorkloadType: corev1alpha1.WorkloadTypeJob},
+		},
+	}
+
+	assert.False(t, task.isWorkloadCreated())
+
+	// the job has finished and is deleted by ttl
+	task.component.Status.Job = &corev1alpha1.ComponentJobStatus{Succeeded: 1}
+	assert.True(t, task.isWorkloadCreated())
+}
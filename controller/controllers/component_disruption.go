@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+	policyV1beta1 "k8s.io/api/policy/v1beta1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var topologySpreadKeys = map[corev1alpha1.TopologySpreadTopology]string{
+	corev1alpha1.TopologySpreadZone: "topology.kubernetes.io/zone",
+	corev1alpha1.TopologySpreadHost: "kubernetes.io/hostname",
+}
+
+// getPodSelectorLabels returns labels selecting all pods of the component, custom labels of the component are excluded.
+func (r *ComponentReconcilerTask) getPodSelectorLabels() map[string]string {
+	return map[string]string{
+		KalmLabelNamespaceKey: r.component.Namespace,
+		KalmLabelComponentKey: r.component.Name,
+	}
+}
+
+func (r *ComponentReconcilerTask) buildTopologySpreadConstraints() []coreV1.TopologySpreadConstraint {
+	var constraints []coreV1.TopologySpreadConstraint
+
+	for _, spread := range r.component.Spec.TopologySpread {
+		maxSkew := spread.MaxSkew
+
+		if maxSkew <= 0 {
+			maxSkew = 1
+		}
+
+		whenUnsatisfiable := coreV1.ScheduleAnyway
+
+		if spread.Hard {
+			whenUnsatisfiable = coreV1.DoNotSchedule
+		}
+
+		constraints = append(constraints, coreV1.TopologySpreadConstraint{
+			MaxSkew:           maxSkew,
+			TopologyKey:       topologySpreadKeys[spread.Topology],
+			WhenUnsatisfiable: whenUnsatisfiable,
+			LabelSelector: &metaV1.LabelSelector{
+				MatchLabels: r.getPodSelectorLabels(),
+			},
+		})
+	}
+
+	return constraints
+}
+
+func isDisruptionBudgetEnabled(component *corev1alpha1.Component) bool {
+	if component.Spec.DisruptionBudget == nil {
+		return false
+	}
+
+	switch component.Spec.WorkloadType {
+	case corev1alpha1.WorkloadTypeServer, corev1alpha1.WorkloadTypeStatefulSet, "":
+		return true
+	}
+
+	return false
+}
+
+// ReconcilePodDisruptionBudget creates or updates the budget of the component, deletes it once the budget is removed.
+func (r *ComponentReconcilerTask) ReconcilePodDisruptionBudget() error {
+	if !isDisruptionBudgetEnabled(r.component) || !IsNamespaceKalmEnabled(r.namespace) {
+		if r.podDisruptionBudget == nil {
+			return nil
+		}
+
+		if err := r.Delete(r.ctx, r.podDisruptionBudget); client.IgnoreNotFound(err) != nil {
+			r.WarningEvent(err, "unable to delete PodDisruptionBudget for Component")
+			return err
+		}
+
+		r.podDisruptionBudget = nil
+
+		return nil
+	}
+
+	spec := policyV1beta1.PodDisruptionBudgetSpec{
+		MinAvailable:   r.component.Spec.DisruptionBudget.MinAvailable,
+		MaxUnavailable: r.component.Spec.DisruptionBudget.MaxUnavailable,
+		Selector: &metaV1.LabelSelector{
+			MatchLabels: r.getPodSelectorLabels(),
+		},
+	}
+
+	if r.podDisruptionBudget == nil {
+		pdb := &policyV1beta1.PodDisruptionBudget{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      r.component.Name,
+				Namespace: r.component.Namespace,
+				Labels:    r.GetLabels(),
+			},
+			Spec: spec,
+		}
+
+		if err := ctrl.SetControllerReference(r.component, pdb, r.Scheme); err != nil {
+			r.WarningEvent(err, "unable to set owner for PodDisruptionBudget")
+			return err
+		}
+
+		if err := r.Create(r.ctx, pdb); err != nil {
+			r.WarningEvent(err, "unable to create PodDisruptionBudget for Component")
+			return err
+		}
+
+		r.podDisruptionBudget = pdb
+
+		return nil
+	}
+
+	copied := r.podDisruptionBudget.DeepCopy()
+	copied.Spec = spec
+
+	if err := r.Patch(r.ctx, copied, client.MergeFrom(r.podDisruptionBudget)); err != nil {
+		r.WarningEvent(err, "unable to patch PodDisruptionBudget for Component")
+		return err
+	}
+
+	r.podDisruptionBudget = copied
+
+	return nil
+}
+
+func (r *ComponentReconcilerTask) LoadPodDisruptionBudget() error {
+	var pdb policyV1beta1.PodDisruptionBudget
+
+	if err := r.LoadItem(&pdb); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if metaV1.IsControlledBy(&pdb, r.component) {
+		r.podDisruptionBudget = &pdb
+	}
+
+	return nil
+}
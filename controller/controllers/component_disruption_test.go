@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildTopologySpreadConstraints(t *testing.T) {
+	task := &ComponentReconcilerTask{
+		component: &corev1alpha1.Component{
+			ObjectMeta: metaV1.ObjectMeta{Name: "web", Namespace: "app"},
+			Spec: corev1alpha1.ComponentSpec{
+				TopologySpread: []corev1alpha1.TopologySpread{
+					{Topology: corev1alpha1.TopologySpreadZone, Hard: true},
+					{Topology: corev1alpha1.TopologySpreadHost, MaxSkew: 2},
+				},
+			},
+		},
+	}
+
+	constraints := task.buildTopologySpreadConstraints()
+
+	assert.Equal(t, 2, len(constraints))
+	assert.Equal(t, "topology.kubernetes.io/zone", constraints[0].TopologyKey)
+	assert.Equal(t, int32(1), constraints[0].MaxSkew)
+	assert.Equal(t, coreV1.DoNotSchedule, constraints[0].WhenUnsatisfiable)
+	assert.Equal(t, "kubernetes.io/hostname", constraints[1].TopologyKey)
+	assert.Equal(t, int32(2), constraints[1].MaxSkew)
+	assert.Equal(t, coreV1.ScheduleAnyway, constraints[1].WhenUnsatisfiable)
+	assert.Equal(t, map[string]string{KalmLabelNamespaceKey: "app", KalmLabelComponentKey: "web"}, constraints[1].LabelSelector.MatchLabels)
+}
@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	batchV1 "k8s.io/api/batch/v1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const AnnoJobSpecHash = "core.kalm.dev/job-spec-hash"
+
+// prepareJobPodTemplate sets a restart policy allowed by jobs, Always is not allowed.
+// The istio sidecar never exits, pods with it would never complete, so it's not injected into job pods.
+func prepareJobPodTemplate(podTemplateSpec *coreV1.PodTemplateSpec) {
+	if podTemplateSpec.Spec.RestartPolicy == coreV1.RestartPolicyAlways ||
+		podTemplateSpec.Spec.RestartPolicy == "" {
+
+		podTemplateSpec.Spec.RestartPolicy = coreV1.RestartPolicyOnFailure
+	}
+
+	if podTemplateSpec.Annotations == nil {
+		podTemplateSpec.Annotations = make(map[string]string)
+	}
+
+	podTemplateSpec.Annotations["sidecar.istio.io/inject"] = "false"
+}
+
+func buildJobSpec(component *corev1alpha1.Component, podTemplateSpec *coreV1.PodTemplateSpec) batchV1.JobSpec {
+	spec := batchV1.JobSpec{
+		Template: *podTemplateSpec,
+	}
+
+	if options := component.Spec.JobOptions; options != nil {
+		spec.BackoffLimit = options.BackoffLimit
+		spec.ActiveDeadlineSeconds = options.ActiveDeadlineSeconds
+		spec.Parallelism = options.Parallelism
+		spec.Completions = options.Completions
+		spec.TTLSecondsAfterFinished = options.TTLSecondsAfterFinished
+	}
+
+	return spec
+}
+
+func hashJobSpec(spec *batchV1.JobSpec) (string, error) {
+	bts, err := json.Marshal(spec)
+
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", md5.Sum(bts)), nil
+}
+
+// ReconcileJob runs the job once for each version of the spec.
+// Pod templates of jobs are immutable, so the job is deleted and created again when the spec changes.
+func (r *ComponentReconcilerTask) ReconcileJob(podTemplateSpec *coreV1.PodTemplateSpec) error {
+	prepareJobPodTemplate(podTemplateSpec)
+
+	spec := buildJobSpec(r.component, podTemplateSpec)
+	specHash, err := hashJobSpec(&spec)
+
+	if err != nil {
+		return err
+	}
+
+	if r.job != nil {
+		if r.job.Annotations[AnnoJobSpecHash] == specHash {
+			return nil
+		}
+
+		if err := r.Delete(r.ctx, r.job, client.PropagationPolicy(metaV1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			r.WarningEvent(err, "unable to delete outdated Job for Component")
+			return err
+		}
+
+		r.NormalEvent("JobDeleted", r.job.Name+" is deleted to run the new spec.")
+		r.job = nil
+	} else if r.component.Status.Job != nil && r.component.Status.Job.SpecHash == specHash {
+		// the job of this spec has finished and is deleted by ttl
+		return nil
+	}
+
+	annotations := r.GetAnnotations()
+	annotations[AnnoJobSpecHash] = specHash
+
+	job := &batchV1.Job{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:        r.component.Name,
+			Namespace:   r.component.Namespace,
+			Labels:      r.GetLabels(),
+			Annotations: annotations,
+		},
+		Spec: spec,
+	}
+
+	if err := ctrl.SetControllerReference(r.component, job, r.Scheme); err != nil {
+		r.WarningEvent(err, "unable to set owner for Job")
+		return err
+	}
+
+	if err := r.Create(r.ctx, job); err != nil {
+		// the outdated job is not fully deleted yet
+		if errors.IsAlreadyExists(err) {
+			r.requeueAfter = time.Second * 2
+			return nil
+		}
+
+		r.WarningEvent(err, "unable to create Job for Component")
+		return err
+	}
+
+	r.NormalEvent("JobCreated", job.Name+" is created.")
+	r.job = job
+
+	return nil
+}
+
+func (r *ComponentReconcilerTask) LoadJob() error {
+	var job batchV1.Job
+
+	if err := r.LoadItem(&job); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if metaV1.IsControlledBy(&job, r.component) {
+		r.job = &job
+	}
+
+	return nil
+}
+
+// getJobStatus returns the status of the job, the previous status is kept if the job is deleted by ttl.
+func getJobStatus(job *batchV1.Job, previous *corev1alpha1.ComponentJobStatus) *corev1alpha1.ComponentJobStatus {
+	if job == nil {
+		return previous
+	}
+
+	status := &corev1alpha1.ComponentJobStatus{
+		SpecHash:       job.Annotations[AnnoJobSpecHash],
+		Completions:    1,
+		Active:         job.Status.Active,
+		Succeeded:      job.Status.Succeeded,
+		Failed:         job.Status.Failed,
+		StartTime:      job.Status.StartTime,
+		CompletionTime: job.Status.CompletionTime,
+	}
+
+	if job.Spec.Completions != nil {
+		status.Completions = *job.Spec.Completions
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchV1.JobFailed && condition.Status == coreV1.ConditionTrue {
+			status.Reason = condition.Reason
+			status.Message = condition.Message
+		}
+	}
+
+	return status
+}
+
+func getJobWorkloadStatus(jobStatus *corev1alpha1.ComponentJobStatus) workloadStatus {
+	if jobStatus == nil {
+		return workloadStatus{reason: "WorkloadNotFound"}
+	}
+
+	ws := workloadStatus{
+		exist:       true,
+		replicas:    jobStatus.Completions,
+		ready:       jobStatus.Succeeded,
+		available:   jobStatus.Succeeded,
+		progressing: jobStatus.Active > 0,
+	}
+
+	if jobStatus.Reason != "" {
+		ws.degraded = true
+		ws.reason = jobStatus.Reason
+		ws.message = jobStatus.Message
+	}
+
+	return ws
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	batchV1 "k8s.io/api/batch/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildJobSpec(t *testing.T) {
+	backoffLimit := int32(2)
+	completions := int32(3)
+
+	component := &corev1alpha1.Component{
+		Spec: corev1alpha1.ComponentSpec{
+			WorkloadType: corev1alpha1.WorkloadTypeJob,
+			JobOptions: &corev1alpha1.JobOptions{
+				BackoffLimit: &backoffLimit,
+				Completions:  &completions,
+			},
+		},
+	}
+
+	template := &coreV1.PodTemplateSpec{
+		Spec: coreV1.PodSpec{RestartPolicy: coreV1.RestartPolicyAlways},
+	}
+
+	prepareJobPodTemplate(template)
+	assert.Equal(t, coreV1.RestartPolicyOnFailure, template.Spec.RestartPolicy)
+	assert.Equal(t, "false", template.Annotations["sidecar.istio.io/inject"])
+
+	spec := buildJobSpec(component, template)
+	assert.Equal(t, &backoffLimit, spec.BackoffLimit)
+	assert.Equal(t, &completions, spec.Completions)
+	assert.Nil(t, spec.Parallelism)
+
+	hash, err := hashJobSpec(&spec)
+	assert.Nil(t, err)
+
+	sameHash, _ := hashJobSpec(&spec)
+	assert.Equal(t, hash, sameHash)
+
+	spec.Template.Spec.Containers = []coreV1.Container{{Name: "migrate", Image: "migrate:v2"}}
+	newHash, _ := hashJobSpec(&spec)
+	assert.NotEqual(t, hash, newHash)
+}
+
+func TestGetJobStatus(t *testing.T) {
+	previous := &corev1alpha1.ComponentJobStatus{SpecHash: "old", Completions: 1, Succeeded: 1}
+
+	// the job is deleted by ttl
+	assert.Equal(t, previous, getJobStatus(nil, previous))
+
+	job := &batchV1.Job{
+		ObjectMeta: metaV1.ObjectMeta{
+			Annotations: map[string]string{AnnoJobSpecHash: "new"},
+		},
+		Status: batchV1.JobStatus{
+			Failed: 3,
+			Conditions: []batchV1.JobCondition{
+				{
+					Type:    batchV1.JobFailed,
+					Status:  coreV1.ConditionTrue,
+					Reason:  "BackoffLimitExceeded",
+					Message: "Job has reached the specified backoff limit",
+				},
+			},
+		},
+	}
+
+	status := getJobStatus(job, previous)
+	assert.Equal(t, "new", status.SpecHash)
+	assert.Equal(t, int32(1), status.Completions)
+	assert.Equal(t, int32(3), status.Failed)
+	assert.Equal(t, "BackoffLimitExceeded", status.Reason)
+
+	ws := getJobWorkloadStatus(status)
+	assert.True(t, ws.exist)
+	assert.True(t, ws.degraded)
+	assert.Equal(t, "BackoffLimitExceeded", ws.reason)
+
+	ws = getJobWorkloadStatus(previous)
+	assert.False(t, ws.degraded)
+	assert.Equal(t, int32(1), ws.ready)
+
+	assert.False(t, getJobWorkloadStatus(nil).exist)
+}
@@ -18,7 +18,6 @@ package controllers
 import (
 	"context"
 	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
-	"github.com/xeipuuv/gojsonschema"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -109,24 +108,14 @@ func (r *ComponentPluginBindingReconcilerTask) UpdatePluginBindingStatus() error
 	isConfigValid := true
 	var configError string
 
-	if pluginProgram.ConfigSchema != nil {
-		if r.binding.Spec.Config == nil {
-			isConfigValid = false
-			configError = "Configuration is required."
-		} else {
-			pluginConfig := gojsonschema.NewStringLoader(string(r.binding.Spec.Config.Raw))
-			res, err := pluginProgram.ConfigSchema.Validate(pluginConfig)
-
-			if err != nil {
-				isConfigValid = false
-				configError = err.Error()
-			} else {
-				if !res.Valid() {
-					isConfigValid = false
-					configError = res.Errors()[0].String()
-				}
-			}
-		}
+	if errList := corev1alpha1.ValidatePluginConfig(pluginProgram.ConfigSchema, r.binding.Spec.Config); len(errList) > 0 {
+		isConfigValid = false
+		configError = errList.Error()
+	}
+
+	// runtime errors, such as timeouts, are reported by the component controller and cleared once the plugin runs successfully
+	if isConfigValid && r.binding.Status.ConfigValid {
+		configError = r.binding.Status.ConfigError
 	}
 
 	pluginBindingCopy := r.binding.DeepCopy()
@@ -40,6 +40,11 @@ const (
 	ComponentPluginMethodBeforeDeploymentSave       ComponentPluginMethod = "BeforeDeploymentSave"
 	ComponentPluginMethodBeforeServiceSave          ComponentPluginMethod = "BeforeServiceSave"
 	ComponentPluginMethodBeforeCronjobSave          ComponentPluginMethod = "BeforeCronjobSave"
+
+	ComponentPluginMethodAfterServiceGeneration        ComponentPluginMethod = "AfterServiceGeneration"
+	ComponentPluginMethodAfterVirtualServiceGeneration ComponentPluginMethod = "AfterVirtualServiceGeneration"
+	ComponentPluginMethodBeforeDelete                  ComponentPluginMethod = "BeforeDelete"
+	ComponentPluginMethodComputeStatus                 ComponentPluginMethod = "ComputeStatus"
 )
 
 var ValidPluginMethods = []ComponentPluginMethod{
@@ -48,6 +53,10 @@ var ValidPluginMethods = []ComponentPluginMethod{
 	ComponentPluginMethodBeforeDeploymentSave,
 	ComponentPluginMethodBeforeServiceSave,
 	ComponentPluginMethodBeforeCronjobSave,
+	ComponentPluginMethodAfterServiceGeneration,
+	ComponentPluginMethodAfterVirtualServiceGeneration,
+	ComponentPluginMethodBeforeDelete,
+	ComponentPluginMethodComputeStatus,
 }
 
 var componentPluginsCache *ComponentPluginsCache
@@ -179,8 +188,7 @@ func (r *ComponentPluginReconcilerTask) Run(req ctrl.Request) error {
 
 	var configSchema *gojsonschema.Schema
 	if r.plugin.Spec.ConfigSchema != nil {
-		schemaLoader := gojsonschema.NewStringLoader(string(r.plugin.Spec.ConfigSchema.Raw))
-		configSchema, err = gojsonschema.NewSchema(schemaLoader)
+		configSchema, err = corev1alpha1.CompilePluginConfigSchema(r.plugin.Spec.ConfigSchema)
 
 		if err != nil {
 			r.WarningEvent(err, "compile plugin config schema error")
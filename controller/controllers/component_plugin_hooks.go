@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	js "github.com/dop251/goja"
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/utils"
+	"github.com/kalmhq/kalm/controller/vm"
+	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Components with plugins defining BeforeDelete hold this finalizer until the hooks are called.
+const componentPluginFinalizerName = "plugins.finalizers.kalm.dev"
+
+// hasBeforeDeleteHooks returns true if any active plugin bound to the component defines BeforeDelete
+func (r *ComponentReconcilerTask) hasBeforeDeleteHooks() bool {
+	if r.pluginBindings == nil {
+		return false
+	}
+
+	for _, binding := range r.pluginBindings.Items {
+		if binding.DeletionTimestamp != nil || binding.Spec.IsDisabled {
+			continue
+		}
+
+		if binding.Spec.ComponentName != "" && binding.Spec.ComponentName != r.component.Name {
+			continue
+		}
+
+		if program := componentPluginsCache.Get(binding.Spec.PluginName); program != nil && program.Methods[ComponentPluginMethodBeforeDelete] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReconcilePluginFinalizer adds the finalizer if BeforeDelete hooks need to be called on deletion, removes it otherwise.
+func (r *ComponentReconcilerTask) ReconcilePluginFinalizer() error {
+	hasFinalizer := utils.ContainsString(r.component.Finalizers, componentPluginFinalizerName)
+	needFinalizer := r.hasBeforeDeleteHooks()
+
+	if hasFinalizer == needFinalizer {
+		return nil
+	}
+
+	copied := r.component.DeepCopy()
+
+	if needFinalizer {
+		copied.Finalizers = append(copied.Finalizers, componentPluginFinalizerName)
+	} else {
+		copied.Finalizers = utils.RemoveString(copied.Finalizers, componentPluginFinalizerName)
+	}
+
+	if err := r.Patch(r.ctx, copied, client.MergeFrom(r.component)); err != nil {
+		r.WarningEvent(err, "Patch component plugin finalizer error.")
+		return err
+	}
+
+	r.component = copied
+
+	return nil
+}
+
+// HandleDelete calls BeforeDelete hooks and releases the finalizer.
+// Failed hooks are reported as events, but never block the deletion.
+func (r *ComponentReconcilerTask) HandleDelete() error {
+	if !utils.ContainsString(r.component.Finalizers, componentPluginFinalizerName) {
+		return nil
+	}
+
+	if err := r.runPlugins(ComponentPluginMethodBeforeDelete, r.component, nil, r.component); err != nil {
+		r.WarningEvent(err, "Run "+ComponentPluginMethodBeforeDelete+" plugin error, continue deleting.")
+	}
+
+	copied := r.component.DeepCopy()
+	copied.Finalizers = utils.RemoveString(copied.Finalizers, componentPluginFinalizerName)
+
+	if err := r.Patch(r.ctx, copied, client.MergeFrom(r.component)); err != nil {
+		r.WarningEvent(err, "Remove component plugin finalizer error.")
+		return err
+	}
+
+	return nil
+}
+
+// runComputeStatusPlugins lets plugins adjust the computed status, e.g. add custom conditions.
+// The status is left unchanged if any plugin fails.
+func (r *ComponentReconcilerTask) runComputeStatusPlugins(status *corev1alpha1.ComponentStatus) {
+	computed := status.DeepCopy()
+
+	if err := r.runPlugins(ComponentPluginMethodComputeStatus, r.component, computed, computed, r.component); err != nil {
+		r.WarningEvent(err, "Run "+ComponentPluginMethodComputeStatus+" plugin error.")
+		return
+	}
+
+	*status = *computed
+}
+
+// runHttpRoutePlugins runs AfterVirtualServiceGeneration of plugins in the namespace of the route,
+// on the istio http rules generated from the route. Only application wide bindings and
+// bindings of components that are destinations of the route are applied.
+// The virtual service is shared by routes of the same host across namespaces, so plugins can only change the rules of their own route.
+func (r *HttpRouteReconcilerTask) runHttpRoutePlugins(route *corev1alpha1.HttpRoute, httpRoutes []*istioNetworkingV1Beta1.HTTPRoute) []*istioNetworkingV1Beta1.HTTPRoute {
+	bindings, err := r.getNamespacePluginBindings(route.Namespace)
+
+	if err != nil {
+		r.EmitWarningEvent(route, err, "get plugin bindings error")
+		return httpRoutes
+	}
+
+	destinations := make(map[string]bool)
+
+	for _, destination := range route.Spec.Destinations {
+		name, namespace, _ := parseDestinationHost(destination.Host, route.Namespace)
+
+		if namespace == route.Namespace {
+			destinations[name] = true
+		}
+	}
+
+	for _, binding := range bindings {
+		if binding.DeletionTimestamp != nil || binding.Spec.IsDisabled {
+			continue
+		}
+
+		if binding.Spec.ComponentName != "" && !destinations[binding.Spec.ComponentName] {
+			continue
+		}
+
+		program := componentPluginsCache.Get(binding.Spec.PluginName)
+
+		if program == nil || !program.Methods[ComponentPluginMethodAfterVirtualServiceGeneration] {
+			continue
+		}
+
+		if errList := corev1alpha1.ValidatePluginConfig(program.ConfigSchema, binding.Spec.Config); len(errList) > 0 {
+			r.EmitWarningEvent(route, errList, "Plugin %s config is invalid, skip it.", binding.Spec.PluginName)
+			continue
+		}
+
+		var config []byte
+
+		if binding.Spec.Config != nil {
+			config = binding.Spec.Config.Raw
+		}
+
+		rt := vm.InitRuntime()
+
+		rt.Set("getApplicationName", func(call js.FunctionCall) js.Value {
+			return rt.ToValue(route.Namespace)
+		})
+
+		rt.Set("getCurrentHttpRoute", func(call js.FunctionCall) js.Value {
+			bts, _ := json.Marshal(route)
+			var res map[string]interface{}
+			_ = json.Unmarshal(bts, &res)
+			return rt.ToValue(res)
+		})
+
+		var res []*istioNetworkingV1Beta1.HTTPRoute
+
+		// istio types are only marshaled correctly by their own json marshalers, pass them as a raw json array
+		bts, err := json.Marshal(httpRoutes)
+
+		if err == nil {
+			var arg []interface{}
+			err = json.Unmarshal(bts, &arg)
+
+			if err == nil {
+				err = vm.RunMethod(rt, program.Program, ComponentPluginMethodAfterVirtualServiceGeneration, config, &res, arg, route)
+			}
+		}
+
+		if err != nil {
+			r.EmitWarningEvent(route, err, "Run plugin %s %s error, the rules are generated without it.", binding.Spec.PluginName, ComponentPluginMethodAfterVirtualServiceGeneration)
+			continue
+		}
+
+		httpRoutes = res
+	}
+
+	return httpRoutes
+}
+
+func (r *HttpRouteReconcilerTask) getNamespacePluginBindings(namespace string) ([]corev1alpha1.ComponentPluginBinding, error) {
+	if r.pluginBindings == nil {
+		r.pluginBindings = make(map[string][]corev1alpha1.ComponentPluginBinding)
+	}
+
+	if bindings, exist := r.pluginBindings[namespace]; exist {
+		return bindings, nil
+	}
+
+	var bindingList corev1alpha1.ComponentPluginBindingList
+
+	if err := r.Reader.List(r.ctx, &bindingList, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("list plugin bindings in %s error: %s", namespace, err.Error())
+	}
+
+	r.pluginBindings[namespace] = bindingList.Items
+
+	return bindingList.Items, nil
+}
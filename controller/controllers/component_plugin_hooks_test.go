@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/vm"
+	"github.com/stretchr/testify/assert"
+	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRunHttpRoutePlugins(t *testing.T) {
+	src := `
+function AfterVirtualServiceGeneration(httpRoutes, route) {
+	for (var i = 0; i < httpRoutes.length; i++) {
+		httpRoutes[i].headers = {request: {set: {"x-org": getConfig().org, "x-route": route.metadata.name}}};
+	}
+	return httpRoutes;
+}`
+
+	program, err := vm.CompileProgram(src)
+	assert.Nil(t, err)
+
+	methods, err := vm.GetDefinedMethods(src, ValidPluginMethods)
+	assert.Nil(t, err)
+
+	componentPluginsCache.Set("org-headers", &ComponentPluginProgram{
+		Program: program,
+		Name:    "org-headers",
+		Methods: methods,
+	})
+	defer componentPluginsCache.Delete("org-headers")
+
+	scheme := runtime.NewScheme()
+	_ = corev1alpha1.AddToScheme(scheme)
+
+	bindings := []runtime.Object{
+		&corev1alpha1.ComponentPluginBinding{
+			ObjectMeta: metaV1.ObjectMeta{Name: "app-wide", Namespace: "app"},
+			Spec: corev1alpha1.ComponentPluginBindingSpec{
+				PluginName: "org-headers",
+				Config:     &runtime.RawExtension{Raw: []byte(`{"org": "kalm"}`)},
+			},
+		},
+	}
+
+	task := &HttpRouteReconcilerTask{
+		HttpRouteReconciler: &HttpRouteReconciler{&BaseReconciler{
+			Reader:   fake.NewFakeClientWithScheme(scheme, bindings...),
+			Log:      ctrl.Log,
+			Recorder: record.NewFakeRecorder(10),
+		}},
+		ctx: context.Background(),
+	}
+
+	route := &corev1alpha1.HttpRoute{
+		ObjectMeta: metaV1.ObjectMeta{Name: "route", Namespace: "app"},
+		Spec: corev1alpha1.HttpRouteSpec{
+			Destinations: []corev1alpha1.HttpRouteDestination{{Host: "web.app.svc.cluster.local:80"}},
+		},
+	}
+
+	res := task.runHttpRoutePlugins(route, []*istioNetworkingV1Beta1.HTTPRoute{{Name: "app-route"}})
+
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, "app-route", res[0].Name)
+	assert.Equal(t, map[string]string{"x-org": "kalm", "x-route": "route"}, res[0].Headers.Request.Set)
+
+	// bindings in other namespaces are not applied
+	route.Namespace = "other"
+	res = task.runHttpRoutePlugins(route, []*istioNetworkingV1Beta1.HTTPRoute{{Name: "other-route"}})
+	assert.Nil(t, res[0].Headers)
+}
+
+func TestAddHostRulesOfMultiHostRoute(t *testing.T) {
+	src := `
+function AfterVirtualServiceGeneration(httpRoutes, route) {
+	var res = [];
+	for (var i = 0; i < httpRoutes.length; i++) {
+		res.push(httpRoutes[i]);
+	}
+	res.push({name: "plugin-rule", route: httpRoutes[0].route});
+	return res;
+}`
+
+	program, err := vm.CompileProgram(src)
+	assert.Nil(t, err)
+
+	methods, err := vm.GetDefinedMethods(src, ValidPluginMethods)
+	assert.Nil(t, err)
+
+	componentPluginsCache.Set("extra-rule", &ComponentPluginProgram{
+		Program: program,
+		Name:    "extra-rule",
+		Methods: methods,
+	})
+	defer componentPluginsCache.Delete("extra-rule")
+
+	scheme := runtime.NewScheme()
+	_ = corev1alpha1.AddToScheme(scheme)
+
+	binding := &corev1alpha1.ComponentPluginBinding{
+		ObjectMeta: metaV1.ObjectMeta{Name: "app-wide", Namespace: "app"},
+		Spec:       corev1alpha1.ComponentPluginBindingSpec{PluginName: "extra-rule"},
+	}
+
+	task := &HttpRouteReconcilerTask{
+		HttpRouteReconciler: &HttpRouteReconciler{&BaseReconciler{
+			Reader:   fake.NewFakeClientWithScheme(scheme, binding),
+			Log:      ctrl.Log,
+			Recorder: record.NewFakeRecorder(10),
+		}},
+		ctx: context.Background(),
+	}
+
+	route := &corev1alpha1.HttpRoute{
+		ObjectMeta: metaV1.ObjectMeta{Name: "route", Namespace: "app"},
+		Spec: corev1alpha1.HttpRouteSpec{
+			Hosts:        []string{"a.example.com", "b.example.com"},
+			Paths:        []string{"/"},
+			Methods:      []corev1alpha1.HttpRouteMethod{"GET"},
+			Schemes:      []corev1alpha1.HttpRouteScheme{"http"},
+			Destinations: []corev1alpha1.HttpRouteDestination{{Host: "web:80", Weight: 1}},
+		},
+	}
+
+	hostRules := make(map[string][]httpRouteRule)
+	task.addHostRules(hostRules, route, corev1alpha1.PortProtocolHTTP)
+
+	assert.Len(t, hostRules, 2)
+	assert.Len(t, hostRules["a.example.com"], 2)
+	assert.Len(t, hostRules["b.example.com"], 2)
+	assert.Equal(t, "plugin-rule", hostRules["a.example.com"][1].rule.Name)
+
+	// plugins run once for the route, all hosts share the same rules
+	for i := range hostRules["a.example.com"] {
+		assert.Same(t, hostRules["a.example.com"][i].rule, hostRules["b.example.com"][i].rule)
+		assert.Equal(t, route, hostRules["b.example.com"][i].route)
+	}
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/vm"
+	coreV1 "k8s.io/api/core/v1"
+	rbacV1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// componentPluginHost implements the host api of plugins bound to a component.
+// Lookups are limited to the namespace of the component, and are only allowed
+// if the RunnerPermission of the component grants "get" on the resource.
+type componentPluginHost struct {
+	task       *ComponentReconcilerTask
+	component  *corev1alpha1.Component
+	pluginName string
+}
+
+var _ vm.Host = &componentPluginHost{}
+
+func (h *componentPluginHost) checkPermission(apiGroup, resource, name string) error {
+	if !runnerPermissionAllows(h.component.Spec.RunnerPermission, apiGroup, resource, "get", name) {
+		return fmt.Errorf("plugin %s has no permission to get %s %s, grant it in runnerPermission of component %s", h.pluginName, resource, name, h.component.Name)
+	}
+
+	return nil
+}
+
+func (h *componentPluginHost) GetComponent() (interface{}, error) {
+	if err := h.checkPermission(corev1alpha1.GroupVersion.Group, "components", h.component.Name); err != nil {
+		return nil, err
+	}
+
+	return h.component, nil
+}
+
+func (h *componentPluginHost) GetNamespaceLabels() (map[string]string, error) {
+	if err := h.checkPermission(coreV1.GroupName, "namespaces", h.component.Namespace); err != nil {
+		return nil, err
+	}
+
+	return h.task.namespace.Labels, nil
+}
+
+func (h *componentPluginHost) GetSecret(name string) (map[string]string, error) {
+	if err := h.checkPermission(coreV1.GroupName, "secrets", name); err != nil {
+		return nil, err
+	}
+
+	var secret coreV1.Secret
+
+	if err := h.task.Reader.Get(h.task.ctx, types.NamespacedName{Namespace: h.component.Namespace, Name: name}, &secret); err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]string, len(secret.Data))
+
+	for k, v := range secret.Data {
+		res[k] = string(v)
+	}
+
+	return res, nil
+}
+
+func (h *componentPluginHost) GetConfigMap(name string) (map[string]string, error) {
+	if err := h.checkPermission(coreV1.GroupName, "configmaps", name); err != nil {
+		return nil, err
+	}
+
+	var configMap coreV1.ConfigMap
+
+	if err := h.task.Reader.Get(h.task.ctx, types.NamespacedName{Namespace: h.component.Namespace, Name: name}, &configMap); err != nil {
+		return nil, err
+	}
+
+	return configMap.Data, nil
+}
+
+// Log records plugin logs in the controller log, and as events of the component.
+func (h *componentPluginHost) Log(level, msg string, fields map[string]interface{}) {
+	keysAndValues := []interface{}{"plugin", h.pluginName, "component", h.component.Name, "namespace", h.component.Namespace}
+	keys := make([]string, 0, len(fields))
+
+	for k := range fields {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(msg)
+
+	for _, k := range keys {
+		keysAndValues = append(keysAndValues, k, fields[k])
+		sb.WriteString(fmt.Sprintf(" %s=%v", k, fields[k]))
+	}
+
+	eventType := coreV1.EventTypeNormal
+
+	if level != vm.LogLevelInfo {
+		eventType = coreV1.EventTypeWarning
+	}
+
+	h.task.Log.Info(msg, append(keysAndValues, "level", level)...)
+	h.task.Recorder.Eventf(h.component, eventType, "PluginLog", "[%s] %s", h.pluginName, sb.String())
+}
+
+// runnerPermissionAllows returns true if any rule of the permission grants the verb on the resource.
+// Rules without resourceNames apply to all objects of the resource.
+func runnerPermissionAllows(permission *corev1alpha1.RunnerPermission, apiGroup, resource, verb, name string) bool {
+	if permission == nil {
+		return false
+	}
+
+	for _, rule := range permission.Rules {
+		if !policyRuleContains(rule.APIGroups, apiGroup) ||
+			!policyRuleContains(rule.Resources, resource) ||
+			!policyRuleContains(rule.Verbs, verb) {
+			continue
+		}
+
+		if len(rule.ResourceNames) == 0 {
+			return true
+		}
+
+		for _, resourceName := range rule.ResourceNames {
+			if resourceName == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func policyRuleContains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == rbacV1.ResourceAll {
+			return true
+		}
+	}
+
+	return false
+}
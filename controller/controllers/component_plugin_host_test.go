@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	rbacV1 "k8s.io/api/rbac/v1"
+)
+
+func TestRunnerPermissionAllows(t *testing.T) {
+	assert.False(t, runnerPermissionAllows(nil, "", "secrets", "get", "db"))
+
+	permission := &corev1alpha1.RunnerPermission{
+		RoleType: "role",
+		Rules: []rbacV1.PolicyRule{
+			{
+				APIGroups:     []string{""},
+				Resources:     []string{"secrets"},
+				Verbs:         []string{"get"},
+				ResourceNames: []string{"db"},
+			},
+			{
+				APIGroups: []string{"*"},
+				Resources: []string{"configmaps"},
+				Verbs:     []string{"*"},
+			},
+		},
+	}
+
+	assert.True(t, runnerPermissionAllows(permission, "", "secrets", "get", "db"))
+	assert.False(t, runnerPermissionAllows(permission, "", "secrets", "get", "other"))
+	assert.False(t, runnerPermissionAllows(permission, "", "secrets", "list", "db"))
+	assert.True(t, runnerPermissionAllows(permission, "", "configmaps", "get", "any"))
+	assert.False(t, runnerPermissionAllows(permission, corev1alpha1.GroupVersion.Group, "components", "get", "web"))
+}
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// How many revisions are kept for each component, older ones will be deleted.
+const ComponentRevisionHistoryLimit = 10
+
+// +kubebuilder:rbac:groups=core.kalm.dev,resources=componentrevisions,verbs=get;list;watch;create;update;patch;delete
+
+// ReconcileRevision snapshots the current component spec into a ComponentRevision if it differs from the latest one.
+func (r *ComponentReconcilerTask) ReconcileRevision() error {
+	var revisionList corev1alpha1.ComponentRevisionList
+
+	if err := r.Reader.List(
+		r.ctx,
+		&revisionList,
+		client.InNamespace(r.component.Namespace),
+		client.MatchingLabels{corev1alpha1.ComponentRevisionLabelComponent: r.component.Name},
+	); err != nil {
+		return err
+	}
+
+	revisions := revisionList.Items
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Spec.Revision < revisions[j].Spec.Revision
+	})
+
+	specHash, err := ComponentSpecHash(&r.component.Spec)
+
+	if err != nil {
+		return err
+	}
+
+	var latest int64
+
+	if len(revisions) > 0 {
+		latestRevision := revisions[len(revisions)-1]
+
+		if latestRevision.Labels[corev1alpha1.ComponentRevisionLabelSpecHash] == specHash {
+			return nil
+		}
+
+		latest = latestRevision.Spec.Revision
+	}
+
+	revision := &corev1alpha1.ComponentRevision{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      ComponentRevisionName(r.component.Name, latest+1),
+			Namespace: r.component.Namespace,
+			Labels: map[string]string{
+				corev1alpha1.ComponentRevisionLabelComponent: r.component.Name,
+				corev1alpha1.ComponentRevisionLabelSpecHash:  specHash,
+				KalmLabelManaged: "true",
+			},
+		},
+		Spec: corev1alpha1.ComponentRevisionSpec{
+			ComponentName: r.component.Name,
+			Revision:      latest + 1,
+			ComponentSpec: *r.component.Spec.DeepCopy(),
+			ChangedBy:     r.component.Annotations[corev1alpha1.AnnoComponentChangedBy],
+			ChangeCause:   r.component.Annotations[corev1alpha1.AnnoComponentChangeCause],
+		},
+	}
+
+	if err := ctrl.SetControllerReference(r.component, revision, r.Scheme); err != nil {
+		r.WarningEvent(err, "unable to set owner for ComponentRevision")
+		return err
+	}
+
+	if err := r.Create(r.ctx, revision); err != nil {
+		// the cache is not synced with the revision created in last reconcile yet
+		if errors.IsAlreadyExists(err) {
+			return nil
+		}
+
+		r.WarningEvent(err, "unable to create ComponentRevision for Component")
+		return err
+	}
+
+	r.NormalEvent("RevisionCreated", "Revision %d is created for component %s", revision.Spec.Revision, r.component.Name)
+
+	revisions = append(revisions, *revision)
+
+	for i := 0; i < len(revisions)-ComponentRevisionHistoryLimit; i++ {
+		if err := r.Delete(r.ctx, &revisions[i]); client.IgnoreNotFound(err) != nil {
+			r.WarningEvent(err, "unable to delete old ComponentRevision %s", revisions[i].Name)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ComponentRevisionName(componentName string, revision int64) string {
+	return fmt.Sprintf("%s-%d", componentName, revision)
+}
+
+func ComponentSpecHash(spec *corev1alpha1.ComponentSpec) (string, error) {
+	bts, err := json.Marshal(spec)
+
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", md5.Sum(bts)), nil
+}
+
+// ComponentRolloutSpecHash is the hash of the spec changes which are rolled out through the new version.
+// Scaling settings are applied to the running versions directly, changing them doesn't start a rollout.
+func ComponentRolloutSpecHash(spec *corev1alpha1.ComponentSpec) (string, error) {
+	copied := spec.DeepCopy()
+	copied.Replicas = nil
+	copied.Autoscaling = nil
+
+	return ComponentSpecHash(copied)
+}
+
+// getRevisionSpecByRolloutHash returns the component spec of the latest revision with the given rollout hash, nil if not found.
+func (r *ComponentReconcilerTask) getRevisionSpecByRolloutHash(rolloutHash string) (*corev1alpha1.ComponentSpec, error) {
+	var revisionList corev1alpha1.ComponentRevisionList
+
+	if err := r.Reader.List(
+		r.ctx,
+		&revisionList,
+		client.InNamespace(r.component.Namespace),
+		client.MatchingLabels{corev1alpha1.ComponentRevisionLabelComponent: r.component.Name},
+	); err != nil {
+		return nil, err
+	}
+
+	revisions := revisionList.Items
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Spec.Revision > revisions[j].Spec.Revision
+	})
+
+	for i := range revisions {
+		hash, err := ComponentRolloutSpecHash(&revisions[i].Spec.ComponentSpec)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if hash == rolloutHash {
+			return &revisions[i].Spec.ComponentSpec, nil
+		}
+	}
+
+	return nil, nil
+}
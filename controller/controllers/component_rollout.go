@@ -0,0 +1,559 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// Pods of the new version are labeled with this key instead of KalmLabelComponentKey,
+	// so the stable service won't send traffic to them.
+	KalmLabelCanaryComponentKey = "kalm-canary-component"
+
+	rolloutCheckInterval           = 10 * time.Second
+	rolloutDefaultAnalysisInterval = 30 * time.Second
+	canaryReleaseCheckInterval     = 2 * time.Second
+)
+
+func getNameForCanary(componentName string) string {
+	return fmt.Sprintf("%s-canary", componentName)
+}
+
+func isRolloutEnabled(component *corev1alpha1.Component) bool {
+	return component.Spec.RolloutStrategy != nil &&
+		(component.Spec.WorkloadType == corev1alpha1.WorkloadTypeServer || component.Spec.WorkloadType == "")
+}
+
+// ReconcileRollout runs the stable and the new version side by side and moves the rollout forward step by step.
+// The traffic split is applied by http route controller according to status.rollout.canaryWeight.
+func (r *ComponentReconcilerTask) ReconcileRollout() error {
+	if !IsNamespaceKalmEnabled(r.namespace) {
+		if err := r.deleteCanary(); err != nil {
+			return err
+		}
+
+		return r.ReconcileWorkload()
+	}
+
+	specHash, err := ComponentRolloutSpecHash(&r.component.Spec)
+
+	if err != nil {
+		return err
+	}
+
+	if r.rollout == nil {
+		r.rollout = &corev1alpha1.ComponentRolloutStatus{}
+	}
+
+	// first deployment, or the strategy is just enabled. The current spec is considered as stable.
+	if r.rollout.StableSpecHash == "" || r.deployment == nil {
+		r.rollout.StableSpecHash = specHash
+		r.rollout.Phase = corev1alpha1.RolloutPhaseSucceeded
+	}
+
+	if specHash == r.rollout.StableSpecHash {
+		if r.rollout.Phase == corev1alpha1.RolloutPhaseProgressing {
+			r.rollout.Phase = corev1alpha1.RolloutPhaseAborted
+			r.rollout.Message = "rollout is cancelled, the spec is reverted to the stable one"
+		}
+
+		r.finishRollout()
+
+		if err := r.releaseCanary(); err != nil {
+			return err
+		}
+
+		return r.ReconcileWorkload()
+	}
+
+	stableSpec, err := r.getRevisionSpecByRolloutHash(r.rollout.StableSpecHash)
+
+	if err != nil {
+		return err
+	}
+
+	// scaling is not rolled out, the stable version follows the current settings
+	if stableSpec != nil {
+		stableSpec.Replicas = r.component.Spec.Replicas
+		stableSpec.Autoscaling = r.component.Spec.Autoscaling
+	}
+
+	if stableSpec == nil {
+		r.NormalEvent("RolloutSkipped", "Stable revision of component %s is not found, the new spec is applied directly.", r.component.Name)
+		r.rollout.StableSpecHash = specHash
+		r.rollout.Phase = corev1alpha1.RolloutPhaseSucceeded
+		r.finishRollout()
+
+		if err := r.releaseCanary(); err != nil {
+			return err
+		}
+
+		return r.ReconcileWorkload()
+	}
+
+	if r.rollout.CanarySpecHash != specHash {
+		r.NormalEvent("RolloutStarted", "Start rolling out component %s with %s strategy.", r.component.Name, r.component.Spec.RolloutStrategy.Type)
+		r.rollout.CanarySpecHash = specHash
+		r.rollout.Phase = corev1alpha1.RolloutPhaseProgressing
+		r.rollout.Step = 0
+		r.rollout.CanaryWeight = 0
+		r.rollout.StepStartedAt = nil
+		r.rollout.Message = ""
+	}
+
+	// the aborted spec is kept as is until the component is changed again
+	if r.rollout.Phase == corev1alpha1.RolloutPhaseAborted {
+		if err := r.releaseCanary(); err != nil {
+			return err
+		}
+
+		return r.reconcileWorkloadWithSpec(stableSpec)
+	}
+
+	steps := r.component.Spec.RolloutStrategy.EffectiveSteps()
+
+	if r.rollout.Step >= len(steps) {
+		return r.promoteRollout(specHash)
+	}
+
+	if err := r.reconcileWorkloadWithSpec(stableSpec); err != nil {
+		return err
+	}
+
+	if err := r.reconcileCanary(); err != nil {
+		return err
+	}
+
+	canaryStatus := getDeploymentStatus(r.canaryDeployment)
+	r.rollout.CanaryReadyReplicas = canaryStatus.ready
+
+	if canaryStatus.degraded {
+		return r.abortRollout(stableSpec, fmt.Sprintf("new version is degraded: %s", canaryStatus.message))
+	}
+
+	if canaryStatus.progressing || canaryStatus.ready < canaryStatus.replicas {
+		r.rollout.Message = fmt.Sprintf("waiting for new version to be ready, %d/%d replicas are ready", canaryStatus.ready, canaryStatus.replicas)
+		r.requeueAfter = rolloutCheckInterval
+		return nil
+	}
+
+	step := steps[r.rollout.Step]
+
+	if r.rollout.StepStartedAt == nil {
+		now := metaV1.Now()
+		r.rollout.StepStartedAt = &now
+		r.rollout.CanaryWeight = step.Weight
+		r.rollout.Message = fmt.Sprintf("step %d/%d, %d%% traffic is sent to new version", r.rollout.Step+1, len(steps), step.Weight)
+		r.NormalEvent("RolloutStepStarted", "Component %s rollout %s", r.component.Name, r.rollout.Message)
+	}
+
+	analysis := r.component.Spec.RolloutStrategy.Analysis
+
+	if analysis != nil && r.rollout.CanaryWeight > 0 {
+		host := fmt.Sprintf("%s.%s.svc.cluster.local", getNameForCanary(r.component.Name), r.component.Namespace)
+		errorRate, hasData, err := canaryErrorRate(host)
+
+		if err != nil {
+			// prometheus is not a hard dependency, keep going and check again later
+			r.Log.Error(err, "query error rate of canary failed", "host", host)
+		} else if hasData && errorRate > float64(analysis.MaxErrorRatePercent) {
+			return r.abortRollout(stableSpec, fmt.Sprintf("error rate of new version is %.2f%%, exceeds %d%%", errorRate, analysis.MaxErrorRatePercent))
+		}
+	}
+
+	pause := time.Duration(step.PauseSeconds) * time.Second
+	elapsed := time.Since(r.rollout.StepStartedAt.Time)
+
+	if elapsed < pause {
+		r.requeueAfter = pause - elapsed
+
+		if analysis != nil {
+			interval := rolloutDefaultAnalysisInterval
+
+			if analysis.IntervalSeconds > 0 {
+				interval = time.Duration(analysis.IntervalSeconds) * time.Second
+			}
+
+			if interval < r.requeueAfter {
+				r.requeueAfter = interval
+			}
+		}
+
+		return nil
+	}
+
+	r.rollout.Step++
+	r.rollout.StepStartedAt = nil
+	r.requeueAfter = time.Second
+
+	return nil
+}
+
+// promoteRollout applies the new spec to the stable workload, the new version keeps receiving traffic until the stable one is ready.
+func (r *ComponentReconcilerTask) promoteRollout(specHash string) error {
+	if err := r.ReconcileWorkload(); err != nil {
+		return err
+	}
+
+	ws := getDeploymentStatus(r.deployment)
+
+	if ws.progressing || ws.ready < ws.replicas {
+		r.rollout.Message = fmt.Sprintf("promoting new version, %d/%d replicas are ready", ws.ready, ws.replicas)
+		r.requeueAfter = rolloutCheckInterval
+		return nil
+	}
+
+	r.rollout.StableSpecHash = specHash
+	r.rollout.Phase = corev1alpha1.RolloutPhaseSucceeded
+	r.rollout.Message = ""
+	r.finishRollout()
+
+	r.NormalEvent("RolloutSucceeded", "Component %s is rolled out.", r.component.Name)
+
+	return r.releaseCanary()
+}
+
+// abortRollout sends all traffic back to the stable version and reverts the component spec to it.
+// The new version is deleted by later reconciles, once routes don't send traffic to it.
+func (r *ComponentReconcilerTask) abortRollout(stableSpec *corev1alpha1.ComponentSpec, reason string) error {
+	r.rollout.Phase = corev1alpha1.RolloutPhaseAborted
+	r.rollout.Message = reason
+	r.rollout.CanaryWeight = 0
+	r.rollout.StepStartedAt = nil
+
+	r.EmitWarningEvent(r.component, fmt.Errorf(reason), "Rollout of component %s is aborted.", r.component.Name)
+
+	copiedComponent := r.component.DeepCopy()
+	copiedComponent.Spec = *stableSpec.DeepCopy()
+
+	if copiedComponent.Annotations == nil {
+		copiedComponent.Annotations = make(map[string]string)
+	}
+
+	copiedComponent.Annotations[corev1alpha1.AnnoComponentChangedBy] = "kalm"
+	copiedComponent.Annotations[corev1alpha1.AnnoComponentChangeCause] = fmt.Sprintf("automatic rollback, %s", reason)
+
+	if err := r.Patch(r.ctx, copiedComponent, client.MergeFrom(r.component)); err != nil {
+		r.WarningEvent(err, "unable to rollback component spec")
+		return err
+	}
+
+	return nil
+}
+
+func (r *ComponentReconcilerTask) finishRollout() {
+	r.rollout.CanarySpecHash = ""
+	r.rollout.CanaryWeight = 0
+	r.rollout.CanaryReadyReplicas = 0
+	r.rollout.Step = 0
+	r.rollout.StepStartedAt = nil
+}
+
+// reconcileWorkloadWithSpec reconciles the stable workload with the given spec instead of the spec of component.
+func (r *ComponentReconcilerTask) reconcileWorkloadWithSpec(spec *corev1alpha1.ComponentSpec) error {
+	currentSpec := r.component.Spec
+	r.component.Spec = *spec
+
+	defer func() {
+		r.component.Spec = currentSpec
+	}()
+
+	return r.ReconcileWorkload()
+}
+
+func (r *ComponentReconcilerTask) getCanaryLabels() map[string]string {
+	labels := r.GetLabels()
+	delete(labels, KalmLabelComponentKey)
+	labels[KalmLabelCanaryComponentKey] = r.component.Name
+
+	return labels
+}
+
+func (r *ComponentReconcilerTask) reconcileCanary() error {
+	template, err := r.GetPodTemplateWithoutVols()
+
+	if err != nil {
+		return err
+	}
+
+	if err := r.prepareVolsForSimpleWorkload(template); err != nil {
+		return err
+	}
+
+	labels := r.getCanaryLabels()
+	template.Labels = labels
+
+	deployment := r.canaryDeployment
+	isNewDeployment := deployment == nil
+
+	if isNewDeployment {
+		deployment = &appsV1.Deployment{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      getNameForCanary(r.component.Name),
+				Namespace: r.component.Namespace,
+				Labels:    labels,
+			},
+			Spec: appsV1.DeploymentSpec{
+				Selector: &metaV1.LabelSelector{
+					MatchLabels: labels,
+				},
+			},
+		}
+	}
+
+	deployment.Spec.Template = *template
+	deployment.Spec.Replicas = r.component.Spec.Replicas
+
+	// follow the replicas decided by the autoscaler of the stable version
+	if isAutoscalingEnabled(r.component) && r.deployment != nil {
+		deployment.Spec.Replicas = r.deployment.Spec.Replicas
+	}
+
+	if err := ctrl.SetControllerReference(r.component, deployment, r.Scheme); err != nil {
+		r.WarningEvent(err, "unable to set owner for canary deployment")
+		return err
+	}
+
+	if isNewDeployment {
+		if err := r.Create(r.ctx, deployment); err != nil {
+			r.WarningEvent(err, "unable to create canary Deployment for Component")
+			return err
+		}
+	} else {
+		if err := r.Update(r.ctx, deployment); err != nil {
+			r.WarningEvent(err, "unable to update canary Deployment for Component")
+			return err
+		}
+	}
+
+	r.canaryDeployment = deployment
+
+	if r.service == nil {
+		return nil
+	}
+
+	service := r.canaryService
+	isNewService := service == nil
+
+	if isNewService {
+		service = &coreV1.Service{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      getNameForCanary(r.component.Name),
+				Namespace: r.component.Namespace,
+				Labels:    labels,
+			},
+		}
+	}
+
+	service.Spec.Selector = labels
+	service.Spec.Ports = r.service.Spec.Ports
+
+	if err := ctrl.SetControllerReference(r.component, service, r.Scheme); err != nil {
+		r.WarningEvent(err, "unable to set owner for canary Service")
+		return err
+	}
+
+	if isNewService {
+		if err := r.Create(r.ctx, service); err != nil {
+			r.WarningEvent(err, "unable to create canary Service for Component")
+			return err
+		}
+	} else {
+		if err := r.Update(r.ctx, service); err != nil {
+			r.WarningEvent(err, "unable to update canary Service for Component")
+			return err
+		}
+	}
+
+	r.canaryService = service
+
+	return nil
+}
+
+// releaseCanary deletes the new version once no traffic is sent to it.
+// The zero canary weight is saved in status at the end of the reconcile, the http route controller removes the canary
+// from virtual services after seeing it, so the deletion is retried until both have happened.
+func (r *ComponentReconcilerTask) releaseCanary() error {
+	if r.canaryDeployment == nil && r.canaryService == nil {
+		return nil
+	}
+
+	routed, err := r.isCanaryRouted()
+
+	if err != nil {
+		return err
+	}
+
+	if routed {
+		r.requeueAfter = canaryReleaseCheckInterval
+		return nil
+	}
+
+	return r.deleteCanary()
+}
+
+// isCanaryRouted returns true if the saved status still has a canary weight, or virtual services still send traffic to the canary service.
+func (r *ComponentReconcilerTask) isCanaryRouted() (bool, error) {
+	if r.component.Status.Rollout != nil && r.component.Status.Rollout.CanaryWeight > 0 {
+		return true, nil
+	}
+
+	var virtualServices v1beta1.VirtualServiceList
+
+	if err := r.Reader.List(r.ctx, &virtualServices, client.MatchingLabels{KALM_ROUTE_LABEL: "true"}); err != nil {
+		return false, err
+	}
+
+	host := fmt.Sprintf("%s.%s.svc.cluster.local", getNameForCanary(r.component.Name), r.component.Namespace)
+
+	return virtualServicesHaveDestination(virtualServices.Items, host), nil
+}
+
+func virtualServicesHaveDestination(virtualServices []v1beta1.VirtualService, host string) bool {
+	for _, vs := range virtualServices {
+		for _, httpRoute := range vs.Spec.Http {
+			for _, destination := range httpRoute.Route {
+				if destination.Destination != nil && destination.Destination.Host == host {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func (r *ComponentReconcilerTask) deleteCanary() error {
+	if r.canaryService != nil {
+		if err := r.Delete(r.ctx, r.canaryService); client.IgnoreNotFound(err) != nil {
+			r.WarningEvent(err, "unable to delete canary Service")
+			return err
+		}
+
+		r.canaryService = nil
+	}
+
+	if r.canaryDeployment != nil {
+		if err := r.Delete(r.ctx, r.canaryDeployment); client.IgnoreNotFound(err) != nil {
+			r.WarningEvent(err, "unable to delete canary Deployment")
+			return err
+		}
+
+		r.canaryDeployment = nil
+	}
+
+	return nil
+}
+
+func (r *ComponentReconcilerTask) LoadCanary() error {
+	key := types.NamespacedName{
+		Namespace: r.component.Namespace,
+		Name:      getNameForCanary(r.component.Name),
+	}
+
+	var deployment appsV1.Deployment
+
+	if err := r.Reader.Get(r.ctx, key, &deployment); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	} else if metaV1.IsControlledBy(&deployment, r.component) {
+		r.canaryDeployment = &deployment
+	}
+
+	var service coreV1.Service
+
+	if err := r.Reader.Get(r.ctx, key, &service); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	} else if metaV1.IsControlledBy(&service, r.component) {
+		r.canaryService = &service
+	}
+
+	return nil
+}
+
+var istioPrometheusAPIAddress = func() string {
+	if os.Getenv("KALM_ISTIO_PROMETHEUS_API_ADDRESS") != "" {
+		return os.Getenv("KALM_ISTIO_PROMETHEUS_API_ADDRESS")
+	}
+
+	return "http://prometheus.istio-system:9090"
+}()
+
+// canaryErrorRate returns the percentage of 5xx responses of the given service host.
+// It's a variable so that it can be replaced in tests.
+var canaryErrorRate = func(host string) (rate float64, hasData bool, err error) {
+	query := fmt.Sprintf(
+		`sum(istio:istio_requests_total:by_destination_service:resp5xx_rate5m{destination_service="%s"}) / sum(istio:istio_requests_total:by_destination_service:rate5m{destination_service="%s"})`,
+		host, host,
+	)
+
+	client := http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("%s/api/v1/query?query=%s", istioPrometheusAPIAddress, url.QueryEscape(query)))
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	var promResp struct {
+		Status string `json:"status"`
+		Data   struct {
+			Result []struct {
+				Value []interface{} `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(body, &promResp); err != nil {
+		return 0, false, err
+	}
+
+	if promResp.Status != "success" {
+		return 0, false, fmt.Errorf("prometheus query failed, status: %s", promResp.Status)
+	}
+
+	if len(promResp.Data.Result) == 0 || len(promResp.Data.Result[0].Value) < 2 {
+		return 0, false, nil
+	}
+
+	valueStr, ok := promResp.Data.Result[0].Value[1].(string)
+
+	if !ok {
+		return 0, false, nil
+	}
+
+	value, err := strconv.ParseFloat(valueStr, 64)
+
+	// no request at all
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false, nil
+	}
+
+	return value * 100, true, nil
+}
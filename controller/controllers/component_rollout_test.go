@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReleaseCanary(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsV1.AddToScheme(scheme)
+	_ = coreV1.AddToScheme(scheme)
+	_ = v1beta1.AddToScheme(scheme)
+
+	canaryMeta := metaV1.ObjectMeta{Namespace: "app", Name: "web-canary"}
+	deployment := &appsV1.Deployment{ObjectMeta: canaryMeta}
+	service := &coreV1.Service{ObjectMeta: canaryMeta}
+
+	vs := &v1beta1.VirtualService{
+		ObjectMeta: metaV1.ObjectMeta{Namespace: "kalm-system", Name: "vs-example-com", Labels: map[string]string{KALM_ROUTE_LABEL: "true"}},
+		Spec: istioNetworkingV1Beta1.VirtualService{
+			Http: []*istioNetworkingV1Beta1.HTTPRoute{{
+				Route: []*istioNetworkingV1Beta1.HTTPRouteDestination{
+					{Destination: &istioNetworkingV1Beta1.Destination{Host: "web.app.svc.cluster.local"}, Weight: 80},
+					{Destination: &istioNetworkingV1Beta1.Destination{Host: "web-canary.app.svc.cluster.local"}, Weight: 20},
+				},
+			}},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme, deployment, service, vs)
+
+	task := &ComponentReconcilerTask{
+		ComponentReconciler: &ComponentReconciler{&BaseReconciler{
+			Client:   c,
+			Reader:   c,
+			Log:      ctrl.Log,
+			Recorder: record.NewFakeRecorder(10),
+		}},
+		ctx: context.Background(),
+		component: &corev1alpha1.Component{
+			ObjectMeta: metaV1.ObjectMeta{Namespace: "app", Name: "web"},
+			Status: corev1alpha1.ComponentStatus{
+				Rollout: &corev1alpha1.ComponentRolloutStatus{CanaryWeight: 20},
+			},
+		},
+		canaryDeployment: deployment,
+		canaryService:    service,
+	}
+
+	canaryKey := types.NamespacedName{Namespace: "app", Name: "web-canary"}
+
+	// the zero weight is not saved yet
+	assert.Nil(t, task.releaseCanary())
+	assert.Equal(t, canaryReleaseCheckInterval, task.requeueAfter)
+	assert.Nil(t, c.Get(task.ctx, canaryKey, &coreV1.Service{}))
+
+	// routes are not reconciled with the zero weight yet
+	task.component.Status.Rollout.CanaryWeight = 0
+	task.requeueAfter = 0
+	assert.Nil(t, task.releaseCanary())
+	assert.Equal(t, canaryReleaseCheckInterval, task.requeueAfter)
+	assert.Nil(t, c.Get(task.ctx, canaryKey, &coreV1.Service{}))
+
+	vs.Spec.Http[0].Route = vs.Spec.Http[0].Route[:1]
+	assert.Nil(t, c.Update(task.ctx, vs))
+
+	task.requeueAfter = 0
+	assert.Nil(t, task.releaseCanary())
+	assert.Equal(t, 0, int(task.requeueAfter))
+	assert.True(t, errors.IsNotFound(c.Get(task.ctx, canaryKey, &coreV1.Service{})))
+	assert.True(t, errors.IsNotFound(c.Get(task.ctx, canaryKey, &appsV1.Deployment{})))
+	assert.Nil(t, task.canaryService)
+	assert.Nil(t, task.canaryDeployment)
+}
+
+func TestComponentRolloutSpecHash(t *testing.T) {
+	one, two := int32(1), int32(2)
+	spec := &corev1alpha1.ComponentSpec{Image: "web:v1", Replicas: &one}
+
+	hash, err := ComponentRolloutSpecHash(spec)
+	assert.Nil(t, err)
+
+	// scaling changes don't start rollouts
+	scaled := spec.DeepCopy()
+	scaled.Replicas = &two
+	scaled.Autoscaling = &corev1alpha1.Autoscaling{MaxReplicas: 10}
+	scaledHash, err := ComponentRolloutSpecHash(scaled)
+	assert.Nil(t, err)
+	assert.Equal(t, hash, scaledHash)
+	assert.Equal(t, &one, spec.Replicas)
+
+	updated := spec.DeepCopy()
+	updated.Image = "web:v2"
+	updatedHash, err := ComponentRolloutSpecHash(updated)
+	assert.Nil(t, err)
+	assert.NotEqual(t, hash, updatedHash)
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// getSecretEnvNames returns names of secrets used by env vars of all containers of the component.
+func getSecretEnvNames(spec *corev1alpha1.ComponentSpec) []string {
+	envs := append([]corev1alpha1.EnvVar{}, spec.Env...)
+
+	for _, c := range spec.Sidecars {
+		envs = append(envs, c.Env...)
+	}
+
+	for _, c := range spec.InitContainers {
+		envs = append(envs, c.Env...)
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+
+	for _, env := range envs {
+		if env.Type != corev1alpha1.EnvVarTypeSecret || seen[env.Value] {
+			continue
+		}
+
+		seen[env.Value] = true
+		names = append(names, env.Value)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func buildSecretEnvSource(secretName string) *coreV1.EnvVarSource {
+	return &coreV1.EnvVarSource{
+		SecretKeyRef: &coreV1.SecretKeySelector{
+			LocalObjectReference: coreV1.LocalObjectReference{
+				Name: corev1alpha1.SecretStoreName,
+			},
+			Key: secretName,
+		},
+	}
+}
+
+// getSecretsVersion returns a hash of versions of secrets used by the component, empty if no secret is used.
+// It's set on the pod template, so pods are restarted when one of the secrets is rotated.
+func getSecretsVersion(store *corev1alpha1.SecretStore, names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	versions := make([]string, 0, len(names))
+
+	for _, name := range names {
+		var version int64
+
+		if store != nil {
+			if item := store.GetSecret(name); item != nil {
+				version = item.Version
+			}
+		}
+
+		versions = append(versions, fmt.Sprintf("%s:%d", name, version))
+	}
+
+	return fmt.Sprintf("%x", md5.Sum([]byte(strings.Join(versions, ","))))
+}
+
+func (r *ComponentReconcilerTask) setSecretsVersion(template *coreV1.PodTemplateSpec) error {
+	names := getSecretEnvNames(&r.component.Spec)
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	var store corev1alpha1.SecretStore
+
+	// pods wait for the secret until the store is created
+	if err := r.Get(r.ctx, types.NamespacedName{Namespace: r.component.Namespace, Name: corev1alpha1.SecretStoreName}, &store); err != nil {
+		if !errors.IsNotFound(err) {
+			r.WarningEvent(err, "get SecretStore failed")
+			return err
+		}
+
+		template.Annotations[corev1alpha1.AnnoSecretsVersion] = getSecretsVersion(nil, names)
+
+		return nil
+	}
+
+	template.Annotations[corev1alpha1.AnnoSecretsVersion] = getSecretsVersion(&store, names)
+
+	return nil
+}
+
+// SecretStoreMapper reconciles components using secrets of the store when the store changes
+type SecretStoreMapper struct {
+	*BaseReconciler
+}
+
+func (r *SecretStoreMapper) Map(object handler.MapObject) []reconcile.Request {
+	store, ok := object.Object.(*corev1alpha1.SecretStore)
+
+	if !ok {
+		return nil
+	}
+
+	var componentList corev1alpha1.ComponentList
+
+	if err := r.Client.List(context.Background(), &componentList, client.InNamespace(store.Namespace)); err != nil {
+		r.Log.Error(err, "Can't list components in mapper.")
+		return nil
+	}
+
+	var res []reconcile.Request
+
+	for i := range componentList.Items {
+		if len(getSecretEnvNames(&componentList.Items[i].Spec)) == 0 {
+			continue
+		}
+
+		res = append(res, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      componentList.Items[i].Name,
+				Namespace: store.Namespace,
+			},
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/utils/secretbox"
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSecretsVersion(t *testing.T) {
+	spec := &corev1alpha1.ComponentSpec{
+		Env: []corev1alpha1.EnvVar{
+			{Name: "DB_PASSWORD", Type: corev1alpha1.EnvVarTypeSecret, Value: "db-password"},
+			{Name: "MODE", Value: "production"},
+		},
+		Sidecars: []corev1alpha1.Container{
+			{Name: "proxy", Env: []corev1alpha1.EnvVar{
+				{Name: "TOKEN", Type: corev1alpha1.EnvVarTypeSecret, Value: "api-token"},
+				{Name: "PASSWORD", Type: corev1alpha1.EnvVarTypeSecret, Value: "db-password"},
+			}},
+		},
+	}
+
+	names := getSecretEnvNames(spec)
+	assert.Equal(t, []string{"api-token", "db-password"}, names)
+	assert.Equal(t, "", getSecretsVersion(nil, nil))
+
+	store := &corev1alpha1.SecretStore{
+		Spec: corev1alpha1.SecretStoreSpec{
+			Secrets: []corev1alpha1.SecretStoreItem{
+				{Name: "db-password", Version: 1},
+				{Name: "api-token", Version: 1},
+				{Name: "unused", Version: 1},
+			},
+		},
+	}
+
+	version := getSecretsVersion(store, names)
+	assert.NotEqual(t, getSecretsVersion(nil, names), version)
+
+	store.Spec.Secrets[2].Version = 2
+	assert.Equal(t, version, getSecretsVersion(store, names), "rotating unused secrets should not restart pods")
+
+	store.Spec.Secrets[0].Version = 2
+	assert.NotEqual(t, version, getSecretsVersion(store, names))
+}
+
+func TestDecryptSecretStore(t *testing.T) {
+	key, _ := secretbox.GenerateKey()
+	encrypted, _ := secretbox.Encrypt(key, "p@ssw0rd")
+
+	store := &corev1alpha1.SecretStore{
+		Spec: corev1alpha1.SecretStoreSpec{
+			Secrets: []corev1alpha1.SecretStoreItem{
+				{Name: "db-password", EncryptedValue: encrypted, Version: 1},
+				{Name: "api-token", EncryptedValue: "v1:broken", Version: 2},
+			},
+		},
+	}
+
+	data, err := decryptSecretStore(key, store, map[string][]byte{"api-token": []byte("old-token")})
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "api-token")
+	assert.Equal(t, map[string][]byte{
+		"db-password": []byte("p@ssw0rd"),
+		"api-token":   []byte("old-token"),
+	}, data)
+}
+
+func TestGetOrCreateSecretStoreKey(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = coreV1.AddToScheme(scheme)
+	c := fake.NewFakeClientWithScheme(scheme)
+
+	key, err := GetOrCreateSecretStoreKey(context.Background(), c, c)
+	assert.Nil(t, err)
+	assert.Equal(t, secretbox.KeySize, len(key))
+
+	again, err := GetOrCreateSecretStoreKey(context.Background(), c, c)
+	assert.Nil(t, err)
+	assert.Equal(t, key, again)
+}
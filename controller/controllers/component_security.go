@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+)
+
+// buildPodSecurityContext merges the typed security context over the deprecated podExt annotations.
+func buildPodSecurityContext(sc *corev1alpha1.SecurityContext, annotations map[string]string) *coreV1.PodSecurityContext {
+	podSecurityContext := GetPodSecurityContextFromAnnotation(annotations)
+
+	if sc == nil || (sc.RunAsUser == nil && sc.RunAsGroup == nil && sc.RunAsNonRoot == nil && sc.FSGroup == nil) {
+		return podSecurityContext
+	}
+
+	if podSecurityContext == nil {
+		podSecurityContext = new(coreV1.PodSecurityContext)
+	}
+
+	if sc.RunAsUser != nil {
+		podSecurityContext.RunAsUser = sc.RunAsUser
+	}
+
+	if sc.RunAsGroup != nil {
+		podSecurityContext.RunAsGroup = sc.RunAsGroup
+	}
+
+	if sc.RunAsNonRoot != nil {
+		podSecurityContext.RunAsNonRoot = sc.RunAsNonRoot
+	}
+
+	if sc.FSGroup != nil {
+		podSecurityContext.FSGroup = sc.FSGroup
+	}
+
+	return podSecurityContext
+}
+
+// buildContainerSecurityContext returns settings which can only be set on containers, nil if none of them is set.
+func buildContainerSecurityContext(sc *corev1alpha1.SecurityContext) *coreV1.SecurityContext {
+	if sc == nil {
+		return nil
+	}
+
+	if sc.ReadOnlyRootFilesystem == nil && sc.AllowPrivilegeEscalation == nil && sc.Privileged == nil &&
+		len(sc.AddCapabilities) == 0 && len(sc.DropCapabilities) == 0 {
+		return nil
+	}
+
+	containerSecurityContext := &coreV1.SecurityContext{
+		ReadOnlyRootFilesystem:   sc.ReadOnlyRootFilesystem,
+		AllowPrivilegeEscalation: sc.AllowPrivilegeEscalation,
+		Privileged:               sc.Privileged,
+	}
+
+	if len(sc.AddCapabilities) > 0 || len(sc.DropCapabilities) > 0 {
+		containerSecurityContext.Capabilities = &coreV1.Capabilities{
+			Add:  sc.AddCapabilities,
+			Drop: sc.DropCapabilities,
+		}
+	}
+
+	return containerSecurityContext
+}
+
+// getSeccompAnnotationValue returns the value of the pod seccomp annotation, the only way to set profiles before kubernetes 1.19.
+func getSeccompAnnotationValue(profile *corev1alpha1.SeccompProfile) string {
+	if profile == nil {
+		return ""
+	}
+
+	switch profile.Type {
+	case corev1alpha1.SeccompProfileRuntimeDefault:
+		return coreV1.SeccompProfileRuntimeDefault
+	case corev1alpha1.SeccompProfileUnconfined:
+		return "unconfined"
+	case corev1alpha1.SeccompProfileLocalhost:
+		return "localhost/" + profile.LocalhostProfile
+	}
+
+	return ""
+}
+
+// applySecurityContext sets the security context of the component to the pod, and to all containers defined by the component.
+func (r *ComponentReconcilerTask) applySecurityContext(template *coreV1.PodTemplateSpec) {
+	sc := r.component.Spec.SecurityContext
+
+	template.Spec.SecurityContext = buildPodSecurityContext(sc, template.Annotations)
+
+	if sc == nil {
+		return
+	}
+
+	for i := range template.Spec.Containers {
+		template.Spec.Containers[i].SecurityContext = buildContainerSecurityContext(sc)
+	}
+
+	for i := range template.Spec.InitContainers {
+		template.Spec.InitContainers[i].SecurityContext = buildContainerSecurityContext(sc)
+	}
+
+	if value := getSeccompAnnotationValue(sc.SeccompProfile); value != "" {
+		if template.Annotations == nil {
+			template.Annotations = make(map[string]string)
+		}
+
+		template.Annotations[coreV1.SeccompPodAnnotationKey] = value
+	}
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplySecurityContext(t *testing.T) {
+	uid := int64(1000)
+	yes := true
+
+	task := &ComponentReconcilerTask{
+		component: &corev1alpha1.Component{
+			ObjectMeta: metaV1.ObjectMeta{Name: "web", Namespace: "app"},
+			Spec: corev1alpha1.ComponentSpec{
+				SecurityContext: &corev1alpha1.SecurityContext{
+					RunAsUser:              &uid,
+					RunAsNonRoot:           &yes,
+					ReadOnlyRootFilesystem: &yes,
+					DropCapabilities:       []coreV1.Capability{"ALL"},
+					SeccompProfile:         &corev1alpha1.SeccompProfile{Type: corev1alpha1.SeccompProfileRuntimeDefault},
+				},
+			},
+		},
+	}
+
+	template := &coreV1.PodTemplateSpec{
+		ObjectMeta: metaV1.ObjectMeta{
+			Annotations: map[string]string{
+				"core.kalm.dev/podExt-securityContext-runAsUser":  "0",
+				"core.kalm.dev/podExt-securityContext-runAsGroup": "2000",
+			},
+		},
+		Spec: coreV1.PodSpec{
+			Containers:     []coreV1.Container{{Name: "web"}, {Name: "proxy"}},
+			InitContainers: []coreV1.Container{{Name: "migrate"}},
+		},
+	}
+
+	task.applySecurityContext(template)
+
+	// typed settings override the annotations
+	assert.Equal(t, uid, *template.Spec.SecurityContext.RunAsUser)
+	assert.Equal(t, int64(2000), *template.Spec.SecurityContext.RunAsGroup)
+	assert.True(t, *template.Spec.SecurityContext.RunAsNonRoot)
+	assert.Equal(t, "runtime/default", template.Annotations[coreV1.SeccompPodAnnotationKey])
+
+	for _, c := range append(template.Spec.Containers, template.Spec.InitContainers...) {
+		assert.True(t, *c.SecurityContext.ReadOnlyRootFilesystem, c.Name)
+		assert.Equal(t, []coreV1.Capability{"ALL"}, c.SecurityContext.Capabilities.Drop, c.Name)
+		assert.Nil(t, c.SecurityContext.Capabilities.Add, c.Name)
+	}
+
+	// only the annotations
+	task.component.Spec.SecurityContext = nil
+	template.Spec.Containers[0].SecurityContext = nil
+	task.applySecurityContext(template)
+	assert.Equal(t, int64(0), *template.Spec.SecurityContext.RunAsUser)
+	assert.Nil(t, template.Spec.SecurityContext.RunAsNonRoot)
+	assert.Nil(t, template.Spec.Containers[0].SecurityContext)
+}
+
+func TestBuildPodSecurityContext(t *testing.T) {
+	fsGroup := int64(3000)
+	annotations := map[string]string{"core.kalm.dev/podExt-securityContext-runAsUser": "1000"}
+
+	assert.Nil(t, buildPodSecurityContext(nil, nil))
+	assert.Equal(t, int64(1000), *buildPodSecurityContext(nil, annotations).RunAsUser)
+
+	// unset typed fields keep the values from annotations
+	podSecurityContext := buildPodSecurityContext(&corev1alpha1.SecurityContext{FSGroup: &fsGroup}, annotations)
+	assert.Equal(t, int64(1000), *podSecurityContext.RunAsUser)
+	assert.Equal(t, fsGroup, *podSecurityContext.FSGroup)
+	assert.Nil(t, podSecurityContext.RunAsGroup)
+	assert.Nil(t, podSecurityContext.RunAsNonRoot)
+}
@@ -0,0 +1,287 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ComponentPluginError is returned when a component plugin fails during reconcile,
+// it's used to distinguish plugin failures from other reconcile errors in component status.
+type ComponentPluginError struct {
+	PluginName string
+	MethodName string
+	Err        error
+}
+
+func (e *ComponentPluginError) Error() string {
+	return fmt.Sprintf("plugin %s failed in %s: %s", e.PluginName, e.MethodName, e.Err.Error())
+}
+
+type workloadStatus struct {
+	exist       bool
+	replicas    int32
+	ready       int32
+	available   int32
+	progressing bool
+	degraded    bool
+	reason      string
+	message     string
+	image       string
+}
+
+// UpdateStatus collects the status of the workload owned by the component and patches it to component status.
+func (r *ComponentReconcilerTask) UpdateStatus(reconcileErr error) error {
+	if err := r.LoadResources(); err != nil {
+		return err
+	}
+
+	status := r.component.Status.DeepCopy()
+	status.ObservedGeneration = r.component.Generation
+
+	if reconcileErr != nil {
+		status.LastError = reconcileErr.Error()
+	} else {
+		status.LastError = ""
+	}
+
+	if pluginErr, ok := reconcileErr.(*ComponentPluginError); ok {
+		status.SetCondition(corev1alpha1.ComponentCondition{
+			Type:    corev1alpha1.ComponentConditionPluginError,
+			Status:  coreV1.ConditionTrue,
+			Reason:  "PluginFailed",
+			Message: pluginErr.Error(),
+		})
+	} else {
+		status.SetCondition(corev1alpha1.ComponentCondition{
+			Type:   corev1alpha1.ComponentConditionPluginError,
+			Status: coreV1.ConditionFalse,
+		})
+	}
+
+	status.Rollout = r.rollout
+	status.Autoscaling = getAutoscalingStatus(r.horizontalPodAutoscaler)
+
+	if r.component.Spec.WorkloadType == corev1alpha1.WorkloadTypeJob {
+		status.Job = getJobStatus(r.job, status.Job)
+	} else {
+		status.Job = nil
+	}
+
+	ws := r.getWorkloadStatus()
+
+	status.Replicas = ws.replicas
+	status.ReadyReplicas = ws.ready
+	status.AvailableReplicas = ws.available
+
+	isReady := ws.exist && !ws.progressing && !ws.degraded && ws.ready >= ws.replicas
+
+	if isReady && ws.image != "" {
+		status.Image = ws.image
+	}
+
+	readyCondition := corev1alpha1.ComponentCondition{
+		Type:   corev1alpha1.ComponentConditionReady,
+		Status: coreV1.ConditionFalse,
+		Reason: ws.reason,
+	}
+
+	if isReady {
+		readyCondition.Status = coreV1.ConditionTrue
+		readyCondition.Reason = "WorkloadReady"
+	} else if !ws.exist && r.isBlockedByDependencies() {
+		readyCondition.Reason = "WaitingForDependencies"
+		readyCondition.Message = r.dependencyCondition.Message
+	} else if readyCondition.Reason == "" {
+		readyCondition.Reason = "WorkloadNotReady"
+		readyCondition.Message = fmt.Sprintf("%d/%d replicas are ready", ws.ready, ws.replicas)
+	}
+
+	status.SetCondition(readyCondition)
+
+	progressingCondition := corev1alpha1.ComponentCondition{
+		Type:   corev1alpha1.ComponentConditionProgressing,
+		Status: coreV1.ConditionFalse,
+	}
+
+	if ws.progressing {
+		progressingCondition.Status = coreV1.ConditionTrue
+		progressingCondition.Reason = "RollingOut"
+		progressingCondition.Message = fmt.Sprintf("rolling out, %d/%d replicas are ready", ws.ready, ws.replicas)
+	} else if r.rollout != nil && r.rollout.Phase == corev1alpha1.RolloutPhaseProgressing {
+		progressingCondition.Status = coreV1.ConditionTrue
+		progressingCondition.Reason = "CanaryRollingOut"
+		progressingCondition.Message = r.rollout.Message
+	}
+
+	status.SetCondition(progressingCondition)
+
+	degradedCondition := corev1alpha1.ComponentCondition{
+		Type:   corev1alpha1.ComponentConditionDegraded,
+		Status: coreV1.ConditionFalse,
+	}
+
+	if reconcileErr != nil {
+		degradedCondition.Status = coreV1.ConditionTrue
+		degradedCondition.Reason = "ReconcileError"
+		degradedCondition.Message = reconcileErr.Error()
+	} else if ws.degraded {
+		degradedCondition.Status = coreV1.ConditionTrue
+		degradedCondition.Reason = ws.reason
+		degradedCondition.Message = ws.message
+	}
+
+	status.SetCondition(degradedCondition)
+
+	if r.dependencyCondition != nil {
+		status.SetCondition(*r.dependencyCondition)
+	} else if len(r.component.Spec.StartAfterComponents) == 0 {
+		status.RemoveCondition(corev1alpha1.ComponentConditionBlocked)
+	}
+
+	r.runComputeStatusPlugins(status)
+
+	if reflect.DeepEqual(*status, r.component.Status) {
+		return nil
+	}
+
+	componentCopy := r.component.DeepCopy()
+	componentCopy.Status = *status
+
+	if err := r.Status().Patch(r.ctx, componentCopy, client.MergeFrom(r.component)); err != nil {
+		r.WarningEvent(err, "Patch component status error.")
+		return err
+	}
+
+	r.component = componentCopy
+
+	return nil
+}
+
+func (r *ComponentReconcilerTask) getWorkloadStatus() workloadStatus {
+	if !IsNamespaceKalmEnabled(r.namespace) {
+		return workloadStatus{reason: "NamespaceNotEnabled"}
+	}
+
+	switch r.component.Spec.WorkloadType {
+	case corev1alpha1.WorkloadTypeServer, "":
+		return getDeploymentStatus(r.deployment)
+	case corev1alpha1.WorkloadTypeStatefulSet:
+		return getStatefulSetStatus(r.statefulSet)
+	case corev1alpha1.WorkloadTypeDaemonSet:
+		return getDaemonSetStatus(r.daemonSet)
+	case corev1alpha1.WorkloadTypeCronjob:
+		if r.cronJob == nil {
+			return workloadStatus{reason: "WorkloadNotFound"}
+		}
+
+		return workloadStatus{
+			exist: true,
+			image: getMainContainerImage(r.cronJob.Spec.JobTemplate.Spec.Template.Spec),
+		}
+	case corev1alpha1.WorkloadTypeJob:
+		ws := getJobWorkloadStatus(getJobStatus(r.job, r.component.Status.Job))
+
+		if r.job != nil {
+			ws.image = getMainContainerImage(r.job.Spec.Template.Spec)
+		}
+
+		return ws
+	}
+
+	return workloadStatus{reason: "UnknownWorkloadType"}
+}
+
+func getDeploymentStatus(deployment *appsV1.Deployment) workloadStatus {
+	if deployment == nil {
+		return workloadStatus{reason: "WorkloadNotFound"}
+	}
+
+	ws := workloadStatus{
+		exist:     true,
+		replicas:  1,
+		ready:     deployment.Status.ReadyReplicas,
+		available: deployment.Status.AvailableReplicas,
+		image:     getMainContainerImage(deployment.Spec.Template.Spec),
+	}
+
+	if deployment.Spec.Replicas != nil {
+		ws.replicas = *deployment.Spec.Replicas
+	}
+
+	ws.progressing = deployment.Status.ObservedGeneration < deployment.Generation ||
+		deployment.Status.UpdatedReplicas < ws.replicas ||
+		deployment.Status.Replicas > deployment.Status.UpdatedReplicas ||
+		deployment.Status.AvailableReplicas < deployment.Status.UpdatedReplicas
+
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsV1.DeploymentProgressing &&
+			condition.Status == coreV1.ConditionFalse &&
+			condition.Reason == "ProgressDeadlineExceeded" {
+
+			ws.degraded = true
+			ws.reason = condition.Reason
+			ws.message = condition.Message
+		}
+
+		if condition.Type == appsV1.DeploymentReplicaFailure && condition.Status == coreV1.ConditionTrue {
+			ws.degraded = true
+			ws.reason = condition.Reason
+			ws.message = condition.Message
+		}
+	}
+
+	return ws
+}
+
+func getStatefulSetStatus(sts *appsV1.StatefulSet) workloadStatus {
+	if sts == nil {
+		return workloadStatus{reason: "WorkloadNotFound"}
+	}
+
+	ws := workloadStatus{
+		exist:     true,
+		replicas:  1,
+		ready:     sts.Status.ReadyReplicas,
+		available: sts.Status.ReadyReplicas,
+		image:     getMainContainerImage(sts.Spec.Template.Spec),
+	}
+
+	if sts.Spec.Replicas != nil {
+		ws.replicas = *sts.Spec.Replicas
+	}
+
+	ws.progressing = sts.Status.ObservedGeneration < sts.Generation ||
+		sts.Status.UpdatedReplicas < ws.replicas ||
+		(sts.Status.UpdateRevision != "" && sts.Status.CurrentRevision != sts.Status.UpdateRevision)
+
+	return ws
+}
+
+func getDaemonSetStatus(ds *appsV1.DaemonSet) workloadStatus {
+	if ds == nil {
+		return workloadStatus{reason: "WorkloadNotFound"}
+	}
+
+	return workloadStatus{
+		exist:     true,
+		replicas:  ds.Status.DesiredNumberScheduled,
+		ready:     ds.Status.NumberReady,
+		available: ds.Status.NumberAvailable,
+		image:     getMainContainerImage(ds.Spec.Template.Spec),
+		progressing: ds.Status.ObservedGeneration < ds.Generation ||
+			ds.Status.UpdatedNumberScheduled < ds.Status.DesiredNumberScheduled,
+	}
+}
+
+func getMainContainerImage(podSpec coreV1.PodSpec) string {
+	if len(podSpec.Containers) == 0 {
+		return ""
+	}
+
+	return podSpec.Containers[0].Image
+}
@@ -34,6 +34,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 	"strings"
+	"time"
 
 	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
 )
@@ -99,7 +100,7 @@ func (r *DockerRegistryReconcileTask) UpdateStatus() (err error) {
 		host = "https://registry-1.docker.io"
 	}
 
-	_, err = registry.New(host, username, password)
+	hub, err := registry.New(host, username, password)
 
 	if err != nil {
 		registryCopy := r.registry.DeepCopy()
@@ -123,18 +124,41 @@ func (r *DockerRegistryReconcileTask) UpdateStatus() (err error) {
 
 		r.Recorder.Event(r.registry, v1.EventTypeWarning, "AuthFailed", message)
 		return nil
+	}
+
+	hub.Logf = registry.Quiet
+	wasVerified := r.registry.Status.AuthenticationVerified
+
+	registryCopy := r.registry.DeepCopy()
+	registryCopy.Status.AuthenticationVerified = true
+
+	components, err := r.getImageUpdatePolicyComponents()
+
+	if err != nil {
+		return err
+	}
+
+	repositories, err := r.listRepositories(hub, components)
+
+	if err != nil {
+		r.WarningEvent(err, "List repositories error.")
 	} else {
-		registryCopy := r.registry.DeepCopy()
-		registryCopy.Status.AuthenticationVerified = true
+		registryCopy.Status.Repositories = repositories
+	}
 
-		if err := r.Status().Patch(r.ctx, registryCopy, client.MergeFrom(r.registry)); err != nil {
-			r.WarningEvent(err, "Patch docker registry status error.")
-			return err
-		}
+	if err := r.Status().Patch(r.ctx, registryCopy, client.MergeFrom(r.registry)); err != nil {
+		r.WarningEvent(err, "Patch docker registry status error.")
+		return err
 	}
 
-	r.Recorder.Eventf(r.registry, v1.EventTypeNormal, "AuthSucceed", "Authenticate docker registry successfully.")
-	return nil
+	r.registry = registryCopy
+
+	// status is patched on every poll, only record the event when it's changed
+	if !wasVerified {
+		r.Recorder.Eventf(r.registry, v1.EventTypeNormal, "AuthSucceed", "Authenticate docker registry successfully.")
+	}
+
+	return r.UpdateComponentImages(components)
 }
 
 func (r *DockerRegistryReconcileTask) DistributeSecrets() (err error) {
@@ -303,6 +327,7 @@ func (r *DockerRegistryReconcileTask) SetupAttributes(req ctrl.Request) (err err
 // +kubebuilder:rbac:groups=core.kalm.dev,resources=dockerregistries,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core.kalm.dev,resources=dockerregistries/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core.kalm.dev,resources=applications,verbs=get;list
+// +kubebuilder:rbac:groups=core.kalm.dev,resources=components,verbs=get;list;watch;update;patch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
@@ -312,7 +337,18 @@ func (r *DockerRegistryReconciler) Reconcile(req ctrl.Request) (ctrl.Result, err
 		ctx:                      context.Background(),
 	}
 
-	return ctrl.Result{}, task.Run(req)
+	if err := task.Run(req); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// poll the registry on interval
+	if task.registry != nil && task.registry.DeletionTimestamp.IsZero() &&
+		task.registry.Spec.PoolingIntervalSeconds != nil && *task.registry.Spec.PoolingIntervalSeconds > 0 {
+
+		return ctrl.Result{RequeueAfter: time.Duration(*task.registry.Spec.PoolingIntervalSeconds) * time.Second}, nil
+	}
+
+	return ctrl.Result{}, nil
 }
 
 type TouchAllRegistriesMapper struct {
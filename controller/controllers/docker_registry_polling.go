@@ -0,0 +1,392 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/docker/distribution/reference"
+	"github.com/heroku/docker-registry-client/registry"
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	dockerHubDomain = "docker.io"
+
+	// at most this number of repositories from the registry catalog are kept in status
+	maxCatalogRepositories = 100
+)
+
+// getRegistryDomain converts the host of a registry to the domain used in image references.
+// https://registry-1.docker.io -> docker.io
+// http://localhost:5000/       -> localhost:5000
+func getRegistryDomain(host string) string {
+	host = strings.TrimPrefix(host, "https://")
+	host = strings.TrimPrefix(host, "http://")
+	host = strings.TrimSuffix(host, "/")
+
+	if idx := strings.Index(host, "/"); idx != -1 {
+		host = host[:idx]
+	}
+
+	switch host {
+	case "", "registry-1.docker.io", "index.docker.io", "registry.hub.docker.com":
+		return dockerHubDomain
+	}
+
+	return host
+}
+
+// parseImage returns the domain, repository path in the registry and tag of an image.
+// nginx:1.19 -> docker.io, library/nginx, 1.19
+func parseImage(image string) (domain, path, tag string, err error) {
+	named, err := reference.ParseNormalizedNamed(image)
+
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if tagged, ok := named.(reference.Tagged); ok {
+		tag = tagged.Tag()
+	}
+
+	return reference.Domain(named), reference.Path(named), tag, nil
+}
+
+// ReplaceImageTagInPlace keeps the repository part of the image as it is written, only the tag is replaced.
+func ReplaceImageTagInPlace(image, tag string) string {
+	if idx := strings.LastIndex(image, ":"); idx != -1 && idx > strings.LastIndex(image, "/") {
+		image = image[:idx]
+	}
+
+	return image + ":" + tag
+}
+
+// getImageUpdatePolicyComponents returns components with image update policy whose image is hosted on this registry.
+func (r *DockerRegistryReconcileTask) getImageUpdatePolicyComponents() ([]corev1alpha1.Component, error) {
+	var componentList corev1alpha1.ComponentList
+
+	if err := r.Reader.List(r.ctx, &componentList); err != nil {
+		return nil, err
+	}
+
+	domain := getRegistryDomain(r.registry.Spec.Host)
+	var res []corev1alpha1.Component
+
+	for _, component := range componentList.Items {
+		if component.Spec.ImageUpdatePolicy == nil || component.DeletionTimestamp != nil {
+			continue
+		}
+
+		imageDomain, _, _, err := parseImage(component.Spec.Image)
+
+		if err != nil || imageDomain != domain {
+			continue
+		}
+
+		res = append(res, component)
+	}
+
+	return res, nil
+}
+
+// listRepositories lists repositories in the catalog of the registry, and tags of them.
+// Registries like docker hub don't support catalog api, only repositories used by components with image update policy are listed in that case.
+// Manifest digests, push time and created time are only fetched for repositories used by components, since it takes extra requests for each tag.
+func (r *DockerRegistryReconcileTask) listRepositories(hub *registry.Registry, components []corev1alpha1.Component) ([]*corev1alpha1.Repository, error) {
+	tracked := make(map[string]bool)
+
+	for _, component := range components {
+		_, path, _, _ := parseImage(component.Spec.Image)
+		tracked[path] = true
+	}
+
+	names := make(map[string]bool)
+
+	for name := range tracked {
+		names[name] = true
+	}
+
+	catalog, err := hub.Repositories()
+
+	if err != nil {
+		r.Log.V(1).Info("list registry catalog failed", "registry", r.registry.Name, "error", err.Error())
+	}
+
+	for i := 0; i < len(catalog) && i < maxCatalogRepositories; i++ {
+		names[catalog[i]] = true
+	}
+
+	previous := make(map[string]map[string]corev1alpha1.RepositoryTag)
+
+	for _, repo := range r.registry.Status.Repositories {
+		if repo == nil {
+			continue
+		}
+
+		previous[repo.Name] = make(map[string]corev1alpha1.RepositoryTag)
+
+		for _, tag := range repo.Tags {
+			previous[repo.Name][tag.Name] = tag
+		}
+	}
+
+	sortedNames := make([]string, 0, len(names))
+
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+
+	sort.Strings(sortedNames)
+
+	repositories := make([]*corev1alpha1.Repository, 0, len(sortedNames))
+
+	for _, name := range sortedNames {
+		tags, err := hub.Tags(name)
+
+		if err != nil {
+			// a deleted or private repository should not stop polling of others
+			r.Log.Error(err, "list tags failed", "registry", r.registry.Name, "repository", name)
+			continue
+		}
+
+		sort.Strings(tags)
+
+		repo := &corev1alpha1.Repository{Name: name, Tags: make([]corev1alpha1.RepositoryTag, 0, len(tags))}
+
+		for _, tagName := range tags {
+			tag := corev1alpha1.RepositoryTag{Name: tagName}
+
+			if tracked[name] {
+				tag = r.describeTag(hub, name, tagName, previous[name])
+			}
+
+			repo.Tags = append(repo.Tags, tag)
+		}
+
+		repositories = append(repositories, repo)
+	}
+
+	return repositories, nil
+}
+
+// getTagPushTime returns the push time of the tag, in milliseconds.
+// Registries don't expose push time, it's the time of the first poll seeing the manifest of the tag, accurate to the polling interval.
+// It's unknown and empty for manifests already there when the tag is described for the first time, e.g. the first poll of a repository.
+func getTagPushTime(previous map[string]corev1alpha1.RepositoryTag, tagName, manifest string, now time.Time) string {
+	old, exist := previous[tagName]
+
+	if exist && old.Manifest == manifest {
+		return old.TimeUploadedMs
+	}
+
+	// the repository or the tag was not described in the last poll
+	if previous == nil || (exist && old.Manifest == "") {
+		return ""
+	}
+
+	return strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10)
+}
+
+// describeTag fills the manifest digest, the push time and the created time of a tag.
+// The image config is downloaded only if the digest changed since last poll.
+func (r *DockerRegistryReconcileTask) describeTag(hub *registry.Registry, repo, tagName string, previous map[string]corev1alpha1.RepositoryTag) corev1alpha1.RepositoryTag {
+	tag := corev1alpha1.RepositoryTag{Name: tagName}
+
+	digest, err := hub.ManifestDigest(repo, tagName)
+
+	if err != nil {
+		return tag
+	}
+
+	tag.Manifest = digest.String()
+	tag.TimeUploadedMs = getTagPushTime(previous, tagName, tag.Manifest, time.Now())
+
+	if old, exist := previous[tagName]; exist && old.Manifest == tag.Manifest && old.TimeCreatedMs != "" {
+		return old
+	}
+
+	manifest, err := hub.ManifestV2(repo, tagName)
+
+	if err != nil {
+		return tag
+	}
+
+	blob, err := hub.DownloadBlob(repo, manifest.Config.Digest)
+
+	if err != nil {
+		return tag
+	}
+
+	defer blob.Close()
+
+	// the build time of the image, reproducible builds set it to a fixed time
+	var config struct {
+		Created time.Time `json:"created"`
+	}
+
+	if err := json.NewDecoder(blob).Decode(&config); err != nil || config.Created.IsZero() {
+		return tag
+	}
+
+	tag.TimeCreatedMs = strconv.FormatInt(config.Created.UnixNano()/int64(time.Millisecond), 10)
+
+	return tag
+}
+
+// UpdateComponentImages bumps images of components according to their image update policy.
+func (r *DockerRegistryReconcileTask) UpdateComponentImages(components []corev1alpha1.Component) error {
+	repositories := make(map[string]*corev1alpha1.Repository)
+
+	for _, repo := range r.registry.Status.Repositories {
+		if repo != nil {
+			repositories[repo.Name] = repo
+		}
+	}
+
+	for i := range components {
+		component := &components[i]
+		_, path, currentTag, err := parseImage(component.Spec.Image)
+
+		if err != nil {
+			continue
+		}
+
+		repo, exist := repositories[path]
+
+		if !exist {
+			continue
+		}
+
+		tag, err := selectImageTag(component.Spec.ImageUpdatePolicy, currentTag, repo.Tags)
+
+		if err != nil {
+			r.EmitWarningEvent(component, err, "Select image tag error.")
+			continue
+		}
+
+		if tag == "" {
+			continue
+		}
+
+		copied := component.DeepCopy()
+		copied.Spec.Image = ReplaceImageTagInPlace(component.Spec.Image, tag)
+
+		if copied.Annotations == nil {
+			copied.Annotations = make(map[string]string)
+		}
+
+		copied.Annotations[corev1alpha1.AnnoComponentChangedBy] = "kalm"
+		copied.Annotations[corev1alpha1.AnnoComponentChangeCause] = fmt.Sprintf("image update policy, registry: %s, image: %s", r.registry.Name, copied.Spec.Image)
+
+		// the next sync of the application source would revert the image
+		if err := SuspendApplicationSourceOf(r.ctx, r.Client, component, "kalm"); err != nil {
+			r.EmitWarningEvent(component, err, "Suspend application source error.")
+			return err
+		}
+
+		if err := r.Patch(r.ctx, copied, client.MergeFrom(component)); err != nil {
+			r.EmitWarningEvent(component, err, "Update image by image update policy error.")
+			return err
+		}
+
+		r.EmitNormalEvent(component, "ImageUpdated", "Image is updated from %s to %s by %s image update policy.", component.Spec.Image, copied.Spec.Image, component.Spec.ImageUpdatePolicy.Type)
+	}
+
+	return nil
+}
+
+// parseTagVersion parses full versions only, with an optional "v" prefix.
+// Floating tags like "1" or "1.2" are not versions of a single image, they are ignored.
+func parseTagVersion(tag string) (*semver.Version, error) {
+	return semver.StrictNewVersion(strings.TrimPrefix(tag, "v"))
+}
+
+// selectImageTag returns the tag the image should be updated to, or empty string if current tag is the best.
+func selectImageTag(policy *corev1alpha1.ImageUpdatePolicy, currentTag string, tags []corev1alpha1.RepositoryTag) (string, error) {
+	var tagRegexp *regexp.Regexp
+
+	if policy.Regex != "" {
+		var err error
+
+		// the regex matches the whole tag, the same as patterns of push webhooks
+		if tagRegexp, err = regexp.Compile("^(?:" + policy.Regex + ")$"); err != nil {
+			return "", err
+		}
+	}
+
+	if policy.Type == corev1alpha1.ImageUpdatePolicySemver {
+		constraint, err := semver.NewConstraint(policy.Semver)
+
+		if err != nil {
+			return "", err
+		}
+
+		var best *semver.Version
+		var bestTag string
+
+		for _, tag := range tags {
+			if tagRegexp != nil && !tagRegexp.MatchString(tag.Name) {
+				continue
+			}
+
+			version, err := parseTagVersion(tag.Name)
+
+			if err != nil || !constraint.Check(version) {
+				continue
+			}
+
+			if best == nil || best.LessThan(version) {
+				best = version
+				bestTag = tag.Name
+			}
+		}
+
+		if best == nil || bestTag == currentTag {
+			return "", nil
+		}
+
+		if current, err := parseTagVersion(currentTag); err == nil && !current.LessThan(best) {
+			return "", nil
+		}
+
+		return bestTag, nil
+	}
+
+	// regex and latest policies pick the latest pushed tag, tags with unknown push time are never picked
+	var bestTag string
+	var bestPushed, currentPushed int64 = -1, -1
+
+	for _, tag := range tags {
+		pushed, err := strconv.ParseInt(tag.TimeUploadedMs, 10, 64)
+
+		if err != nil {
+			continue
+		}
+
+		if tag.Name == currentTag {
+			currentPushed = pushed
+		}
+
+		if tagRegexp != nil && !tagRegexp.MatchString(tag.Name) {
+			continue
+		}
+
+		if pushed > bestPushed {
+			bestPushed = pushed
+			bestTag = tag.Name
+		}
+	}
+
+	if bestTag == "" || bestTag == currentTag || bestPushed <= currentPushed {
+		return "", nil
+	}
+
+	return bestTag, nil
+}
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/heroku/docker-registry-client/registry"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetRegistryDomain(t *testing.T) {
+	testCases := map[string]string{
+		"":                             "docker.io",
+		"https://registry-1.docker.io": "docker.io",
+		"https://index.docker.io/v1/":  "docker.io",
+		"http://localhost:5000/":       "localhost:5000",
+		"https://gcr.io":               "gcr.io",
+	}
+
+	for host, domain := range testCases {
+		assert.Equal(t, domain, getRegistryDomain(host), host)
+	}
+}
+
+func TestParseImage(t *testing.T) {
+	domain, path, tag, err := parseImage("nginx:1.19")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"docker.io", "library/nginx", "1.19"}, []string{domain, path, tag})
+
+	domain, path, tag, err = parseImage("localhost:5000/foo/bar")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"localhost:5000", "foo/bar", ""}, []string{domain, path, tag})
+
+	assert.Equal(t, "localhost:5000/foo:v2", ReplaceImageTagInPlace("localhost:5000/foo:v1", "v2"))
+	assert.Equal(t, "localhost:5000/foo:v2", ReplaceImageTagInPlace("localhost:5000/foo", "v2"))
+}
+
+func TestSelectImageTag(t *testing.T) {
+	tags := []v1alpha1.RepositoryTag{
+		{Name: "1.0.0", TimeUploadedMs: "1000"},
+		{Name: "1.1.0", TimeUploadedMs: "3000"},
+		{Name: "1.2.0-rc.1", TimeUploadedMs: "4000"},
+		{Name: "2.0.0", TimeUploadedMs: "2000"},
+		{Name: "v2.1.0"},
+		{Name: "3"},
+		{Name: "dev-abc", TimeUploadedMs: "5000"},
+		// reproducible builds, pushed before polling started
+		{Name: "no-time", TimeCreatedMs: "0"},
+	}
+
+	testCases := []struct {
+		policy   v1alpha1.ImageUpdatePolicy
+		current  string
+		expected string
+	}{
+		{v1alpha1.ImageUpdatePolicy{Type: v1alpha1.ImageUpdatePolicySemver, Semver: "^1.0.0"}, "1.0.0", "1.1.0"},
+		{v1alpha1.ImageUpdatePolicy{Type: v1alpha1.ImageUpdatePolicySemver, Semver: "^1.0.0"}, "1.1.0", ""},
+		{v1alpha1.ImageUpdatePolicy{Type: v1alpha1.ImageUpdatePolicySemver, Semver: "*"}, "1.0.0", "v2.1.0"},
+		{v1alpha1.ImageUpdatePolicy{Type: v1alpha1.ImageUpdatePolicySemver, Semver: "~2.0.0 || ~1.1.0"}, "1.0.0", "2.0.0"},
+		{v1alpha1.ImageUpdatePolicy{Type: v1alpha1.ImageUpdatePolicySemver, Semver: "~1.2.0-rc.0"}, "1.1.0", "1.2.0-rc.1"},
+		// never downgrade
+		{v1alpha1.ImageUpdatePolicy{Type: v1alpha1.ImageUpdatePolicySemver, Semver: "^1.0.0"}, "1.5.0", ""},
+		{v1alpha1.ImageUpdatePolicy{Type: v1alpha1.ImageUpdatePolicyRegex, Regex: `^\d+\.\d+\.\d+$`}, "1.0.0", "1.1.0"},
+		{v1alpha1.ImageUpdatePolicy{Type: v1alpha1.ImageUpdatePolicyRegex, Regex: `dev-.*`}, "1.0.0", "dev-abc"},
+		// the regex matches whole tags
+		{v1alpha1.ImageUpdatePolicy{Type: v1alpha1.ImageUpdatePolicyRegex, Regex: `dev`}, "1.0.0", ""},
+		{v1alpha1.ImageUpdatePolicy{Type: v1alpha1.ImageUpdatePolicyLatest}, "1.0.0", "dev-abc"},
+		{v1alpha1.ImageUpdatePolicy{Type: v1alpha1.ImageUpdatePolicyLatest}, "dev-abc", ""},
+		// 2.0.0 is pushed before 1.1.0
+		{v1alpha1.ImageUpdatePolicy{Type: v1alpha1.ImageUpdatePolicyRegex, Regex: `2\..*`}, "1.1.0", ""},
+	}
+
+	for _, tc := range testCases {
+		tag, err := selectImageTag(&tc.policy, tc.current, tags)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.expected, tag, "%+v %s", tc.policy, tc.current)
+	}
+}
+
+func TestGetTagPushTime(t *testing.T) {
+	now := time.Unix(100, 0)
+	previous := map[string]v1alpha1.RepositoryTag{
+		"v1":          {Name: "v1", Manifest: "sha256:a", TimeUploadedMs: "1000"},
+		"undescribed": {Name: "undescribed"},
+	}
+
+	assert.Equal(t, "1000", getTagPushTime(previous, "v1", "sha256:a", now))
+	// pushed again
+	assert.Equal(t, "100000", getTagPushTime(previous, "v1", "sha256:b", now))
+	assert.Equal(t, "100000", getTagPushTime(previous, "v2", "sha256:c", now))
+	// unknown if the tag or the repository was not described before
+	assert.Equal(t, "", getTagPushTime(previous, "undescribed", "sha256:d", now))
+	assert.Equal(t, "", getTagPushTime(nil, "v1", "sha256:a", now))
+}
+
+// Run a local registry and push an image before running this test
+//
+//	docker run -d -p 5000:5000 registry:2
+//	docker tag nginx:latest localhost:5000/nginx:1.0.0 && docker push localhost:5000/nginx:1.0.0
+//	KALM_TEST_LOCAL_REGISTRY=http://localhost:5000 go test ./controllers -run TestPollLocalRegistry
+func TestUpdateComponentImages(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+
+	source := &v1alpha1.ApplicationSource{ObjectMeta: metaV1.ObjectMeta{Name: "web-repo"}}
+	component := &v1alpha1.Component{
+		ObjectMeta: metaV1.ObjectMeta{
+			Namespace: "app",
+			Name:      "web",
+			Labels:    map[string]string{v1alpha1.ApplicationSourceLabelName: source.Name},
+		},
+		Spec: v1alpha1.ComponentSpec{
+			Image:             "localhost:5000/web:1.0.0",
+			ImageUpdatePolicy: &v1alpha1.ImageUpdatePolicy{Type: v1alpha1.ImageUpdatePolicySemver, Semver: "^1.0.0"},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme, source, component)
+
+	task := &DockerRegistryReconcileTask{
+		DockerRegistryReconciler: &DockerRegistryReconciler{&BaseReconciler{
+			Client:   c,
+			Reader:   c,
+			Log:      ctrl.Log,
+			Recorder: record.NewFakeRecorder(10),
+		}},
+		ctx: context.Background(),
+		registry: &v1alpha1.DockerRegistry{
+			Status: v1alpha1.DockerRegistryStatus{
+				Repositories: []*v1alpha1.Repository{
+					{Name: "web", Tags: []v1alpha1.RepositoryTag{{Name: "1.0.0"}, {Name: "1.1.0", TimeUploadedMs: "1000"}}},
+				},
+			},
+		},
+	}
+
+	assert.Nil(t, task.UpdateComponentImages([]v1alpha1.Component{*component}))
+
+	var updated v1alpha1.Component
+	assert.Nil(t, c.Get(task.ctx, types.NamespacedName{Namespace: "app", Name: "web"}, &updated))
+	assert.Equal(t, "localhost:5000/web:1.1.0", updated.Spec.Image)
+
+	// otherwise the next sync reverts the image
+	var suspended v1alpha1.ApplicationSource
+	assert.Nil(t, c.Get(task.ctx, types.NamespacedName{Name: source.Name}, &suspended))
+	assert.True(t, suspended.Spec.Suspend)
+	assert.Equal(t, "kalm", suspended.Annotations[v1alpha1.AnnoApplicationSourceSuspendedBy])
+}
+
+func TestPollLocalRegistry(t *testing.T) {
+	host := os.Getenv("KALM_TEST_LOCAL_REGISTRY")
+
+	if host == "" {
+		t.Skip()
+	}
+
+	hub, err := registry.New(host, "", "")
+	assert.Nil(t, err)
+
+	task := &DockerRegistryReconcileTask{
+		DockerRegistryReconciler: &DockerRegistryReconciler{&BaseReconciler{Log: ctrl.Log}},
+		ctx:                      context.Background(),
+		registry:                 &v1alpha1.DockerRegistry{Spec: v1alpha1.DockerRegistrySpec{Host: host}},
+	}
+
+	components := []v1alpha1.Component{
+		{Spec: v1alpha1.ComponentSpec{Image: getRegistryDomain(host) + "/nginx:1.0.0"}},
+	}
+
+	repositories, err := task.listRepositories(hub, components)
+	assert.Nil(t, err)
+
+	var nginx *v1alpha1.Repository
+
+	for _, repo := range repositories {
+		if repo.Name == "nginx" {
+			nginx = repo
+		}
+	}
+
+	assert.NotNil(t, nginx)
+	assert.NotEmpty(t, nginx.Tags)
+	assert.NotEmpty(t, nginx.Tags[0].Manifest)
+	assert.NotEmpty(t, nginx.Tags[0].TimeCreatedMs)
+}
@@ -85,6 +85,11 @@ func (r *GatewayReconcilerTask) HttpsGateway() error {
 		return err
 	}
 
+	credentials, err := r.MutualTLSCredentials(certs.Items)
+	if err != nil {
+		return err
+	}
+
 	if len(certs.Items) == 0 {
 		if !isCreate {
 			return r.Delete(r.ctx, gw)
@@ -118,6 +123,17 @@ func (r *GatewayReconcilerTask) HttpsGateway() error {
 		gw.Spec.Servers = append(gw.Spec.Servers, server)
 	}
 
+	// hosts requiring client certificates are served by MUTUAL servers only
+	mutualHosts := make(map[string]bool)
+
+	for _, credential := range credentials {
+		for _, host := range credential.Hosts {
+			mutualHosts[host] = true
+		}
+	}
+
+	gw.Spec.Servers = append(removeServerHosts(gw.Spec.Servers, mutualHosts), buildMutualTLSServers(credentials)...)
+
 	return r.updateGateway(isCreate, gw)
 }
 
@@ -200,6 +216,7 @@ type GatewayReconciler struct {
 }
 
 // +kubebuilder:rbac:groups=networking.istio.io,resources=gateways,verbs=*
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
 
 func (r *GatewayReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	if req.Namespace != KALM_GATEWAY_NAMESPACE || req.Name != KALM_GATEWAY_NAME {
@@ -226,6 +243,19 @@ func (r *KalmGatewayRequestMapper) Map(handler.MapObject) []reconcile.Request {
 	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: KALM_GATEWAY_NAMESPACE, Name: KALM_GATEWAY_NAME}}}
 }
 
+type KalmGatewaySecretMapper struct {
+	*BaseReconciler
+}
+
+// Map enqueues the gateway for certificates in the gateway namespace and Kalm secrets of applications, which may hold client CAs
+func (r *KalmGatewaySecretMapper) Map(object handler.MapObject) []reconcile.Request {
+	if object.Meta.GetNamespace() != KALM_GATEWAY_NAMESPACE && object.Meta.GetName() != corev1alpha1.SecretStoreName {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: KALM_GATEWAY_NAMESPACE, Name: KALM_GATEWAY_NAME}}}
+}
+
 func (r *GatewayReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1beta1.Gateway{}).
@@ -241,5 +271,11 @@ func (r *GatewayReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				ToRequests: &KalmGatewayRequestMapper{r.BaseReconciler},
 			},
 		).
+		Watches(
+			&source.Kind{Type: &coreV1.Secret{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: &KalmGatewaySecretMapper{r.BaseReconciler},
+			},
+		).
 		Complete(r)
 }
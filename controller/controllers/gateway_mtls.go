@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+)
+
+const KALM_MTLS_CREDENTIAL_LABEL = "kalm-mtls-credential"
+
+// mutualTLSCredential is a server certificate and a client CA used by the gateway on some hosts.
+// It's saved as a generic secret in the gateway namespace, in the format read by istio for MUTUAL tls.
+type mutualTLSCredential struct {
+	Name           string
+	CertSecretName string
+	RouteNamespace string
+	CASecret       string
+	Hosts          []string
+}
+
+func getMutualTLSCredentialName(certSecretName, routeNamespace, caSecret string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(fmt.Sprintf("%s/%s", routeNamespace, caSecret)))
+	return fmt.Sprintf("%s-mtls-%x", certSecretName, h.Sum32())
+}
+
+// buildMutualTLSCredentials groups hosts of routes requiring client certificates by the server certificate and the client CA.
+// A host is served by one tls setting, the oldest route of the host wins. Hosts without certificates are skipped.
+func buildMutualTLSCredentials(certs []corev1alpha1.HttpsCert, routes []corev1alpha1.HttpRoute) []*mutualTLSCredential {
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].CreationTimestamp.Before(&routes[j].CreationTimestamp)
+	})
+
+	claimedHosts := make(map[string]bool)
+	credentialMap := make(map[string]*mutualTLSCredential)
+	var credentials []*mutualTLSCredential
+
+	for _, route := range routes {
+		if route.Spec.ClientCertificate == nil {
+			continue
+		}
+
+		for _, host := range route.Spec.Hosts {
+			if claimedHosts[host] {
+				continue
+			}
+
+			var certSecretName string
+
+			for _, cert := range certs {
+				if certCanBeUsedOnDomain(cert.Spec.Domains, host) {
+					_, certSecretName = getCertAndCertSecretName(cert)
+					break
+				}
+			}
+
+			if certSecretName == "" {
+				continue
+			}
+
+			claimedHosts[host] = true
+
+			name := getMutualTLSCredentialName(certSecretName, route.Namespace, route.Spec.ClientCertificate.CASecret)
+			credential, ok := credentialMap[name]
+
+			if !ok {
+				credential = &mutualTLSCredential{
+					Name:           name,
+					CertSecretName: certSecretName,
+					RouteNamespace: route.Namespace,
+					CASecret:       route.Spec.ClientCertificate.CASecret,
+				}
+				credentialMap[name] = credential
+				credentials = append(credentials, credential)
+			}
+
+			credential.Hosts = append(credential.Hosts, host)
+		}
+	}
+
+	return credentials
+}
+
+func buildMutualTLSServers(credentials []*mutualTLSCredential) []*istioNetworkingV1Beta1.Server {
+	var servers []*istioNetworkingV1Beta1.Server
+
+	for _, credential := range credentials {
+		servers = append(servers, &istioNetworkingV1Beta1.Server{
+			Hosts: credential.Hosts,
+			Port: &istioNetworkingV1Beta1.Port{
+				Number:   443,
+				Protocol: "HTTPS",
+				Name:     fmt.Sprintf("https-%s", credential.Name),
+			},
+			Tls: &istioNetworkingV1Beta1.ServerTLSSettings{
+				Mode:           istioNetworkingV1Beta1.ServerTLSSettings_MUTUAL,
+				CredentialName: credential.Name,
+			},
+		})
+	}
+
+	return servers
+}
+
+// removeServerHosts removes the hosts from the servers, servers without hosts are dropped.
+func removeServerHosts(servers []*istioNetworkingV1Beta1.Server, hosts map[string]bool) []*istioNetworkingV1Beta1.Server {
+	var res []*istioNetworkingV1Beta1.Server
+
+	for _, server := range servers {
+		var serverHosts []string
+
+		for _, host := range server.Hosts {
+			if !hosts[host] {
+				serverHosts = append(serverHosts, host)
+			}
+		}
+
+		if len(serverHosts) == 0 {
+			continue
+		}
+
+		server.Hosts = serverHosts
+		res = append(res, server)
+	}
+
+	return res
+}
+
+// saveMutualTLSCredential copies the server certificate and the client CA into the credential secret.
+// false is returned if any of them doesn't exist yet, hosts of the credential are not served with client certificates until then.
+func (r *GatewayReconcilerTask) saveMutualTLSCredential(credential *mutualTLSCredential) (bool, error) {
+	var certSecret coreV1.Secret
+	if err := r.Reader.Get(r.ctx, types.NamespacedName{Namespace: KALM_GATEWAY_NAMESPACE, Name: credential.CertSecretName}, &certSecret); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	var kalmSecrets coreV1.Secret
+	if err := r.Reader.Get(r.ctx, types.NamespacedName{Namespace: credential.RouteNamespace, Name: corev1alpha1.SecretStoreName}, &kalmSecrets); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	cert, key, ca := certSecret.Data[coreV1.TLSCertKey], certSecret.Data[coreV1.TLSPrivateKeyKey], kalmSecrets.Data[credential.CASecret]
+
+	if len(cert) == 0 || len(key) == 0 || len(ca) == 0 {
+		return false, nil
+	}
+
+	data := map[string][]byte{
+		"cert":   cert,
+		"key":    key,
+		"cacert": ca,
+	}
+
+	var secret coreV1.Secret
+	if err := r.Reader.Get(r.ctx, types.NamespacedName{Namespace: KALM_GATEWAY_NAMESPACE, Name: credential.Name}, &secret); err != nil {
+		if !errors.IsNotFound(err) {
+			return false, err
+		}
+
+		secret = coreV1.Secret{
+			ObjectMeta: metaV1.ObjectMeta{
+				Namespace: KALM_GATEWAY_NAMESPACE,
+				Name:      credential.Name,
+				Labels: map[string]string{
+					KALM_MTLS_CREDENTIAL_LABEL: "true",
+				},
+			},
+			Type: coreV1.SecretTypeOpaque,
+			Data: data,
+		}
+
+		return true, r.Create(r.ctx, &secret)
+	}
+
+	secret.Data = data
+
+	return true, r.Update(r.ctx, &secret)
+}
+
+// MutualTLSCredentials saves credentials used by routes requiring client certificates, and deletes the unused ones.
+func (r *GatewayReconcilerTask) MutualTLSCredentials(certs []corev1alpha1.HttpsCert) ([]*mutualTLSCredential, error) {
+	var routes corev1alpha1.HttpRouteList
+	if err := r.Reader.List(r.ctx, &routes); err != nil {
+		return nil, err
+	}
+
+	var saved []*mutualTLSCredential
+	used := make(map[string]bool)
+
+	for _, credential := range buildMutualTLSCredentials(certs, routes.Items) {
+		ok, err := r.saveMutualTLSCredential(credential)
+
+		if err != nil {
+			r.Log.Error(err, fmt.Sprintf("Save mtls credential %s error.", credential.Name))
+			return nil, err
+		}
+
+		if ok {
+			used[credential.Name] = true
+			saved = append(saved, credential)
+		}
+	}
+
+	var secrets coreV1.SecretList
+	if err := r.Reader.List(r.ctx, &secrets, client.InNamespace(KALM_GATEWAY_NAMESPACE), client.MatchingLabels{KALM_MTLS_CREDENTIAL_LABEL: "true"}); err != nil {
+		return nil, err
+	}
+
+	for i := range secrets.Items {
+		if used[secrets.Items[i].Name] {
+			continue
+		}
+
+		if err := r.Delete(r.ctx, &secrets.Items[i]); client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+	}
+
+	return saved, nil
+}
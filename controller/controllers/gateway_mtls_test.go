@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildMutualTLSCredentials(t *testing.T) {
+	certs := []v1alpha1.HttpsCert{
+		{ObjectMeta: v1.ObjectMeta{Name: "wildcard"}, Spec: v1alpha1.HttpsCertSpec{Domains: []string{"*.example.com"}}},
+		{ObjectMeta: v1.ObjectMeta{Name: "other"}, Spec: v1alpha1.HttpsCertSpec{Domains: []string{"other.com"}}},
+	}
+
+	now := time.Now()
+
+	newRoute := func(namespace, name string, created time.Time, caSecret string, hosts ...string) v1alpha1.HttpRoute {
+		route := newStatusTestRoute(namespace, name, []string{"/"})
+		route.CreationTimestamp = v1.NewTime(created)
+		route.Spec.Hosts = hosts
+
+		if caSecret != "" {
+			route.Spec.ClientCertificate = &v1alpha1.HttpRouteClientCertificate{CASecret: caSecret}
+		}
+
+		return *route
+	}
+
+	routes := []v1alpha1.HttpRoute{
+		newRoute("ns-b", "newer", now, "ca", "a.example.com", "b.example.com"),
+		newRoute("ns-a", "older", now.Add(-time.Hour), "ca", "a.example.com", "other.com", "no-cert.io"),
+		newRoute("ns-a", "plain", now.Add(-2*time.Hour), "", "c.example.com"),
+	}
+
+	credentials := buildMutualTLSCredentials(certs, routes)
+	assert.Len(t, credentials, 3)
+
+	assert.Equal(t, getMutualTLSCredentialName("wildcard", "ns-a", "ca"), credentials[0].Name)
+	assert.Equal(t, []string{"a.example.com"}, credentials[0].Hosts)
+	assert.Equal(t, "wildcard", credentials[0].CertSecretName)
+
+	assert.Equal(t, "other", credentials[1].CertSecretName)
+	assert.Equal(t, []string{"other.com"}, credentials[1].Hosts)
+
+	// the host claimed by the older route is skipped
+	assert.Equal(t, "ns-b", credentials[2].RouteNamespace)
+	assert.Equal(t, []string{"b.example.com"}, credentials[2].Hosts)
+	assert.NotEqual(t, credentials[0].Name, credentials[2].Name)
+
+	servers := buildMutualTLSServers(credentials)
+	assert.Equal(t, istioNetworkingV1Beta1.ServerTLSSettings_MUTUAL, servers[0].Tls.Mode)
+	assert.Equal(t, credentials[0].Name, servers[0].Tls.CredentialName)
+}
+
+func TestRemoveServerHosts(t *testing.T) {
+	servers := []*istioNetworkingV1Beta1.Server{
+		{Hosts: []string{"*.example.com", "a.example.com"}},
+		{Hosts: []string{"other.com"}},
+	}
+
+	servers = removeServerHosts(servers, map[string]bool{"a.example.com": true, "other.com": true})
+
+	assert.Len(t, servers, 1)
+	assert.Equal(t, []string{"*.example.com"}, servers[0].Hosts)
+}
@@ -195,12 +195,16 @@ func (suite *BasicSuite) SetupSuite() {
 	suite.Nil(NewComponentReconciler(mgr).SetupWithManager(mgr))
 	suite.Nil(NewComponentPluginReconciler(mgr).SetupWithManager(mgr))
 	suite.Nil(NewComponentPluginBindingReconciler(mgr).SetupWithManager(mgr))
+	suite.Nil(NewSecretStoreReconciler(mgr).SetupWithManager(mgr))
+	suite.Nil(NewApplicationSourceReconciler(mgr).SetupWithManager(mgr))
+	suite.Nil(NewPreviewEnvironmentReconciler(mgr).SetupWithManager(mgr))
 
 	suite.Nil(NewHttpsCertIssuerReconciler(mgr).SetupWithManager(mgr))
 	suite.Nil(NewHttpsCertReconciler(mgr).SetupWithManager(mgr))
 
 	suite.Nil(NewDockerRegistryReconciler(mgr).SetupWithManager(mgr))
 	suite.Nil(NewHttpRouteReconciler(mgr).SetupWithManager(mgr))
+	suite.Nil(NewTcpRouteReconciler(mgr).SetupWithManager(mgr))
 	suite.Nil(NewGatewayReconciler(mgr).SetupWithManager(mgr))
 	suite.Nil(NewSingleSignOnConfigReconciler(mgr).SetupWithManager(mgr))
 	suite.Nil(NewProtectedEndpointReconciler(mgr).SetupWithManager(mgr))
@@ -0,0 +1,357 @@
+package controllers
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+
+	"istio.io/api/networking/v1alpha3"
+	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
+	securityV1Beta1 "istio.io/api/security/v1beta1"
+	istioTypeV1Beta1 "istio.io/api/type/v1beta1"
+	v1alpha32 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/security/v1beta1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+)
+
+const (
+	XFF_TRUSTED_HOPS_ENVOY_FILTER_NAME  = "kalm-xff-trusted-hops"
+	SOURCE_IP_TAGGING_ENVOY_FILTER_NAME = "kalm-source-ip-tagging"
+
+	// the ip tagging filter appends tags of the client address to this header, separated by commas
+	ENVOY_IP_TAGS_HEADER = "x-envoy-ip-tags"
+
+	// envoy replaces it with the subject of the client certificate
+	DOWNSTREAM_PEER_SUBJECT = "%DOWNSTREAM_PEER_SUBJECT%"
+)
+
+func getHttpRouteAuthorizationPolicyName(route *corev1alpha1.HttpRoute) string {
+	return fmt.Sprintf("kalm-route-access-%s-%s", route.Namespace, route.Name)
+}
+
+// getSourceIPDeniedTag is the ip tag of client addresses the route denies
+func getSourceIPDeniedTag(route *corev1alpha1.HttpRoute) string {
+	return fmt.Sprintf("kalm-source-ip-denied-%s-%s", route.Namespace, route.Name)
+}
+
+// getGatewayXffNumTrustedHops is the number of proxies in front of the ingress gateway, e.g. a cloud load balancer.
+// Client addresses are taken from X-Forwarded-For after skipping this many hops, so clients can't fake them.
+// It's a property of the cluster network, so it's configured on the controller instead of routes.
+func getGatewayXffNumTrustedHops() int {
+	hops, _ := strconv.Atoi(os.Getenv("KALM_GATEWAY_XFF_NUM_TRUSTED_HOPS"))
+
+	if hops < 0 {
+		return 0
+	}
+
+	return hops
+}
+
+func parseCIDROrIP(s string) *net.IPNet {
+	if _, ipNet, err := net.ParseCIDR(s); err == nil {
+		return ipNet
+	}
+
+	ip := net.ParseIP(s)
+
+	if ip == nil {
+		return nil
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
+
+// appendCIDRsNotIn splits the block until parts of it are either in or out of the cidrs, and appends the parts out of them.
+func appendCIDRsNotIn(res []*net.IPNet, block *net.IPNet, cidrs []*net.IPNet) []*net.IPNet {
+	ones, bits := block.Mask.Size()
+	overlapped := false
+
+	for _, cidr := range cidrs {
+		if len(cidr.IP) != len(block.IP) {
+			continue
+		}
+
+		cidrOnes, _ := cidr.Mask.Size()
+
+		if cidrOnes <= ones {
+			if cidr.Contains(block.IP) {
+				return res
+			}
+		} else if block.Contains(cidr.IP) {
+			overlapped = true
+		}
+	}
+
+	if !overlapped {
+		return append(res, block)
+	}
+
+	mask := net.CIDRMask(ones+1, bits)
+	low := &net.IPNet{IP: append(net.IP{}, block.IP...), Mask: mask}
+	high := &net.IPNet{IP: append(net.IP{}, block.IP...), Mask: mask}
+	high.IP[ones/8] |= 0x80 >> uint(ones%8)
+
+	return appendCIDRsNotIn(appendCIDRsNotIn(res, low, cidrs), high, cidrs)
+}
+
+// buildSourceIPDeniedCIDRs returns the client addresses denied by the route, the deny list and addresses out of the allow list.
+func buildSourceIPDeniedCIDRs(sourceIP *corev1alpha1.HttpRouteSourceIP) []*net.IPNet {
+	if sourceIP == nil {
+		return nil
+	}
+
+	var res []*net.IPNet
+
+	for _, s := range sourceIP.Deny {
+		if cidr := parseCIDROrIP(s); cidr != nil {
+			res = append(res, cidr)
+		}
+	}
+
+	if len(sourceIP.Allow) == 0 {
+		return res
+	}
+
+	var allowed []*net.IPNet
+
+	for _, s := range sourceIP.Allow {
+		if cidr := parseCIDROrIP(s); cidr != nil {
+			allowed = append(allowed, cidr)
+		}
+	}
+
+	res = appendCIDRsNotIn(res, &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}, allowed)
+	res = appendCIDRsNotIn(res, &net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)}, allowed)
+
+	return res
+}
+
+// buildSourceIPTaggingEnvoyFilter tags client addresses denied by routes.
+// Only denied addresses are tagged, so a client adding tags in the header itself can't get access.
+// nil is returned if no route limits source ips.
+func buildSourceIPTaggingEnvoyFilter(routes []corev1alpha1.HttpRoute) *v1alpha32.EnvoyFilter {
+	var ipTags []interface{}
+
+	for i := range routes {
+		route := &routes[i]
+		var ipList []interface{}
+
+		for _, cidr := range buildSourceIPDeniedCIDRs(route.Spec.SourceIP) {
+			ones, _ := cidr.Mask.Size()
+			ipList = append(ipList, map[string]interface{}{
+				"address_prefix": cidr.IP.String(),
+				"prefix_len":     ones,
+			})
+		}
+
+		if len(ipList) == 0 {
+			continue
+		}
+
+		ipTags = append(ipTags, map[string]interface{}{
+			"ip_tag_name": getSourceIPDeniedTag(route),
+			"ip_list":     ipList,
+		})
+	}
+
+	if len(ipTags) == 0 {
+		return nil
+	}
+
+	// the client address honors X-Forwarded-For with the trusted hops of the gateway
+	return buildIngressGatewayHttpFilter(SOURCE_IP_TAGGING_ENVOY_FILTER_NAME, map[string]interface{}{
+		"name": "envoy.filters.http.ip_tagging",
+		"typed_config": map[string]interface{}{
+			"@type":        "type.googleapis.com/envoy.extensions.filters.http.ip_tagging.v3.IPTagging",
+			"request_type": "BOTH",
+			"ip_tags":      ipTags,
+		},
+	})
+}
+
+// buildXffTrustedHopsEnvoyFilter makes the ingress gateway take the client address from X-Forwarded-For, skipping the trusted proxies.
+func buildXffTrustedHopsEnvoyFilter(hops int) *v1alpha32.EnvoyFilter {
+	return &v1alpha32.EnvoyFilter{
+		ObjectMeta: metaV1.ObjectMeta{
+			Namespace: istioNamespace,
+			Name:      XFF_TRUSTED_HOPS_ENVOY_FILTER_NAME,
+			Labels: map[string]string{
+				KALM_ROUTE_LABEL: "true",
+			},
+		},
+		Spec: v1alpha3.EnvoyFilter{
+			WorkloadSelector: &v1alpha3.WorkloadSelector{
+				Labels: map[string]string{
+					"app": "istio-ingressgateway",
+				},
+			},
+			ConfigPatches: []*v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{
+				{
+					ApplyTo: v1alpha3.EnvoyFilter_NETWORK_FILTER,
+					Match: &v1alpha3.EnvoyFilter_EnvoyConfigObjectMatch{
+						Context: v1alpha3.EnvoyFilter_GATEWAY,
+						ObjectTypes: &v1alpha3.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+							Listener: &v1alpha3.EnvoyFilter_ListenerMatch{
+								FilterChain: &v1alpha3.EnvoyFilter_ListenerMatch_FilterChainMatch{
+									Filter: &v1alpha3.EnvoyFilter_ListenerMatch_FilterMatch{
+										Name: "envoy.http_connection_manager",
+									},
+								},
+							},
+						},
+					},
+					Patch: &v1alpha3.EnvoyFilter_Patch{
+						Operation: v1alpha3.EnvoyFilter_Patch_MERGE,
+						Value: golangMapToProtoStruct(map[string]interface{}{
+							"typed_config": map[string]interface{}{
+								"@type":                "type.googleapis.com/envoy.config.filter.network.http_connection_manager.v2.HttpConnectionManager",
+								"use_remote_address":   true,
+								"xff_num_trusted_hops": hops,
+							},
+						}),
+					},
+				},
+			},
+		},
+	}
+}
+
+// buildSourceIPDeniedHttpRoutes returns rules responding 403 to tagged clients, they are put before the rules of the route.
+func (r *HttpRouteReconcilerTask) buildSourceIPDeniedHttpRoutes(route *corev1alpha1.HttpRoute) []*istioNetworkingV1Beta1.HTTPRoute {
+	if len(buildSourceIPDeniedCIDRs(route.Spec.SourceIP)) == 0 {
+		return nil
+	}
+
+	tagMatch := &istioNetworkingV1Beta1.StringMatch{
+		MatchType: &istioNetworkingV1Beta1.StringMatch_Regex{
+			Regex: fmt.Sprintf("^(.*,)?%s(,.*)?$", regexp.QuoteMeta(getSourceIPDeniedTag(route))),
+		},
+	}
+
+	var res []*istioNetworkingV1Beta1.HTTPRoute
+
+	for _, match := range r.BuildMatches(route) {
+		if match.Headers == nil {
+			match.Headers = make(map[string]*istioNetworkingV1Beta1.StringMatch)
+		}
+
+		match.Headers[ENVOY_IP_TAGS_HEADER] = tagMatch
+
+		res = append(res, &istioNetworkingV1Beta1.HTTPRoute{
+			Name:  getIstioHttpRouteName(route) + "-source-ip-denied",
+			Match: []*istioNetworkingV1Beta1.HTTPMatchRequest{match},
+			// a destination is required, requests are aborted before sending to it
+			Route: r.BuildDestinations(route),
+			Fault: &istioNetworkingV1Beta1.HTTPFaultInjection{
+				Abort: &istioNetworkingV1Beta1.HTTPFaultInjection_Abort{
+					ErrorType: &istioNetworkingV1Beta1.HTTPFaultInjection_Abort_HttpStatus{
+						HttpStatus: 403,
+					},
+					Percentage: &istioNetworkingV1Beta1.Percent{
+						Value: 100,
+					},
+				},
+			},
+		})
+	}
+
+	return res
+}
+
+// buildHttpRouteAuthorizationOperation matches requests of the route, regex paths are rejected by the webhook.
+func buildHttpRouteAuthorizationOperation(route *corev1alpha1.HttpRoute) *securityV1Beta1.Operation {
+	operation := &securityV1Beta1.Operation{}
+
+	for _, host := range route.Spec.Hosts {
+		// the host header may contain a port
+		operation.Hosts = append(operation.Hosts, host, host+":*")
+	}
+
+	for _, path := range route.Spec.Paths {
+		if route.GetPathType() == corev1alpha1.HttpRoutePathTypeExact {
+			operation.Paths = append(operation.Paths, path)
+		} else {
+			operation.Paths = append(operation.Paths, path+"*")
+		}
+	}
+
+	if !isAllowAllMethods(route.Spec.Methods) {
+		for _, method := range route.Spec.Methods {
+			operation.Methods = append(operation.Methods, string(method))
+		}
+	}
+
+	return operation
+}
+
+// buildHttpRouteAuthorizationPolicy denies requests of the route not sent through tls connections verifying client certificates.
+// Only DENY rules are used, an ALLOW policy would deny all requests of other routes on the gateway.
+// Source ips are denied by virtual service rules, ipBlocks of policies never use X-Forwarded-For in this istio version.
+// nil is returned if the route has no client certificate.
+func buildHttpRouteAuthorizationPolicy(route *corev1alpha1.HttpRoute) *v1beta1.AuthorizationPolicy {
+	var rules []*securityV1Beta1.Rule
+
+	to := []*securityV1Beta1.Rule_To{{Operation: buildHttpRouteAuthorizationOperation(route)}}
+
+	// A client could open a connection with the server name of a host without client certificates, and send requests of the route in it.
+	// Certificates are verified on connections to hosts of the route only.
+	if route.Spec.ClientCertificate != nil {
+		rules = append(rules, &securityV1Beta1.Rule{
+			To: to,
+			When: []*securityV1Beta1.Condition{
+				{
+					Key:       "connection.sni",
+					NotValues: route.Spec.Hosts,
+				},
+			},
+		})
+	}
+
+	if len(rules) == 0 {
+		return nil
+	}
+
+	return &v1beta1.AuthorizationPolicy{
+		ObjectMeta: metaV1.ObjectMeta{
+			Namespace: istioNamespace,
+			Name:      getHttpRouteAuthorizationPolicyName(route),
+			Labels: map[string]string{
+				KALM_ROUTE_LABEL: "true",
+			},
+		},
+		Spec: securityV1Beta1.AuthorizationPolicy{
+			Selector: &istioTypeV1Beta1.WorkloadSelector{
+				MatchLabels: map[string]string{
+					"app": "istio-ingressgateway",
+				},
+			},
+			Action: securityV1Beta1.AuthorizationPolicy_DENY,
+			Rules:  rules,
+		},
+	}
+}
+
+// saveRouteAuthorizationPolicy creates the policy, or updates the existing one in the map and removes it from the map, so it's not cleaned.
+func (r *HttpRouteReconcilerTask) saveRouteAuthorizationPolicy(policy *v1beta1.AuthorizationPolicy, existing map[string]*v1beta1.AuthorizationPolicy) error {
+	fetched, ok := existing[policy.Name]
+
+	if !ok {
+		return r.Create(r.ctx, policy)
+	}
+
+	delete(existing, policy.Name)
+
+	copied := fetched.DeepCopy()
+	copied.Labels = policy.Labels
+	copied.Spec = policy.Spec
+
+	return r.Update(r.ctx, copied)
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"net"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	securityV1Beta1 "istio.io/api/security/v1beta1"
+)
+
+func TestBuildHttpRouteAuthorizationPolicy(t *testing.T) {
+	route := newStatusTestRoute("test-ns", "api", []string{"/api"}, "GET")
+
+	assert.Nil(t, buildHttpRouteAuthorizationPolicy(route))
+
+	// source ips are denied by virtual service rules
+	route.Spec.SourceIP = &v1alpha1.HttpRouteSourceIP{Allow: []string{"10.0.0.0/8"}}
+	assert.Nil(t, buildHttpRouteAuthorizationPolicy(route))
+
+	route.Spec.ClientCertificate = &v1alpha1.HttpRouteClientCertificate{CASecret: "client-ca"}
+
+	policy := buildHttpRouteAuthorizationPolicy(route)
+
+	assert.Equal(t, "kalm-route-access-test-ns-api", policy.Name)
+	assert.Equal(t, istioNamespace, policy.Namespace)
+	assert.Equal(t, "true", policy.Labels[KALM_ROUTE_LABEL])
+	assert.Equal(t, securityV1Beta1.AuthorizationPolicy_DENY, policy.Spec.Action)
+	assert.Len(t, policy.Spec.Rules, 1)
+
+	operation := policy.Spec.Rules[0].To[0].Operation
+	assert.Equal(t, []string{"example.com", "example.com:*"}, operation.Hosts)
+	assert.Equal(t, []string{"/api*"}, operation.Paths)
+	assert.Equal(t, []string{"GET"}, operation.Methods)
+
+	assert.Nil(t, policy.Spec.Rules[0].From)
+	assert.Equal(t, "connection.sni", policy.Spec.Rules[0].When[0].Key)
+	assert.Equal(t, []string{"example.com"}, policy.Spec.Rules[0].When[0].NotValues)
+}
+
+func cidrStrings(cidrs []*net.IPNet) []string {
+	res := make([]string, 0, len(cidrs))
+
+	for _, cidr := range cidrs {
+		res = append(res, cidr.String())
+	}
+
+	return res
+}
+
+func TestBuildSourceIPDeniedCIDRs(t *testing.T) {
+	assert.Empty(t, buildSourceIPDeniedCIDRs(nil))
+	assert.Empty(t, buildSourceIPDeniedCIDRs(&v1alpha1.HttpRouteSourceIP{Allow: []string{"0.0.0.0/0", "::/0"}}))
+
+	assert.Equal(t, []string{"10.1.0.0/16", "2001:db8::1/128"}, cidrStrings(buildSourceIPDeniedCIDRs(&v1alpha1.HttpRouteSourceIP{
+		Deny: []string{"10.1.0.0/16", "2001:db8::1"},
+	})))
+
+	assert.Equal(t, []string{"0.0.0.0/1", "192.0.0.0/2", "::/0"}, cidrStrings(buildSourceIPDeniedCIDRs(&v1alpha1.HttpRouteSourceIP{
+		Allow: []string{"128.0.0.0/2"},
+	})))
+
+	denied := buildSourceIPDeniedCIDRs(&v1alpha1.HttpRouteSourceIP{
+		Allow: []string{"10.0.0.0/8", "192.168.1.10"},
+		Deny:  []string{"10.1.0.0/16"},
+	})
+
+	isDenied := func(ip string) bool {
+		for _, cidr := range denied {
+			if cidr.Contains(net.ParseIP(ip)) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	assert.False(t, isDenied("10.0.0.1"))
+	assert.False(t, isDenied("10.255.255.255"))
+	assert.False(t, isDenied("192.168.1.10"))
+	assert.True(t, isDenied("10.1.2.3"))
+	assert.True(t, isDenied("9.255.255.255"))
+	assert.True(t, isDenied("11.0.0.0"))
+	assert.True(t, isDenied("192.168.1.11"))
+	assert.True(t, isDenied("2001:db8::1"))
+}
+
+func TestBuildSourceIPTaggingEnvoyFilter(t *testing.T) {
+	api := newStatusTestRoute("test-ns", "api", []string{"/"})
+	web := newStatusTestRoute("test-ns", "web", []string{"/"})
+
+	assert.Nil(t, buildSourceIPTaggingEnvoyFilter([]v1alpha1.HttpRoute{*api, *web}))
+
+	api.Spec.SourceIP = &v1alpha1.HttpRouteSourceIP{Deny: []string{"10.1.0.0/16"}}
+
+	filter := buildSourceIPTaggingEnvoyFilter([]v1alpha1.HttpRoute{*api, *web})
+
+	assert.Equal(t, SOURCE_IP_TAGGING_ENVOY_FILTER_NAME, filter.Name)
+	assert.Equal(t, "true", filter.Labels[KALM_ROUTE_LABEL])
+
+	value := protoStructToMap(t, filter.Spec.ConfigPatches[0].Patch.Value)
+	assert.Equal(t, "envoy.filters.http.ip_tagging", value["name"])
+
+	config := value["typed_config"].(map[string]interface{})
+	assert.Equal(t, "BOTH", config["request_type"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"ip_tag_name": "kalm-source-ip-denied-test-ns-api",
+			"ip_list": []interface{}{
+				map[string]interface{}{"address_prefix": "10.1.0.0", "prefix_len": float64(16)},
+			},
+		},
+	}, config["ip_tags"])
+}
+
+func TestBuildXffTrustedHopsEnvoyFilter(t *testing.T) {
+	filter := buildXffTrustedHopsEnvoyFilter(2)
+
+	assert.Equal(t, XFF_TRUSTED_HOPS_ENVOY_FILTER_NAME, filter.Name)
+	assert.Equal(t, "true", filter.Labels[KALM_ROUTE_LABEL])
+
+	value := protoStructToMap(t, filter.Spec.ConfigPatches[0].Patch.Value)
+	config := value["typed_config"].(map[string]interface{})
+
+	assert.Equal(t, true, config["use_remote_address"])
+	assert.Equal(t, float64(2), config["xff_num_trusted_hops"])
+}
+
+func TestBuildSourceIPDeniedHttpRoutes(t *testing.T) {
+	route := newStatusTestRoute("test-ns", "api", []string{"/api", "/v2"}, "GET")
+	task := &HttpRouteReconcilerTask{}
+
+	assert.Empty(t, task.buildSourceIPDeniedHttpRoutes(route))
+
+	route.Spec.SourceIP = &v1alpha1.HttpRouteSourceIP{Allow: []string{"10.0.0.0/8"}}
+
+	routes := task.buildSourceIPDeniedHttpRoutes(route)
+	assert.Len(t, routes, 2)
+
+	denied := routes[0]
+	assert.Equal(t, "kalm-route-test-ns-api-source-ip-denied", denied.Name)
+	assert.Equal(t, int32(403), denied.Fault.Abort.GetHttpStatus())
+	assert.Equal(t, float64(100), denied.Fault.Abort.Percentage.Value)
+	assert.Equal(t, task.BuildMatches(route)[0].Uri, denied.Match[0].Uri)
+
+	tags := regexp.MustCompile(denied.Match[0].Headers[ENVOY_IP_TAGS_HEADER].GetRegex())
+	assert.True(t, tags.MatchString("kalm-source-ip-denied-test-ns-api"))
+	assert.True(t, tags.MatchString("a,kalm-source-ip-denied-test-ns-api,b"))
+	assert.False(t, tags.MatchString("kalm-source-ip-denied-test-ns-api-v2"))
+	assert.False(t, tags.MatchString("kalm-source-ip-denied-test-nsxapi"))
+
+	// denied rules are before rules of the route after sorting
+	rules := append(routes, task.buildIstioHttpRoutes(route)...)
+	sort.SliceStable(rules, func(i, j int) bool { return sortRoutes(rules[i], rules[j]) })
+
+	assert.Equal(t, "kalm-route-test-ns-api-source-ip-denied", rules[0].Name)
+	assert.Equal(t, "kalm-route-test-ns-api", rules[1].Name)
+	assert.Equal(t, rules[0].Match[0].Uri, rules[1].Match[0].Uri)
+}
+
+func TestClientCertificateSubjectHeader(t *testing.T) {
+	route := newStatusTestRoute("test-ns", "api", []string{"/"})
+	task := &HttpRouteReconcilerTask{}
+
+	assert.NotContains(t, task.buildIstioHttpRoutes(route)[0].Headers.Request.Set, v1alpha1.DefaultClientCertificateSubjectHeader)
+
+	route.Spec.ClientCertificate = &v1alpha1.HttpRouteClientCertificate{CASecret: "client-ca"}
+	assert.Equal(t, DOWNSTREAM_PEER_SUBJECT, task.buildIstioHttpRoutes(route)[0].Headers.Request.Set[v1alpha1.DefaultClientCertificateSubjectHeader])
+
+	route.Spec.ClientCertificate.SubjectHeader = "X-Subject"
+	assert.Equal(t, DOWNSTREAM_PEER_SUBJECT, task.buildIstioHttpRoutes(route)[0].Headers.Request.Set["X-Subject"])
+}
@@ -18,17 +18,24 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"github.com/Masterminds/semver/v3"
 	protoTypes "github.com/gogo/protobuf/types"
 	"istio.io/api/networking/v1alpha3"
 	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
 	v1alpha32 "istio.io/client-go/pkg/apis/networking/v1alpha3"
 	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	v1beta12 "istio.io/client-go/pkg/apis/security/v1beta1"
+	coreV1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"math"
 	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 	"sort"
@@ -63,10 +70,17 @@ type HttpRouteReconcilerTask struct {
 	gateways                  []v1beta1.Gateway
 	virtualServices           []v1beta1.VirtualService
 	httpsRedirectEnvoyFilters []v1alpha32.EnvoyFilter
+	authorizationPolicies     []v1beta12.AuthorizationPolicy
+
+	// "namespace/name" of component -> percentage of traffic sent to the new version during rollout
+	canaryWeights map[string]int
+
+	// namespace -> plugin bindings, loaded on demand
+	pluginBindings map[string][]corev1alpha1.ComponentPluginBinding
 }
 
 func getIstioHttpRouteName(route *corev1alpha1.HttpRoute) string {
-	return fmt.Sprintf("kalm-route-%s", route.Name)
+	return fmt.Sprintf("kalm-route-%s-%s", route.Namespace, route.Name)
 }
 
 func getHttpsRedirectEnvoyFilterName(route *corev1alpha1.HttpRoute) string {
@@ -77,16 +91,13 @@ func getHttpsRedirectEnvoyFilterName(route *corev1alpha1.HttpRoute) string {
 func (r *HttpRouteReconcilerTask) buildIstioHttpRoute(route *corev1alpha1.HttpRoute) *istioNetworkingV1Beta1.HTTPRoute {
 	spec := &route.Spec
 	httpRoute := &istioNetworkingV1Beta1.HTTPRoute{
-		Name:  getIstioHttpRouteName(route),
-		Route: r.BuildDestinations(route),
-		Headers: &istioNetworkingV1Beta1.Headers{
-			Request: &istioNetworkingV1Beta1.Headers_HeaderOperations{
-				Remove: DANGEROUS_HEADERS,
-				Set: map[string]string{
-					KALM_ROUTE_HEADER: "true",
-				},
-			},
-		},
+		Name:    getIstioHttpRouteName(route),
+		Route:   r.BuildDestinations(route),
+		Headers: buildIstioHttpRouteHeaders(spec.Headers),
+	}
+
+	if spec.ClientCertificate != nil {
+		httpRoute.Headers.Request.Set[spec.ClientCertificate.GetSubjectHeader()] = DOWNSTREAM_PEER_SUBJECT
 	}
 
 	if spec.StripPath {
@@ -95,6 +106,18 @@ func (r *HttpRouteReconcilerTask) buildIstioHttpRoute(route *corev1alpha1.HttpRo
 		}
 	}
 
+	if spec.Rewrite != nil && (spec.Rewrite.Uri != "" || spec.Rewrite.Authority != "") {
+		if httpRoute.Rewrite == nil {
+			httpRoute.Rewrite = &istioNetworkingV1Beta1.HTTPRewrite{}
+		}
+
+		if spec.Rewrite.Uri != "" {
+			httpRoute.Rewrite.Uri = spec.Rewrite.Uri
+		}
+
+		httpRoute.Rewrite.Authority = spec.Rewrite.Authority
+	}
+
 	if spec.Timeout != nil {
 		httpRoute.Timeout = &protoTypes.Duration{
 			Seconds: int64(*spec.Timeout),
@@ -169,6 +192,71 @@ func (r *HttpRouteReconcilerTask) buildIstioHttpRoute(route *corev1alpha1.HttpRo
 
 }
 
+func isDangerousHeader(name string) bool {
+	for _, header := range DANGEROUS_HEADERS {
+		if strings.EqualFold(name, header) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func copyHeaderOperations(operations *corev1alpha1.HttpRouteHeaderOperations, dst *istioNetworkingV1Beta1.Headers_HeaderOperations, skipDangerous bool) {
+	for name, value := range operations.Set {
+		if !skipDangerous || !isDangerousHeader(name) {
+			if dst.Set == nil {
+				dst.Set = make(map[string]string)
+			}
+
+			dst.Set[name] = value
+		}
+	}
+
+	for name, value := range operations.Add {
+		if !skipDangerous || !isDangerousHeader(name) {
+			if dst.Add == nil {
+				dst.Add = make(map[string]string)
+			}
+
+			dst.Add[name] = value
+		}
+	}
+
+	for _, name := range operations.Remove {
+		if !skipDangerous || !isDangerousHeader(name) {
+			dst.Remove = append(dst.Remove, name)
+		}
+	}
+}
+
+// buildIstioHttpRouteHeaders merges header operations of the route with headers kalm manages.
+// Operations on dangerous headers are dropped, they are also rejected by the webhook.
+func buildIstioHttpRouteHeaders(headers *corev1alpha1.HttpRouteHeaders) *istioNetworkingV1Beta1.Headers {
+	res := &istioNetworkingV1Beta1.Headers{
+		Request: &istioNetworkingV1Beta1.Headers_HeaderOperations{},
+	}
+
+	if headers != nil && headers.Request != nil {
+		copyHeaderOperations(headers.Request, res.Request, true)
+	}
+
+	res.Request.Remove = append(res.Request.Remove, DANGEROUS_HEADERS...)
+
+	if res.Request.Set == nil {
+		res.Request.Set = make(map[string]string)
+	}
+
+	res.Request.Set[KALM_ROUTE_HEADER] = "true"
+
+	if headers != nil && headers.Response != nil {
+		res.Response = &istioNetworkingV1Beta1.Headers_HeaderOperations{}
+		copyHeaderOperations(headers.Response, res.Response, false)
+	}
+
+	return res
+}
+
 func toStringSlice(list []corev1alpha1.AllowMethod) (rst []string) {
 	for _, one := range list {
 		rst = append(rst, string(one))
@@ -236,6 +324,50 @@ func (r *HttpRouteReconcilerTask) buildHttpsRedirectEnvoyFilter(route *corev1alp
 	return filter, nil
 }
 
+// buildIngressGatewayHttpFilter inserts the http filter before the router of the ingress gateway
+func buildIngressGatewayHttpFilter(name string, filter map[string]interface{}) *v1alpha32.EnvoyFilter {
+	return &v1alpha32.EnvoyFilter{
+		ObjectMeta: metaV1.ObjectMeta{
+			Namespace: istioNamespace,
+			Name:      name,
+			Labels: map[string]string{
+				KALM_ROUTE_LABEL: "true",
+			},
+		},
+		Spec: v1alpha3.EnvoyFilter{
+			WorkloadSelector: &v1alpha3.WorkloadSelector{
+				Labels: map[string]string{
+					"app": "istio-ingressgateway",
+				},
+			},
+			ConfigPatches: []*v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{
+				{
+					ApplyTo: v1alpha3.EnvoyFilter_HTTP_FILTER,
+					Match: &v1alpha3.EnvoyFilter_EnvoyConfigObjectMatch{
+						Context: v1alpha3.EnvoyFilter_GATEWAY,
+						ObjectTypes: &v1alpha3.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+							Listener: &v1alpha3.EnvoyFilter_ListenerMatch{
+								FilterChain: &v1alpha3.EnvoyFilter_ListenerMatch_FilterChainMatch{
+									Filter: &v1alpha3.EnvoyFilter_ListenerMatch_FilterMatch{
+										Name: "envoy.http_connection_manager",
+										SubFilter: &v1alpha3.EnvoyFilter_ListenerMatch_SubFilterMatch{
+											Name: "envoy.router",
+										},
+									},
+								},
+							},
+						},
+					},
+					Patch: &v1alpha3.EnvoyFilter_Patch{
+						Operation: v1alpha3.EnvoyFilter_Patch_INSERT_BEFORE,
+						Value:     golangMapToProtoStruct(filter),
+					},
+				},
+			},
+		},
+	}
+}
+
 func (r *HttpRouteReconcilerTask) buildIstioHttpRoutes(route *corev1alpha1.HttpRoute) []*istioNetworkingV1Beta1.HTTPRoute {
 	matches := r.BuildMatches(route)
 	res := make([]*istioNetworkingV1Beta1.HTTPRoute, 0)
@@ -265,6 +397,18 @@ func sortRoutes(a, b *istioNetworkingV1Beta1.HTTPRoute) bool {
 		return true
 	}
 
+	aExact, aUriIsExact := aUri.MatchType.(*istioNetworkingV1Beta1.StringMatch_Exact)
+	bExact, bUriIsExact := bUri.MatchType.(*istioNetworkingV1Beta1.StringMatch_Exact)
+
+	// exact paths are the most specific ones
+	if aUriIsExact != bUriIsExact {
+		return aUriIsExact
+	}
+
+	if aUriIsExact && bUriIsExact {
+		return aExact.Exact > bExact.Exact
+	}
+
 	aRegexp, aUriIsRegexp := aUri.MatchType.(*istioNetworkingV1Beta1.StringMatch_Regex)
 	bRegexp, bUriIsRegexp := bUri.MatchType.(*istioNetworkingV1Beta1.StringMatch_Regex)
 
@@ -313,70 +457,202 @@ func (r *HttpRouteReconcilerTask) Run(ctrl.Request) error {
 	}
 	r.httpsRedirectEnvoyFilters = httpsRedirectEnvoyFilters.Items
 
+	var authorizationPolicies v1beta12.AuthorizationPolicyList
+	if err := r.Reader.List(r.ctx, &authorizationPolicies, client.MatchingLabels{KALM_ROUTE_LABEL: "true"}); err != nil {
+		return err
+	}
+	r.authorizationPolicies = authorizationPolicies.Items
+
+	var components corev1alpha1.ComponentList
+	if err := r.Reader.List(r.ctx, &components); err != nil {
+		return err
+	}
+
+	r.canaryWeights = make(map[string]int)
+	for _, component := range components.Items {
+		if component.Status.Rollout != nil && component.Status.Rollout.CanaryWeight > 0 {
+			r.canaryWeights[fmt.Sprintf("%s/%s", component.Namespace, component.Name)] = component.Status.Rollout.CanaryWeight
+		}
+	}
+
+	// older routes come first, so the winner of duplicate rules doesn't change between reconciles
+	sort.SliceStable(r.routes, func(i, j int) bool { return isHttpRouteOlder(&r.routes[i], &r.routes[j]) })
+
 	// Each host will has a virtual service
 	// Kalm will order http route rules, and set them in the virtual service http field.
-	hostVirtualService := make(map[string][]*istioNetworkingV1Beta1.HTTPRoute)
+	hostRules := make(map[string][]httpRouteRule)
 
-	for _, route := range r.routes {
-		for _, host := range route.Spec.Hosts {
-			if _, ok := hostVirtualService[host]; ok {
-				hostVirtualService[host] = append(hostVirtualService[host], r.buildIstioHttpRoutes(&route)...)
-			} else {
-				hostVirtualService[host] = r.buildIstioHttpRoutes(&route)
-			}
+	// errors are recorded in status of routes, other hosts and routes are still reconciled
+	hostErrors := make(map[string]error)
+	routeErrors := make(map[*corev1alpha1.HttpRoute]error)
+	var reconcileErr error
+
+	grpcWeb := false
+
+	for i := range r.routes {
+		route := &r.routes[i]
+
+		protocol, err := r.getGrpcProtocolOfRoute(route)
+
+		if err != nil {
+			routeErrors[route] = err
+			reconcileErr = err
 		}
+
+		grpcWeb = grpcWeb || protocol == corev1alpha1.PortProtocolGRPCWEB
+
+		r.addHostRules(hostRules, route, protocol)
 	}
 
-	for host, routes := range hostVirtualService {
+	for host, rules := range hostRules {
 		// Less reports whether the element with
 		// index i should sort before the element with index j.
-		sort.Slice(routes, func(i, j int) bool { return sortRoutes(routes[i], routes[j]) })
+		sort.SliceStable(rules, func(i, j int) bool { return sortRoutes(rules[i].rule, rules[j].rule) })
+
+		routes := make([]*istioNetworkingV1Beta1.HTTPRoute, 0, len(rules))
+
+		for _, rule := range rules {
+			routes = append(routes, rule.rule)
+		}
 
 		if err := r.SaveVirtualService(host, routes); err != nil {
-			return err
+			hostErrors[host] = err
+			reconcileErr = err
 		}
 	}
 
-	httpsRedirectFilterMap := make(map[string]*v1alpha32.EnvoyFilter)
+	// all envoy filters of routes, the ones left in the map are cleaned
+	envoyFilterMap := make(map[string]*v1alpha32.EnvoyFilter)
 
 	for i := range r.httpsRedirectEnvoyFilters {
 		filter := r.httpsRedirectEnvoyFilters[i]
-		httpsRedirectFilterMap[filter.Name] = &filter
+		envoyFilterMap[filter.Name] = &filter
 	}
 
+	authorizationPolicyMap := make(map[string]*v1beta12.AuthorizationPolicy)
+
+	for i := range r.authorizationPolicies {
+		policy := r.authorizationPolicies[i]
+		authorizationPolicyMap[policy.Name] = &policy
+	}
+
+	rateLimited := false
+	sourceIPLimited := false
+
+	var gatewayVersion *semver.Version
+	var gatewayVersionErr error
+	var gatewayVersionChecked bool
+
 	// Create or delete envoy filter on gateway for routes
-	for _, route := range r.routes {
-		filterName := getHttpsRedirectEnvoyFilterName(&route)
+	for i := range r.routes {
+		route := &r.routes[i]
+		filterName := getHttpsRedirectEnvoyFilterName(route)
 		if route.Spec.HttpRedirectToHttps {
-			if _, ok := httpsRedirectFilterMap[filterName]; !ok {
-				filter, err := r.buildHttpsRedirectEnvoyFilter(&route)
+			if _, ok := envoyFilterMap[filterName]; !ok {
+				filter, err := r.buildHttpsRedirectEnvoyFilter(route)
 
 				if err != nil {
-					return err
+					routeErrors[route] = err
+					reconcileErr = err
+					continue
 				}
 
 				if err := r.Create(r.ctx, filter); err != nil {
-					r.EmitWarningEvent(&route, err, "Create Https Redirect filter Error")
-					return err
+					r.EmitWarningEvent(route, err, "Create Https Redirect filter Error")
+					routeErrors[route] = err
+					reconcileErr = err
 				}
 			} else {
-				delete(httpsRedirectFilterMap, filterName)
+				delete(envoyFilterMap, filterName)
+			}
+		}
+
+		sourceIPLimited = sourceIPLimited || len(buildSourceIPDeniedCIDRs(route.Spec.SourceIP)) > 0
+
+		if policy := buildHttpRouteAuthorizationPolicy(route); policy != nil {
+			if err := r.saveRouteAuthorizationPolicy(policy, authorizationPolicyMap); err != nil {
+				r.EmitWarningEvent(route, err, "Save Authorization Policy Error")
+				routeErrors[route] = err
+				reconcileErr = err
+			}
+		}
+
+		if route.Spec.RateLimit != nil {
+			if !gatewayVersionChecked {
+				gatewayVersion, gatewayVersionErr = r.getIngressGatewayVersion()
+				gatewayVersionChecked = true
+			}
+
+			if err := checkRateLimitSupported(route.Spec.RateLimit, gatewayVersion, gatewayVersionErr); err != nil {
+				r.EmitWarningEvent(route, err, "Rate limit is not supported by the ingress gateway")
+				routeErrors[route] = err
+				continue
+			}
+
+			rateLimited = true
+
+			if err := r.saveRouteEnvoyFilter(r.buildRateLimitEnvoyFilter(route), envoyFilterMap); err != nil {
+				r.EmitWarningEvent(route, err, "Save Rate Limit filter Error")
+				routeErrors[route] = err
+				reconcileErr = err
 			}
 		}
 	}
 
+	if sourceIPLimited {
+		if err := r.saveRouteEnvoyFilter(buildSourceIPTaggingEnvoyFilter(r.routes), envoyFilterMap); err != nil {
+			r.Log.Error(err, "save source ip tagging envoy filter error.")
+			reconcileErr = err
+		}
+
+		if hops := getGatewayXffNumTrustedHops(); hops > 0 {
+			if err := r.saveRouteEnvoyFilter(buildXffTrustedHopsEnvoyFilter(hops), envoyFilterMap); err != nil {
+				r.Log.Error(err, "save xff trusted hops envoy filter error.")
+				reconcileErr = err
+			}
+		}
+	}
+
+	if grpcWeb {
+		if err := r.saveRouteEnvoyFilter(buildGrpcWebEnvoyFilter(), envoyFilterMap); err != nil {
+			r.Log.Error(err, "save grpc web envoy filter error.")
+			reconcileErr = err
+		}
+	}
+
+	if rateLimited {
+		if err := r.saveRouteEnvoyFilter(buildLocalRateLimitEnvoyFilter(), envoyFilterMap); err != nil {
+			r.Log.Error(err, "save local rate limit envoy filter error.")
+			reconcileErr = err
+		}
+	}
+
+	if err := r.updateHttpRouteStatuses(hostRules, hostErrors, routeErrors); err != nil {
+		return err
+	}
+
+	if reconcileErr != nil {
+		return reconcileErr
+	}
+
 	// clean left unused envoy filters
-	for filterName := range httpsRedirectFilterMap {
-		filter := httpsRedirectFilterMap[filterName]
+	for filterName := range envoyFilterMap {
+		filter := envoyFilterMap[filterName]
 
 		if err := r.Delete(r.ctx, filter); err != nil {
 			return err
 		}
 	}
 
+	for policyName := range authorizationPolicyMap {
+		if err := r.Delete(r.ctx, authorizationPolicyMap[policyName]); err != nil {
+			return err
+		}
+	}
+
 	// delete old virtual Service
 	for _, vs := range r.virtualServices {
-		if hostVirtualService[vs.Spec.Hosts[0]] == nil {
+		if hostRules[vs.Spec.Hosts[0]] == nil {
 			if err := r.Delete(r.ctx, &vs); err != nil {
 				return err
 			}
@@ -386,6 +662,25 @@ func (r *HttpRouteReconcilerTask) Run(ctrl.Request) error {
 	return nil
 }
 
+// addHostRules adds the istio http rules of the route to all hosts of the route.
+// The rules are the same on all hosts, so they are generated and passed to plugins only once.
+func (r *HttpRouteReconcilerTask) addHostRules(hostRules map[string][]httpRouteRule, route *corev1alpha1.HttpRoute, protocol corev1alpha1.PortProtocol) {
+	httpRoutes := r.runHttpRoutePlugins(route, r.buildIstioHttpRoutes(route))
+
+	for _, httpRoute := range httpRoutes {
+		applyGrpcSettings(route, httpRoute, protocol)
+	}
+
+	// rules are sorted by uris stably, denied rules stay before rules with the same matches
+	httpRoutes = append(r.buildSourceIPDeniedHttpRoutes(route), httpRoutes...)
+
+	for _, host := range route.Spec.Hosts {
+		for _, httpRoute := range httpRoutes {
+			hostRules[host] = append(hostRules[host], httpRouteRule{route: route, rule: httpRoute})
+		}
+	}
+}
+
 func (r *HttpRouteReconcilerTask) SaveVirtualService(host string, routes []*istioNetworkingV1Beta1.HTTPRoute) error {
 	virtualServiceName := fmt.Sprintf("vs-%s", strings.ReplaceAll(strings.ReplaceAll(host, "*", "wildcard"), ".", "-"))
 	virtualServiceNamespace := "kalm-system"
@@ -561,18 +856,31 @@ func (r *HttpRouteReconcilerTask) BuildMatches(route *corev1alpha1.HttpRoute) []
 			}
 		}
 
-		// TODO check the path is a regexp or not.
-
-		// https://github.com/istio/istio/blob/6d6a23d1a644a19cec87d7641c4747135d35692b/pilot/pkg/networking/core/v1alpha3/route/route.go#L1026
-		// This is a hack of istio route translation logic, which I think is wrong.
-		// The isCacheAllMatch doesn't consider about m.Methods and will ignore all match cases behind.
-		// If the path is prefix "/", leave the Uri nil to bypass this logic.
-		if path != "/" {
+		switch route.GetPathType() {
+		case corev1alpha1.HttpRoutePathTypeRegex:
 			match.Uri = &istioNetworkingV1Beta1.StringMatch{
-				MatchType: &istioNetworkingV1Beta1.StringMatch_Prefix{
-					Prefix: path,
+				MatchType: &istioNetworkingV1Beta1.StringMatch_Regex{
+					Regex: path,
 				},
 			}
+		case corev1alpha1.HttpRoutePathTypeExact:
+			match.Uri = &istioNetworkingV1Beta1.StringMatch{
+				MatchType: &istioNetworkingV1Beta1.StringMatch_Exact{
+					Exact: path,
+				},
+			}
+		default:
+			// https://github.com/istio/istio/blob/6d6a23d1a644a19cec87d7641c4747135d35692b/pilot/pkg/networking/core/v1alpha3/route/route.go#L1026
+			// This is a hack of istio route translation logic, which I think is wrong.
+			// The isCacheAllMatch doesn't consider about m.Methods and will ignore all match cases behind.
+			// If the path is prefix "/", leave the Uri nil to bypass this logic.
+			if path != "/" {
+				match.Uri = &istioNetworkingV1Beta1.StringMatch{
+					MatchType: &istioNetworkingV1Beta1.StringMatch_Prefix{
+						Prefix: path,
+					},
+				}
+			}
 		}
 
 		r.PatchConditionsToHttpMatch(match, spec)
@@ -585,7 +893,8 @@ func (r *HttpRouteReconcilerTask) BuildMatches(route *corev1alpha1.HttpRoute) []
 		// To solve this, add another route with path prefix /bbbb/
 		//   Request #1 doesn't match this route. Skip
 		//   Request #2 will be rewritten to /aaaa, which is correct.
-		if route.Spec.StripPath && path != "/" {
+		// Same for rewrites to a prefix ending with a slash, e.g. /api/v2 -> /legacy/
+		if isPrefixRewrittenToDirectory(route) && match.Uri != nil {
 			copyedMatch := match.DeepCopy()
 
 			copyedMatch.Uri = &istioNetworkingV1Beta1.StringMatch{
@@ -601,6 +910,19 @@ func (r *HttpRouteReconcilerTask) BuildMatches(route *corev1alpha1.HttpRoute) []
 	return res
 }
 
+// true if the matched prefix is replaced with a path ending with a slash
+func isPrefixRewrittenToDirectory(route *corev1alpha1.HttpRoute) bool {
+	if route.GetPathType() != corev1alpha1.HttpRoutePathTypePrefix {
+		return false
+	}
+
+	if route.Spec.StripPath {
+		return true
+	}
+
+	return route.Spec.Rewrite != nil && strings.HasSuffix(route.Spec.Rewrite.Uri, "/")
+}
+
 func toHttpRouteDestination(destination corev1alpha1.HttpRouteDestination, weight int32, namespace string) *istioNetworkingV1Beta1.HTTPRouteDestination {
 	colon := strings.LastIndexByte(destination.Host, ':')
 	var host, port string
@@ -636,8 +958,10 @@ func toHttpRouteDestination(destination corev1alpha1.HttpRouteDestination, weigh
 func (r *HttpRouteReconcilerTask) BuildDestinations(route *corev1alpha1.HttpRoute) []*istioNetworkingV1Beta1.HTTPRouteDestination {
 	res := make([]*istioNetworkingV1Beta1.HTTPRouteDestination, 0)
 
-	weights := adjustDestinationWeightToSumTo100(route.Spec.Destinations)
-	for i, destination := range route.Spec.Destinations {
+	destinations := r.splitCanaryDestinations(route.Spec.Destinations, route.Namespace)
+
+	weights := adjustDestinationWeightToSumTo100(destinations)
+	for i, destination := range destinations {
 		weight := weights[i]
 		res = append(res, toHttpRouteDestination(destination, weight, route.Namespace))
 	}
@@ -645,6 +969,67 @@ func (r *HttpRouteReconcilerTask) BuildDestinations(route *corev1alpha1.HttpRout
 	return res
 }
 
+// If a destination is a component in rollout, split its weight between the stable and the new version.
+func (r *HttpRouteReconcilerTask) splitCanaryDestinations(destinations []corev1alpha1.HttpRouteDestination, namespace string) []corev1alpha1.HttpRouteDestination {
+	if len(r.canaryWeights) == 0 {
+		return destinations
+	}
+
+	res := make([]corev1alpha1.HttpRouteDestination, 0, len(destinations))
+
+	for _, destination := range destinations {
+		componentName, componentNamespace, port := parseDestinationHost(destination.Host, namespace)
+		canaryWeight := r.canaryWeights[fmt.Sprintf("%s/%s", componentNamespace, componentName)]
+
+		// weights are percentages of the original weight, scale the others to keep the ratio
+		if canaryWeight <= 0 {
+			res = append(res, corev1alpha1.HttpRouteDestination{
+				Host:   destination.Host,
+				Weight: destination.Weight * 100,
+			})
+			continue
+		}
+
+		canaryHost := fmt.Sprintf("%s.%s.svc.cluster.local", getNameForCanary(componentName), componentNamespace)
+
+		if port != "" {
+			canaryHost = canaryHost + ":" + port
+		}
+
+		res = append(res,
+			corev1alpha1.HttpRouteDestination{
+				Host:   destination.Host,
+				Weight: destination.Weight * (100 - canaryWeight),
+			},
+			corev1alpha1.HttpRouteDestination{
+				Host:   canaryHost,
+				Weight: destination.Weight * canaryWeight,
+			},
+		)
+	}
+
+	return res
+}
+
+// parse destination host in format of "name[:port]", "name.namespace[:port]" or "name.namespace.svc.cluster.local[:port]"
+func parseDestinationHost(destinationHost, defaultNamespace string) (name, namespace, port string) {
+	host := destinationHost
+	colon := strings.LastIndexByte(host, ':')
+
+	if colon != -1 {
+		port = host[colon+1:]
+		host = host[:colon]
+	}
+
+	parts := strings.Split(host, ".")
+
+	if len(parts) == 1 {
+		return parts[0], defaultNamespace, port
+	}
+
+	return parts[0], parts[1], port
+}
+
 func adjustDestinationWeightToSumTo100(destinations []corev1alpha1.HttpRouteDestination) []int32 {
 	var originWeights []int
 	for _, destination := range destinations {
@@ -712,6 +1097,11 @@ type HttpRouteReconciler struct {
 // +kubebuilder:rbac:groups=core.kalm.dev,resources=httproutes/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=networking.istio.io,resources=virtualservices,verbs=*
 // +kubebuilder:rbac:groups=networking.istio.io,resources=gateways,verbs=*
+// +kubebuilder:rbac:groups=core.kalm.dev,resources=components,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+// +kubebuilder:rbac:groups=security.istio.io,resources=authorizationpolicies,verbs=get;list;watch;create;update;patch;delete
+
+// +kubebuilder:rbac:groups=core.kalm.dev,resources=componentpluginbindings,verbs=get;list;watch
 
 func (r *HttpRouteReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	task := &HttpRouteReconcilerTask{
@@ -729,6 +1119,8 @@ func NewHttpRouteReconciler(mgr ctrl.Manager) *HttpRouteReconciler {
 type WatchAllKalmGateway struct{}
 type WatchAllKalmVirtualService struct{}
 type WatchAllKalmEnvoyFilter struct{}
+type WatchAllKalmAuthorizationPolicy struct{}
+type WatchAllKalmComponentPluginBinding struct{}
 
 func (*WatchAllKalmGateway) Map(object handler.MapObject) []reconcile.Request {
 	gateway, ok := object.Object.(*v1beta1.Gateway)
@@ -756,6 +1148,73 @@ func (*WatchAllKalmEnvoyFilter) Map(object handler.MapObject) []reconcile.Reques
 	return []reconcile.Request{{NamespacedName: types.NamespacedName{}}}
 }
 
+func (*WatchAllKalmAuthorizationPolicy) Map(object handler.MapObject) []reconcile.Request {
+	policy, ok := object.Object.(*v1beta12.AuthorizationPolicy)
+	if !ok || policy.Labels == nil || policy.Labels[KALM_ROUTE_LABEL] != "true" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{}}}
+}
+
+// WatchKalmRouteComponentRollout reconciles routes when the canary weight of a component used as their destination is changed
+type WatchKalmRouteComponentRollout struct {
+	*BaseReconciler
+}
+
+func getComponentCanaryWeight(object runtime.Object) int {
+	component, ok := object.(*corev1alpha1.Component)
+
+	if !ok || component.Status.Rollout == nil {
+		return 0
+	}
+
+	return component.Status.Rollout.CanaryWeight
+}
+
+// componentCanaryWeightChanged skips rollout status writes not changing the traffic split, e.g. the periodic requeue of a rollout step
+var componentCanaryWeightChanged = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		return getComponentCanaryWeight(e.Object) > 0
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return getComponentCanaryWeight(e.ObjectOld) != getComponentCanaryWeight(e.ObjectNew)
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return getComponentCanaryWeight(e.Object) > 0
+	},
+	GenericFunc: func(event.GenericEvent) bool {
+		return false
+	},
+}
+
+func (r *WatchKalmRouteComponentRollout) Map(object handler.MapObject) []reconcile.Request {
+	if _, ok := object.Object.(*corev1alpha1.Component); !ok {
+		return nil
+	}
+
+	var routeList corev1alpha1.HttpRouteList
+
+	if err := r.Client.List(context.Background(), &routeList); err != nil {
+		r.Log.Error(err, "Can't list http routes in mapper.")
+		return nil
+	}
+
+	for i := range routeList.Items {
+		if routeHasDestination(&routeList.Items[i], object.Meta.GetName(), object.Meta.GetNamespace()) {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{}}}
+		}
+	}
+
+	return nil
+}
+
+func (*WatchAllKalmComponentPluginBinding) Map(object handler.MapObject) []reconcile.Request {
+	if _, ok := object.Object.(*corev1alpha1.ComponentPluginBinding); !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{}}}
+}
+
 func (r *HttpRouteReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&corev1alpha1.HttpRoute{}).
@@ -777,5 +1236,30 @@ func (r *HttpRouteReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				ToRequests: &WatchAllKalmEnvoyFilter{},
 			},
 		).
+		Watches(
+			&source.Kind{Type: &v1beta12.AuthorizationPolicy{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: &WatchAllKalmAuthorizationPolicy{},
+			},
+		).
+		Watches(
+			&source.Kind{Type: &corev1alpha1.Component{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: &WatchKalmRouteComponentRollout{r.BaseReconciler},
+			},
+			builder.WithPredicates(componentCanaryWeightChanged),
+		).
+		Watches(
+			&source.Kind{Type: &corev1alpha1.ComponentPluginBinding{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: &WatchAllKalmComponentPluginBinding{},
+			},
+		).
+		Watches(
+			&source.Kind{Type: &coreV1.Service{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: &WatchKalmRouteDestinationService{r.BaseReconciler},
+			},
+		).
 		Complete(r)
 }
@@ -105,3 +105,74 @@ func TestAdjustWeightToSumTo100(t *testing.T) {
 		assert.True(t, 100 == sum(rst))
 	}
 }
+
+func TestSplitCanaryDestinations(t *testing.T) {
+	task := &HttpRouteReconcilerTask{
+		canaryWeights: map[string]int{"ns/foo": 20},
+	}
+
+	destinations := task.splitCanaryDestinations([]v1alpha1.HttpRouteDestination{
+		{Host: "foo:8080", Weight: 1},
+		{Host: "bar.ns.svc.cluster.local:8080", Weight: 1},
+	}, "ns")
+
+	assert.Equal(t, []v1alpha1.HttpRouteDestination{
+		{Host: "foo:8080", Weight: 80},
+		{Host: "foo-canary.ns.svc.cluster.local:8080", Weight: 20},
+		{Host: "bar.ns.svc.cluster.local:8080", Weight: 100},
+	}, destinations)
+
+	assert.Equal(t, []int32{40, 10, 50}, adjustWeightToSumTo100([]int{80, 20, 100}))
+}
+
+func TestBuildIstioHttpRoutesWithRewriteAndHeaders(t *testing.T) {
+	task := &HttpRouteReconcilerTask{}
+
+	route := &v1alpha1.HttpRoute{
+		ObjectMeta: v1.ObjectMeta{Namespace: "ns", Name: "legacy"},
+		Spec: v1alpha1.HttpRouteSpec{
+			Hosts:        []string{"example.com"},
+			Paths:        []string{"/api/v2"},
+			Methods:      []v1alpha1.HttpRouteMethod{"GET"},
+			Schemes:      []v1alpha1.HttpRouteScheme{"http"},
+			Destinations: []v1alpha1.HttpRouteDestination{{Host: "legacy:80", Weight: 1}},
+			Rewrite:      &v1alpha1.HttpRouteRewrite{Uri: "/", Authority: "legacy.internal"},
+			Headers: &v1alpha1.HttpRouteHeaders{
+				Request: &v1alpha1.HttpRouteHeaderOperations{
+					Set: map[string]string{"X-Forwarded-Prefix": "/api/v2", "Kalm-Route": "false"},
+				},
+				Response: &v1alpha1.HttpRouteHeaderOperations{
+					Remove: []string{"Server"},
+				},
+			},
+		},
+	}
+
+	httpRoutes := task.buildIstioHttpRoutes(route)
+	assert.Len(t, httpRoutes, 2)
+	assert.Equal(t, "/api/v2", httpRoutes[0].Match[0].Uri.GetPrefix())
+	assert.Equal(t, "/api/v2/", httpRoutes[1].Match[0].Uri.GetPrefix())
+
+	httpRoute := httpRoutes[0]
+	assert.Equal(t, "/", httpRoute.Rewrite.Uri)
+	assert.Equal(t, "legacy.internal", httpRoute.Rewrite.Authority)
+	assert.Equal(t, map[string]string{"X-Forwarded-Prefix": "/api/v2", KALM_ROUTE_HEADER: "true"}, httpRoute.Headers.Request.Set)
+	assert.Equal(t, DANGEROUS_HEADERS, httpRoute.Headers.Request.Remove)
+	assert.Equal(t, []string{"Server"}, httpRoute.Headers.Response.Remove)
+
+	route.Spec.Rewrite = &v1alpha1.HttpRouteRewrite{Uri: "/legacy"}
+	assert.Len(t, task.buildIstioHttpRoutes(route), 1)
+
+	route.Spec.PathType = v1alpha1.HttpRoutePathTypeRegex
+	route.Spec.Paths = []string{"^/users/[0-9]+$"}
+	httpRoutes = task.buildIstioHttpRoutes(route)
+	assert.Len(t, httpRoutes, 1)
+	assert.Equal(t, "^/users/[0-9]+$", httpRoutes[0].Match[0].Uri.GetRegex())
+
+	route.Spec.PathType = v1alpha1.HttpRoutePathTypeExact
+	route.Spec.Paths = []string{"/health"}
+	exact := task.buildIstioHttpRoutes(route)[0]
+	assert.Equal(t, "/health", exact.Match[0].Uri.GetExact())
+	assert.True(t, sortRoutes(exact, httpRoutes[0]))
+	assert.False(t, sortRoutes(httpRoutes[0], exact))
+}
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+
+	protoTypes "github.com/gogo/protobuf/types"
+	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
+	v1alpha32 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+)
+
+const GRPC_WEB_ENVOY_FILTER_NAME = "kalm-grpc-web"
+
+// headers used by grpc-web clients in browsers
+var grpcWebCORSAllowHeaders = []string{"content-type", "x-grpc-web", "x-user-agent", "grpc-timeout"}
+var grpcWebCORSExposeHeaders = []string{"grpc-status", "grpc-message"}
+
+// getServicePortProtocol returns the protocol of the service port from its name, e.g. grpc-web-8080.
+// Ports are named in this way by components, https://istio.io/latest/docs/ops/configuration/traffic-management/protocol-selection/
+func getServicePortProtocol(service *coreV1.Service, port string) corev1alpha1.PortProtocol {
+	var servicePort *coreV1.ServicePort
+
+	if port == "" {
+		if len(service.Spec.Ports) == 1 {
+			servicePort = &service.Spec.Ports[0]
+		}
+	} else {
+		p, _ := strconv.Atoi(port)
+
+		for i := range service.Spec.Ports {
+			if int(service.Spec.Ports[i].Port) == p {
+				servicePort = &service.Spec.Ports[i]
+				break
+			}
+		}
+	}
+
+	if servicePort == nil {
+		return corev1alpha1.PortProtocolUnknown
+	}
+
+	switch {
+	case strings.HasPrefix(servicePort.Name, string(corev1alpha1.PortProtocolGRPCWEB)):
+		return corev1alpha1.PortProtocolGRPCWEB
+	case strings.HasPrefix(servicePort.Name, string(corev1alpha1.PortProtocolGRPC)):
+		return corev1alpha1.PortProtocolGRPC
+	}
+
+	return corev1alpha1.PortProtocolUnknown
+}
+
+// getGrpcProtocolOfRoute returns grpc or grpc-web if a destination of the route is a port of the protocol, grpc-web wins if both are found.
+func (r *HttpRouteReconcilerTask) getGrpcProtocolOfRoute(route *corev1alpha1.HttpRoute) (corev1alpha1.PortProtocol, error) {
+	protocol := corev1alpha1.PortProtocolUnknown
+
+	for _, destination := range route.Spec.Destinations {
+		host := destination.Host
+
+		if colon := strings.LastIndexByte(host, ':'); colon != -1 {
+			host = host[:colon]
+		}
+
+		if !isClusterLocalDestination(host) {
+			continue
+		}
+
+		name, namespace, port := parseDestinationHost(destination.Host, route.Namespace)
+
+		var service coreV1.Service
+
+		if err := r.Reader.Get(r.ctx, types.NamespacedName{Namespace: namespace, Name: name}, &service); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+
+			return protocol, err
+		}
+
+		switch getServicePortProtocol(&service, port) {
+		case corev1alpha1.PortProtocolGRPCWEB:
+			return corev1alpha1.PortProtocolGRPCWEB, nil
+		case corev1alpha1.PortProtocolGRPC:
+			protocol = corev1alpha1.PortProtocolGRPC
+		}
+	}
+
+	return protocol, nil
+}
+
+// applyGrpcSettings adjusts the istio route for grpc destinations.
+// The gateway talks http2 to grpc ports already, as they are named with the grpc prefix.
+// Streams are long-lived, so the route timeout is disabled unless the route sets one.
+// Browsers send grpc-web requests cross-origin, headers of grpc-web are allowed and exposed if cors is enabled.
+func applyGrpcSettings(route *corev1alpha1.HttpRoute, httpRoute *istioNetworkingV1Beta1.HTTPRoute, protocol corev1alpha1.PortProtocol) {
+	if protocol != corev1alpha1.PortProtocolGRPC && protocol != corev1alpha1.PortProtocolGRPCWEB {
+		return
+	}
+
+	if route.Spec.Timeout == nil {
+		httpRoute.Timeout = &protoTypes.Duration{}
+	}
+
+	if protocol != corev1alpha1.PortProtocolGRPCWEB || httpRoute.CorsPolicy == nil {
+		return
+	}
+
+	// copied, the slices are shared with the spec of the route
+	allowHeaders := append([]string{}, httpRoute.CorsPolicy.AllowHeaders...)
+	exposeHeaders := append([]string{}, httpRoute.CorsPolicy.ExposeHeaders...)
+
+	for _, header := range grpcWebCORSAllowHeaders {
+		allowHeaders = appendIfMissing(allowHeaders, header)
+	}
+
+	for _, header := range grpcWebCORSExposeHeaders {
+		exposeHeaders = appendIfMissing(exposeHeaders, header)
+	}
+
+	httpRoute.CorsPolicy.AllowHeaders = allowHeaders
+	httpRoute.CorsPolicy.ExposeHeaders = exposeHeaders
+}
+
+// buildGrpcWebEnvoyFilter translates grpc-web requests of browsers to grpc on the ingress gateway.
+// Other requests are not touched by the filter.
+func buildGrpcWebEnvoyFilter() *v1alpha32.EnvoyFilter {
+	return buildIngressGatewayHttpFilter(GRPC_WEB_ENVOY_FILTER_NAME, map[string]interface{}{
+		"name": "envoy.filters.http.grpc_web",
+		"typed_config": map[string]interface{}{
+			"@type": "type.googleapis.com/envoy.extensions.filters.http.grpc_web.v3.GrpcWeb",
+		},
+	})
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"testing"
+
+	protoTypes "github.com/gogo/protobuf/types"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+)
+
+func TestGetServicePortProtocol(t *testing.T) {
+	service := &coreV1.Service{
+		Spec: coreV1.ServiceSpec{
+			Ports: []coreV1.ServicePort{
+				{Name: "grpc-9090", Port: 9090},
+				{Name: "grpc-web-8080", Port: 8080},
+				{Name: "http-80", Port: 80},
+			},
+		},
+	}
+
+	assert.Equal(t, v1alpha1.PortProtocolGRPC, getServicePortProtocol(service, "9090"))
+	assert.Equal(t, v1alpha1.PortProtocolGRPCWEB, getServicePortProtocol(service, "8080"))
+	assert.Equal(t, v1alpha1.PortProtocolUnknown, getServicePortProtocol(service, "80"))
+	assert.Equal(t, v1alpha1.PortProtocolUnknown, getServicePortProtocol(service, ""))
+
+	service.Spec.Ports = service.Spec.Ports[:1]
+	assert.Equal(t, v1alpha1.PortProtocolGRPC, getServicePortProtocol(service, ""))
+}
+
+func TestApplyGrpcSettings(t *testing.T) {
+	route := newStatusTestRoute("test-ns", "grpc", []string{"/"})
+	route.Spec.CORS = &v1alpha1.HttpRouteCORS{AllowHeaders: []string{"authorization", "content-type"}}
+
+	task := &HttpRouteReconcilerTask{}
+
+	httpRoute := task.buildIstioHttpRoutes(route)[0]
+	applyGrpcSettings(route, httpRoute, v1alpha1.PortProtocolHTTP)
+	assert.Nil(t, httpRoute.Timeout)
+
+	applyGrpcSettings(route, httpRoute, v1alpha1.PortProtocolGRPC)
+	assert.Equal(t, &protoTypes.Duration{}, httpRoute.Timeout)
+	assert.Empty(t, httpRoute.CorsPolicy.ExposeHeaders)
+
+	applyGrpcSettings(route, httpRoute, v1alpha1.PortProtocolGRPCWEB)
+	assert.Equal(t, []string{"authorization", "content-type", "x-grpc-web", "x-user-agent", "grpc-timeout"}, httpRoute.CorsPolicy.AllowHeaders)
+	assert.Equal(t, []string{"grpc-status", "grpc-message"}, httpRoute.CorsPolicy.ExposeHeaders)
+
+	timeout := 30
+	route.Spec.Timeout = &timeout
+	httpRoute = task.buildIstioHttpRoutes(route)[0]
+	applyGrpcSettings(route, httpRoute, v1alpha1.PortProtocolGRPC)
+	assert.Equal(t, int64(30), httpRoute.Timeout.Seconds)
+}
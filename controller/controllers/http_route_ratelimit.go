@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"istio.io/api/networking/v1alpha3"
+	v1alpha32 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	appsV1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+)
+
+const (
+	LOCAL_RATE_LIMIT_ENVOY_FILTER_NAME = "kalm-local-rate-limit"
+	LOCAL_RATE_LIMIT_FILTER_NAME       = "envoy.filters.http.local_ratelimit"
+	LOCAL_RATE_LIMIT_TYPE_URL          = "type.googleapis.com/envoy.extensions.filters.http.local_ratelimit.v3.LocalRateLimit"
+
+	// upper bound of buckets kept for distinct values of a rate limit key
+	LOCAL_RATE_LIMIT_MAX_KEYS = 10000
+
+	ingressGatewayName = "istio-ingressgateway"
+)
+
+var (
+	// the http local rate limit filter comes with envoy 1.17 in istio 1.9
+	minLocalRateLimitIstioVersion = semver.MustParse("1.9.0")
+
+	// a bucket for each value of a descriptor entry without value comes with envoy 1.34 in istio 1.26
+	minKeyedLocalRateLimitIstioVersion = semver.MustParse("1.26.0")
+)
+
+func getRateLimitEnvoyFilterName(route *corev1alpha1.HttpRoute) string {
+	return fmt.Sprintf("rate-limit-%s-%s", route.Namespace, route.Name)
+}
+
+// GetRateLimitStatPrefix is the prefix of envoy stats of the route, e.g. kalm_route_default_api.http_local_rate_limit.rate_limited
+func GetRateLimitStatPrefix(namespace, name string) string {
+	return strings.ReplaceAll(fmt.Sprintf("kalm_route_%s_%s", namespace, name), "-", "_")
+}
+
+// getIngressGatewayVersion returns the istio version of the ingress gateway, parsed from the image tag of its proxy.
+func (r *HttpRouteReconcilerTask) getIngressGatewayVersion() (*semver.Version, error) {
+	var deployment appsV1.Deployment
+
+	if err := r.Get(r.ctx, types.NamespacedName{Namespace: istioNamespace, Name: ingressGatewayName}, &deployment); err != nil {
+		return nil, err
+	}
+
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name != "istio-proxy" {
+			continue
+		}
+
+		_, _, tag, err := parseImage(container.Image)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return semver.NewVersion(tag)
+	}
+
+	return nil, fmt.Errorf("istio-proxy container is not found in the ingress gateway")
+}
+
+// checkRateLimitSupported returns an error if the envoy of the ingress gateway doesn't support the rate limit.
+// Filters of unsupported rate limits are not created, the gateway would reject them along with all route updates.
+func checkRateLimitSupported(limit *corev1alpha1.HttpRouteRateLimit, gatewayVersion *semver.Version, gatewayVersionErr error) error {
+	if gatewayVersionErr != nil {
+		return fmt.Errorf("rate limit is not applied, version of the ingress gateway is unknown: %s", gatewayVersionErr.Error())
+	}
+
+	required := minLocalRateLimitIstioVersion
+
+	if limit.Key != nil {
+		required = minKeyedLocalRateLimitIstioVersion
+	}
+
+	// builds like 1.9.0-distroless are the same version
+	version, _ := gatewayVersion.SetPrerelease("")
+
+	if version.LessThan(required) {
+		return fmt.Errorf("rate limit is not applied, it needs istio %s or later on the ingress gateway, which runs %s", required, gatewayVersion)
+	}
+
+	return nil
+}
+
+func typedStruct(value map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"@type":    "type.googleapis.com/udpa.type.v1.TypedStruct",
+		"type_url": LOCAL_RATE_LIMIT_TYPE_URL,
+		"value":    value,
+	}
+}
+
+func fullPercent(runtimeKey string) map[string]interface{} {
+	return map[string]interface{}{
+		"runtime_key": runtimeKey,
+		"default_value": map[string]interface{}{
+			"numerator":   100,
+			"denominator": "HUNDRED",
+		},
+	}
+}
+
+// buildLocalRateLimitEnvoyFilter adds the local rate limit filter to the http connection manager of the ingress gateway.
+// It's disabled unless a route enables it, it's shared by all routes so requests are not counted twice.
+func buildLocalRateLimitEnvoyFilter() *v1alpha32.EnvoyFilter {
+	return buildIngressGatewayHttpFilter(LOCAL_RATE_LIMIT_ENVOY_FILTER_NAME, map[string]interface{}{
+		"name": LOCAL_RATE_LIMIT_FILTER_NAME,
+		"typed_config": typedStruct(map[string]interface{}{
+			"stat_prefix": "http_local_rate_limiter",
+		}),
+	})
+}
+
+// buildRateLimitDescriptorAction returns the action generating the descriptor entry of the key, requests are counted per value of the entry.
+func buildRateLimitDescriptorAction(key *corev1alpha1.HttpRouteRateLimitKey) (action map[string]interface{}, descriptorKey string) {
+	switch key.Type {
+	case corev1alpha1.HttpRouteRateLimitKeyTypeHeader:
+		return map[string]interface{}{
+			"request_headers": map[string]interface{}{
+				"header_name":    key.Name,
+				"descriptor_key": "header",
+			},
+		}, "header"
+	case corev1alpha1.HttpRouteRateLimitKeyTypeJwtClaim:
+		// payloads of verified tokens are saved in dynamic metadata by issuer
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"descriptor_key": "jwt_claim",
+				"source":         "DYNAMIC",
+				"metadata_key": map[string]interface{}{
+					"key": "envoy.filters.http.jwt_authn",
+					"path": []interface{}{
+						map[string]interface{}{"key": key.Issuer},
+						map[string]interface{}{"key": key.Name},
+					},
+				},
+			},
+		}, "jwt_claim"
+	default:
+		return map[string]interface{}{
+			"remote_address": map[string]interface{}{},
+		}, "remote_address"
+	}
+}
+
+// buildRateLimitEnvoyFilter enables the local rate limit filter on the envoy routes of the http route.
+// Without a key, all requests of the route share a token bucket.
+// With a key, each value of the key gets its own bucket, and requests without the key share the default one.
+func (r *HttpRouteReconcilerTask) buildRateLimitEnvoyFilter(route *corev1alpha1.HttpRoute) *v1alpha32.EnvoyFilter {
+	limit := route.Spec.RateLimit
+
+	fillInterval := "1s"
+
+	if limit.Unit == corev1alpha1.HttpRouteRateLimitUnitMinute {
+		fillInterval = "60s"
+	}
+
+	tokenBucket := map[string]interface{}{
+		"max_tokens":      limit.GetBurst(),
+		"tokens_per_fill": limit.RequestsPerUnit,
+		"fill_interval":   fillInterval,
+	}
+
+	config := map[string]interface{}{
+		"stat_prefix":     GetRateLimitStatPrefix(route.Namespace, route.Name),
+		"token_bucket":    tokenBucket,
+		"filter_enabled":  fullPercent("local_rate_limit_enabled"),
+		"filter_enforced": fullPercent("local_rate_limit_enforced"),
+		"status": map[string]interface{}{
+			"code": limit.GetResponseStatus(),
+		},
+	}
+
+	routePatch := map[string]interface{}{}
+
+	if limit.Key != nil {
+		action, descriptorKey := buildRateLimitDescriptorAction(limit.Key)
+
+		// the empty value matches all values of the key, and a bucket is kept for each of them
+		config["always_consume_default_token_bucket"] = false
+		config["max_dynamic_descriptors"] = LOCAL_RATE_LIMIT_MAX_KEYS
+		config["descriptors"] = []interface{}{
+			map[string]interface{}{
+				"entries": []interface{}{
+					map[string]interface{}{"key": descriptorKey},
+				},
+				"token_bucket": tokenBucket,
+			},
+		}
+
+		routePatch["rate_limits"] = []interface{}{
+			map[string]interface{}{
+				"actions": []interface{}{action},
+			},
+		}
+	}
+
+	routePatch["typed_per_filter_config"] = map[string]interface{}{
+		LOCAL_RATE_LIMIT_FILTER_NAME: typedStruct(config),
+	}
+
+	return &v1alpha32.EnvoyFilter{
+		ObjectMeta: metaV1.ObjectMeta{
+			Namespace: istioNamespace,
+			Name:      getRateLimitEnvoyFilterName(route),
+			Labels: map[string]string{
+				KALM_ROUTE_LABEL: "true",
+			},
+		},
+		Spec: v1alpha3.EnvoyFilter{
+			WorkloadSelector: &v1alpha3.WorkloadSelector{
+				Labels: map[string]string{
+					"app": ingressGatewayName,
+				},
+			},
+			ConfigPatches: []*v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{
+				{
+					ApplyTo: v1alpha3.EnvoyFilter_HTTP_ROUTE,
+					Match: &v1alpha3.EnvoyFilter_EnvoyConfigObjectMatch{
+						Context: v1alpha3.EnvoyFilter_GATEWAY,
+						ObjectTypes: &v1alpha3.EnvoyFilter_EnvoyConfigObjectMatch_RouteConfiguration{
+							RouteConfiguration: &v1alpha3.EnvoyFilter_RouteConfigurationMatch{
+								Vhost: &v1alpha3.EnvoyFilter_RouteConfigurationMatch_VirtualHostMatch{
+									Route: &v1alpha3.EnvoyFilter_RouteConfigurationMatch_RouteMatch{
+										Name: getIstioHttpRouteName(route),
+									},
+								},
+							},
+						},
+					},
+					Patch: &v1alpha3.EnvoyFilter_Patch{
+						Operation: v1alpha3.EnvoyFilter_Patch_MERGE,
+						Value:     golangMapToProtoStruct(routePatch),
+					},
+				},
+			},
+		},
+	}
+}
+
+// saveRouteEnvoyFilter creates the filter, or updates the existing one in the map and removes it from the map, so it's not cleaned.
+func (r *HttpRouteReconcilerTask) saveRouteEnvoyFilter(filter *v1alpha32.EnvoyFilter, existing map[string]*v1alpha32.EnvoyFilter) error {
+	fetched, ok := existing[filter.Name]
+
+	if !ok {
+		return r.Create(r.ctx, filter)
+	}
+
+	delete(existing, filter.Name)
+
+	copied := fetched.DeepCopy()
+	copied.Labels = filter.Labels
+	copied.Spec = filter.Spec
+
+	return r.Update(r.ctx, copied)
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/gogo/protobuf/jsonpb"
+	protoTypes "github.com/gogo/protobuf/types"
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"istio.io/api/networking/v1alpha3"
+	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func protoStructToMap(t *testing.T, value *protoTypes.Struct) map[string]interface{} {
+	str, err := (&jsonpb.Marshaler{}).MarshalToString(value)
+	assert.Nil(t, err)
+
+	var res map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(str), &res))
+
+	return res
+}
+
+func TestBuildRateLimitEnvoyFilter(t *testing.T) {
+	burst := 20
+	route := newStatusTestRoute("test-ns", "api", []string{"/"})
+	route.Spec.RateLimit = &v1alpha1.HttpRouteRateLimit{
+		RequestsPerUnit: 10,
+		Unit:            v1alpha1.HttpRouteRateLimitUnitMinute,
+		Burst:           &burst,
+	}
+
+	task := &HttpRouteReconcilerTask{}
+	filter := task.buildRateLimitEnvoyFilter(route)
+
+	assert.Equal(t, "rate-limit-test-ns-api", filter.Name)
+	assert.Equal(t, "true", filter.Labels[KALM_ROUTE_LABEL])
+
+	patch := filter.Spec.ConfigPatches[0]
+	assert.Equal(t, v1alpha3.EnvoyFilter_HTTP_ROUTE, patch.ApplyTo)
+	assert.Equal(t, "kalm-route-test-ns-api", patch.Match.GetRouteConfiguration().Vhost.Route.Name)
+
+	value := protoStructToMap(t, patch.Patch.Value)
+	assert.NotContains(t, value, "rate_limits")
+
+	config := value["typed_per_filter_config"].(map[string]interface{})[LOCAL_RATE_LIMIT_FILTER_NAME].(map[string]interface{})["value"].(map[string]interface{})
+	assert.Equal(t, "kalm_route_test_ns_api", config["stat_prefix"])
+	assert.Equal(t, map[string]interface{}{"max_tokens": float64(20), "tokens_per_fill": float64(10), "fill_interval": "60s"}, config["token_bucket"])
+	assert.Equal(t, map[string]interface{}{"code": float64(429)}, config["status"])
+	assert.NotContains(t, config, "descriptors")
+
+	route.Spec.RateLimit.Key = &v1alpha1.HttpRouteRateLimitKey{Type: v1alpha1.HttpRouteRateLimitKeyTypeHeader, Name: "X-Api-Key"}
+
+	value = protoStructToMap(t, task.buildRateLimitEnvoyFilter(route).Spec.ConfigPatches[0].Patch.Value)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"actions": []interface{}{
+				map[string]interface{}{
+					"request_headers": map[string]interface{}{"header_name": "X-Api-Key", "descriptor_key": "header"},
+				},
+			},
+		},
+	}, value["rate_limits"])
+
+	config = value["typed_per_filter_config"].(map[string]interface{})[LOCAL_RATE_LIMIT_FILTER_NAME].(map[string]interface{})["value"].(map[string]interface{})
+	assert.Equal(t, false, config["always_consume_default_token_bucket"])
+	assert.Len(t, config["descriptors"], 1)
+}
+
+func TestBuildRateLimitDescriptorAction(t *testing.T) {
+	_, key := buildRateLimitDescriptorAction(&v1alpha1.HttpRouteRateLimitKey{Type: v1alpha1.HttpRouteRateLimitKeyTypeIP})
+	assert.Equal(t, "remote_address", key)
+
+	action, key := buildRateLimitDescriptorAction(&v1alpha1.HttpRouteRateLimitKey{
+		Type:   v1alpha1.HttpRouteRateLimitKeyTypeJwtClaim,
+		Name:   "sub",
+		Issuer: "https://accounts.example.com",
+	})
+
+	assert.Equal(t, "jwt_claim", key)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"key": "https://accounts.example.com"},
+		map[string]interface{}{"key": "sub"},
+	}, action["metadata"].(map[string]interface{})["metadata_key"].(map[string]interface{})["path"])
+}
+
+func TestGetIngressGatewayVersion(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = appsV1.AddToScheme(scheme)
+
+	gateway := &appsV1.Deployment{
+		ObjectMeta: metaV1.ObjectMeta{Namespace: istioNamespace, Name: ingressGatewayName},
+		Spec: appsV1.DeploymentSpec{
+			Template: coreV1.PodTemplateSpec{
+				Spec: coreV1.PodSpec{
+					Containers: []coreV1.Container{{Name: "istio-proxy", Image: "docker.io/istio/proxyv2:1.6.1"}},
+				},
+			},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(scheme, gateway)
+
+	task := &HttpRouteReconcilerTask{
+		HttpRouteReconciler: &HttpRouteReconciler{&BaseReconciler{Client: c, Reader: c, Log: ctrl.Log}},
+		ctx:                 context.Background(),
+	}
+
+	version, err := task.getIngressGatewayVersion()
+	assert.Nil(t, err)
+	assert.Equal(t, "1.6.1", version.String())
+}
+
+func TestCheckRateLimitSupported(t *testing.T) {
+	limit := &v1alpha1.HttpRouteRateLimit{RequestsPerUnit: 10, Unit: v1alpha1.HttpRouteRateLimitUnitSecond}
+	keyed := &v1alpha1.HttpRouteRateLimit{RequestsPerUnit: 10, Unit: v1alpha1.HttpRouteRateLimitUnitSecond, Key: &v1alpha1.HttpRouteRateLimitKey{Type: v1alpha1.HttpRouteRateLimitKeyTypeIP}}
+
+	// the bundled istio has no http local rate limit filter
+	assert.NotNil(t, checkRateLimitSupported(limit, semver.MustParse("1.6.1"), nil))
+	assert.Nil(t, checkRateLimitSupported(limit, semver.MustParse("1.9.0-distroless"), nil))
+	assert.NotNil(t, checkRateLimitSupported(keyed, semver.MustParse("1.9.0"), nil))
+	assert.Nil(t, checkRateLimitSupported(keyed, semver.MustParse("1.26.2"), nil))
+	assert.NotNil(t, checkRateLimitSupported(limit, nil, fmt.Errorf("not found")))
+}
@@ -0,0 +1,427 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// a rule in the virtual service of a host, with the route it's generated from
+type httpRouteRule struct {
+	route *corev1alpha1.HttpRoute
+	rule  *istioNetworkingV1Beta1.HTTPRoute
+}
+
+func getHttpRouteKey(route *corev1alpha1.HttpRoute) string {
+	return route.Namespace + "/" + route.Name
+}
+
+func isHttpRouteOlder(a, b *corev1alpha1.HttpRoute) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+
+	return getHttpRouteKey(a) < getHttpRouteKey(b)
+}
+
+// parseMethodsOfMatch reverses the "^(GET|POST)$" regexp built in BuildMatches, nil means any method.
+func parseMethodsOfMatch(method *istioNetworkingV1Beta1.StringMatch) (map[string]bool, bool) {
+	if method == nil {
+		return nil, true
+	}
+
+	regex, ok := method.MatchType.(*istioNetworkingV1Beta1.StringMatch_Regex)
+
+	if !ok || !strings.HasPrefix(regex.Regex, "^(") || !strings.HasSuffix(regex.Regex, ")$") {
+		return nil, false
+	}
+
+	res := make(map[string]bool)
+
+	for _, m := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(regex.Regex, "^("), ")$"), "|") {
+		res[m] = true
+	}
+
+	return res, true
+}
+
+func stringMatchesCover(a, b map[string]*istioNetworkingV1Beta1.StringMatch) bool {
+	for k, v := range a {
+		if !reflect.DeepEqual(v, b[k]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// httpMatchCovers returns true if every request matching b also matches a,
+// so a rule with match b after a rule with match a is never used.
+func httpMatchCovers(a, b *istioNetworkingV1Beta1.HTTPMatchRequest) bool {
+	gateways := make(map[string]bool, len(a.Gateways))
+
+	for _, g := range a.Gateways {
+		gateways[g] = true
+	}
+
+	for _, g := range b.Gateways {
+		if !gateways[g] {
+			return false
+		}
+	}
+
+	aMethods, aOk := parseMethodsOfMatch(a.Method)
+	bMethods, bOk := parseMethodsOfMatch(b.Method)
+
+	if !aOk || !bOk {
+		if !reflect.DeepEqual(a.Method, b.Method) {
+			return false
+		}
+	} else if aMethods != nil {
+		if bMethods == nil {
+			return false
+		}
+
+		for m := range bMethods {
+			if !aMethods[m] {
+				return false
+			}
+		}
+	}
+
+	if a.Uri != nil {
+		if b.Uri == nil {
+			return false
+		}
+
+		aPrefix, aIsPrefix := a.Uri.MatchType.(*istioNetworkingV1Beta1.StringMatch_Prefix)
+		bPrefix, bIsPrefix := b.Uri.MatchType.(*istioNetworkingV1Beta1.StringMatch_Prefix)
+
+		bExact, bIsExact := b.Uri.MatchType.(*istioNetworkingV1Beta1.StringMatch_Exact)
+
+		if aIsPrefix && bIsPrefix {
+			if !strings.HasPrefix(bPrefix.Prefix, aPrefix.Prefix) {
+				return false
+			}
+		} else if aIsPrefix && bIsExact {
+			if !strings.HasPrefix(bExact.Exact, aPrefix.Prefix) {
+				return false
+			}
+		} else if !reflect.DeepEqual(a.Uri, b.Uri) {
+			return false
+		}
+	}
+
+	return stringMatchesCover(a.Headers, b.Headers) && stringMatchesCover(a.QueryParams, b.QueryParams)
+}
+
+func appendIfMissing(list []string, item string) []string {
+	for _, v := range list {
+		if v == item {
+			return list
+		}
+	}
+
+	return append(list, item)
+}
+
+// buildHttpRouteHostStatus finds the priority, conflicting and shadowing routes of the route in the sorted rules of a host.
+func buildHttpRouteHostStatus(route *corev1alpha1.HttpRoute, host string, rules []httpRouteRule) corev1alpha1.HttpRouteHostStatus {
+	status := corev1alpha1.HttpRouteHostStatus{
+		Host:     host,
+		Priority: -1,
+	}
+
+	checked := make(map[*corev1alpha1.HttpRoute]bool)
+
+	for j, rule := range rules {
+		if rule.route != route {
+			if !checked[rule.route] {
+				checked[rule.route] = true
+
+				for _, match := range route.DuplicateMatches(rule.route) {
+					if strings.HasPrefix(match, host+" ") {
+						status.ConflictingRoutes = appendIfMissing(status.ConflictingRoutes, getHttpRouteKey(rule.route))
+					}
+				}
+			}
+
+			continue
+		}
+
+		if status.Priority == -1 {
+			status.Priority = j
+		}
+
+		if len(rule.rule.Match) == 0 {
+			continue
+		}
+
+		for i := 0; i < j; i++ {
+			before := rules[i]
+
+			if before.route == route || len(before.rule.Match) == 0 {
+				continue
+			}
+
+			if httpMatchCovers(before.rule.Match[0], rule.rule.Match[0]) {
+				status.ShadowedBy = appendIfMissing(status.ShadowedBy, getHttpRouteKey(before.route))
+			}
+		}
+	}
+
+	return status
+}
+
+// isClusterLocalDestination returns true if the destination is a service in the cluster.
+// Hosts in format of "name", "name.namespace", "name.namespace.svc" and "name.namespace.svc.cluster.local" are in the cluster.
+func isClusterLocalDestination(host string) bool {
+	parts := strings.Split(host, ".")
+
+	switch len(parts) {
+	case 1, 2:
+		return true
+	case 3:
+		return parts[2] == "svc"
+	case 5:
+		return strings.HasSuffix(host, ".svc.cluster.local")
+	}
+
+	return false
+}
+
+// checkHttpRouteDestination checks the service of a cluster local destination, service is nil if it doesn't exist.
+func checkHttpRouteDestination(destinationHost, defaultNamespace string, service *coreV1.Service) corev1alpha1.HttpRouteDestinationStatus {
+	status := corev1alpha1.HttpRouteDestinationStatus{Host: destinationHost}
+
+	name, namespace, port := parseDestinationHost(destinationHost, defaultNamespace)
+
+	if service == nil {
+		status.Message = fmt.Sprintf("service %s/%s not found", namespace, name)
+		return status
+	}
+
+	status.ServiceExists = true
+
+	if port == "" {
+		if len(service.Spec.Ports) == 1 {
+			status.PortMatched = true
+		} else {
+			status.Message = fmt.Sprintf("port is required, service %s/%s has %d ports", namespace, name, len(service.Spec.Ports))
+		}
+
+		return status
+	}
+
+	p, _ := strconv.Atoi(port)
+
+	for _, servicePort := range service.Spec.Ports {
+		if int(servicePort.Port) == p {
+			status.PortMatched = true
+			return status
+		}
+	}
+
+	status.Message = fmt.Sprintf("port %s is not a port of service %s/%s", port, namespace, name)
+
+	return status
+}
+
+func (r *HttpRouteReconcilerTask) buildHttpRouteDestinationStatuses(route *corev1alpha1.HttpRoute) ([]corev1alpha1.HttpRouteDestinationStatus, error) {
+	res := make([]corev1alpha1.HttpRouteDestinationStatus, 0, len(route.Spec.Destinations))
+
+	for _, destination := range route.Spec.Destinations {
+		host := destination.Host
+
+		if colon := strings.LastIndexByte(host, ':'); colon != -1 {
+			host = host[:colon]
+		}
+
+		if !isClusterLocalDestination(host) {
+			res = append(res, corev1alpha1.HttpRouteDestinationStatus{
+				Host:          destination.Host,
+				ServiceExists: true,
+				PortMatched:   true,
+				Message:       "host is outside of the cluster, it's not checked",
+			})
+			continue
+		}
+
+		name, namespace, _ := parseDestinationHost(destination.Host, route.Namespace)
+
+		var service coreV1.Service
+
+		if err := r.Client.Get(r.ctx, types.NamespacedName{Namespace: namespace, Name: name}, &service); err != nil {
+			if !errors.IsNotFound(err) {
+				return nil, err
+			}
+
+			res = append(res, checkHttpRouteDestination(destination.Host, route.Namespace, nil))
+			continue
+		}
+
+		res = append(res, checkHttpRouteDestination(destination.Host, route.Namespace, &service))
+	}
+
+	return res, nil
+}
+
+func (r *HttpRouteReconcilerTask) buildHttpRouteStatus(route *corev1alpha1.HttpRoute, hostRules map[string][]httpRouteRule, reconcileErr error) (*corev1alpha1.HttpRouteStatus, error) {
+	status := route.Status.DeepCopy()
+	status.ObservedGeneration = route.Generation
+	status.Hosts = nil
+
+	var conflicts, shadows []string
+
+	for _, host := range route.Spec.Hosts {
+		hostStatus := buildHttpRouteHostStatus(route, host, hostRules[host])
+		status.Hosts = append(status.Hosts, hostStatus)
+
+		for _, key := range hostStatus.ConflictingRoutes {
+			conflicts = append(conflicts, fmt.Sprintf("%s is also routed by %s", host, key))
+		}
+
+		for _, key := range hostStatus.ShadowedBy {
+			shadows = append(shadows, fmt.Sprintf("rules on %s are matched by %s first", host, key))
+		}
+	}
+
+	destinations, err := r.buildHttpRouteDestinationStatuses(route)
+
+	if err != nil {
+		return nil, err
+	}
+
+	status.Destinations = destinations
+
+	if reconcileErr != nil {
+		status.LastReconcileError = reconcileErr.Error()
+		status.SetCondition(corev1alpha1.HttpRouteStatusCondition{
+			Type:    corev1alpha1.HttpRouteConditionAccepted,
+			Status:  coreV1.ConditionFalse,
+			Reason:  "ReconcileError",
+			Message: reconcileErr.Error(),
+		})
+	} else {
+		status.LastReconcileError = ""
+		status.SetCondition(corev1alpha1.HttpRouteStatusCondition{
+			Type:   corev1alpha1.HttpRouteConditionAccepted,
+			Status: coreV1.ConditionTrue,
+			Reason: "Accepted",
+		})
+	}
+
+	if len(conflicts) > 0 {
+		status.SetCondition(corev1alpha1.HttpRouteStatusCondition{
+			Type:    corev1alpha1.HttpRouteConditionConflicted,
+			Status:  coreV1.ConditionTrue,
+			Reason:  "DuplicateMatch",
+			Message: strings.Join(conflicts, "; "),
+		})
+	} else {
+		status.SetCondition(corev1alpha1.HttpRouteStatusCondition{
+			Type:   corev1alpha1.HttpRouteConditionConflicted,
+			Status: coreV1.ConditionFalse,
+			Reason: "NoConflicts",
+		})
+	}
+
+	if len(shadows) > 0 {
+		status.SetCondition(corev1alpha1.HttpRouteStatusCondition{
+			Type:    corev1alpha1.HttpRouteConditionShadowed,
+			Status:  coreV1.ConditionTrue,
+			Reason:  "ShadowedByRoutes",
+			Message: strings.Join(shadows, "; "),
+		})
+	} else {
+		status.SetCondition(corev1alpha1.HttpRouteStatusCondition{
+			Type:   corev1alpha1.HttpRouteConditionShadowed,
+			Status: coreV1.ConditionFalse,
+			Reason: "NotShadowed",
+		})
+	}
+
+	return status, nil
+}
+
+func (r *HttpRouteReconcilerTask) updateHttpRouteStatuses(hostRules map[string][]httpRouteRule, hostErrors map[string]error, routeErrors map[*corev1alpha1.HttpRoute]error) error {
+	for i := range r.routes {
+		route := &r.routes[i]
+		reconcileErr := routeErrors[route]
+
+		for _, host := range route.Spec.Hosts {
+			if reconcileErr == nil && hostErrors[host] != nil {
+				reconcileErr = fmt.Errorf("save virtual service of host %s failed: %s", host, hostErrors[host].Error())
+			}
+		}
+
+		status, err := r.buildHttpRouteStatus(route, hostRules, reconcileErr)
+
+		if err != nil {
+			return err
+		}
+
+		if reflect.DeepEqual(*status, route.Status) {
+			continue
+		}
+
+		copied := route.DeepCopy()
+		copied.Status = *status
+
+		if err := r.Status().Patch(r.ctx, copied, client.MergeFrom(route)); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WatchKalmRouteDestinationService reconciles routes when services used as their destinations are changed
+type WatchKalmRouteDestinationService struct {
+	*BaseReconciler
+}
+
+func (r *WatchKalmRouteDestinationService) Map(object handler.MapObject) []reconcile.Request {
+	if _, ok := object.Object.(*coreV1.Service); !ok {
+		return nil
+	}
+
+	var routeList corev1alpha1.HttpRouteList
+
+	if err := r.Client.List(context.Background(), &routeList); err != nil {
+		r.Log.Error(err, "Can't list http routes in mapper.")
+		return nil
+	}
+
+	for i := range routeList.Items {
+		if routeHasDestination(&routeList.Items[i], object.Meta.GetName(), object.Meta.GetNamespace()) {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{}}}
+		}
+	}
+
+	return nil
+}
+
+// routeHasDestination returns true if the service, or the component of the same name, is a destination of the route
+func routeHasDestination(route *corev1alpha1.HttpRoute, name, namespace string) bool {
+	for _, destination := range route.Spec.Destinations {
+		destinationName, destinationNamespace, _ := parseDestinationHost(destination.Host, route.Namespace)
+
+		if destinationName == name && destinationNamespace == namespace {
+			return true
+		}
+	}
+
+	return false
+}
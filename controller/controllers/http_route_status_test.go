@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func newStatusTestRoute(namespace, name string, paths []string, methods ...v1alpha1.HttpRouteMethod) *v1alpha1.HttpRoute {
+	return &v1alpha1.HttpRoute{
+		ObjectMeta: v1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: v1alpha1.HttpRouteSpec{
+			Hosts:        []string{"example.com"},
+			Paths:        paths,
+			Methods:      methods,
+			Schemes:      []v1alpha1.HttpRouteScheme{"http"},
+			Destinations: []v1alpha1.HttpRouteDestination{{Host: "web:80", Weight: 1}},
+		},
+	}
+}
+
+func sortedStatusTestRules(routes ...*v1alpha1.HttpRoute) []httpRouteRule {
+	task := &HttpRouteReconcilerTask{}
+
+	var rules []httpRouteRule
+
+	for _, route := range routes {
+		for _, rule := range task.buildIstioHttpRoutes(route) {
+			rules = append(rules, httpRouteRule{route: route, rule: rule})
+		}
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool { return sortRoutes(rules[i].rule, rules[j].rule) })
+
+	return rules
+}
+
+func TestBuildHttpRouteHostStatus(t *testing.T) {
+	first := newStatusTestRoute("foo", "first", []string{"/api"}, "GET", "POST")
+	duplicate := newStatusTestRoute("foo", "duplicate", []string{"/api"}, "POST", "GET")
+	narrow := newStatusTestRoute("bar", "narrow", []string{"/api/v1", "/api"}, "GET")
+	root := newStatusTestRoute("bar", "root", []string{"/"}, "GET")
+
+	rules := sortedStatusTestRules(first, duplicate, narrow, root)
+
+	status := buildHttpRouteHostStatus(first, "example.com", rules)
+	assert.Equal(t, 1, status.Priority)
+	assert.Equal(t, []string{"foo/duplicate"}, status.ConflictingRoutes)
+	assert.Empty(t, status.ShadowedBy)
+
+	status = buildHttpRouteHostStatus(duplicate, "example.com", rules)
+	assert.Equal(t, 2, status.Priority)
+	assert.Equal(t, []string{"foo/first"}, status.ConflictingRoutes)
+	assert.Equal(t, []string{"foo/first"}, status.ShadowedBy)
+
+	// "/api/v1" is served by narrow, "/api" GET requests are taken by first
+	status = buildHttpRouteHostStatus(narrow, "example.com", rules)
+	assert.Equal(t, 0, status.Priority)
+	assert.Empty(t, status.ConflictingRoutes)
+	assert.Equal(t, []string{"foo/first", "foo/duplicate"}, status.ShadowedBy)
+
+	status = buildHttpRouteHostStatus(root, "example.com", rules)
+	assert.Equal(t, 4, status.Priority)
+	assert.Empty(t, status.ShadowedBy)
+}
+
+func TestHttpMatchCovers(t *testing.T) {
+	task := &HttpRouteReconcilerTask{}
+
+	all := task.BuildMatches(newStatusTestRoute("foo", "all", []string{"/"}, "GET", "POST"))[0]
+	get := task.BuildMatches(newStatusTestRoute("foo", "get", []string{"/"}, "GET"))[0]
+	api := task.BuildMatches(newStatusTestRoute("foo", "api", []string{"/api"}, "GET"))[0]
+
+	withHeader := newStatusTestRoute("foo", "header", []string{"/api"}, "GET")
+	withHeader.Spec.Conditions = []v1alpha1.HttpRouteCondition{
+		{Type: v1alpha1.HttpRouteConditionTypeHeader, Name: "x-canary", Operator: v1alpha1.HRCOEqual, Value: "true"},
+	}
+	header := task.BuildMatches(withHeader)[0]
+
+	assert.True(t, httpMatchCovers(all, get))
+	assert.False(t, httpMatchCovers(get, all))
+	assert.True(t, httpMatchCovers(get, api))
+	assert.False(t, httpMatchCovers(api, get))
+	assert.True(t, httpMatchCovers(api, header))
+	assert.False(t, httpMatchCovers(header, api))
+}
+
+func TestCheckHttpRouteDestination(t *testing.T) {
+	service := &coreV1.Service{
+		Spec: coreV1.ServiceSpec{
+			Ports: []coreV1.ServicePort{{Name: "http", Port: 80}, {Name: "metrics", Port: 9090}},
+		},
+	}
+
+	status := checkHttpRouteDestination("web:80", "foo", service)
+	assert.True(t, status.ServiceExists)
+	assert.True(t, status.PortMatched)
+
+	status = checkHttpRouteDestination("web.foo.svc.cluster.local:8080", "bar", service)
+	assert.True(t, status.ServiceExists)
+	assert.False(t, status.PortMatched)
+	assert.Equal(t, "port 8080 is not a port of service foo/web", status.Message)
+
+	status = checkHttpRouteDestination("web", "foo", service)
+	assert.False(t, status.PortMatched)
+	assert.Equal(t, "port is required, service foo/web has 2 ports", status.Message)
+
+	status = checkHttpRouteDestination("web:80", "foo", nil)
+	assert.False(t, status.ServiceExists)
+	assert.Equal(t, "service foo/web not found", status.Message)
+
+	assert.True(t, isClusterLocalDestination("web"))
+	assert.True(t, isClusterLocalDestination("web.foo.svc.cluster.local"))
+	assert.False(t, isClusterLocalDestination("api.example.org.cn"))
+}
+
+func TestComponentCanaryWeightChanged(t *testing.T) {
+	stable := &v1alpha1.Component{ObjectMeta: v1.ObjectMeta{Namespace: "test-ns", Name: "web"}}
+	rollout := stable.DeepCopy()
+	rollout.Status.Rollout = &v1alpha1.ComponentRolloutStatus{CanaryWeight: 20}
+	requeued := rollout.DeepCopy()
+
+	assert.False(t, componentCanaryWeightChanged.Create(event.CreateEvent{Object: stable}))
+	assert.True(t, componentCanaryWeightChanged.Update(event.UpdateEvent{ObjectOld: stable, ObjectNew: rollout}))
+	assert.False(t, componentCanaryWeightChanged.Update(event.UpdateEvent{ObjectOld: rollout, ObjectNew: requeued}))
+	assert.True(t, componentCanaryWeightChanged.Delete(event.DeleteEvent{Object: rollout}))
+
+	route := newStatusTestRoute("test-ns", "api", []string{"/"})
+	assert.True(t, routeHasDestination(route, "web", "test-ns"))
+	assert.False(t, routeHasDestination(route, "web", "other-ns"))
+}
@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// PreviewEnvironmentReconciler publishes urls of preview environments and deletes expired ones.
+// Applications are cloned into preview environments by the api server.
+type PreviewEnvironmentReconciler struct {
+	*BaseReconciler
+}
+
+// +kubebuilder:rbac:groups=core.kalm.dev,resources=previewenvironments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.kalm.dev,resources=previewenvironments/status,verbs=get;update;patch
+
+func (r *PreviewEnvironmentReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+
+	var preview corev1alpha1.PreviewEnvironment
+
+	if err := r.Get(ctx, req.NamespacedName, &preview); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if preview.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	expiresAt := preview.CreationTimestamp.Add(time.Duration(preview.GetTTL()) * time.Second)
+
+	if !time.Now().Before(expiresAt) {
+		return ctrl.Result{}, r.deleteExpired(ctx, &preview)
+	}
+
+	status, err := r.buildStatus(ctx, &preview)
+
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	expires := metaV1.NewTime(expiresAt)
+	status.ExpiresAt = &expires
+
+	if !reflect.DeepEqual(*status, preview.Status) {
+		copied := preview.DeepCopy()
+		copied.Status = *status
+
+		if err := r.Status().Patch(ctx, copied, client.MergeFrom(&preview)); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: time.Until(expiresAt)}, nil
+}
+
+// deleteExpired deletes the namespace of the environment and the environment itself.
+// The namespace is only deleted if it's labeled with the environment, an application which happens to have the same name is kept.
+func (r *PreviewEnvironmentReconciler) deleteExpired(ctx context.Context, preview *corev1alpha1.PreviewEnvironment) error {
+	var namespace coreV1.Namespace
+
+	err := r.Get(ctx, types.NamespacedName{Name: getPreviewEnvironmentNamespace(preview)}, &namespace)
+
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if err == nil && namespace.Labels[corev1alpha1.PreviewEnvironmentLabelName] == preview.Name && namespace.DeletionTimestamp == nil {
+		if err := r.Delete(ctx, &namespace); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	r.Log.Info("preview environment is expired, deleted", "name", preview.Name)
+
+	return client.IgnoreNotFound(r.Delete(ctx, preview))
+}
+
+func getPreviewEnvironmentNamespace(preview *corev1alpha1.PreviewEnvironment) string {
+	if preview.Status.Namespace != "" {
+		return preview.Status.Namespace
+	}
+
+	return preview.Name
+}
+
+func (r *PreviewEnvironmentReconciler) buildStatus(ctx context.Context, preview *corev1alpha1.PreviewEnvironment) (*corev1alpha1.PreviewEnvironmentStatus, error) {
+	status := &corev1alpha1.PreviewEnvironmentStatus{
+		Namespace: getPreviewEnvironmentNamespace(preview),
+	}
+
+	var namespace coreV1.Namespace
+
+	if err := r.Get(ctx, types.NamespacedName{Name: status.Namespace}, &namespace); err != nil {
+		if errors.IsNotFound(err) {
+			status.Message = fmt.Sprintf("namespace %s not found", status.Namespace)
+			return status, nil
+		}
+
+		return nil, err
+	}
+
+	if namespace.Labels[corev1alpha1.PreviewEnvironmentLabelName] != preview.Name {
+		status.Message = fmt.Sprintf("namespace %s doesn't belong to the preview environment", status.Namespace)
+		return status, nil
+	}
+
+	var routeList corev1alpha1.HttpRouteList
+
+	if err := r.List(ctx, &routeList, client.InNamespace(status.Namespace)); err != nil {
+		return nil, err
+	}
+
+	status.URLs = getPreviewEnvironmentURLs(routeList.Items)
+
+	if len(status.URLs) > 0 {
+		status.URL = status.URLs[0]
+	}
+
+	var componentList corev1alpha1.ComponentList
+
+	if err := r.List(ctx, &componentList, client.InNamespace(status.Namespace)); err != nil {
+		return nil, err
+	}
+
+	status.Ready = len(componentList.Items) > 0
+
+	for i := range componentList.Items {
+		if !componentList.Items[i].Status.IsReady() {
+			status.Ready = false
+			break
+		}
+	}
+
+	return status, nil
+}
+
+// getPreviewEnvironmentURLs returns an url for each host of the routes, https is used if the route accepts it.
+// Https urls come first, then the shortest hosts, so the first one is usually the main entry of the application.
+func getPreviewEnvironmentURLs(routes []corev1alpha1.HttpRoute) []string {
+	schemes := make(map[string]string)
+
+	for _, route := range routes {
+		scheme := "http"
+
+		for _, s := range route.Spec.Schemes {
+			if s == "https" {
+				scheme = "https"
+			}
+		}
+
+		for _, host := range route.Spec.Hosts {
+			if schemes[host] != "https" {
+				schemes[host] = scheme
+			}
+		}
+	}
+
+	hosts := make([]string, 0, len(schemes))
+
+	for host := range schemes {
+		hosts = append(hosts, host)
+	}
+
+	sort.Slice(hosts, func(i, j int) bool {
+		a, b := hosts[i], hosts[j]
+
+		if schemes[a] != schemes[b] {
+			return schemes[a] == "https"
+		}
+
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+
+		return a < b
+	})
+
+	res := make([]string, 0, len(hosts))
+
+	for _, host := range hosts {
+		res = append(res, schemes[host]+"://"+host)
+	}
+
+	return res
+}
+
+// PreviewEnvironmentMapper reconciles the preview environment when objects in its namespace are changed
+type PreviewEnvironmentMapper struct {
+	*BaseReconciler
+}
+
+func (r *PreviewEnvironmentMapper) Map(object handler.MapObject) []reconcile.Request {
+	var namespace coreV1.Namespace
+
+	if err := r.Client.Get(context.Background(), types.NamespacedName{Name: object.Meta.GetNamespace()}, &namespace); err != nil {
+		return nil
+	}
+
+	name, exist := namespace.Labels[corev1alpha1.PreviewEnvironmentLabelName]
+
+	if !exist {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name}}}
+}
+
+func NewPreviewEnvironmentReconciler(mgr ctrl.Manager) *PreviewEnvironmentReconciler {
+	return &PreviewEnvironmentReconciler{
+		NewBaseReconciler(mgr, "PreviewEnvironment"),
+	}
+}
+
+func (r *PreviewEnvironmentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	mapper := &PreviewEnvironmentMapper{r.BaseReconciler}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1alpha1.PreviewEnvironment{}).
+		Watches(&source.Kind{Type: &corev1alpha1.Component{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: mapper}).
+		Watches(&source.Kind{Type: &corev1alpha1.HttpRoute{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: mapper}).
+		Complete(r)
+}
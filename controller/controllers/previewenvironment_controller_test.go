@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPreviewEnvironmentURLs(t *testing.T) {
+	routes := []corev1alpha1.HttpRoute{
+		{
+			Spec: corev1alpha1.HttpRouteSpec{
+				Hosts:   []string{"pr-1.preview.example.com"},
+				Schemes: []corev1alpha1.HttpRouteScheme{"http"},
+			},
+		},
+		{
+			Spec: corev1alpha1.HttpRouteSpec{
+				Hosts:   []string{"pr-1.preview.example.com", "pr-1-admin.preview.example.com"},
+				Schemes: []corev1alpha1.HttpRouteScheme{"http", "https"},
+			},
+		},
+		{
+			Spec: corev1alpha1.HttpRouteSpec{
+				Hosts:   []string{"pr-1-docs.preview.example.com"},
+				Schemes: []corev1alpha1.HttpRouteScheme{"http"},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{
+		"https://pr-1.preview.example.com",
+		"https://pr-1-admin.preview.example.com",
+		"http://pr-1-docs.preview.example.com",
+	}, getPreviewEnvironmentURLs(routes))
+
+	assert.Empty(t, getPreviewEnvironmentURLs(nil))
+}
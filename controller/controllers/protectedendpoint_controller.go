@@ -542,6 +542,12 @@ func golangValueToProtoValue(val interface{}) *protoTypes.Value {
 				StringValue: typeVal,
 			},
 		}
+	case int:
+		return &protoTypes.Value{
+			Kind: &protoTypes.Value_NumberValue{
+				NumberValue: float64(typeVal),
+			},
+		}
 	case []interface{}:
 		values := make([]*protoTypes.Value, len(typeVal))
 
@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/kalmhq/kalm/controller/utils/secretbox"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// the cluster key encrypting all SecretStores, generated on first use
+	SecretStoreKeySecretName = "kalm-secret-store-key"
+	SecretStoreKeySecretKey  = "key"
+)
+
+// SecretStoreReconciler decrypts a SecretStore into a Secret with the same name
+type SecretStoreReconciler struct {
+	*BaseReconciler
+}
+
+// +kubebuilder:rbac:groups=core.kalm.dev,resources=secretstores,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.kalm.dev,resources=secretstores/status,verbs=get;update;patch
+
+func (r *SecretStoreReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+
+	var store corev1alpha1.SecretStore
+
+	if err := r.Get(ctx, req.NamespacedName, &store); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	key, err := GetOrCreateSecretStoreKey(ctx, r.Client, r.Reader)
+
+	if err != nil {
+		r.EmitWarningEvent(&store, err, "unable to get the cluster key of secret stores")
+		return ctrl.Result{}, err
+	}
+
+	var secret coreV1.Secret
+	var existing *coreV1.Secret
+
+	if err := r.Get(ctx, req.NamespacedName, &secret); err != nil {
+		if !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+	} else if metaV1.IsControlledBy(&secret, &store) {
+		existing = &secret
+	} else {
+		err := fmt.Errorf("secret %s already exists and is not managed by kalm", secret.Name)
+		r.EmitWarningEvent(&store, err, err.Error())
+		return ctrl.Result{}, r.updateStatus(ctx, &store, err.Error())
+	}
+
+	var previous map[string][]byte
+
+	if existing != nil {
+		previous = existing.Data
+	}
+
+	data, decryptErr := decryptSecretStore(key, &store, previous)
+
+	if existing == nil {
+		secret = coreV1.Secret{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      store.Name,
+				Namespace: store.Namespace,
+				Labels:    map[string]string{KalmLabelManaged: "true"},
+			},
+			Type: coreV1.SecretTypeOpaque,
+			Data: data,
+		}
+
+		if err := ctrl.SetControllerReference(&store, &secret, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if err := r.Create(ctx, &secret); err != nil {
+			r.EmitWarningEvent(&store, err, "unable to create secret of SecretStore")
+			return ctrl.Result{}, err
+		}
+	} else {
+		copied := existing.DeepCopy()
+		copied.Data = data
+
+		if err := r.Update(ctx, copied); err != nil {
+			r.EmitWarningEvent(&store, err, "unable to update secret of SecretStore")
+			return ctrl.Result{}, err
+		}
+	}
+
+	var errMsg string
+
+	if decryptErr != nil {
+		errMsg = decryptErr.Error()
+		r.EmitWarningEvent(&store, decryptErr, errMsg)
+	}
+
+	return ctrl.Result{}, r.updateStatus(ctx, &store, errMsg)
+}
+
+func (r *SecretStoreReconciler) updateStatus(ctx context.Context, store *corev1alpha1.SecretStore, errMsg string) error {
+	if store.Status.ObservedGeneration == store.Generation && store.Status.Error == errMsg {
+		return nil
+	}
+
+	copied := store.DeepCopy()
+	copied.Status.ObservedGeneration = store.Generation
+	copied.Status.Error = errMsg
+
+	return r.Status().Patch(ctx, copied, client.MergeFrom(store))
+}
+
+// decryptSecretStore returns the data of the decrypted Secret.
+// Items that can't be decrypted keep their previous values, so running pods are not broken by a bad write.
+func decryptSecretStore(key []byte, store *corev1alpha1.SecretStore, previous map[string][]byte) (map[string][]byte, error) {
+	data := make(map[string][]byte, len(store.Spec.Secrets))
+
+	var failed []string
+
+	for _, item := range store.Spec.Secrets {
+		value, err := secretbox.Decrypt(key, item.EncryptedValue)
+
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%s)", item.Name, err.Error()))
+
+			if v, exist := previous[item.Name]; exist {
+				data[item.Name] = v
+			}
+
+			continue
+		}
+
+		data[item.Name] = []byte(value)
+	}
+
+	if len(failed) > 0 {
+		return data, fmt.Errorf("unable to decrypt secrets: %s", strings.Join(failed, ", "))
+	}
+
+	return data, nil
+}
+
+// GetOrCreateSecretStoreKey returns the cluster key, the key is generated if it doesn't exist yet.
+// The reader should not be cached, so the controller doesn't watch secrets of the system namespace for this.
+func GetOrCreateSecretStoreKey(ctx context.Context, c client.Client, reader client.Reader) ([]byte, error) {
+	var secret coreV1.Secret
+	namespacedName := types.NamespacedName{Namespace: KalmSystemNamespace, Name: SecretStoreKeySecretName}
+
+	err := reader.Get(ctx, namespacedName, &secret)
+
+	if err == nil {
+		return getSecretStoreKey(&secret)
+	}
+
+	if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	key, err := secretbox.GenerateKey()
+
+	if err != nil {
+		return nil, err
+	}
+
+	secret = coreV1.Secret{
+		ObjectMeta: metaV1.ObjectMeta{
+			Namespace: KalmSystemNamespace,
+			Name:      SecretStoreKeySecretName,
+		},
+		Type: coreV1.SecretTypeOpaque,
+		Data: map[string][]byte{SecretStoreKeySecretKey: key},
+	}
+
+	if err := c.Create(ctx, &secret); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return nil, err
+		}
+
+		// created by the api or another replica at the same time
+		if err := reader.Get(ctx, namespacedName, &secret); err != nil {
+			return nil, err
+		}
+
+		return getSecretStoreKey(&secret)
+	}
+
+	return key, nil
+}
+
+func getSecretStoreKey(secret *coreV1.Secret) ([]byte, error) {
+	key := secret.Data[SecretStoreKeySecretKey]
+
+	if len(key) != secretbox.KeySize {
+		return nil, fmt.Errorf("invalid cluster key in secret %s/%s", secret.Namespace, secret.Name)
+	}
+
+	return key, nil
+}
+
+func NewSecretStoreReconciler(mgr ctrl.Manager) *SecretStoreReconciler {
+	return &SecretStoreReconciler{
+		NewBaseReconciler(mgr, "SecretStore"),
+	}
+}
+
+func (r *SecretStoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1alpha1.SecretStore{}).
+		Owns(&coreV1.Secret{}).
+		Complete(r)
+}
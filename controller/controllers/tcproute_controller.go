@@ -0,0 +1,378 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
+)
+
+const (
+	KALM_TCP_ROUTE_LABEL = "kalm-tcp-route"
+
+	// ports of the gateway servers are declared on the ingress gateway by the kalm operator
+	TCP_GATEWAY_NAME = "kalm-tcp-gateway"
+)
+
+var TCP_GATEWAY_NAMESPACED_NAME = types.NamespacedName{Namespace: KALM_GATEWAY_NAMESPACE, Name: TCP_GATEWAY_NAME}
+
+type TcpRouteReconcilerTask struct {
+	*TcpRouteReconciler
+	ctx context.Context
+}
+
+func getTcpRouteVirtualServiceName(route *corev1alpha1.TcpRoute) string {
+	return fmt.Sprintf("tcp-route-%s", route.Name)
+}
+
+func isTcpRouteOlder(a, b *corev1alpha1.TcpRoute) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+
+	return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+}
+
+// acceptTcpRoutes decides which routes are served, older routes win conflicts.
+// The returned map is route -> reason why it's rejected, accepted routes are not in it.
+func acceptTcpRoutes(routes []*corev1alpha1.TcpRoute) map[*corev1alpha1.TcpRoute]string {
+	rejected := make(map[*corev1alpha1.TcpRoute]string)
+	var accepted []*corev1alpha1.TcpRoute
+
+	for _, route := range routes {
+		for _, other := range accepted {
+			if msg := route.ConflictsWith(other); msg != "" {
+				rejected[route] = msg
+				break
+			}
+		}
+
+		if _, ok := rejected[route]; !ok {
+			accepted = append(accepted, route)
+		}
+	}
+
+	return rejected
+}
+
+// buildTcpGatewayServers returns a server for each port of accepted routes.
+// Tls is passed through, destinations terminate it.
+func buildTcpGatewayServers(routes []*corev1alpha1.TcpRoute) []*istioNetworkingV1Beta1.Server {
+	servers := make(map[int]*istioNetworkingV1Beta1.Server)
+
+	for _, route := range routes {
+		server, ok := servers[route.Spec.Port]
+
+		if !ok {
+			server = &istioNetworkingV1Beta1.Server{
+				Port: &istioNetworkingV1Beta1.Port{
+					Number:   uint32(route.Spec.Port),
+					Protocol: strings.ToUpper(string(route.Spec.Protocol)),
+					Name:     fmt.Sprintf("%s-%d", route.Spec.Protocol, route.Spec.Port),
+				},
+			}
+
+			if route.Spec.Protocol == corev1alpha1.TcpRouteProtocolTLS {
+				server.Tls = &istioNetworkingV1Beta1.ServerTLSSettings{
+					Mode: istioNetworkingV1Beta1.ServerTLSSettings_PASSTHROUGH,
+				}
+			} else {
+				server.Hosts = []string{"*"}
+			}
+
+			servers[route.Spec.Port] = server
+		}
+
+		if route.Spec.Protocol == corev1alpha1.TcpRouteProtocolTLS {
+			for _, host := range route.Spec.Hosts {
+				server.Hosts = appendIfMissing(server.Hosts, host)
+			}
+		}
+	}
+
+	res := make([]*istioNetworkingV1Beta1.Server, 0, len(servers))
+
+	for _, server := range servers {
+		sort.Strings(server.Hosts)
+		res = append(res, server)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Port.Number < res[j].Port.Number })
+
+	return res
+}
+
+func buildTcpRouteDestinations(route *corev1alpha1.TcpRoute) []*istioNetworkingV1Beta1.RouteDestination {
+	weights := make([]int, 0, len(route.Spec.Destinations))
+
+	for _, destination := range route.Spec.Destinations {
+		weights = append(weights, destination.Weight)
+	}
+
+	adjustedWeights := adjustWeightToSumTo100(weights)
+	res := make([]*istioNetworkingV1Beta1.RouteDestination, 0, len(route.Spec.Destinations))
+
+	for i, destination := range route.Spec.Destinations {
+		dest := toHttpRouteDestination(corev1alpha1.HttpRouteDestination{Host: destination.Host}, adjustedWeights[i], route.Namespace)
+
+		res = append(res, &istioNetworkingV1Beta1.RouteDestination{
+			Destination: dest.Destination,
+			Weight:      dest.Weight,
+		})
+	}
+
+	return res
+}
+
+func buildTcpRouteVirtualServiceSpec(route *corev1alpha1.TcpRoute) istioNetworkingV1Beta1.VirtualService {
+	gateway := TCP_GATEWAY_NAMESPACED_NAME.String()
+
+	if route.Spec.Protocol == corev1alpha1.TcpRouteProtocolTLS {
+		return istioNetworkingV1Beta1.VirtualService{
+			Hosts:    route.Spec.Hosts,
+			Gateways: []string{gateway},
+			Tls: []*istioNetworkingV1Beta1.TLSRoute{
+				{
+					Match: []*istioNetworkingV1Beta1.TLSMatchAttributes{
+						{
+							Port:     uint32(route.Spec.Port),
+							SniHosts: route.Spec.Hosts,
+							Gateways: []string{gateway},
+						},
+					},
+					Route: buildTcpRouteDestinations(route),
+				},
+			},
+		}
+	}
+
+	return istioNetworkingV1Beta1.VirtualService{
+		Hosts:    []string{"*"},
+		Gateways: []string{gateway},
+		Tcp: []*istioNetworkingV1Beta1.TCPRoute{
+			{
+				Match: []*istioNetworkingV1Beta1.L4MatchAttributes{
+					{
+						Port:     uint32(route.Spec.Port),
+						Gateways: []string{gateway},
+					},
+				},
+				Route: buildTcpRouteDestinations(route),
+			},
+		},
+	}
+}
+
+func (r *TcpRouteReconcilerTask) saveGateway(servers []*istioNetworkingV1Beta1.Server) error {
+	var gw v1beta1.Gateway
+
+	err := r.Reader.Get(r.ctx, TCP_GATEWAY_NAMESPACED_NAME, &gw)
+
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	isCreate := errors.IsNotFound(err)
+
+	if len(servers) == 0 {
+		if isCreate {
+			return nil
+		}
+
+		return client.IgnoreNotFound(r.Delete(r.ctx, &gw))
+	}
+
+	gw.Name = TCP_GATEWAY_NAMESPACED_NAME.Name
+	gw.Namespace = TCP_GATEWAY_NAMESPACED_NAME.Namespace
+	gw.Spec.Selector = map[string]string{"istio": "ingressgateway"}
+	gw.Spec.Servers = servers
+
+	if isCreate {
+		return r.Create(r.ctx, &gw)
+	}
+
+	return r.Update(r.ctx, &gw)
+}
+
+func (r *TcpRouteReconcilerTask) saveVirtualService(route *corev1alpha1.TcpRoute, existing map[string]*v1beta1.VirtualService) error {
+	key := route.Namespace + "/" + getTcpRouteVirtualServiceName(route)
+	spec := buildTcpRouteVirtualServiceSpec(route)
+
+	vs, ok := existing[key]
+	delete(existing, key)
+
+	if !ok {
+		vs = &v1beta1.VirtualService{
+			ObjectMeta: metaV1.ObjectMeta{
+				Namespace: route.Namespace,
+				Name:      getTcpRouteVirtualServiceName(route),
+				Labels: map[string]string{
+					KALM_TCP_ROUTE_LABEL: "true",
+				},
+			},
+			Spec: spec,
+		}
+
+		if err := ctrl.SetControllerReference(route, vs, r.Scheme); err != nil {
+			return err
+		}
+
+		return r.Create(r.ctx, vs)
+	}
+
+	copied := vs.DeepCopy()
+	copied.Spec = spec
+
+	return r.Update(r.ctx, copied)
+}
+
+func (r *TcpRouteReconcilerTask) Run() error {
+	var routeList corev1alpha1.TcpRouteList
+
+	if err := r.Reader.List(r.ctx, &routeList); err != nil {
+		return err
+	}
+
+	var vsList v1beta1.VirtualServiceList
+
+	if err := r.Reader.List(r.ctx, &vsList, client.MatchingLabels{KALM_TCP_ROUTE_LABEL: "true"}); err != nil {
+		return err
+	}
+
+	existing := make(map[string]*v1beta1.VirtualService, len(vsList.Items))
+
+	for i := range vsList.Items {
+		existing[vsList.Items[i].Namespace+"/"+vsList.Items[i].Name] = &vsList.Items[i]
+	}
+
+	routes := make([]*corev1alpha1.TcpRoute, 0, len(routeList.Items))
+
+	for i := range routeList.Items {
+		if routeList.Items[i].DeletionTimestamp == nil {
+			routes = append(routes, &routeList.Items[i])
+		}
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool { return isTcpRouteOlder(routes[i], routes[j]) })
+
+	rejected := acceptTcpRoutes(routes)
+	accepted := make([]*corev1alpha1.TcpRoute, 0, len(routes))
+
+	for _, route := range routes {
+		if _, ok := rejected[route]; !ok {
+			accepted = append(accepted, route)
+		}
+	}
+
+	servers := buildTcpGatewayServers(accepted)
+
+	if err := r.saveGateway(servers); err != nil {
+		r.Log.Error(err, "save tcp gateway error.")
+		return err
+	}
+
+	var reconcileErr error
+
+	for _, route := range accepted {
+		if err := r.saveVirtualService(route, existing); err != nil {
+			r.EmitWarningEvent(route, err, "Save TcpRoute VirtualService Error")
+			rejected[route] = err.Error()
+			reconcileErr = err
+		}
+	}
+
+	// rejected routes and deleted routes
+	for _, vs := range existing {
+		if err := r.Delete(r.ctx, vs); client.IgnoreNotFound(err) != nil {
+			reconcileErr = err
+		}
+	}
+
+	for _, route := range routes {
+		status := corev1alpha1.TcpRouteStatus{Accepted: true}
+
+		if msg, ok := rejected[route]; ok {
+			status = corev1alpha1.TcpRouteStatus{Message: msg}
+		}
+
+		if reflect.DeepEqual(status, route.Status) {
+			continue
+		}
+
+		copied := route.DeepCopy()
+		copied.Status = status
+
+		if err := r.Status().Patch(r.ctx, copied, client.MergeFrom(route)); client.IgnoreNotFound(err) != nil {
+			reconcileErr = err
+		}
+	}
+
+	return reconcileErr
+}
+
+// TcpRouteReconciler reconciles a TcpRoute object
+type TcpRouteReconciler struct {
+	*BaseReconciler
+}
+
+// +kubebuilder:rbac:groups=core.kalm.dev,resources=tcproutes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.kalm.dev,resources=tcproutes/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=networking.istio.io,resources=virtualservices,verbs=*
+// +kubebuilder:rbac:groups=networking.istio.io,resources=gateways,verbs=*
+
+// Reconcile serves all routes at once, ports and the gateway are shared by routes of all namespaces.
+func (r *TcpRouteReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	task := &TcpRouteReconcilerTask{
+		TcpRouteReconciler: r,
+		ctx:                context.Background(),
+	}
+
+	return ctrl.Result{}, task.Run()
+}
+
+func NewTcpRouteReconciler(mgr ctrl.Manager) *TcpRouteReconciler {
+	return &TcpRouteReconciler{NewBaseReconciler(mgr, "TcpRoute")}
+}
+
+type WatchAllKalmTcpRouteObject struct{}
+
+func (*WatchAllKalmTcpRouteObject) Map(object handler.MapObject) []reconcile.Request {
+	if object.Meta.GetLabels()[KALM_TCP_ROUTE_LABEL] != "true" &&
+		!(object.Meta.GetNamespace() == TCP_GATEWAY_NAMESPACED_NAME.Namespace && object.Meta.GetName() == TCP_GATEWAY_NAMESPACED_NAME.Name) {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{}}}
+}
+
+func (r *TcpRouteReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1alpha1.TcpRoute{}).
+		Watches(
+			&source.Kind{Type: &v1beta1.Gateway{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: &WatchAllKalmTcpRouteObject{},
+			},
+		).
+		Watches(
+			&source.Kind{Type: &v1beta1.VirtualService{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: &WatchAllKalmTcpRouteObject{},
+			},
+		).
+		Complete(r)
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kalmhq/kalm/controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	istioNetworkingV1Beta1 "istio.io/api/networking/v1beta1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestTcpRoute(namespace, name string, port int, protocol v1alpha1.TcpRouteProtocol, hosts ...string) *v1alpha1.TcpRoute {
+	return &v1alpha1.TcpRoute{
+		ObjectMeta: metaV1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: v1alpha1.TcpRouteSpec{
+			Port:         port,
+			Protocol:     protocol,
+			Hosts:        hosts,
+			Destinations: []v1alpha1.TcpRouteDestination{{Host: name + ":" + "5432", Weight: 1}},
+		},
+	}
+}
+
+func TestAcceptTcpRoutes(t *testing.T) {
+	postgres := newTestTcpRoute("db", "postgres", 5432, v1alpha1.TcpRouteProtocolTCP)
+	postgres2 := newTestTcpRoute("db2", "postgres", 5432, v1alpha1.TcpRouteProtocolTCP)
+	mqtt := newTestTcpRoute("mqtt", "broker", 8883, v1alpha1.TcpRouteProtocolTLS, "mqtt.example.com")
+	mqtt2 := newTestTcpRoute("mqtt2", "broker", 8883, v1alpha1.TcpRouteProtocolTLS, "mqtt2.example.com")
+	mqtt3 := newTestTcpRoute("mqtt3", "broker", 8883, v1alpha1.TcpRouteProtocolTLS, "mqtt.example.com")
+
+	postgres2.CreationTimestamp = metaV1.NewTime(time.Now())
+	assert.True(t, isTcpRouteOlder(postgres, postgres2))
+
+	rejected := acceptTcpRoutes([]*v1alpha1.TcpRoute{postgres, mqtt, postgres2, mqtt2, mqtt3})
+
+	assert.Equal(t, map[*v1alpha1.TcpRoute]string{
+		postgres2: "port 5432 is already routed by db/postgres",
+		mqtt3:     "mqtt.example.com on port 8883 is already routed by mqtt/broker",
+	}, rejected)
+
+	servers := buildTcpGatewayServers([]*v1alpha1.TcpRoute{mqtt, postgres, mqtt2})
+
+	assert.Equal(t, []*istioNetworkingV1Beta1.Server{
+		{
+			Port:  &istioNetworkingV1Beta1.Port{Number: 5432, Protocol: "TCP", Name: "tcp-5432"},
+			Hosts: []string{"*"},
+		},
+		{
+			Port:  &istioNetworkingV1Beta1.Port{Number: 8883, Protocol: "TLS", Name: "tls-8883"},
+			Hosts: []string{"mqtt.example.com", "mqtt2.example.com"},
+			Tls:   &istioNetworkingV1Beta1.ServerTLSSettings{Mode: istioNetworkingV1Beta1.ServerTLSSettings_PASSTHROUGH},
+		},
+	}, servers)
+}
+
+func TestBuildTcpRouteVirtualServiceSpec(t *testing.T) {
+	route := newTestTcpRoute("db", "postgres", 5432, v1alpha1.TcpRouteProtocolTCP)
+	route.Spec.Destinations = append(route.Spec.Destinations, v1alpha1.TcpRouteDestination{Host: "postgres-replica:5432", Weight: 1})
+
+	spec := buildTcpRouteVirtualServiceSpec(route)
+
+	assert.Equal(t, []string{"*"}, spec.Hosts)
+	assert.Empty(t, spec.Tls)
+	assert.Equal(t, uint32(5432), spec.Tcp[0].Match[0].Port)
+	assert.Equal(t, []string{"istio-system/kalm-tcp-gateway"}, spec.Tcp[0].Match[0].Gateways)
+	assert.Equal(t, "postgres.db.svc.cluster.local", spec.Tcp[0].Route[0].Destination.Host)
+	assert.Equal(t, uint32(5432), spec.Tcp[0].Route[0].Destination.Port.Number)
+	assert.Equal(t, int32(50), spec.Tcp[0].Route[0].Weight)
+	assert.Equal(t, int32(50), spec.Tcp[0].Route[1].Weight)
+
+	route = newTestTcpRoute("mqtt", "broker", 8883, v1alpha1.TcpRouteProtocolTLS, "mqtt.example.com")
+	spec = buildTcpRouteVirtualServiceSpec(route)
+
+	assert.Empty(t, spec.Tcp)
+	assert.Equal(t, []string{"mqtt.example.com"}, spec.Hosts)
+	assert.Equal(t, []string{"mqtt.example.com"}, spec.Tls[0].Match[0].SniHosts)
+	assert.Equal(t, uint32(8883), spec.Tls[0].Match[0].Port)
+}
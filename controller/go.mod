@@ -3,6 +3,7 @@ module github.com/kalmhq/kalm/controller
 go 1.13
 
 require (
+	github.com/Masterminds/semver/v3 v3.1.0
 	github.com/coreos/prometheus-operator v0.29.0
 	github.com/dlclark/regexp2 v1.2.0 // indirect
 	github.com/docker/distribution v0.0.0-20171011171712-7484e51bf6af
@@ -18,6 +18,7 @@ package main
 import (
 	"flag"
 	"os"
+	"time"
 
 	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	elkv1 "github.com/elastic/cloud-on-k8s/pkg/apis/elasticsearch/v1"
@@ -34,6 +35,7 @@ import (
 
 	corev1alpha1 "github.com/kalmhq/kalm/controller/api/v1alpha1"
 	"github.com/kalmhq/kalm/controller/controllers"
+	"github.com/kalmhq/kalm/controller/vm"
 
 	cmv1alpha2 "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha2"
 	apiregistration "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
@@ -77,8 +79,24 @@ func main() {
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	var pluginTimeout time.Duration
+	var pluginMemoryLimitMB uint64
+	flag.DurationVar(&pluginTimeout, "plugin-timeout", vm.GetDefaultLimits().Timeout,
+		"Max execution time of a component plugin method, 0 means no limit.")
+	flag.Uint64Var(&pluginMemoryLimitMB, "plugin-memory-limit-mb", vm.GetDefaultLimits().MaxMemoryBytes>>20,
+		"Approximate max heap growth in MB of the whole process during a component plugin method, 0 means no limit. Off by default.")
+	var localGitRepoRoot string
+	flag.StringVar(&localGitRepoRoot, "local-git-repo-root", "",
+		"Directory on the controller filesystem that application sources can sync local repositories from. Local repositories are not allowed if it's empty.")
 	flag.Parse()
 
+	corev1alpha1.SetLocalGitRepoRoot(localGitRepoRoot)
+
+	pluginLimits := vm.GetDefaultLimits()
+	pluginLimits.Timeout = pluginTimeout
+	pluginLimits.MaxMemoryBytes = pluginMemoryLimitMB << 20
+	vm.SetDefaultLimits(pluginLimits)
+
 	ctrl.SetLogger(zap.New(func(o *zap.Options) {
 		o.Development = true
 	}))
@@ -116,6 +134,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = controllers.NewSecretStoreReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SecretStore")
+		os.Exit(1)
+	}
+
+	if err = controllers.NewApplicationSourceReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ApplicationSource")
+		os.Exit(1)
+	}
+
+	if err = controllers.NewPreviewEnvironmentReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "PreviewEnvironment")
+		os.Exit(1)
+	}
+
 	if err = controllers.NewHttpsCertIssuerReconciler(mgr).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "HttpsCertIssuer")
 		os.Exit(1)
@@ -135,6 +168,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = controllers.NewTcpRouteReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "TcpRoute")
+		os.Exit(1)
+	}
+
 	if err = controllers.NewGatewayReconciler(mgr).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Gateway")
 		os.Exit(1)
@@ -207,6 +245,16 @@ func main() {
 			os.Exit(1)
 		}
 
+		if err = (&corev1alpha1.TcpRoute{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "TcpRoute")
+			os.Exit(1)
+		}
+
+		if err = (&corev1alpha1.ApplicationSource{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ApplicationSource")
+			os.Exit(1)
+		}
+
 		if err = (&corev1alpha1.HttpsCert{}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "HttpsCert")
 			os.Exit(1)
@@ -0,0 +1,91 @@
+package gitrepo
+
+// This package keeps local checkouts of git repositories for ApplicationSources.
+// It runs the git command, so git should be installed in the image of the controller.
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Checkout clones the repository into dir on first use, later calls fetch the updates.
+// The revision, a branch, tag or commit, is checked out and the sha of the commit is returned.
+// The default branch is used if the revision is empty.
+func Checkout(ctx context.Context, repo, revision, dir string) (string, error) {
+	if _, err := os.Stat(dir); err == nil {
+		url, err := run(ctx, dir, "config", "--get", "remote.origin.url")
+
+		// the repo of the source is changed, or the previous clone is broken
+		if err != nil || url != repo {
+			if err := os.RemoveAll(dir); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if _, err := run(ctx, "", "clone", "--quiet", "--no-checkout", "--", repo, dir); err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+	} else if _, err := run(ctx, dir, "fetch", "--quiet", "--prune", "--tags", "--force", "origin"); err != nil {
+		return "", err
+	}
+
+	sha, err := resolve(ctx, dir, revision)
+
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := run(ctx, dir, "checkout", "--quiet", "--force", "--detach", sha); err != nil {
+		return "", err
+	}
+
+	return sha, nil
+}
+
+func resolve(ctx context.Context, dir, revision string) (string, error) {
+	candidates := []string{"origin/HEAD"}
+
+	if revision != "" {
+		// remote branches are preferred, local branches are not updated by fetch
+		candidates = []string{"origin/" + revision, revision}
+	}
+
+	for _, candidate := range candidates {
+		if sha, err := run(ctx, dir, "rev-parse", "--verify", "--quiet", candidate+"^{commit}"); err == nil {
+			return sha, nil
+		}
+	}
+
+	return "", fmt.Errorf("revision %s is not found", revision)
+}
+
+func run(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+
+	// never wait for credentials
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+
+		if msg == "" {
+			msg = err.Error()
+		}
+
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
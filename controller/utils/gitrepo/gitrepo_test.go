@@ -0,0 +1,61 @@
+package gitrepo
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func commitFile(t *testing.T, repo, name, content string) string {
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(repo, name), []byte(content), 0644))
+
+	_, err := run(context.Background(), repo, "add", name)
+	assert.Nil(t, err)
+
+	_, err = run(context.Background(), repo, "-c", "user.name=test", "-c", "user.email=test@kalm.dev", "commit", "--quiet", "-m", name)
+	assert.Nil(t, err)
+
+	sha, err := run(context.Background(), repo, "rev-parse", "HEAD")
+	assert.Nil(t, err)
+
+	return sha
+}
+
+func TestCheckout(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "gitrepo")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tmp)
+
+	repo := filepath.Join(tmp, "repo")
+	checkout := filepath.Join(tmp, "checkout")
+
+	_, err = run(context.Background(), "", "init", "--quiet", repo)
+	assert.Nil(t, err)
+
+	first := commitFile(t, repo, "a.yaml", "a")
+
+	sha, err := Checkout(context.Background(), repo, "", checkout)
+	assert.Nil(t, err)
+	assert.Equal(t, first, sha)
+
+	second := commitFile(t, repo, "b.yaml", "b")
+
+	sha, err = Checkout(context.Background(), "file://"+repo, "", checkout)
+	assert.Nil(t, err)
+	assert.Equal(t, second, sha)
+	assert.FileExists(t, filepath.Join(checkout, "b.yaml"))
+
+	sha, err = Checkout(context.Background(), "file://"+repo, first, checkout)
+	assert.Nil(t, err)
+	assert.Equal(t, first, sha)
+
+	_, err = os.Stat(filepath.Join(checkout, "b.yaml"))
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = Checkout(context.Background(), "file://"+repo, "not-exist", checkout)
+	assert.NotNil(t, err)
+}
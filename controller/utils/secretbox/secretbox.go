@@ -0,0 +1,96 @@
+package secretbox
+
+// This package encrypts values of SecretStores with AES-256-GCM.
+// Encrypted values are "v1:" followed by base64 of nonce and ciphertext,
+// the version prefix leaves room for changing the algorithm later.
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	KeySize = 32
+
+	prefixV1 = "v1:"
+)
+
+// GenerateKey returns a new random key.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key size %d, expected %d", len(key), KeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Encrypt encrypts the plaintext with the key, a random nonce is used for each call.
+func Encrypt(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return prefixV1 + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts values returned by Encrypt, it fails if the value is encrypted with another key or is modified.
+func Decrypt(key []byte, encrypted string) (string, error) {
+	if !strings.HasPrefix(encrypted, prefixV1) {
+		return "", fmt.Errorf("unknown encryption format")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, prefixV1))
+
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newGCM(key)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("encrypted value is too short")
+	}
+
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
@@ -0,0 +1,55 @@
+package secretbox
+
+import (
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	key, err := GenerateKey()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := Encrypt(key, "p@ssw0rd")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if encrypted == "p@ssw0rd" {
+		t.Fatal("value is not encrypted")
+	}
+
+	if again, _ := Encrypt(key, "p@ssw0rd"); again == encrypted {
+		t.Fatal("nonce should be random")
+	}
+
+	plaintext, err := Decrypt(key, encrypted)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plaintext != "p@ssw0rd" {
+		t.Fatalf("expected p@ssw0rd, got %s", plaintext)
+	}
+
+	otherKey, _ := GenerateKey()
+
+	if _, err := Decrypt(otherKey, encrypted); err == nil {
+		t.Fatal("should fail with another key")
+	}
+
+	if _, err := Decrypt(key, encrypted[:len(encrypted)-4]+"AAAA"); err == nil {
+		t.Fatal("should fail if the value is modified")
+	}
+
+	if _, err := Decrypt(key, "p@ssw0rd"); err == nil {
+		t.Fatal("should fail for plain values")
+	}
+
+	if _, err := Encrypt(key[:16], "p@ssw0rd"); err == nil {
+		t.Fatal("should fail for invalid keys")
+	}
+}
@@ -0,0 +1,196 @@
+package vm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	js "github.com/dop251/goja"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+// Host provides read only access to the cluster for plugins.
+// Implementations are responsible for checking permissions of the plugin on each call.
+type Host interface {
+	GetComponent() (interface{}, error)
+	GetNamespaceLabels() (map[string]string, error)
+	GetSecret(name string) (map[string]string, error)
+	GetConfigMap(name string) (map[string]string, error)
+	Log(level, msg string, fields map[string]interface{})
+}
+
+// InitRuntimeWithHost returns a runtime with the host functions of the curated host api.
+// Errors of host functions are thrown as javascript exceptions, plugins can catch them.
+func InitRuntimeWithHost(host Host) *js.Runtime {
+	runtime := InitRuntime()
+
+	runtime.Set("getComponent", func(call js.FunctionCall) js.Value {
+		res, err := host.GetComponent()
+		return hostResult(runtime, res, err)
+	})
+
+	runtime.Set("getNamespaceLabels", func(call js.FunctionCall) js.Value {
+		res, err := host.GetNamespaceLabels()
+		return hostResult(runtime, res, err)
+	})
+
+	runtime.Set("getSecret", func(call js.FunctionCall) js.Value {
+		res, err := host.GetSecret(call.Argument(0).String())
+		return hostResult(runtime, res, err)
+	})
+
+	runtime.Set("getConfigMap", func(call js.FunctionCall) js.Value {
+		res, err := host.GetConfigMap(call.Argument(0).String())
+		return hostResult(runtime, res, err)
+	})
+
+	log := runtime.NewObject()
+
+	for _, level := range []string{LogLevelInfo, LogLevelWarn, LogLevelError} {
+		level := level
+
+		_ = log.Set(level, func(call js.FunctionCall) js.Value {
+			var fields map[string]interface{}
+
+			if obj, ok := call.Argument(1).Export().(map[string]interface{}); ok {
+				fields = obj
+			}
+
+			host.Log(level, call.Argument(0).String(), fields)
+			return js.Undefined()
+		})
+	}
+
+	runtime.Set("log", log)
+
+	return runtime
+}
+
+// initHelpers sets pure helper functions, which don't access the cluster.
+func initHelpers(runtime *js.Runtime) {
+	runtime.Set("parseQuantity", func(call js.FunctionCall) js.Value {
+		quantity, err := resource.ParseQuantity(call.Argument(0).String())
+
+		if err != nil {
+			panic(runtime.NewGoError(err))
+		}
+
+		return runtime.ToValue(map[string]interface{}{
+			"string":     quantity.String(),
+			"value":      quantity.Value(),
+			"milliValue": quantity.MilliValue(),
+		})
+	})
+
+	runtime.Set("compareQuantity", func(call js.FunctionCall) js.Value {
+		a, err := resource.ParseQuantity(call.Argument(0).String())
+
+		if err != nil {
+			panic(runtime.NewGoError(err))
+		}
+
+		b, err := resource.ParseQuantity(call.Argument(1).String())
+
+		if err != nil {
+			panic(runtime.NewGoError(err))
+		}
+
+		return runtime.ToValue(a.Cmp(b))
+	})
+
+	runtime.Set("compareSemver", func(call js.FunctionCall) js.Value {
+		a, err := semver.NewVersion(call.Argument(0).String())
+
+		if err != nil {
+			panic(runtime.NewGoError(err))
+		}
+
+		b, err := semver.NewVersion(call.Argument(1).String())
+
+		if err != nil {
+			panic(runtime.NewGoError(err))
+		}
+
+		return runtime.ToValue(a.Compare(b))
+	})
+
+	runtime.Set("satisfiesSemver", func(call js.FunctionCall) js.Value {
+		version, err := semver.NewVersion(call.Argument(0).String())
+
+		if err != nil {
+			panic(runtime.NewGoError(err))
+		}
+
+		constraint, err := semver.NewConstraint(call.Argument(1).String())
+
+		if err != nil {
+			panic(runtime.NewGoError(err))
+		}
+
+		return runtime.ToValue(constraint.Check(version))
+	})
+
+	runtime.Set("base64Encode", func(call js.FunctionCall) js.Value {
+		return runtime.ToValue(base64.StdEncoding.EncodeToString([]byte(call.Argument(0).String())))
+	})
+
+	runtime.Set("base64Decode", func(call js.FunctionCall) js.Value {
+		bts, err := base64.StdEncoding.DecodeString(call.Argument(0).String())
+
+		if err != nil {
+			panic(runtime.NewGoError(err))
+		}
+
+		return runtime.ToValue(string(bts))
+	})
+
+	runtime.Set("parseYaml", func(call js.FunctionCall) js.Value {
+		var res interface{}
+
+		if err := yaml.Unmarshal([]byte(call.Argument(0).String()), &res); err != nil {
+			panic(runtime.NewGoError(err))
+		}
+
+		// convert to the same types as json values, e.g. all numbers are float64
+		return hostResult(runtime, res, nil)
+	})
+
+	runtime.Set("stringifyYaml", func(call js.FunctionCall) js.Value {
+		bts, err := yaml.Marshal(call.Argument(0).Export())
+
+		if err != nil {
+			panic(runtime.NewGoError(err))
+		}
+
+		return runtime.ToValue(string(bts))
+	})
+}
+
+// hostResult converts the result to plain javascript values through json, or throws the error.
+func hostResult(runtime *js.Runtime, res interface{}, err error) js.Value {
+	if err != nil {
+		panic(runtime.NewGoError(err))
+	}
+
+	bts, err := json.Marshal(res)
+
+	if err != nil {
+		panic(runtime.NewGoError(fmt.Errorf("marshal host result error: %s", err.Error())))
+	}
+
+	var value interface{}
+
+	if err := json.Unmarshal(bts, &value); err != nil {
+		panic(runtime.NewGoError(err))
+	}
+
+	return runtime.ToValue(value)
+}
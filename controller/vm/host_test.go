@@ -0,0 +1,105 @@
+package vm
+
+import (
+	"fmt"
+)
+
+type fakeHost struct {
+	secrets map[string]map[string]string
+	logs    []string
+}
+
+func (h *fakeHost) GetComponent() (interface{}, error) {
+	return map[string]interface{}{"metadata": map[string]interface{}{"name": "web"}}, nil
+}
+
+func (h *fakeHost) GetNamespaceLabels() (map[string]string, error) {
+	return map[string]string{"env": "prod"}, nil
+}
+
+func (h *fakeHost) GetSecret(name string) (map[string]string, error) {
+	if secret, exist := h.secrets[name]; exist {
+		return secret, nil
+	}
+
+	return nil, fmt.Errorf("no permission to get secrets %s", name)
+}
+
+func (h *fakeHost) GetConfigMap(name string) (map[string]string, error) {
+	return nil, fmt.Errorf("no permission to get configmaps %s", name)
+}
+
+func (h *fakeHost) Log(level, msg string, fields map[string]interface{}) {
+	h.logs = append(h.logs, fmt.Sprintf("%s %s %v", level, msg, fields))
+}
+
+func (suite *VmTestSuite) TestHelpers() {
+	runtime := InitRuntime()
+	program, _ := CompileProgram(`
+function test() {
+	var obj = parseYaml("a: 1\nb: [x, y]\n");
+	return {
+		quantity: parseQuantity("500m").milliValue,
+		compareQuantity: compareQuantity("1Gi", "1000Mi"),
+		compareSemver: compareSemver("1.2.3", "1.10.0"),
+		satisfiesSemver: satisfiesSemver("1.2.3", "^1.0.0"),
+		base64: base64Decode(base64Encode("kalm")),
+		yaml: obj.b[1],
+		stringifyYaml: stringifyYaml({a: 1}),
+	};
+}
+`)
+
+	var res map[string]interface{}
+	err := RunMethod(runtime, program, "test", nil, &res)
+	suite.Nil(err)
+	suite.Equal(map[string]interface{}{
+		"quantity":        float64(500),
+		"compareQuantity": float64(1),
+		"compareSemver":   float64(-1),
+		"satisfiesSemver": true,
+		"base64":          "kalm",
+		"yaml":            "y",
+		"stringifyYaml":   "a: 1\n",
+	}, res)
+
+	program, _ = CompileProgram(`
+function test() {
+	try {
+		parseQuantity("abc");
+	} catch (e) {
+		return "caught";
+	}
+}
+`)
+
+	var caught string
+	err = RunMethod(runtime, program, "test", nil, &caught)
+	suite.Nil(err)
+	suite.Equal("caught", caught)
+}
+
+func (suite *VmTestSuite) TestHost() {
+	host := &fakeHost{secrets: map[string]map[string]string{"db": {"password": "123"}}}
+	runtime := InitRuntimeWithHost(host)
+	program, _ := CompileProgram(`
+function test(secretName) {
+	log.info("reading secret", {name: secretName});
+	return {
+		component: getComponent().metadata.name,
+		env: getNamespaceLabels().env,
+		password: getSecret(secretName).password,
+	};
+}
+`)
+
+	var res map[string]interface{}
+	err := RunMethod(runtime, program, "test", nil, &res, "db")
+	suite.Nil(err)
+	suite.Equal(map[string]interface{}{"component": "web", "env": "prod", "password": "123"}, res)
+	suite.Equal([]string{"info reading secret map[name:db]"}, host.logs)
+
+	err = RunMethod(runtime, program, "test", nil, &res, "other")
+	suite.NotNil(err)
+	suite.Contains(err.Error(), "no permission to get secrets other")
+}
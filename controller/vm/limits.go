@@ -0,0 +1,168 @@
+package vm
+
+import (
+	"fmt"
+	goRuntime "runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	js "github.com/dop251/goja"
+)
+
+// Limits restricts the resources a single plugin method invocation can use.
+// goja can't count instructions, the timeout is the cpu budget of an invocation.
+type Limits struct {
+	// zero means no timeout
+	Timeout time.Duration
+
+	// The runtime shares the heap with the controller, so the memory usage is approximated by
+	// the heap growth of the whole process during the invocation, sampled every MemorySampleInterval.
+	// Allocations of other goroutines and concurrent invocations are counted as well, and every sample
+	// stops the world to read the mem stats. It's only a safety net against runaway plugins.
+	// zero means no limit, which is the default
+	MaxMemoryBytes uint64
+
+	MemorySampleInterval time.Duration
+}
+
+var (
+	defaultLimitsMut sync.RWMutex
+	defaultLimits    = Limits{
+		Timeout:              3 * time.Second,
+		MemorySampleInterval: 50 * time.Millisecond,
+	}
+)
+
+func SetDefaultLimits(limits Limits) {
+	defaultLimitsMut.Lock()
+	defer defaultLimitsMut.Unlock()
+	defaultLimits = limits
+}
+
+func GetDefaultLimits() Limits {
+	defaultLimitsMut.RLock()
+	defer defaultLimitsMut.RUnlock()
+	return defaultLimits
+}
+
+// TimeoutError is returned when a plugin method runs longer than the timeout
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("plugin execution timeout after %s", e.Timeout)
+}
+
+// MemoryLimitError is returned when the process heap grows more than the memory limit during a plugin method
+type MemoryLimitError struct {
+	Limit uint64
+	Used  uint64
+}
+
+func (e *MemoryLimitError) Error() string {
+	return fmt.Sprintf("plugin execution exceeded memory limit, used %d bytes, limit %d bytes", e.Used, e.Limit)
+}
+
+// PanicError is returned when the plugin runtime or a host function panics
+type PanicError struct {
+	Value interface{}
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("plugin execution panic: %v", e.Value)
+}
+
+// IsLimitError returns true if the error is caused by limits or panics instead of the plugin code itself.
+func IsLimitError(err error) bool {
+	switch err.(type) {
+	case *TimeoutError, *MemoryLimitError, *PanicError:
+		return true
+	}
+
+	return false
+}
+
+// runWithLimits runs the program in the runtime, interrupts it once any of the limits is exceeded.
+// Panics are recovered and returned as PanicError, so a bad plugin can't crash the controller.
+func runWithLimits(runtime *js.Runtime, program *js.Program, limits Limits) (res js.Value, err error) {
+	done := make(chan struct{})
+	watcherExited := make(chan struct{})
+
+	go func() {
+		defer close(watcherExited)
+		watchLimits(runtime, limits, done)
+	}()
+
+	defer func() {
+		close(done)
+		<-watcherExited
+
+		// the runtime may be reused by the caller
+		runtime.ClearInterrupt()
+
+		if r := recover(); r != nil {
+			res = nil
+			err = &PanicError{Value: r, Stack: string(debug.Stack())}
+		}
+	}()
+
+	res, err = runtime.RunProgram(program)
+
+	if interrupted, ok := err.(*js.InterruptedError); ok {
+		if limitErr, ok := interrupted.Value().(error); ok {
+			err = limitErr
+		}
+	}
+
+	return res, err
+}
+
+func watchLimits(runtime *js.Runtime, limits Limits, done chan struct{}) {
+	var timeout <-chan time.Time
+
+	if limits.Timeout > 0 {
+		timer := time.NewTimer(limits.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var sample <-chan time.Time
+	var startHeap uint64
+
+	if limits.MaxMemoryBytes > 0 {
+		interval := limits.MemorySampleInterval
+
+		if interval <= 0 {
+			interval = 50 * time.Millisecond
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		sample = ticker.C
+		startHeap = heapAlloc()
+	}
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-timeout:
+			runtime.Interrupt(&TimeoutError{Timeout: limits.Timeout})
+			return
+		case <-sample:
+			if used := heapAlloc(); used > startHeap && used-startHeap > limits.MaxMemoryBytes {
+				runtime.Interrupt(&MemoryLimitError{Limit: limits.MaxMemoryBytes, Used: used - startHeap})
+				return
+			}
+		}
+	}
+}
+
+func heapAlloc() uint64 {
+	var stats goRuntime.MemStats
+	goRuntime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
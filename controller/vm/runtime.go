@@ -7,6 +7,7 @@ import (
 
 func initRuntime(runtime *js.Runtime) {
 	initConsole(runtime)
+	initHelpers(runtime)
 	runtime.Set("global", runtime.GlobalObject())
 }
 
@@ -29,7 +30,7 @@ func GetDefinedMethods(src string, methods []string) (map[string]bool, error) {
 
 	runtime := InitRuntime()
 	runtime.Set("__methods", methods)
-	res, err := runtime.RunProgram(program)
+	res, err := runWithLimits(runtime, program, GetDefaultLimits())
 
 	if err != nil {
 		return nil, err
@@ -44,7 +45,12 @@ func GetDefinedMethods(src string, methods []string) (map[string]bool, error) {
 	return tmp, nil
 }
 
+// RunMethod runs the method with default limits
 func RunMethod(runtime *js.Runtime, program *js.Program, methodName string, config []byte, dest interface{}, args ...interface{}) error {
+	return RunMethodWithLimits(runtime, program, GetDefaultLimits(), methodName, config, dest, args...)
+}
+
+func RunMethodWithLimits(runtime *js.Runtime, program *js.Program, limits Limits, methodName string, config []byte, dest interface{}, args ...interface{}) error {
 	runtime.Set("__targetMethodName", methodName)
 
 	if args != nil {
@@ -57,8 +63,9 @@ func RunMethod(runtime *js.Runtime, program *js.Program, methodName string, conf
 			case string, int, int8, int16, int32, int64, float32, float64, uint, uint8, uint16, uint32, uint64, bool:
 				_args = append(_args, val)
 			default:
+				// objects become maps, slices become arrays
 				bts, _ := json.Marshal(arg)
-				obj := make(map[string]interface{})
+				var obj interface{}
 				_ = json.Unmarshal(bts, &obj)
 				_args = append(_args, obj)
 			}
@@ -79,7 +86,7 @@ func RunMethod(runtime *js.Runtime, program *js.Program, methodName string, conf
 		return runtime.ToValue(res)
 	})
 
-	res, err := runtime.RunProgram(program)
+	res, err := runWithLimits(runtime, program, limits)
 
 	if err != nil {
 		return err
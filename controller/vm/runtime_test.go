@@ -1,9 +1,11 @@
 package vm
 
 import (
+	js "github.com/dop251/goja"
 	"github.com/stretchr/testify/suite"
 	"strings"
 	"testing"
+	"time"
 )
 
 type VmTestSuite struct {
@@ -108,3 +110,73 @@ func (suite *VmTestSuite) TestBadSourceCode() {
 func TestVmSuite(t *testing.T) {
 	suite.Run(t, new(VmTestSuite))
 }
+
+func (suite *VmTestSuite) TestTimeout() {
+	runtime := InitRuntime()
+	program, _ := CompileProgram(`
+function infiniteLoop() {
+	while(true) {}
+}
+
+function ok() {
+	return "ok";
+}
+`)
+
+	err := RunMethodWithLimits(runtime, program, Limits{Timeout: 100 * time.Millisecond}, "infiniteLoop", nil, nil)
+	suite.IsType(&TimeoutError{}, err)
+	suite.True(IsLimitError(err))
+
+	// the runtime is still usable after being interrupted
+	var res string
+	err = RunMethodWithLimits(runtime, program, Limits{Timeout: 100 * time.Millisecond}, "ok", nil, &res)
+	suite.Nil(err)
+	suite.Equal("ok", res)
+}
+
+func (suite *VmTestSuite) TestMemoryLimit() {
+	runtime := InitRuntime()
+	program, _ := CompileProgram(`
+function allocate() {
+	var arr = [];
+	while(true) {
+		arr.push("some string to fill the memory " + arr.length);
+	}
+}
+`)
+
+	err := RunMethodWithLimits(runtime, program, Limits{
+		Timeout:              10 * time.Second,
+		MaxMemoryBytes:       16 << 20,
+		MemorySampleInterval: 10 * time.Millisecond,
+	}, "allocate", nil, nil)
+
+	suite.IsType(&MemoryLimitError{}, err)
+}
+
+func (suite *VmTestSuite) TestPanicIsolation() {
+	runtime := InitRuntime()
+	runtime.Set("hostPanic", func(call js.FunctionCall) js.Value {
+		panic("host function panic")
+	})
+
+	program, _ := CompileProgram(`
+function callHost() {
+	hostPanic();
+}
+`)
+
+	err := RunMethod(runtime, program, "callHost", nil, nil)
+	suite.IsType(&PanicError{}, err)
+	suite.True(strings.Contains(err.Error(), "host function panic"))
+}
+
+func (suite *VmTestSuite) TestGetDefinedMethodsTimeout() {
+	limits := GetDefaultLimits()
+	defer SetDefaultLimits(limits)
+
+	SetDefaultLimits(Limits{Timeout: 100 * time.Millisecond})
+
+	_, err := GetDefinedMethods(`while(true) {}`, []string{"foo"})
+	suite.IsType(&TimeoutError{}, err)
+}
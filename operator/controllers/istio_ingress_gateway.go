@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	installv1alpha1 "github.com/kalmhq/kalm/operator/api/v1alpha1"
+	istioNetworkingV1beta1 "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	IstioIngressGatewayName = "istio-ingressgateway"
+
+	// the gateway of tcp routes, servers are kept by the kalm controller
+	KalmTcpGatewayName = "kalm-tcp-gateway"
+
+	// ports of the ingress gateway service opened for tcp routes are named with this prefix
+	TcpRouteServicePortPrefix = "tcp-kalm-"
+)
+
+// getIngressGatewayServicePorts returns ports of the ingress gateway service with ports of tcp routes.
+// Ports of the service are replaced by the istio operator, so they are declared in the IstioOperator.
+// Nil is returned if there is no tcp route, ports of the istio profile are used.
+func (r *KalmOperatorConfigReconciler) getIngressGatewayServicePorts(ctx context.Context) ([]corev1.ServicePort, error) {
+	var gateway v1beta1.Gateway
+
+	if err := r.Get(ctx, types.NamespacedName{Namespace: NamespaceIstio, Name: KalmTcpGatewayName}, &gateway); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if len(gateway.Spec.Servers) == 0 {
+		return nil, nil
+	}
+
+	var service corev1.Service
+
+	// ports are added once the ingress gateway is installed, so ports of the profile are kept
+	if err := r.Get(ctx, types.NamespacedName{Namespace: NamespaceIstio, Name: IstioIngressGatewayName}, &service); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return buildIngressGatewayServicePorts(service.Spec.Ports, gateway.Spec.Servers), nil
+}
+
+// buildIngressGatewayServicePorts replaces ports opened for tcp routes, other ports of the service are kept.
+// Node ports already assigned by kubernetes are kept, so they don't change when ports are declared.
+func buildIngressGatewayServicePorts(current []corev1.ServicePort, servers []*istioNetworkingV1beta1.Server) []corev1.ServicePort {
+	res := make([]corev1.ServicePort, 0, len(current)+len(servers))
+	nodePorts := make(map[string]int32, len(current))
+
+	for _, port := range current {
+		nodePorts[port.Name] = port.NodePort
+
+		if strings.HasPrefix(port.Name, TcpRouteServicePortPrefix) {
+			continue
+		}
+
+		res = append(res, corev1.ServicePort{
+			Name:       port.Name,
+			Protocol:   port.Protocol,
+			Port:       port.Port,
+			TargetPort: port.TargetPort,
+			NodePort:   port.NodePort,
+		})
+	}
+
+	for _, server := range servers {
+		if server.Port == nil {
+			continue
+		}
+
+		name := fmt.Sprintf("%s%d", TcpRouteServicePortPrefix, server.Port.Number)
+
+		res = append(res, corev1.ServicePort{
+			Name:       name,
+			Protocol:   corev1.ProtocolTCP,
+			Port:       int32(server.Port.Number),
+			TargetPort: intstr.FromInt(int(server.Port.Number)),
+			NodePort:   nodePorts[name],
+		})
+	}
+
+	return res
+}
+
+// isServicePortsEqual compares name, port and target port.
+// Node ports and default values are filled by kubernetes, they are ignored.
+func isServicePortsEqual(a, b []corev1.ServicePort) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Name != b[i].Name || a[i].Port != b[i].Port || a[i].TargetPort != b[i].TargetPort {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setIngressGatewayServicePorts declares ports in the ingress gateway of the IstioOperator.
+// Ports declared in the current IstioOperator are kept if they are equal, to not update the istio installation.
+func setIngressGatewayServicePorts(desired, current runtime.Object, ports []corev1.ServicePort) error {
+	istioOperator, ok := desired.(*installv1alpha1.IstioOperator)
+
+	if !ok || len(ports) == 0 {
+		return nil
+	}
+
+	if currentIstioOperator, ok := current.(*installv1alpha1.IstioOperator); ok && len(currentIstioOperator.Spec.Raw) > 0 {
+		currentSpec := map[string]interface{}{}
+
+		if err := json.Unmarshal(currentIstioOperator.Spec.Raw, &currentSpec); err != nil {
+			return err
+		}
+
+		if declared, err := getDeclaredIngressGatewayServicePorts(currentSpec); err != nil {
+			return err
+		} else if isServicePortsEqual(declared, ports) {
+			ports = declared
+		}
+	}
+
+	spec := map[string]interface{}{}
+
+	if len(istioOperator.Spec.Raw) > 0 {
+		if err := json.Unmarshal(istioOperator.Spec.Raw, &spec); err != nil {
+			return err
+		}
+	}
+
+	getOrCreateMap(getOrCreateMap(getIngressGatewaySpec(spec), "k8s"), "service")["ports"] = ports
+
+	raw, err := json.Marshal(spec)
+
+	if err != nil {
+		return err
+	}
+
+	istioOperator.Spec.Raw = raw
+
+	return nil
+}
+
+func getDeclaredIngressGatewayServicePorts(spec map[string]interface{}) ([]corev1.ServicePort, error) {
+	service, _ := getOrCreateMap(getIngressGatewaySpec(spec), "k8s")["service"].(map[string]interface{})
+
+	if service == nil || service["ports"] == nil {
+		return nil, nil
+	}
+
+	bts, err := json.Marshal(service["ports"])
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ports []corev1.ServicePort
+
+	if err := json.Unmarshal(bts, &ports); err != nil {
+		return nil, err
+	}
+
+	return ports, nil
+}
+
+// getIngressGatewaySpec returns the istio-ingressgateway in spec.components.ingressGateways, it's added if missing.
+func getIngressGatewaySpec(spec map[string]interface{}) map[string]interface{} {
+	components := getOrCreateMap(spec, "components")
+	gateways, _ := components["ingressGateways"].([]interface{})
+
+	for _, item := range gateways {
+		if gateway, ok := item.(map[string]interface{}); ok && gateway["name"] == IstioIngressGatewayName {
+			return gateway
+		}
+	}
+
+	gateway := map[string]interface{}{"name": IstioIngressGatewayName, "enabled": true}
+	components["ingressGateways"] = append(gateways, gateway)
+
+	return gateway
+}
+
+func getOrCreateMap(m map[string]interface{}, key string) map[string]interface{} {
+	if value, ok := m[key].(map[string]interface{}); ok {
+		return value
+	}
+
+	value := map[string]interface{}{}
+	m[key] = value
+
+	return value
+}
+
+type KalmTcpGatewayWatcher struct{}
+
+// Map reconciles ports of the ingress gateway when ports of tcp routes are changed
+func (KalmTcpGatewayWatcher) Map(object handler.MapObject) []reconcile.Request {
+	if object.Meta.GetNamespace() != NamespaceIstio || object.Meta.GetName() != KalmTcpGatewayName {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: NamespaceIstio, Name: "reconcile-caused-by-tcp-gateway-change"}}}
+}
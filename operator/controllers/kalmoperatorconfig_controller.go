@@ -26,6 +26,7 @@ import (
 	promconfig "github.com/prometheus/prometheus/config"
 	"istio.io/api/security/v1beta1"
 	v1beta13 "istio.io/api/type/v1beta1"
+	networkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
 	v1beta12 "istio.io/client-go/pkg/apis/security/v1beta1"
 	appsV1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/apps/v1"
@@ -137,7 +138,10 @@ func (r *KalmOperatorConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 	return ctrl.Result{}, err
 }
 
-func (r *KalmOperatorConfigReconciler) applyFromYaml(ctx context.Context, yamlName string) error {
+// objectMutator changes an object decoded from yaml before it's applied, current is nil if the object is not created.
+type objectMutator func(desired, current runtime.Object) error
+
+func (r *KalmOperatorConfigReconciler) applyFromYaml(ctx context.Context, yamlName string, mutators ...objectMutator) error {
 	fileContent := MustAsset(yamlName)
 
 	objectsBytes := utils.SeparateYamlBytes(fileContent)
@@ -168,6 +172,11 @@ func (r *KalmOperatorConfigReconciler) applyFromYaml(ctx context.Context, yamlNa
 
 		if err := r.Client.Get(ctx, objectKey, fetchedObj); err != nil {
 			if errors.IsNotFound(err) {
+				if err := mutateObject(object, nil, mutators); err != nil {
+					r.Log.Error(err, fmt.Sprintf("Mutate object error: %v", objectKey))
+					return err
+				}
+
 				err = r.Client.Create(ctx, object)
 
 				if err != nil {
@@ -183,6 +192,11 @@ func (r *KalmOperatorConfigReconciler) applyFromYaml(ctx context.Context, yamlNa
 			}
 		}
 
+		if err := mutateObject(object, fetchedObj, mutators); err != nil {
+			r.Log.Error(err, fmt.Sprintf("Mutate object error: %v", objectKey))
+			return err
+		}
+
 		if err := r.Client.Patch(ctx, object, client.Merge); err != nil {
 			r.Log.Error(err, fmt.Sprintf("Apply object failed. %v", objectKey))
 			return err
@@ -193,6 +207,16 @@ func (r *KalmOperatorConfigReconciler) applyFromYaml(ctx context.Context, yamlNa
 	return nil
 }
 
+func mutateObject(desired, current runtime.Object, mutators []objectMutator) error {
+	for _, mutator := range mutators {
+		if err := mutator(desired, current); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 var retryLaterErr = fmt.Errorf("retry later")
 
 const istioPromRecordingRulesFileName = "istio-prom-recording-rules.yaml"
@@ -216,7 +240,18 @@ func (r *KalmOperatorConfigReconciler) reconcileResources(config *installv1alpha
 			return err
 		}
 
-		if err := r.applyFromYaml(ctx, "istiocontrolplane.yaml"); err != nil {
+		ingressGatewayPorts, err := r.getIngressGatewayServicePorts(ctx)
+
+		if err != nil {
+			log.Error(err, "get ports of istio ingress gateway error.")
+			return err
+		}
+
+		setIngressGatewayPorts := func(desired, current runtime.Object) error {
+			return setIngressGatewayServicePorts(desired, current, ingressGatewayPorts)
+		}
+
+		if err := r.applyFromYaml(ctx, "istiocontrolplane.yaml", setIngressGatewayPorts); err != nil {
 			log.Error(err, "install istio plane error.")
 			return err
 		}
@@ -527,6 +562,9 @@ func (r *KalmOperatorConfigReconciler) SetupWithManager(mgr ctrl.Manager) error
 		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
 			ToRequests: &KalmIstioPrometheusWather{},
 		}).
+		Watches(&source.Kind{Type: &networkingv1beta1.Gateway{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: &KalmTcpGatewayWatcher{},
+		}).
 		Complete(r)
 }
 